@@ -22,9 +22,11 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/disk"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/hybrid"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/tiered"
 	"d7y.io/dragonfly/v2/cdnsystem/plugins"
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver/local"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver/s3"
 	"d7y.io/dragonfly/v2/cmd/dependency/base"
 	"d7y.io/dragonfly/v2/pkg/unit"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
@@ -70,6 +72,12 @@ func NewDefaultPlugins() map[plugins.PluginType][]*plugins.PluginProperties {
 				Config: &storedriver.Config{
 					BaseDir: DefaultMemoryBaseDir,
 				},
+			}, {
+				Name:   s3.DriverName,
+				Enable: false,
+				Config: &storedriver.Config{
+					BaseDir: DefaultS3CacheBaseDir,
+				},
 			},
 		}, plugins.StorageManagerPlugin: {
 			{
@@ -78,6 +86,8 @@ func NewDefaultPlugins() map[plugins.PluginType][]*plugins.PluginProperties {
 				Config: &storage.Config{
 					GCInitialDelay: 0 * time.Second,
 					GCInterval:     15 * time.Second,
+					ScrubInterval:  24 * time.Hour,
+					ScrubRate:      10 * unit.MB,
 					DriverConfigs: map[string]*storage.DriverConfig{
 						local.DiskDriverName: {
 							GCConfig: &storage.GCConfig{
@@ -94,6 +104,8 @@ func NewDefaultPlugins() map[plugins.PluginType][]*plugins.PluginProperties {
 				Config: &storage.Config{
 					GCInitialDelay: 0 * time.Second,
 					GCInterval:     15 * time.Second,
+					ScrubInterval:  24 * time.Hour,
+					ScrubRate:      10 * unit.MB,
 					DriverConfigs: map[string]*storage.DriverConfig{
 						local.DiskDriverName: {
 							GCConfig: &storage.GCConfig{
@@ -113,6 +125,29 @@ func NewDefaultPlugins() map[plugins.PluginType][]*plugins.PluginProperties {
 						},
 					},
 				},
+			}, {
+				Name:   tiered.StorageMode,
+				Enable: false,
+				Config: &storage.Config{
+					GCInitialDelay:     0 * time.Second,
+					GCInterval:         15 * time.Second,
+					ScrubInterval:      24 * time.Hour,
+					ScrubRate:          10 * unit.MB,
+					Tiers:              []string{local.MemoryDriverName, local.DiskDriverName},
+					TierInterval:       5 * time.Minute,
+					PromoteAccessCount: 3,
+					DemoteIdleTime:     time.Hour,
+					DriverConfigs: map[string]*storage.DriverConfig{
+						local.DiskDriverName: {
+							GCConfig: &storage.GCConfig{
+								YoungGCThreshold:  100 * unit.GB,
+								FullGCThreshold:   5 * unit.GB,
+								CleanRatio:        1,
+								IntervalThreshold: 2 * time.Hour,
+							},
+						},
+					},
+				},
 			},
 		},
 	}
@@ -126,11 +161,17 @@ func NewDefaultBaseProperties() *BaseProperties {
 		SystemReservedBandwidth: DefaultSystemReservedBandwidth,
 		MaxBandwidth:            DefaultMaxBandwidth,
 		FailAccessInterval:      DefaultFailAccessInterval,
+		SourceConcurrency:       DefaultSourceConcurrency,
+		SourceChunkSize:         DefaultSourceChunkSize,
+		PieceDigestAlgorithm:    DefaultPieceDigestAlgorithm,
 		GCInitialDelay:          DefaultGCInitialDelay,
 		GCMetaInterval:          DefaultGCMetaInterval,
 		TaskExpireTime:          DefaultTaskExpireTime,
 		StorageMode:             DefaultStorageMode,
 		AdvertiseIP:             iputils.HostIP,
+		Admin: AdminConfig{
+			Addr: DefaultAdminAddr,
+		},
 		Manager: ManagerConfig{
 			KeepAlive: KeepAliveConfig{
 				Interval:         DefaultKeepAliveInterval,
@@ -152,6 +193,16 @@ type BaseProperties struct {
 	// default: 8001
 	DownloadPort int `yaml:"downloadPort" mapstructure:"downloadPort"`
 
+	// EnableDownloadServer enables the built-in piece server on DownloadPort instead of another file server like nginx.
+	// When upgrading a deployment served by nginx, stop nginx on DownloadPort before enabling it,
+	// otherwise the cdn fails to start because the port is in use.
+	// default: false
+	EnableDownloadServer bool `yaml:"enableDownloadServer" mapstructure:"enableDownloadServer"`
+
+	// DownloadLimit is the network bandwidth that the built-in piece server can use.
+	// default: 0, no limit, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
+	DownloadLimit unit.Bytes `yaml:"downloadLimit" mapstructure:"downloadLimit"`
+
 	// SystemReservedBandwidth is the network bandwidth reserved for system software.
 	// default: 20 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
 	SystemReservedBandwidth unit.Bytes `yaml:"systemReservedBandwidth" mapstructure:"systemReservedBandwidth"`
@@ -160,6 +211,12 @@ type BaseProperties struct {
 	// default: 200 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
 	MaxBandwidth unit.Bytes `yaml:"maxBandwidth" mapstructure:"maxBandwidth"`
 
+	// Metrics is the listen address of prometheus metrics server, metrics server is disabled when it's empty.
+	Metrics string `yaml:"metrics" mapstructure:"metrics"`
+
+	// Admin is the configuration of admin api to list, purge and refresh the cached tasks and trigger gc.
+	Admin AdminConfig `yaml:"admin" mapstructure:"admin"`
+
 	// AdvertiseIP is used to set the ip that we advertise to other peer in the p2p-network.
 	// By default, the first non-loop address is advertised.
 	AdvertiseIP string `yaml:"advertiseIP" mapstructure:"advertiseIP"`
@@ -169,6 +226,28 @@ type BaseProperties struct {
 	// default: 3
 	FailAccessInterval time.Duration `yaml:"failAccessInterval" mapstructure:"failAccessInterval"`
 
+	// SourceConcurrency is the number of ranges fetched from the source concurrently when the source supports range requests.
+	// The file is downloaded with a single stream when it is less than 2.
+	// default: 4
+	SourceConcurrency int `yaml:"sourceConcurrency" mapstructure:"sourceConcurrency"`
+
+	// SourceChunkSize is the size of each range fetched from the source concurrently, it is aligned to the piece size.
+	// default: 64MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
+	SourceChunkSize unit.Bytes `yaml:"sourceChunkSize" mapstructure:"sourceChunkSize"`
+
+	// SourceLimits limits the concurrent connections and bandwidth to the source hosts,
+	// the first rule matched with the url of source is applied.
+	SourceLimits []SourceLimit `yaml:"sourceLimits" mapstructure:"sourceLimits"`
+
+	// FreshnessRules decides how long the cache of task is fresh without revalidating with the source,
+	// the first rule matched with the url of source is applied, the cache is always revalidated when no rule matched.
+	FreshnessRules []FreshnessRule `yaml:"freshnessRules" mapstructure:"freshnessRules"`
+
+	// PieceDigestAlgorithm is the algorithm of the piece digests, one of md5, sha256 and sha512.
+	// The piece digests are in format of algorithm:encoded except md5, which is compatible with the old clients.
+	// default: md5
+	PieceDigestAlgorithm string `yaml:"pieceDigestAlgorithm" mapstructure:"pieceDigestAlgorithm"`
+
 	// gc related
 	// GCInitialDelay is the delay time from the start to the first GC execution.
 	// default: 6s
@@ -183,13 +262,55 @@ type BaseProperties struct {
 	// default: 3min
 	TaskExpireTime time.Duration `yaml:"taskExpireTime" mapstructure:"taskExpireTime"`
 
-	// StorageMode disk/hybrid/memory
+	// StorageMode disk/hybrid/tiered
 	StorageMode string `yaml:"storageMode" mapstructure:"storageMode"`
 
 	// Manager configuration
 	Manager ManagerConfig `yaml:"manager" mapstructure:"manager"`
 }
 
+type SourceLimit struct {
+	// URLPattern is the regular expression to match the url of source, every matched host has its own limit.
+	URLPattern string `yaml:"urlPattern" mapstructure:"urlPattern"`
+
+	// Concurrency is the max number of concurrent connections to a host.
+	// default: 0, no limit
+	Concurrency int `yaml:"concurrency" mapstructure:"concurrency"`
+
+	// Bandwidth is the network bandwidth that cdn can use to download from a host.
+	// default: 0, no limit, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
+	Bandwidth unit.Bytes `yaml:"bandwidth" mapstructure:"bandwidth"`
+
+	// WaitTimeout is the max time to wait for a connection to a host, the task fails with a source limited error after that.
+	// default: 0, wait until the task is canceled
+	WaitTimeout time.Duration `yaml:"waitTimeout" mapstructure:"waitTimeout"`
+}
+
+type FreshnessRule struct {
+	// URLPattern is the regular expression to match the url of source.
+	URLPattern string `yaml:"urlPattern" mapstructure:"urlPattern"`
+
+	// Policy is one of immutable, ttl, cacheControl and revalidate.
+	// immutable: the cache never expires, it is used for content-addressed files like registry blobs.
+	// ttl: the cache is fresh in TTL after downloaded.
+	// cacheControl: the cache is fresh according to the Cache-Control or Expires header of the source response.
+	// revalidate: the cache is always revalidated with the source.
+	Policy string `yaml:"policy" mapstructure:"policy"`
+
+	// TTL is the time the cache is fresh after downloaded, it is used with the ttl policy.
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+}
+
+type AdminConfig struct {
+	// Addr is the listen address of admin api, admin api is disabled when it's empty.
+	// default: 127.0.0.1:8005
+	Addr string `yaml:"addr" mapstructure:"addr"`
+
+	// Token is the bearer token required in the Authorization header of admin api requests,
+	// it must be set when Addr is not a loopback address.
+	Token string `yaml:"token" mapstructure:"token"`
+}
+
 type ManagerConfig struct {
 	// NetAddr is manager address.
 	Addr string `yaml:"addr" mapstructure:"addr"`
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 const (
@@ -31,6 +32,8 @@ const (
 	DefaultListenPort = 8003
 	// DefaultDownloadPort is the default port for download files from cdn.
 	DefaultDownloadPort = 8001
+	// DefaultAdminAddr is the default listen address of admin api, it is only accessible locally.
+	DefaultAdminAddr = "127.0.0.1:8005"
 )
 
 const (
@@ -50,6 +53,33 @@ const (
 	DefaultFailAccessInterval = 3 * time.Minute
 )
 
+const (
+	// DefaultSourceConcurrency is the default number of ranges fetched from the source concurrently.
+	DefaultSourceConcurrency = 4
+
+	// DefaultSourceChunkSize is the default size of each range fetched from the source concurrently.
+	DefaultSourceChunkSize = 64 * unit.MB
+)
+
+const (
+	// DefaultPieceDigestAlgorithm is the default algorithm of the piece digests.
+	DefaultPieceDigestAlgorithm = digestutils.AlgorithmMD5
+)
+
+const (
+	// FreshnessPolicyImmutable means the cache never expires.
+	FreshnessPolicyImmutable = "immutable"
+
+	// FreshnessPolicyTTL means the cache is fresh in a fixed time after downloaded.
+	FreshnessPolicyTTL = "ttl"
+
+	// FreshnessPolicyCacheControl means the cache is fresh according to the Cache-Control or Expires header of the source.
+	FreshnessPolicyCacheControl = "cacheControl"
+
+	// FreshnessPolicyRevalidate means the cache is always revalidated with the source.
+	FreshnessPolicyRevalidate = "revalidate"
+)
+
 // gc
 const (
 	// DefaultGCInitialDelay is the delay time from the start to the first GC execution.
@@ -26,4 +26,7 @@ var (
 	DefaultDiskBaseDir = filepath.Join(basic.HomeDir, "ftp")
 
 	DefaultMemoryBaseDir = "/dev/shm/dragonfly"
+
+	// DefaultS3CacheBaseDir is the local cache directory of s3 driver
+	DefaultS3CacheBaseDir = filepath.Join(basic.HomeDir, "s3cache")
 )
@@ -52,6 +52,9 @@ func (mm *cacheDataManager) writeFileMetaDataByTask(task *types.SeedTask) (*stor
 	metaData := &storage.FileMetaData{
 		TaskID:          task.TaskID,
 		TaskURL:         task.TaskURL,
+		URL:             task.URL,
+		Header:          task.Header,
+		RequestDigest:   task.RequestDigest,
 		PieceSize:       task.PieceSize,
 		SourceFileLen:   task.SourceFileLength,
 		AccessTime:      getCurrentTimeMillisFunc(),
@@ -88,7 +91,7 @@ func (mm *cacheDataManager) updateAccessTime(taskID string, accessTime int64) er
 	return mm.storage.WriteFileMetaData(taskID, originMetaData)
 }
 
-func (mm *cacheDataManager) updateExpireInfo(taskID string, expireInfo map[string]string) error {
+func (mm *cacheDataManager) updateExpireInfo(taskID string, expireInfo map[string]string, freshnessPolicy string, freshUntil int64) error {
 	mm.cacheLocker.Lock(taskID, false)
 	defer mm.cacheLocker.UnLock(taskID, false)
 
@@ -98,6 +101,8 @@ func (mm *cacheDataManager) updateExpireInfo(taskID string, expireInfo map[strin
 	}
 
 	originMetaData.ExpireInfo = expireInfo
+	originMetaData.FreshnessPolicy = freshnessPolicy
+	originMetaData.FreshUntil = freshUntil
 
 	return mm.storage.WriteFileMetaData(taskID, originMetaData)
 }
@@ -119,8 +124,8 @@ func (mm *cacheDataManager) updateStatusAndResult(taskID string, metaData *stora
 		if metaData.TotalPieceCount > 0 {
 			originMetaData.TotalPieceCount = metaData.TotalPieceCount
 		}
-		if !stringutils.IsBlank(metaData.SourceRealMd5) {
-			originMetaData.SourceRealMd5 = metaData.SourceRealMd5
+		if !stringutils.IsBlank(metaData.SourceRealDigest) {
+			originMetaData.SourceRealDigest = metaData.SourceRealDigest
 		}
 		if !stringutils.IsBlank(metaData.PieceMd5Sign) {
 			originMetaData.PieceMd5Sign = metaData.PieceMd5Sign
@@ -198,6 +203,10 @@ func (mm *cacheDataManager) readDownloadFile(taskID string) (io.ReadCloser, erro
 	return mm.storage.ReadDownloadFile(taskID)
 }
 
+func (mm *cacheDataManager) readDownloadFileRange(taskID string, offset, length int64) (io.ReadCloser, error) {
+	return mm.storage.ReadDownloadFileRange(taskID, offset, length)
+}
+
 func (mm *cacheDataManager) resetRepo(task *types.SeedTask) error {
 	mm.cacheLocker.Lock(task.TaskID, false)
 	defer mm.cacheLocker.UnLock(task.TaskID, false)
@@ -18,7 +18,6 @@ package cdn
 
 import (
 	"context"
-	"crypto/md5"
 	"fmt"
 	"hash"
 	"io"
@@ -33,12 +32,15 @@ import (
 	"d7y.io/dragonfly/v2/pkg/source"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
 	"github.com/pkg/errors"
 )
 
 // cacheDetector detect task cache
 type cacheDetector struct {
 	cacheDataManager *cacheDataManager
+	sourceLimiter    *sourceLimiter
+	freshness        *freshness
 }
 
 // cacheResult cache result of detect
@@ -53,18 +55,20 @@ func (s *cacheResult) String() string {
 }
 
 // newCacheDetector create a new cache detector
-func newCacheDetector(cacheDataManager *cacheDataManager) *cacheDetector {
+func newCacheDetector(cacheDataManager *cacheDataManager, sourceLimiter *sourceLimiter, freshness *freshness) *cacheDetector {
 	return &cacheDetector{
 		cacheDataManager: cacheDataManager,
+		sourceLimiter:    sourceLimiter,
+		freshness:        freshness,
 	}
 }
 
-func (cd *cacheDetector) detectCache(task *types.SeedTask, fileMd5 hash.Hash) (*cacheResult, error) {
+func (cd *cacheDetector) detectCache(task *types.SeedTask, fileDigest hash.Hash) (*cacheResult, error) {
 	//err := cd.cacheStore.CreateUploadLink(ctx, task.TaskId)
 	//if err != nil {
 	//	return nil, errors.Wrapf(err, "failed to create upload symbolic link")
 	//}
-	result, err := cd.doDetect(task, fileMd5)
+	result, err := cd.doDetect(task, fileDigest)
 	if err != nil {
 		logger.WithTaskID(task.TaskID).Infof("failed to detect cache, reset cache: %v", err)
 		metaData, err := cd.resetCache(task)
@@ -83,7 +87,7 @@ func (cd *cacheDetector) detectCache(task *types.SeedTask, fileMd5 hash.Hash) (*
 }
 
 // doDetect the actual detect action which detects file metaData and pieces metaData of specific task
-func (cd *cacheDetector) doDetect(task *types.SeedTask, fileMd5 hash.Hash) (result *cacheResult, err error) {
+func (cd *cacheDetector) doDetect(task *types.SeedTask, fileDigest hash.Hash) (result *cacheResult, err error) {
 	fileMetaData, err := cd.cacheDataManager.readFileMetaData(task.TaskID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "read file meta data of task %s", task.TaskID)
@@ -91,16 +95,29 @@ func (cd *cacheDetector) doDetect(task *types.SeedTask, fileMd5 hash.Hash) (resu
 	if err := checkSameFile(task, fileMetaData); err != nil {
 		return nil, errors.Wrapf(err, "check same file")
 	}
-	ctx, expireCancel := context.WithTimeout(context.Background(), 4*time.Second)
-	defer expireCancel()
-	expired, err := source.IsExpired(ctx, task.URL, task.Header, fileMetaData.ExpireInfo)
-	if err != nil {
-		// 如果获取失败，则认为没有过期，防止打爆源
-		logger.WithTaskID(task.TaskID).Errorf("failed to check if the task expired: %v", err)
-	}
-	logger.WithTaskID(task.TaskID).Debugf("task expired result: %t", expired)
-	if expired {
-		return nil, cdnerrors.ErrResourceExpired{URL: task.URL}
+	// the fresh cache is used without revalidating with the source
+	nowMillis := getCurrentTimeMillisFunc()
+	if cd.freshness.isFresh(task.URL, fileMetaData, nowMillis) {
+		logger.WithTaskID(task.TaskID).Debugf("task is fresh with freshness policy %s", fileMetaData.FreshnessPolicy)
+	} else {
+		var expired bool
+		err := cd.sourceLimiter.do(context.Background(), task.URL, func() (err error) {
+			ctx, expireCancel := context.WithTimeout(context.Background(), sourceProbeTimeout)
+			defer expireCancel()
+			expired, err = source.IsExpired(ctx, task.URL, task.Header, fileMetaData.ExpireInfo)
+			return err
+		})
+		if err != nil {
+			// 如果获取失败，则认为没有过期，防止打爆源
+			logger.WithTaskID(task.TaskID).Errorf("failed to check if the task expired: %v", err)
+		}
+		logger.WithTaskID(task.TaskID).Debugf("task expired result: %t", expired)
+		if expired {
+			return nil, cdnerrors.ErrResourceExpired{URL: task.URL}
+		}
+		if err == nil {
+			cd.refreshFreshness(task, fileMetaData, nowMillis)
+		}
 	}
 	// not expired
 	if fileMetaData.Finish {
@@ -111,14 +128,34 @@ func (cd *cacheDetector) doDetect(task *types.SeedTask, fileMd5 hash.Hash) (resu
 	// detect the cache situation by reading piece meta and data file
 	ctx, rangeCancel := context.WithTimeout(context.Background(), 4*time.Second)
 	defer rangeCancel()
-	supportRange, err := source.IsSupportRange(ctx, task.URL, task.Header)
+	var supportRange bool
+	err = cd.sourceLimiter.do(ctx, task.URL, func() (err error) {
+		supportRange, err = source.IsSupportRange(ctx, task.URL, task.Header)
+		return err
+	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "check if url(%s) supports range request", task.URL)
 	}
 	if !supportRange {
 		return nil, cdnerrors.ErrResourceNotSupportRangeRequest{URL: task.URL}
 	}
-	return cd.parseByReadFile(task.TaskID, fileMetaData, fileMd5)
+	return cd.parseByReadFile(task.TaskID, fileMetaData, fileDigest)
+}
+
+// refreshFreshness re-evaluates the freshness of the cache which is just revalidated with the source and persists it,
+// so that the cache is fresh again from now on.
+func (cd *cacheDetector) refreshFreshness(task *types.SeedTask, fileMetaData *storage.FileMetaData, nowMillis int64) {
+	freshnessPolicy, freshUntil := cd.freshness.evaluate(task.URL, fileMetaData.ExpireInfo, timeutils.MillisUnixTime(nowMillis))
+	if !canBeFresh(freshnessPolicy) && !canBeFresh(fileMetaData.FreshnessPolicy) {
+		return
+	}
+	if err := cd.cacheDataManager.updateExpireInfo(task.TaskID, fileMetaData.ExpireInfo, freshnessPolicy, freshUntil); err != nil {
+		logger.WithTaskID(task.TaskID).Errorf("failed to update freshness after revalidation: %v", err)
+		return
+	}
+	fileMetaData.FreshnessPolicy = freshnessPolicy
+	fileMetaData.FreshUntil = freshUntil
+	logger.WithTaskID(task.TaskID).Debugf("task is revalidated, freshness policy: %s, fresh until: %d", freshnessPolicy, freshUntil)
 }
 
 // parseByReadMetaFile detect cache by read meta and pieceMeta files of task
@@ -154,7 +191,7 @@ func (cd *cacheDetector) parseByReadMetaFile(taskID string, fileMetaData *storag
 }
 
 // parseByReadFile detect cache by read pieceMeta and data files of task
-func (cd *cacheDetector) parseByReadFile(taskID string, metaData *storage.FileMetaData, fileMd5 hash.Hash) (*cacheResult, error) {
+func (cd *cacheDetector) parseByReadFile(taskID string, metaData *storage.FileMetaData, fileDigest hash.Hash) (*cacheResult, error) {
 	reader, err := cd.cacheDataManager.readDownloadFile(taskID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "read data file")
@@ -177,7 +214,7 @@ func (cd *cacheDetector) parseByReadFile(taskID string, metaData *storage.FileMe
 			break
 		}
 		// read content
-		if err := checkPieceContent(reader, tempRecords[index], fileMd5); err != nil {
+		if err := checkPieceContent(reader, tempRecords[index], fileDigest); err != nil {
 			logger.WithTaskID(taskID).Errorf("read content of pieceNum %d failed: %v", tempRecords[index].PieceNum, err)
 			break
 		}
@@ -237,30 +274,34 @@ func checkSameFile(task *types.SeedTask, metaData *storage.FileMetaData) error {
 	if metaData.TaskURL != task.TaskURL {
 		return errors.Errorf("meta task taskUrl(%s) is not equals with task taskUrl(%s)", metaData.TaskURL, task.URL)
 	}
-	if !stringutils.IsBlank(metaData.SourceRealMd5) && !stringutils.IsBlank(task.RequestMd5) &&
-		metaData.SourceRealMd5 != task.RequestMd5 {
-		return errors.Errorf("meta task source md5(%s) is not equals with task request md5(%s)",
-			metaData.SourceRealMd5, task.RequestMd5)
+	if !stringutils.IsBlank(metaData.SourceRealDigest) && !stringutils.IsBlank(task.RequestDigest) &&
+		metaData.SourceRealDigest != task.RequestDigest {
+		return errors.Errorf("meta task source digest(%s) is not equals with task request digest(%s)",
+			metaData.SourceRealDigest, task.RequestDigest)
 	}
 	return nil
 }
 
 //checkPieceContent read piece content from reader and check data integrity by pieceMetaRecord
-func checkPieceContent(reader io.Reader, pieceRecord *storage.PieceMetaRecord, fileMd5 hash.Hash) error {
-	// todo Analyze the original data for the slice format to calculate fileMd5
-	pieceMd5 := md5.New()
-	tee := io.TeeReader(io.TeeReader(io.LimitReader(reader, int64(pieceRecord.PieceLen)), pieceMd5), fileMd5)
+func checkPieceContent(reader io.Reader, pieceRecord *storage.PieceMetaRecord, fileDigest hash.Hash) error {
+	// todo Analyze the original data for the slice format to calculate fileDigest
+	algorithm, encoded, err := digestutils.Parse(pieceRecord.Md5)
+	if err != nil {
+		return errors.Wrap(err, "parse piece digest")
+	}
+	pieceDigest, _ := digestutils.NewHash(algorithm)
+	tee := io.TeeReader(io.TeeReader(io.LimitReader(reader, int64(pieceRecord.PieceLen)), pieceDigest), fileDigest)
 	if n, err := io.Copy(ioutil.Discard, tee); n != int64(pieceRecord.PieceLen) || err != nil {
 		return errors.Wrap(err, "read piece content")
 	}
-	realPieceMd5 := digestutils.ToHashString(pieceMd5)
+	realPieceDigest := digestutils.ToHashString(pieceDigest)
 	// check piece content
-	if realPieceMd5 != pieceRecord.Md5 {
+	if realPieceDigest != encoded {
 		err := cdnerrors.ErrInconsistentValues{
 			Expected: pieceRecord.Md5,
-			Actual:   realPieceMd5,
+			Actual:   digestutils.Format(algorithm, realPieceDigest),
 		}
-		return errors.Wrap(err, "compare piece md5")
+		return errors.Wrap(err, "compare piece digest")
 	}
 	return nil
 }
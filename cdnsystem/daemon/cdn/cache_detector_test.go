@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
 	storageMock "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/mock"
 	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
@@ -54,7 +55,11 @@ func (suite *CacheDetectorTestSuite) SetupSuite() {
 	source.Register("http", sourceClient)
 	storageMgr := storageMock.NewMockManager(ctrl)
 	cacheDataManager := newCacheDataManager(storageMgr)
-	suite.detector = newCacheDetector(cacheDataManager)
+	sourceLimiter, err := newSourceLimiter(nil)
+	suite.Nil(err)
+	freshness, err := newFreshness(nil)
+	suite.Nil(err)
+	suite.detector = newCacheDetector(cacheDataManager, sourceLimiter, freshness)
 	storageMgr.EXPECT().ReadFileMetaData(fullExpiredCache.taskID).Return(fullExpiredCache.fileMeta, nil).AnyTimes()
 	storageMgr.EXPECT().ReadFileMetaData(fullNoExpiredCache.taskID).Return(fullNoExpiredCache.fileMeta, nil).AnyTimes()
 	storageMgr.EXPECT().ReadFileMetaData(partialNotSupportRangeCache.taskID).Return(partialNotSupportRangeCache.fileMeta, nil).AnyTimes()
@@ -218,37 +223,37 @@ var fullPieceMetaRecords = append(partialPieceMetaRecords, &storage.PieceMetaRec
 
 func newCompletedFileMeta(taskID string, URL string, success bool) *storage.FileMetaData {
 	return &storage.FileMetaData{
-		TaskID:          taskID,
-		TaskURL:         URL,
-		PieceSize:       2000,
-		SourceFileLen:   9789,
-		AccessTime:      1624126443284,
-		Interval:        0,
-		CdnFileLength:   9789,
-		SourceRealMd5:   "",
-		PieceMd5Sign:    "98166bdfebb7b71dd5c6d47492d844f4421d90199641ca11fd8ce3111894115a",
-		ExpireInfo:      nil,
-		Finish:          true,
-		Success:         success,
-		TotalPieceCount: 5,
+		TaskID:           taskID,
+		TaskURL:          URL,
+		PieceSize:        2000,
+		SourceFileLen:    9789,
+		AccessTime:       1624126443284,
+		Interval:         0,
+		CdnFileLength:    9789,
+		SourceRealDigest: "",
+		PieceMd5Sign:     "98166bdfebb7b71dd5c6d47492d844f4421d90199641ca11fd8ce3111894115a",
+		ExpireInfo:       nil,
+		Finish:           true,
+		Success:          success,
+		TotalPieceCount:  5,
 	}
 }
 
 func newPartialFileMeta(taskID string, URL string) *storage.FileMetaData {
 	return &storage.FileMetaData{
-		TaskID:          taskID,
-		TaskURL:         URL,
-		PieceSize:       2000,
-		SourceFileLen:   9789,
-		AccessTime:      1624126443284,
-		Interval:        0,
-		CdnFileLength:   0,
-		SourceRealMd5:   "",
-		PieceMd5Sign:    "",
-		ExpireInfo:      nil,
-		Finish:          false,
-		Success:         false,
-		TotalPieceCount: 0,
+		TaskID:           taskID,
+		TaskURL:          URL,
+		PieceSize:        2000,
+		SourceFileLen:    9789,
+		AccessTime:       1624126443284,
+		Interval:         0,
+		CdnFileLength:    0,
+		SourceRealDigest: "",
+		PieceMd5Sign:     "",
+		ExpireInfo:       nil,
+		Finish:           false,
+		Success:          false,
+		TotalPieceCount:  0,
 	}
 }
 
@@ -452,3 +457,38 @@ func (suite *CacheDetectorTestSuite) TestCheckPieceContent() {
 		})
 	}
 }
+
+func (suite *CacheDetectorTestSuite) TestRefreshFreshness() {
+	ctrl := gomock.NewController(suite.T())
+	storageMgr := storageMock.NewMockManager(ctrl)
+	sourceLimiter, err := newSourceLimiter(nil)
+	suite.Nil(err)
+	freshness, err := newFreshness([]config.FreshnessRule{
+		{URLPattern: "^http://ttl.com/", Policy: config.FreshnessPolicyTTL, TTL: time.Minute},
+	})
+	suite.Nil(err)
+	detector := newCacheDetector(newCacheDataManager(storageMgr), sourceLimiter, freshness)
+
+	task := &types.SeedTask{TaskID: "ttlTask", URL: "http://ttl.com/file"}
+	nowMillis := int64(1624126443284)
+	fileMeta := newCompletedFileMeta(task.TaskID, task.URL, true)
+	fileMeta.FreshnessPolicy = config.FreshnessPolicyTTL
+	fileMeta.FreshUntil = nowMillis - 1
+	suite.False(freshness.isFresh(task.URL, fileMeta, nowMillis))
+
+	var written *storage.FileMetaData
+	storageMgr.EXPECT().ReadFileMetaData(task.TaskID).Return(fileMeta, nil).Times(1)
+	storageMgr.EXPECT().WriteFileMetaData(task.TaskID, gomock.Any()).DoAndReturn(func(taskID string, metaData *storage.FileMetaData) error {
+		written = metaData
+		return nil
+	}).Times(1)
+	// the revalidated cache is fresh again for the ttl
+	detector.refreshFreshness(task, fileMeta, nowMillis)
+	suite.Equal(nowMillis+60*1000, written.FreshUntil)
+	suite.Equal(config.FreshnessPolicyTTL, written.FreshnessPolicy)
+	suite.True(freshness.isFresh(task.URL, fileMeta, nowMillis))
+
+	// nothing is written when the cache is always revalidated
+	otherTask := &types.SeedTask{TaskID: "otherTask", URL: "http://other.com/file"}
+	detector.refreshFreshness(otherTask, newCompletedFileMeta(otherTask.TaskID, otherTask.URL, true), nowMillis)
+}
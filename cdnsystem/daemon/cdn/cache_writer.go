@@ -18,7 +18,7 @@ package cdn
 
 import (
 	"bytes"
-	"crypto/md5"
+	"context"
 	"fmt"
 	"io"
 	"sync"
@@ -29,6 +29,8 @@ import (
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
 type piece struct {
@@ -46,15 +48,39 @@ type downloadMetadata struct {
 	pieceMd5Sign         string
 }
 
+// storageError is the error caused by the storage rather than the source.
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storageError) Unwrap() error {
+	return e.err
+}
+
+func isStorageError(err error) bool {
+	var e *storageError
+	return errors.As(err, &e)
+}
+
+// rangeFetcher fetches the content of the source in the range [start, end].
+type rangeFetcher func(ctx context.Context, start, end int64) (io.ReadCloser, error)
+
 type cacheWriter struct {
 	cdnReporter      *reporter
 	cacheDataManager *cacheDataManager
+	// pieceDigestAlgorithm is the algorithm of the piece digests
+	pieceDigestAlgorithm string
 }
 
-func newCacheWriter(cdnReporter *reporter, cacheDataManager *cacheDataManager) *cacheWriter {
+func newCacheWriter(cdnReporter *reporter, cacheDataManager *cacheDataManager, pieceDigestAlgorithm string) *cacheWriter {
 	return &cacheWriter{
-		cdnReporter:      cdnReporter,
-		cacheDataManager: cacheDataManager,
+		cdnReporter:          cdnReporter,
+		cacheDataManager:     cacheDataManager,
+		pieceDigestAlgorithm: pieceDigestAlgorithm,
 	}
 }
 
@@ -73,19 +99,38 @@ func (cw *cacheWriter) startWriter(reader io.Reader, task *types.SeedTask, detec
 	if err != nil {
 		return &downloadMetadata{backSourceLength: backSourceFileLength}, fmt.Errorf("write data: %v", err)
 	}
-	storageInfo, err := cw.cacheDataManager.statDownloadFile(task.TaskID)
+	return cw.getDownloadMetadata(task.TaskID, backSourceFileLength, currentSourceFileLength+backSourceFileLength, totalPieceCount)
+}
+
+// startParallelWriter fetches the ranges of the source concurrently and writes them to the underlying storage,
+// every piece is written and reported as soon as it is downloaded, so the pieces are out of order.
+func (cw *cacheWriter) startParallelWriter(ctx context.Context, task *types.SeedTask, detectResult *cacheResult, concurrency int,
+	chunkSize int64, fetch rangeFetcher) (*downloadMetadata, error) {
+	if detectResult == nil {
+		detectResult = &cacheResult{}
+	}
+	currentSourceFileLength := detectResult.breakPoint
+	backSourceFileLength, totalPieceCount, err := cw.doParallelWrite(ctx, task, currentSourceFileLength, concurrency, chunkSize, fetch)
+	if err != nil {
+		return &downloadMetadata{backSourceLength: backSourceFileLength}, errors.Wrap(err, "write data")
+	}
+	return cw.getDownloadMetadata(task.TaskID, backSourceFileLength, currentSourceFileLength+backSourceFileLength, totalPieceCount)
+}
+
+func (cw *cacheWriter) getDownloadMetadata(taskID string, backSourceFileLength, realSourceFileLength int64, totalPieceCount int) (*downloadMetadata, error) {
+	storageInfo, err := cw.cacheDataManager.statDownloadFile(taskID)
 	if err != nil {
-		return &downloadMetadata{backSourceLength: backSourceFileLength}, fmt.Errorf("stat cdn download file: %v", err)
+		return &downloadMetadata{backSourceLength: backSourceFileLength}, &storageError{fmt.Errorf("stat cdn download file: %v", err)}
 	}
 	// todo Try getting it from the ProgressManager first
-	pieceMd5Sign, _, err := cw.cacheDataManager.getPieceMd5Sign(task.TaskID)
+	pieceMd5Sign, _, err := cw.cacheDataManager.getPieceMd5Sign(taskID)
 	if err != nil {
-		return &downloadMetadata{backSourceLength: backSourceFileLength}, fmt.Errorf("get piece md5 sign: %v", err)
+		return &downloadMetadata{backSourceLength: backSourceFileLength}, &storageError{fmt.Errorf("get piece md5 sign: %v", err)}
 	}
 	return &downloadMetadata{
 		backSourceLength:     backSourceFileLength,
 		realCdnFileLength:    storageInfo.Size,
-		realSourceFileLength: currentSourceFileLength + backSourceFileLength,
+		realSourceFileLength: realSourceFileLength,
 		pieceTotalCount:      int32(totalPieceCount),
 		pieceMd5Sign:         pieceMd5Sign,
 	}, nil
@@ -101,7 +146,7 @@ func (cw *cacheWriter) doWrite(reader io.Reader, task *types.SeedTask, routineCo
 	buf := make([]byte, 256*1024)
 	jobCh := make(chan *piece)
 	var wg = &sync.WaitGroup{}
-	cw.writerPool(wg, routineCount, jobCh, bufPool)
+	cw.writerPool(wg, routineCount, jobCh, bufPool, nil)
 	for {
 		var bb = bufPool.Get().(*bytes.Buffer)
 		bb.Reset()
@@ -132,7 +177,117 @@ func (cw *cacheWriter) doWrite(reader io.Reader, task *types.SeedTask, routineCo
 	return backSourceFileLength, curPieceNum, nil
 }
 
-func (cw *cacheWriter) writerPool(wg *sync.WaitGroup, routineCount int, pieceCh chan *piece, bufPool *sync.Pool) {
+func (cw *cacheWriter) doParallelWrite(ctx context.Context, task *types.SeedTask, start int64, concurrency int, chunkSize int64,
+	fetch rangeFetcher) (n int64, totalPiece int, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var bufPool = &sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+	var (
+		backSourceFileLength = atomic.NewInt64(0)
+		fetchErr             error
+		fetchErrOnce         sync.Once
+		fetchWg              sync.WaitGroup
+	)
+	onError := func(err error) {
+		fetchErrOnce.Do(func() {
+			fetchErr = err
+			cancel()
+		})
+	}
+	jobCh := make(chan *piece)
+	var wg = &sync.WaitGroup{}
+	cw.writerPool(wg, calculateRoutineCount(task.SourceFileLength-start, task.PieceSize), jobCh, bufPool, func(err error) {
+		onError(&storageError{err})
+	})
+
+	chunkCh := make(chan int64)
+	fetchWg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer fetchWg.Done()
+			buf := make([]byte, 256*1024)
+			for chunkStart := range chunkCh {
+				chunkEnd := chunkStart + chunkSize - 1
+				if chunkEnd >= task.SourceFileLength {
+					chunkEnd = task.SourceFileLength - 1
+				}
+				n, err := cw.writeChunk(ctx, task, chunkStart, chunkEnd, fetch, jobCh, bufPool, buf)
+				backSourceFileLength.Add(n)
+				if err != nil {
+					onError(err)
+					return
+				}
+			}
+		}()
+	}
+loop:
+	for offset := start; offset < task.SourceFileLength; offset += chunkSize {
+		select {
+		case chunkCh <- offset:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(chunkCh)
+	fetchWg.Wait()
+	close(jobCh)
+	wg.Wait()
+	if fetchErr != nil {
+		return backSourceFileLength.Load(), 0, fetchErr
+	}
+	if err := ctx.Err(); err != nil {
+		return backSourceFileLength.Load(), 0, err
+	}
+	return backSourceFileLength.Load(), int((task.SourceFileLength + int64(task.PieceSize) - 1) / int64(task.PieceSize)), nil
+}
+
+// writeChunk fetches the range [start, end] of the source and sends it to the writer pool piece by piece.
+func (cw *cacheWriter) writeChunk(ctx context.Context, task *types.SeedTask, start, end int64, fetch rangeFetcher, jobCh chan<- *piece,
+	bufPool *sync.Pool, buf []byte) (int64, error) {
+	reader, err := fetch(ctx, start, end)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fetch source taskID %s range %d-%d", task.TaskID, start, end)
+	}
+	defer reader.Close()
+	var n int64
+	for offset := start; offset <= end; offset += int64(task.PieceSize) {
+		pieceNum := int32(offset / int64(task.PieceSize))
+		size := int64(task.PieceSize)
+		if offset+size > end+1 {
+			size = end + 1 - offset
+		}
+		var bb = bufPool.Get().(*bytes.Buffer)
+		bb.Reset()
+		written, err := io.CopyBuffer(bb, io.LimitReader(reader, size), buf)
+		n += written
+		if err == nil && written != size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			bufPool.Put(bb)
+			return n, fmt.Errorf("read source taskID %s pieceNum %d piece: %v", task.TaskID, pieceNum, err)
+		}
+		select {
+		case jobCh <- &piece{
+			taskID:       task.TaskID,
+			pieceNum:     pieceNum,
+			pieceSize:    task.PieceSize,
+			pieceContent: bb,
+		}:
+		case <-ctx.Done():
+			bufPool.Put(bb)
+			return n, ctx.Err()
+		}
+	}
+	return n, nil
+}
+
+// writerPool writes the pieces received from pieceCh to storage, onError is called with the failure of writing a piece if it is not nil.
+func (cw *cacheWriter) writerPool(wg *sync.WaitGroup, routineCount int, pieceCh chan *piece, bufPool *sync.Pool, onError func(error)) {
 	wg.Add(routineCount)
 	for i := 0; i < routineCount; i++ {
 		go func() {
@@ -143,32 +298,39 @@ func (cw *cacheWriter) writerPool(wg *sync.WaitGroup, routineCount int, pieceCh
 				originPieceLen := waitToWriteContent.Len() // the length of the original data that has not been processed
 				pieceLen := originPieceLen                 // the real length written to the storage medium after processing
 				pieceStyle := types.PlainUnspecified
-				pieceMd5 := md5.New()
-				err := cw.cacheDataManager.writeDownloadFile(piece.taskID, int64(piece.pieceNum)*int64(piece.pieceSize), int64(waitToWriteContent.Len()),
-					io.TeeReader(io.LimitReader(piece.pieceContent, int64(waitToWriteContent.Len())), pieceMd5))
+				pieceDigest, _ := digestutils.NewHash(cw.pieceDigestAlgorithm)
+				pieceOffset := int64(piece.pieceNum) * int64(piece.pieceSize)
+				err := cw.cacheDataManager.writeDownloadFile(piece.taskID, pieceOffset, int64(waitToWriteContent.Len()),
+					io.TeeReader(io.LimitReader(piece.pieceContent, int64(waitToWriteContent.Len())), pieceDigest))
 				// Recycle Buffer
 				bufPool.Put(waitToWriteContent)
 				if err != nil {
 					logger.Errorf("write taskID %s pieceNum %d file: %v", piece.taskID, piece.pieceNum, err)
+					if onError != nil {
+						onError(fmt.Errorf("write taskID %s pieceNum %d file: %v", piece.taskID, piece.pieceNum, err))
+					}
 					continue
 				}
 				pieceRecord := &storage.PieceMetaRecord{
 					PieceNum: piece.pieceNum,
 					PieceLen: int32(pieceLen),
-					Md5:      digestutils.ToHashString(pieceMd5),
+					Md5:      digestutils.Format(cw.pieceDigestAlgorithm, digestutils.ToHashString(pieceDigest)),
 					Range: &rangeutils.Range{
-						StartIndex: uint64(piece.pieceNum * piece.pieceSize),
-						EndIndex:   uint64(piece.pieceNum*piece.pieceSize + int32(pieceLen) - 1),
+						StartIndex: uint64(pieceOffset),
+						EndIndex:   uint64(pieceOffset + int64(pieceLen) - 1),
 					},
 					OriginRange: &rangeutils.Range{
-						StartIndex: uint64(piece.pieceNum * piece.pieceSize),
-						EndIndex:   uint64(piece.pieceNum*piece.pieceSize + int32(originPieceLen) - 1),
+						StartIndex: uint64(pieceOffset),
+						EndIndex:   uint64(pieceOffset + int64(originPieceLen) - 1),
 					},
 					PieceStyle: pieceStyle,
 				}
 				// write piece meta to storage
 				if err := cw.cacheDataManager.appendPieceMetaData(piece.taskID, pieceRecord); err != nil {
 					logger.Errorf("write piece meta file: %v", err)
+					if onError != nil {
+						onError(fmt.Errorf("write piece meta file: %v", err))
+					}
 					continue
 				}
 
@@ -36,6 +36,7 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver/local"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -92,7 +93,7 @@ func (suite *CacheWriterTestSuite) SetupSuite() {
 	cacheDataManager := newCacheDataManager(storeMgr)
 	progressMgr, _ := progress.NewManager()
 	cdnReporter := newReporter(progressMgr)
-	suite.writer = newCacheWriter(cdnReporter, cacheDataManager)
+	suite.writer = newCacheWriter(cdnReporter, cacheDataManager, digestutils.AlgorithmMD5)
 }
 
 func (suite *CacheWriterTestSuite) TearDownSuite() {
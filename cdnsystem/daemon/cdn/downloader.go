@@ -19,18 +19,28 @@ package cdn
 import (
 	"context"
 	"fmt"
+	"hash"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
 	"d7y.io/dragonfly/v2/pkg/source"
 	"d7y.io/dragonfly/v2/pkg/structure/maputils"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
 	"github.com/pkg/errors"
 )
 
 const RangeHeaderName = "Range"
 
+// errRangeNotSatisfied is returned when the source does not respond with the requested range,
+// e.g. the range header is ignored and the whole content is returned with status 200.
+var errRangeNotSatisfied = errors.New("source does not respond with the requested range")
+
 func (cm *Manager) download(ctx context.Context, task *types.SeedTask, detectResult *cacheResult) (io.ReadCloser, error) {
 	headers := maputils.DeepCopyMap(nil, task.Header)
 	if detectResult.breakPoint > 0 {
@@ -45,14 +55,133 @@ func (cm *Manager) download(ctx context.Context, task *types.SeedTask, detectRes
 	}
 	logger.WithTaskID(task.TaskID).Infof("start download url %s at range:%d-%d: with header: %+v", task.URL, detectResult.breakPoint,
 		task.SourceFileLength, task.Header)
-	reader, responseHeader, err := source.DownloadWithResponseHeader(ctx, task.URL, headers)
+	reader, responseHeader, err := cm.downloadWithLimit(ctx, task.URL, headers)
 	// update Expire info
 	if err == nil {
-		expireInfo := map[string]string{
-			source.LastModified: responseHeader.Get(source.LastModified),
-			source.ETag:         responseHeader.Get(source.ETag),
-		}
-		cm.updateExpireInfo(task.TaskID, expireInfo)
+		cm.updateExpireInfo(task, responseHeader)
 	}
 	return reader, err
 }
+
+// downloadWithLimit waits for a connection to the source and downloads from it,
+// the connection is released when the returned reader is closed.
+func (cm *Manager) downloadWithLimit(ctx context.Context, url string, headers map[string]string) (io.ReadCloser, source.ResponseHeader, error) {
+	limiter, release, err := cm.sourceLimiter.acquire(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, responseHeader, err := source.DownloadWithResponseHeader(ctx, url, headers)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return limiter.wrap(reader, release), responseHeader, nil
+}
+
+// GetContentLength gets the content length of the source with the connection limited as the downloads,
+// the waiting for connection is limited by the wait timeout of source limit rule, and only the probe is limited
+// by sourceProbeTimeout. The content length is -1 when it can not be got.
+func (cm *Manager) GetContentLength(ctx context.Context, url string, header map[string]string) (int64, error) {
+	var contentLength int64 = -1
+	err := cm.sourceLimiter.do(ctx, url, func() (err error) {
+		probeCtx, cancel := context.WithTimeout(ctx, sourceProbeTimeout)
+		defer cancel()
+		contentLength, err = source.GetContentLength(probeCtx, url, header)
+		return err
+	})
+	return contentLength, err
+}
+
+// canDownloadInParallel checks whether the remaining ranges of the source file can be fetched concurrently.
+func (cm *Manager) canDownloadInParallel(ctx context.Context, task *types.SeedTask, detectResult *cacheResult) bool {
+	if cm.cfg.SourceConcurrency < 2 || task.SourceFileLength <= 0 || task.PieceSize <= 0 {
+		return false
+	}
+	// the range of the task is specified by the request
+	for k := range task.Header {
+		if strings.EqualFold(k, RangeHeaderName) {
+			return false
+		}
+	}
+	if task.SourceFileLength-detectResult.breakPoint <= cm.sourceChunkSize(task.PieceSize) {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	defer cancel()
+	var supportRange bool
+	err := cm.sourceLimiter.do(ctx, task.URL, func() (err error) {
+		supportRange, err = source.IsSupportRange(ctx, task.URL, task.Header)
+		return err
+	})
+	if err != nil {
+		logger.WithTaskID(task.TaskID).Warnf("failed to check if url(%s) supports range request: %v", task.URL, err)
+		return false
+	}
+	return supportRange
+}
+
+// sourceChunkSize returns the size of range fetched from the source concurrently, which is aligned to the piece size.
+func (cm *Manager) sourceChunkSize(pieceSize int32) int64 {
+	chunkSize := int64(cm.cfg.SourceChunkSize)
+	if chunkSize <= int64(pieceSize) {
+		return int64(pieceSize)
+	}
+	return chunkSize / int64(pieceSize) * int64(pieceSize)
+}
+
+// downloadInParallel fetches the remaining ranges of the source file concurrently and writes them to storage,
+// the digest of the source file is calculated by reading back the stored file because the pieces are written out of order.
+func (cm *Manager) downloadInParallel(ctx context.Context, task *types.SeedTask, detectResult *cacheResult,
+	fileDigest hash.Hash) (*downloadMetadata, string, error) {
+	var updateExpireOnce sync.Once
+	fetch := func(ctx context.Context, start, end int64) (io.ReadCloser, error) {
+		headers := maputils.DeepCopyMap(nil, task.Header)
+		headers[RangeHeaderName] = fmt.Sprintf("bytes=%d-%d", start, end)
+		logger.WithTaskID(task.TaskID).Debugf("start download url %s at range:%d-%d", task.URL, start, end)
+		reader, responseHeader, err := cm.downloadWithLimit(ctx, task.URL, headers)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkContentRange(responseHeader.Get(source.ContentRange), start, end); err != nil {
+			reader.Close()
+			return nil, err
+		}
+		updateExpireOnce.Do(func() {
+			cm.updateExpireInfo(task, responseHeader)
+		})
+		return struct {
+			io.Reader
+			io.Closer
+		}{limitreader.NewLimitReaderWithLimiter(cm.limiter, reader, false), reader}, nil
+	}
+	logger.WithTaskID(task.TaskID).Infof("start download url %s in parallel from %d with concurrency %d", task.URL,
+		detectResult.breakPoint, cm.cfg.SourceConcurrency)
+	downloadMetadata, err := cm.writer.startParallelWriter(ctx, task, detectResult, cm.cfg.SourceConcurrency,
+		cm.sourceChunkSize(task.PieceSize), fetch)
+	if err != nil {
+		return downloadMetadata, "", err
+	}
+	reader, err := cm.cacheDataManager.readDownloadFileRange(task.TaskID, detectResult.breakPoint, 0)
+	if err != nil {
+		return downloadMetadata, "", &storageError{errors.Wrap(err, "read download file")}
+	}
+	defer reader.Close()
+	if _, err := io.Copy(fileDigest, reader); err != nil {
+		return downloadMetadata, "", &storageError{errors.Wrap(err, "calculate digest of download file")}
+	}
+	return downloadMetadata, digestutils.ToHashString(fileDigest), nil
+}
+
+// checkContentRange checks whether the content range of the response is exactly the requested range [start, end].
+func checkContentRange(contentRange string, start, end int64) error {
+	// contentRange: "bytes start-end/total"
+	rangeStr := strings.TrimPrefix(contentRange, "bytes ")
+	if i := strings.Index(rangeStr, "/"); i >= 0 {
+		rangeStr = rangeStr[:i]
+	}
+	r, err := rangeutils.ParseRange(rangeStr)
+	if err != nil || int64(r.StartIndex) != start || int64(r.EndIndex) != end {
+		return errors.Wrapf(errRangeNotSatisfied, "request range %d-%d, response content range %q", start, end, contentRange)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"github.com/pkg/errors"
+)
+
+// freshness decides how long the cache of task is fresh without revalidating with the source.
+type freshness struct {
+	rules []*freshnessRule
+}
+
+type freshnessRule struct {
+	config.FreshnessRule
+	pattern *regexp.Regexp
+}
+
+func newFreshness(rules []config.FreshnessRule) (*freshness, error) {
+	freshnessRules := make([]*freshnessRule, 0, len(rules))
+	for _, rule := range rules {
+		switch rule.Policy {
+		case config.FreshnessPolicyImmutable, config.FreshnessPolicyCacheControl, config.FreshnessPolicyRevalidate:
+		case config.FreshnessPolicyTTL:
+			if rule.TTL <= 0 {
+				return nil, errors.Errorf("invalid ttl %s of freshness rule %s", rule.TTL, rule.URLPattern)
+			}
+		default:
+			return nil, errors.Errorf("unknown freshness policy %s of freshness rule %s", rule.Policy, rule.URLPattern)
+		}
+		pattern, err := regexp.Compile(rule.URLPattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid url pattern %s of freshness rule", rule.URLPattern)
+		}
+		freshnessRules = append(freshnessRules, &freshnessRule{
+			FreshnessRule: rule,
+			pattern:       pattern,
+		})
+	}
+	return &freshness{
+		rules: freshnessRules,
+	}, nil
+}
+
+// match returns the first rule matched with url, returns nil if no rule matched.
+func (f *freshness) match(url string) *freshnessRule {
+	for _, rule := range f.rules {
+		if rule.pattern.MatchString(url) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// evaluate returns the freshness policy of url and the time in millis until which the cache is fresh.
+func (f *freshness) evaluate(url string, responseHeader source.ResponseHeader, now time.Time) (string, int64) {
+	rule := f.match(url)
+	if rule == nil {
+		return config.FreshnessPolicyRevalidate, 0
+	}
+	switch rule.Policy {
+	case config.FreshnessPolicyTTL:
+		return rule.Policy, now.Add(rule.TTL).UnixNano() / int64(time.Millisecond)
+	case config.FreshnessPolicyCacheControl:
+		return rule.Policy, freshUntilByCacheControl(responseHeader, now)
+	default:
+		return rule.Policy, 0
+	}
+}
+
+// freshUntilByCacheControl calculates the time in millis until which the cache is fresh by the Cache-Control and Expires header.
+func freshUntilByCacheControl(responseHeader source.ResponseHeader, now time.Time) int64 {
+	var maxAge, sMaxAge = -1, -1
+	for _, directive := range strings.Split(responseHeader.Get(source.CacheControl), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			if v, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				maxAge = v
+			}
+		case strings.HasPrefix(directive, "s-maxage="):
+			if v, err := strconv.Atoi(strings.TrimPrefix(directive, "s-maxage=")); err == nil {
+				sMaxAge = v
+			}
+		}
+	}
+	// s-maxage overrides max-age for the shared cache
+	if sMaxAge >= 0 {
+		maxAge = sMaxAge
+	}
+	if maxAge >= 0 {
+		return now.Add(time.Duration(maxAge)*time.Second).UnixNano() / int64(time.Millisecond)
+	}
+	if expires, err := http.ParseTime(responseHeader.Get(source.Expires)); err == nil {
+		return expires.UnixNano() / int64(time.Millisecond)
+	}
+	return 0
+}
+
+// isFresh checks whether the cache of url is fresh at the time in millis, the fresh cache is used without revalidating with the source.
+// The stored freshness is capped by the current rule of url, so that the change of rules takes effect on the existing cache.
+func (f *freshness) isFresh(url string, metaData *storage.FileMetaData, nowMillis int64) bool {
+	rule := f.match(url)
+	if rule == nil || rule.Policy != metaData.FreshnessPolicy {
+		return false
+	}
+	switch metaData.FreshnessPolicy {
+	case config.FreshnessPolicyImmutable:
+		return true
+	case config.FreshnessPolicyTTL:
+		return nowMillis < metaData.FreshUntil && metaData.FreshUntil <= nowMillis+int64(rule.TTL/time.Millisecond)
+	case config.FreshnessPolicyCacheControl:
+		return nowMillis < metaData.FreshUntil
+	default:
+		return false
+	}
+}
+
+// canBeFresh checks whether the cache with the freshness policy can be used without revalidating with the source.
+func canBeFresh(policy string) bool {
+	switch policy {
+	case config.FreshnessPolicyImmutable, config.FreshnessPolicyTTL, config.FreshnessPolicyCacheControl:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/pkg/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFreshness(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []config.FreshnessRule
+		wantErr bool
+	}{
+		{name: "empty rules", rules: nil},
+		{name: "valid rules", rules: []config.FreshnessRule{
+			{URLPattern: "blobs/sha256:", Policy: config.FreshnessPolicyImmutable},
+			{URLPattern: ".*", Policy: config.FreshnessPolicyTTL, TTL: time.Minute},
+		}},
+		{name: "unknown policy", rules: []config.FreshnessRule{{URLPattern: ".*", Policy: "unknown"}}, wantErr: true},
+		{name: "ttl without duration", rules: []config.FreshnessRule{{URLPattern: ".*", Policy: config.FreshnessPolicyTTL}}, wantErr: true},
+		{name: "invalid pattern", rules: []config.FreshnessRule{{URLPattern: "(", Policy: config.FreshnessPolicyRevalidate}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newFreshness(tt.rules)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFreshnessEvaluate(t *testing.T) {
+	now := time.Date(2021, 6, 6, 12, 0, 0, 0, time.UTC)
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+	f, err := newFreshness([]config.FreshnessRule{
+		{URLPattern: "/blobs/sha256:", Policy: config.FreshnessPolicyImmutable},
+		{URLPattern: "^http://ttl.com/", Policy: config.FreshnessPolicyTTL, TTL: time.Minute},
+		{URLPattern: "^http://cache.com/", Policy: config.FreshnessPolicyCacheControl},
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name           string
+		url            string
+		responseHeader source.ResponseHeader
+		wantPolicy     string
+		wantFreshUntil int64
+	}{
+		{
+			name:       "immutable",
+			url:        "http://registry.com/v2/library/alpine/blobs/sha256:abc",
+			wantPolicy: config.FreshnessPolicyImmutable,
+		}, {
+			name:           "ttl",
+			url:            "http://ttl.com/file",
+			wantPolicy:     config.FreshnessPolicyTTL,
+			wantFreshUntil: nowMillis + 60*1000,
+		}, {
+			name:           "max-age",
+			url:            "http://cache.com/file",
+			responseHeader: source.ResponseHeader{source.CacheControl: "public, max-age=10"},
+			wantPolicy:     config.FreshnessPolicyCacheControl,
+			wantFreshUntil: nowMillis + 10*1000,
+		}, {
+			name:           "s-maxage overrides max-age",
+			url:            "http://cache.com/file",
+			responseHeader: source.ResponseHeader{source.CacheControl: "max-age=10, s-maxage=20"},
+			wantPolicy:     config.FreshnessPolicyCacheControl,
+			wantFreshUntil: nowMillis + 20*1000,
+		}, {
+			name:           "no-cache",
+			url:            "http://cache.com/file",
+			responseHeader: source.ResponseHeader{source.CacheControl: "no-cache, max-age=10"},
+			wantPolicy:     config.FreshnessPolicyCacheControl,
+		}, {
+			name:           "expires",
+			url:            "http://cache.com/file",
+			responseHeader: source.ResponseHeader{source.Expires: "Sun, 06 Jun 2021 12:01:00 GMT"},
+			wantPolicy:     config.FreshnessPolicyCacheControl,
+			wantFreshUntil: nowMillis + 60*1000,
+		}, {
+			name:       "no rule matched",
+			url:        "http://other.com/file",
+			wantPolicy: config.FreshnessPolicyRevalidate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, freshUntil := f.evaluate(tt.url, tt.responseHeader, now)
+			assert.Equal(t, tt.wantPolicy, policy)
+			assert.Equal(t, tt.wantFreshUntil, freshUntil)
+		})
+	}
+}
+
+func TestIsFresh(t *testing.T) {
+	assert := assert.New(t)
+	f, err := newFreshness([]config.FreshnessRule{
+		{URLPattern: "^http://immutable.com/", Policy: config.FreshnessPolicyImmutable},
+		{URLPattern: "^http://ttl.com/", Policy: config.FreshnessPolicyTTL, TTL: time.Millisecond},
+		{URLPattern: "^http://cache.com/", Policy: config.FreshnessPolicyCacheControl},
+		{URLPattern: "^http://revalidate.com/", Policy: config.FreshnessPolicyRevalidate},
+	})
+	assert.Nil(err)
+	assert.True(f.isFresh("http://immutable.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyImmutable}, 100))
+	assert.True(f.isFresh("http://ttl.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyTTL, FreshUntil: 101}, 100))
+	assert.False(f.isFresh("http://cache.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyCacheControl, FreshUntil: 100}, 100))
+	assert.False(f.isFresh("http://revalidate.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyRevalidate}, 100))
+	assert.False(f.isFresh("http://other.com/", &storage.FileMetaData{}, 100))
+
+	// the stored freshness is capped by the current rules
+	assert.False(f.isFresh("http://other.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyImmutable}, 100))
+	assert.False(f.isFresh("http://ttl.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyImmutable}, 100))
+	assert.False(f.isFresh("http://ttl.com/", &storage.FileMetaData{FreshnessPolicy: config.FreshnessPolicyTTL, FreshUntil: 102}, 100))
+}
@@ -20,22 +20,26 @@ import (
 	"time"
 
 	"context"
-	"crypto/md5"
 	"fmt"
+	"hash"
 
 	"d7y.io/dragonfly/v2/cdnsystem/daemon"
 	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/disk"   // To register diskStorage
 	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/hybrid" // To register hybridStorage
+	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/tiered" // To register tieredStorage
 	"d7y.io/dragonfly/v2/internal/rpc/cdnsystem/server"
+	"d7y.io/dragonfly/v2/pkg/source"
 	"d7y.io/dragonfly/v2/pkg/synclock"
 	"d7y.io/dragonfly/v2/pkg/util/timeutils"
 
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
 	"d7y.io/dragonfly/v2/pkg/ratelimiter/ratelimiter"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 	"github.com/pkg/errors"
 )
@@ -54,6 +58,8 @@ type Manager struct {
 	cdnReporter      *reporter
 	detector         *cacheDetector
 	writer           *cacheWriter
+	sourceLimiter    *sourceLimiter
+	freshness        *freshness
 }
 
 // NewManager returns a new Manager.
@@ -65,6 +71,21 @@ func newManager(cfg *config.Config, cacheStore storage.Manager, progressMgr daem
 	rateLimiter := ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.MaxBandwidth-cfg.SystemReservedBandwidth)), 2)
 	cacheDataManager := newCacheDataManager(cacheStore)
 	cdnReporter := newReporter(progressMgr)
+	sourceLimiter, err := newSourceLimiter(cfg.SourceLimits)
+	if err != nil {
+		return nil, errors.Wrap(err, "create source limiter")
+	}
+	freshness, err := newFreshness(cfg.FreshnessRules)
+	if err != nil {
+		return nil, errors.Wrap(err, "create freshness")
+	}
+	pieceDigestAlgorithm := cfg.PieceDigestAlgorithm
+	if stringutils.IsBlank(pieceDigestAlgorithm) {
+		pieceDigestAlgorithm = digestutils.AlgorithmMD5
+	}
+	if _, err := digestutils.NewHash(pieceDigestAlgorithm); err != nil {
+		return nil, errors.Wrap(err, "invalid piece digest algorithm")
+	}
 	return &Manager{
 		cfg:              cfg,
 		cacheStore:       cacheStore,
@@ -73,8 +94,10 @@ func newManager(cfg *config.Config, cacheStore storage.Manager, progressMgr daem
 		cacheDataManager: cacheDataManager,
 		cdnReporter:      cdnReporter,
 		progressMgr:      progressMgr,
-		detector:         newCacheDetector(cacheDataManager),
-		writer:           newCacheWriter(cdnReporter, cacheDataManager),
+		detector:         newCacheDetector(cacheDataManager, sourceLimiter, freshness),
+		writer:           newCacheWriter(cdnReporter, cacheDataManager, pieceDigestAlgorithm),
+		sourceLimiter:    sourceLimiter,
+		freshness:        freshness,
 	}, nil
 }
 
@@ -85,8 +108,8 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.SeedTask) (seedTa
 	cm.cdnLocker.Lock(task.TaskID, false)
 	defer cm.cdnLocker.UnLock(task.TaskID, false)
 	// first: detect Cache
-	fileMd5 := md5.New()
-	detectResult, err := cm.detector.detectCache(task, fileMd5)
+	digestAlgorithm, fileDigest := newSourceDigest(task)
+	detectResult, err := cm.detector.detectCache(task, fileDigest)
 	if err != nil {
 		seedTask.UpdateStatus(types.TaskInfoCdnStatusFailed)
 		return seedTask, errors.Wrapf(err, "failed to detect cache")
@@ -100,61 +123,98 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.SeedTask) (seedTa
 	// full cache
 	if detectResult.breakPoint == -1 {
 		logger.WithTaskID(task.TaskID).Infof("cache full hit on local")
-		seedTask.UpdateTaskInfo(types.TaskInfoCdnStatusSuccess, detectResult.fileMetaData.SourceRealMd5, detectResult.fileMetaData.PieceMd5Sign,
+		seedTask.UpdateTaskInfo(types.TaskInfoCdnStatusSuccess, detectResult.fileMetaData.SourceRealDigest, detectResult.fileMetaData.PieceMd5Sign,
 			detectResult.fileMetaData.SourceFileLen, detectResult.fileMetaData.CdnFileLength)
 		return seedTask, nil
 	}
 	server.StatSeedStart(task.TaskID, task.URL)
 	start := time.Now()
-	// third: start to download the source file
-	body, err := cm.download(ctx, task, detectResult)
-	// download fail
-	if err != nil {
-		server.StatSeedFinish(task.TaskID, task.URL, false, err, start.Nanosecond(), time.Now().Nanosecond(), 0, 0)
-		seedTask.UpdateStatus(types.TaskInfoCdnStatusSourceError)
-		return seedTask, err
+	var (
+		downloadMetadata *downloadMetadata
+		sourceDigest     string
+	)
+	parallel := cm.canDownloadInParallel(ctx, task, detectResult)
+	if parallel {
+		// third and forth: download the ranges of the source file concurrently and write them to storage
+		downloadMetadata, sourceDigest, err = cm.downloadInParallel(ctx, task, detectResult, fileDigest)
+		if errors.Is(err, errRangeNotSatisfied) {
+			logger.WithTaskID(task.TaskID).Warnf("source does not support parallel range download, fall back to a single stream: %v", err)
+			// the pieces written in parallel are dropped, so that the piece meta records are not duplicated
+			metaData, resetErr := cm.detector.resetCache(task)
+			if resetErr != nil {
+				server.StatSeedFinish(task.TaskID, task.URL, false, resetErr, start.Nanosecond(), time.Now().Nanosecond(), 0, 0)
+				seedTask.UpdateStatus(types.TaskInfoCdnStatusFailed)
+				return seedTask, errors.Wrap(resetErr, "failed to reset cache")
+			}
+			detectResult = &cacheResult{fileMetaData: metaData}
+			parallel = false
+		} else if err != nil {
+			server.StatSeedFinish(task.TaskID, task.URL, false, err, start.Nanosecond(), time.Now().Nanosecond(), downloadMetadata.backSourceLength,
+				downloadMetadata.realSourceFileLength)
+			logger.WithTaskID(task.TaskID).Errorf("failed to download in parallel for task: %v", err)
+			seedTask.UpdateStatus(sourceErrorStatus(err))
+			return seedTask, err
+		}
 	}
-	defer body.Close()
+	if !parallel {
+		// third: start to download the source file
+		body, err := cm.download(ctx, task, detectResult)
+		// download fail
+		if err != nil {
+			server.StatSeedFinish(task.TaskID, task.URL, false, err, start.Nanosecond(), time.Now().Nanosecond(), 0, 0)
+			seedTask.UpdateStatus(sourceErrorStatus(err))
+			return seedTask, err
+		}
+		defer body.Close()
 
-	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(body, cm.limiter, fileMd5)
-	// forth: write to storage
-	downloadMetadata, err := cm.writer.startWriter(reader, task, detectResult)
-	if err != nil {
-		server.StatSeedFinish(task.TaskID, task.URL, false, err, start.Nanosecond(), time.Now().Nanosecond(), downloadMetadata.backSourceLength,
-			downloadMetadata.realSourceFileLength)
-		logger.WithTaskID(task.TaskID).Errorf("failed to write for task: %v", err)
-		seedTask.UpdateStatus(types.TaskInfoCdnStatusFailed)
-		return seedTask, err
+		reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(body, cm.limiter, fileDigest)
+		// forth: write to storage
+		downloadMetadata, err = cm.writer.startWriter(reader, task, detectResult)
+		if err != nil {
+			server.StatSeedFinish(task.TaskID, task.URL, false, err, start.Nanosecond(), time.Now().Nanosecond(), downloadMetadata.backSourceLength,
+				downloadMetadata.realSourceFileLength)
+			logger.WithTaskID(task.TaskID).Errorf("failed to write for task: %v", err)
+			seedTask.UpdateStatus(types.TaskInfoCdnStatusFailed)
+			return seedTask, err
+		}
+		sourceDigest = reader.Md5()
 	}
+	sourceDigest = digestutils.Format(digestAlgorithm, sourceDigest)
 	server.StatSeedFinish(task.TaskID, task.URL, true, nil, start.Nanosecond(), time.Now().Nanosecond(), downloadMetadata.backSourceLength,
 		downloadMetadata.realSourceFileLength)
-	sourceMD5 := reader.Md5()
 	// fifth: handle CDN result
-	success, err := cm.handleCDNResult(task, sourceMD5, downloadMetadata)
+	success, err := cm.handleCDNResult(task, sourceDigest, downloadMetadata)
 	if err != nil || !success {
 		seedTask.UpdateStatus(types.TaskInfoCdnStatusFailed)
 		return seedTask, err
 	}
-	seedTask.UpdateTaskInfo(types.TaskInfoCdnStatusSuccess, sourceMD5, downloadMetadata.pieceMd5Sign,
+	seedTask.UpdateTaskInfo(types.TaskInfoCdnStatusSuccess, sourceDigest, downloadMetadata.pieceMd5Sign,
 		downloadMetadata.realSourceFileLength, downloadMetadata.realCdnFileLength)
 	return seedTask, nil
 }
 
 func (cm *Manager) Delete(taskID string) error {
-	err := cm.cacheStore.DeleteTask(taskID)
+	err := cm.cacheStore.PurgeTask(taskID)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete task files")
 	}
 	return nil
 }
 
-func (cm *Manager) handleCDNResult(task *types.SeedTask, sourceMd5 string, downloadMetadata *downloadMetadata) (bool, error) {
+func (cm *Manager) IsFresh(url string, freshnessPolicy string, freshUntil int64) bool {
+	return cm.freshness.isFresh(url, &storage.FileMetaData{
+		FreshnessPolicy: freshnessPolicy,
+		FreshUntil:      freshUntil,
+	}, getCurrentTimeMillisFunc())
+}
+
+func (cm *Manager) handleCDNResult(task *types.SeedTask, sourceDigest string, downloadMetadata *downloadMetadata) (bool, error) {
 	logger.WithTaskID(task.TaskID).Debugf("handle cdn result, downloadMetaData: %+v", downloadMetadata)
 	var isSuccess = true
 	var errorMsg string
-	// check md5
-	if !stringutils.IsBlank(task.RequestMd5) && task.RequestMd5 != sourceMd5 {
-		errorMsg = fmt.Sprintf("file md5 not match expected:%s real:%s", task.RequestMd5, sourceMd5)
+	// check digest
+	if !stringutils.IsBlank(task.RequestDigest) && task.RequestDigest != sourceDigest {
+		errorMsg = fmt.Sprintf("file digest not match expected:%s real:%s", task.RequestDigest, sourceDigest)
 		isSuccess = false
 	}
 	// check source length
@@ -180,13 +240,13 @@ func (cm *Manager) handleCDNResult(task *types.SeedTask, sourceMd5 string, downl
 		cdnFileLength = 0
 	}
 	if err := cm.cacheDataManager.updateStatusAndResult(task.TaskID, &storage.FileMetaData{
-		Finish:          true,
-		Success:         isSuccess,
-		SourceRealMd5:   sourceMd5,
-		PieceMd5Sign:    pieceMd5Sign,
-		CdnFileLength:   cdnFileLength,
-		SourceFileLen:   sourceFileLen,
-		TotalPieceCount: downloadMetadata.pieceTotalCount,
+		Finish:           true,
+		Success:          isSuccess,
+		SourceRealDigest: sourceDigest,
+		PieceMd5Sign:     pieceMd5Sign,
+		CdnFileLength:    cdnFileLength,
+		SourceFileLen:    sourceFileLen,
+		TotalPieceCount:  downloadMetadata.pieceTotalCount,
 	}); err != nil {
 		return false, errors.Wrap(err, "failed to update task status and result")
 	}
@@ -195,19 +255,54 @@ func (cm *Manager) handleCDNResult(task *types.SeedTask, sourceMd5 string, downl
 		return false, errors.New(errorMsg)
 	}
 
-	logger.WithTaskID(task.TaskID).Infof("success to get task, downloadMetadata:%+v realMd5: %s", downloadMetadata, sourceMd5)
+	logger.WithTaskID(task.TaskID).Infof("success to get task, downloadMetadata:%+v realDigest: %s", downloadMetadata, sourceDigest)
 
 	return true, nil
 }
 
-func (cm *Manager) updateExpireInfo(taskID string, expireInfo map[string]string) {
-	if err := cm.cacheDataManager.updateExpireInfo(taskID, expireInfo); err != nil {
-		logger.WithTaskID(taskID).Errorf("failed to update expireInfo(%s): %v", expireInfo, err)
+func (cm *Manager) updateExpireInfo(task *types.SeedTask, responseHeader source.ResponseHeader) {
+	// the cache control headers are kept to re-evaluate the freshness after revalidation
+	expireInfo := map[string]string{
+		source.LastModified: responseHeader.Get(source.LastModified),
+		source.ETag:         responseHeader.Get(source.ETag),
+		source.CacheControl: responseHeader.Get(source.CacheControl),
+		source.Expires:      responseHeader.Get(source.Expires),
+	}
+	freshnessPolicy, freshUntil := cm.freshness.evaluate(task.URL, responseHeader, time.Now())
+	if err := cm.cacheDataManager.updateExpireInfo(task.TaskID, expireInfo, freshnessPolicy, freshUntil); err != nil {
+		logger.WithTaskID(task.TaskID).Errorf("failed to update expireInfo(%s): %v", expireInfo, err)
+		return
 	}
-	logger.WithTaskID(taskID).Infof("success to update expireInfo(%s)", expireInfo)
+	logger.WithTaskID(task.TaskID).Infof("success to update expireInfo(%s), freshness policy: %s, fresh until: %d", expireInfo,
+		freshnessPolicy, freshUntil)
 }
 
 /*
 	helper functions
 */
 var getCurrentTimeMillisFunc = timeutils.CurrentTimeMillis
+
+// sourceErrorStatus returns the cdn status of task when failed to download from source,
+// the failures of storage are reported as failed rather than source error.
+func sourceErrorStatus(err error) string {
+	if isStorageError(err) {
+		return types.TaskInfoCdnStatusFailed
+	}
+	if cdnerrors.IsSourceLimited(err) {
+		return types.TaskInfoCdnStatusSourceLimited
+	}
+	return types.TaskInfoCdnStatusSourceError
+}
+
+// newSourceDigest returns the hash to calculate the digest of source file with the algorithm of the request digest,
+// md5 is used when the task is requested without digest.
+func newSourceDigest(task *types.SeedTask) (string, hash.Hash) {
+	algorithm := digestutils.AlgorithmMD5
+	if !stringutils.IsBlank(task.RequestDigest) {
+		if requestAlgorithm, _, err := digestutils.Parse(task.RequestDigest); err == nil {
+			algorithm = requestAlgorithm
+		}
+	}
+	h, _ := digestutils.NewHash(algorithm)
+	return algorithm, h
+}
@@ -34,10 +34,13 @@ import (
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/source"
 	sourceMock "d7y.io/dragonfly/v2/pkg/source/mock"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
 	"d7y.io/dragonfly/v2/pkg/util/timeutils"
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -134,8 +137,8 @@ func (suite *CDNManagerTestSuite) TestTriggerCDN() {
 		Header:           map[string]string{"md5": "f1e2488bba4d1267948d9e2f7008571c"},
 		CdnStatus:        types.TaskInfoCdnStatusRunning,
 		PieceTotal:       0,
-		RequestMd5:       "f1e2488bba4d1267948d9e2f7008571c",
-		SourceRealMd5:    "",
+		RequestDigest:    "f1e2488bba4d1267948d9e2f7008571c",
+		SourceRealDigest: "",
 		PieceMd5Sign:     "",
 	}
 
@@ -149,8 +152,8 @@ func (suite *CDNManagerTestSuite) TestTriggerCDN() {
 		Header:           map[string]string{"md5": "f1e2488bba4d1267948d9e2f7008571c"},
 		CdnStatus:        types.TaskInfoCdnStatusSuccess,
 		PieceTotal:       0,
-		RequestMd5:       "f1e2488bba4d1267948d9e2f7008571c",
-		SourceRealMd5:    "f1e2488bba4d1267948d9e2f7008571c",
+		RequestDigest:    "f1e2488bba4d1267948d9e2f7008571c",
+		SourceRealDigest: "f1e2488bba4d1267948d9e2f7008571c",
 		PieceMd5Sign:     "bb138842f338fff90af737e4a6b2c6f8e2a7031ca9d5900bc9b646f6406d890f",
 	}
 	gotSeedTask, err := suite.cm.TriggerCDN(context.Background(), sourceTask)
@@ -161,3 +164,175 @@ func (suite *CDNManagerTestSuite) TestTriggerCDN() {
 	suite.Equal(targetTask, cacheSeedTask)
 	// todo test range download
 }
+
+func (suite *CDNManagerTestSuite) TestTriggerCDNInParallel() {
+	parallelURL := "http://dragonfly.io.com/parallel"
+	parallelTaskID := idgen.TaskID(parallelURL, "", nil, "dragonfly")
+	content, err := ioutil.ReadFile("../../testdata/cdn/go.html")
+	suite.Nil(err)
+
+	ctrl := gomock.NewController(suite.T())
+	sourceClient := sourceMock.NewMockResourceClient(ctrl)
+	source.Register("http", sourceClient)
+	defer source.UnRegister("http")
+	sourceClient.EXPECT().IsSupportRange(gomock.Any(), parallelURL, gomock.Any()).Return(true, nil).AnyTimes()
+	sourceClient.EXPECT().IsExpired(gomock.Any(), parallelURL, gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	sourceClient.EXPECT().DownloadWithResponseHeader(gomock.Any(), parallelURL, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, url string, header source.RequestHeader) (io.ReadCloser, source.ResponseHeader, error) {
+			rang, ok := header["Range"]
+			suite.True(ok)
+			r, err := rangeutils.ParseHTTPRange(rang)
+			suite.Nil(err)
+			return ioutil.NopCloser(strings.NewReader(string(content[r.StartIndex : r.EndIndex+1]))), map[string]string{
+				source.LastModified: "Sun, 06 Jun 2021 12:52:30 GMT",
+				source.ETag:         "etag",
+				source.ContentRange: fmt.Sprintf("bytes %d-%d/%d", r.StartIndex, r.EndIndex, len(content)),
+			}, nil
+		},
+	).Times(10)
+
+	progressMgr := mock.NewMockSeedProgressMgr(ctrl)
+	progressMgr.EXPECT().PublishPiece(parallelTaskID, gomock.Any()).Return(nil).Times(98)
+	storeMgr, ok := storage.Get(config.DefaultStorageMode)
+	suite.True(ok)
+	cfg := config.New()
+	cfg.SourceConcurrency = 3
+	cfg.SourceChunkSize = 1050
+	cm, err := newManager(cfg, storeMgr, progressMgr)
+	suite.Nil(err)
+
+	sourceTask := &types.SeedTask{
+		TaskID:           parallelTaskID,
+		URL:              parallelURL,
+		TaskURL:          parallelURL,
+		SourceFileLength: 9789,
+		PieceSize:        100,
+		CdnStatus:        types.TaskInfoCdnStatusRunning,
+		RequestDigest:    "f1e2488bba4d1267948d9e2f7008571c",
+	}
+	gotSeedTask, err := cm.TriggerCDN(context.Background(), sourceTask)
+	suite.Nil(err)
+	suite.Equal(types.TaskInfoCdnStatusSuccess, gotSeedTask.CdnStatus)
+	suite.Equal(int64(9789), gotSeedTask.CdnFileLength)
+	suite.Equal("f1e2488bba4d1267948d9e2f7008571c", gotSeedTask.SourceRealDigest)
+	suite.Equal("bb138842f338fff90af737e4a6b2c6f8e2a7031ca9d5900bc9b646f6406d890f", gotSeedTask.PieceMd5Sign)
+}
+
+func (suite *CDNManagerTestSuite) TestTriggerCDNInParallelWithRangeIgnored() {
+	ignoredURL := "http://dragonfly.io.com/range-ignored"
+	ignoredTaskID := idgen.TaskID(ignoredURL, "", nil, "dragonfly")
+	content, err := ioutil.ReadFile("../../testdata/cdn/go.html")
+	suite.Nil(err)
+
+	ctrl := gomock.NewController(suite.T())
+	sourceClient := sourceMock.NewMockResourceClient(ctrl)
+	source.Register("http", sourceClient)
+	defer source.UnRegister("http")
+	sourceClient.EXPECT().IsSupportRange(gomock.Any(), ignoredURL, gomock.Any()).Return(true, nil).AnyTimes()
+	sourceClient.EXPECT().IsExpired(gomock.Any(), ignoredURL, gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	// the source ignores the range header and always responds with the whole content
+	sourceClient.EXPECT().DownloadWithResponseHeader(gomock.Any(), ignoredURL, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, url string, header source.RequestHeader) (io.ReadCloser, source.ResponseHeader, error) {
+			return ioutil.NopCloser(strings.NewReader(string(content))), map[string]string{
+				source.LastModified: "Sun, 06 Jun 2021 12:52:30 GMT",
+				source.ETag:         "etag",
+			}, nil
+		},
+	).MinTimes(2)
+
+	progressMgr := mock.NewMockSeedProgressMgr(ctrl)
+	progressMgr.EXPECT().PublishPiece(ignoredTaskID, gomock.Any()).Return(nil).Times(98)
+	storeMgr, ok := storage.Get(config.DefaultStorageMode)
+	suite.True(ok)
+	cfg := config.New()
+	cfg.SourceConcurrency = 3
+	cfg.SourceChunkSize = 1050
+	cm, err := newManager(cfg, storeMgr, progressMgr)
+	suite.Nil(err)
+
+	sourceTask := &types.SeedTask{
+		TaskID:           ignoredTaskID,
+		URL:              ignoredURL,
+		TaskURL:          ignoredURL,
+		SourceFileLength: 9789,
+		PieceSize:        100,
+		CdnStatus:        types.TaskInfoCdnStatusRunning,
+		RequestDigest:    "f1e2488bba4d1267948d9e2f7008571c",
+	}
+	gotSeedTask, err := cm.TriggerCDN(context.Background(), sourceTask)
+	suite.Nil(err)
+	suite.Equal(types.TaskInfoCdnStatusSuccess, gotSeedTask.CdnStatus)
+	suite.Equal(int64(9789), gotSeedTask.CdnFileLength)
+	suite.Equal("f1e2488bba4d1267948d9e2f7008571c", gotSeedTask.SourceRealDigest)
+	suite.Equal("bb138842f338fff90af737e4a6b2c6f8e2a7031ca9d5900bc9b646f6406d890f", gotSeedTask.PieceMd5Sign)
+}
+
+func TestCheckContentRange(t *testing.T) {
+	assert.Nil(t, checkContentRange("bytes 100-199/9789", 100, 199))
+	assert.True(t, errors.Is(checkContentRange("", 100, 199), errRangeNotSatisfied))
+	assert.True(t, errors.Is(checkContentRange("bytes 0-9788/9789", 100, 199), errRangeNotSatisfied))
+	assert.True(t, errors.Is(checkContentRange("bytes */9789", 100, 199), errRangeNotSatisfied))
+}
+
+func TestSourceErrorStatus(t *testing.T) {
+	assert.Equal(t, types.TaskInfoCdnStatusSourceError, sourceErrorStatus(fmt.Errorf("connection refused")))
+	assert.Equal(t, types.TaskInfoCdnStatusFailed, sourceErrorStatus(errors.Wrap(&storageError{fmt.Errorf("disk full")}, "write data")))
+}
+
+func (suite *CDNManagerTestSuite) TestTriggerCDNWithSHA256Digest() {
+	sha256URL := "http://dragonfly.io.com/sha256"
+	content, err := ioutil.ReadFile("../../testdata/cdn/go.html")
+	suite.Nil(err)
+	sourceDigest := "sha256:" + digestutils.Sha256(string(content))
+	sha256TaskID := idgen.TaskID(sha256URL, "", &base.UrlMeta{Digest: sourceDigest}, "dragonfly")
+
+	ctrl := gomock.NewController(suite.T())
+	sourceClient := sourceMock.NewMockResourceClient(ctrl)
+	source.Register("http", sourceClient)
+	defer source.UnRegister("http")
+	sourceClient.EXPECT().IsSupportRange(gomock.Any(), sha256URL, gomock.Any()).Return(false, nil).AnyTimes()
+	sourceClient.EXPECT().IsExpired(gomock.Any(), sha256URL, gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	sourceClient.EXPECT().DownloadWithResponseHeader(gomock.Any(), sha256URL, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, url string, header source.RequestHeader) (io.ReadCloser, source.ResponseHeader, error) {
+			return ioutil.NopCloser(strings.NewReader(string(content))), map[string]string{
+				source.LastModified: "Sun, 06 Jun 2021 12:52:30 GMT",
+				source.ETag:         "etag",
+			}, nil
+		},
+	).Times(1)
+
+	progressMgr := mock.NewMockSeedProgressMgr(ctrl)
+	progressMgr.EXPECT().PublishPiece(sha256TaskID, gomock.Any()).DoAndReturn(func(taskID string, piece *types.SeedPiece) error {
+		suite.True(strings.HasPrefix(piece.PieceMd5, "sha256:"))
+		return nil
+	}).Times(98 * 2)
+	storeMgr, ok := storage.Get(config.DefaultStorageMode)
+	suite.True(ok)
+	cfg := config.New()
+	cfg.PieceDigestAlgorithm = digestutils.AlgorithmSHA256
+	cm, err := newManager(cfg, storeMgr, progressMgr)
+	suite.Nil(err)
+
+	sourceTask := &types.SeedTask{
+		TaskID:           sha256TaskID,
+		URL:              sha256URL,
+		TaskURL:          sha256URL,
+		SourceFileLength: 9789,
+		PieceSize:        100,
+		CdnStatus:        types.TaskInfoCdnStatusRunning,
+		RequestDigest:    sourceDigest,
+	}
+	gotSeedTask, err := cm.TriggerCDN(context.Background(), sourceTask)
+	suite.Nil(err)
+	suite.Equal(types.TaskInfoCdnStatusSuccess, gotSeedTask.CdnStatus)
+	suite.Equal(sourceDigest, gotSeedTask.SourceRealDigest)
+	// the sha256 piece digests are verified when the cache is hit
+	cacheSeedTask, err := cm.TriggerCDN(context.Background(), gotSeedTask)
+	suite.Nil(err)
+	suite.Equal(types.TaskInfoCdnStatusSuccess, cacheSeedTask.CdnStatus)
+	suite.Equal(sourceDigest, cacheSeedTask.SourceRealDigest)
+
+	cfg.PieceDigestAlgorithm = "sha1"
+	_, err = newManager(cfg, storeMgr, progressMgr)
+	suite.NotNil(err)
+}
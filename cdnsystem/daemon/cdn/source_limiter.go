@@ -0,0 +1,215 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/ratelimiter"
+	"github.com/pkg/errors"
+)
+
+// hostLimiterIdleTimeout is how long an unused host limiter is kept.
+const hostLimiterIdleTimeout = 10 * time.Minute
+
+// sourceProbeTimeout is the timeout of the requests probing the source such as HEAD,
+// the waiting for connection before probing is not included.
+var sourceProbeTimeout = 4 * time.Second
+
+// sourceLimiter limits the concurrent connections and bandwidth to the source hosts.
+type sourceLimiter struct {
+	rules []*sourceLimitRule
+	// hosts maps the rule and host to the hostLimiter
+	hosts map[string]*hostLimiter
+	mu    sync.Mutex
+	// idleTimeout is how long an unused host limiter is kept before it is evicted
+	idleTimeout time.Duration
+	lastEvict   time.Time
+}
+
+type sourceLimitRule struct {
+	config.SourceLimit
+	pattern *regexp.Regexp
+}
+
+type hostLimiter struct {
+	// connections holds a token for every connection, it is nil when the concurrency is not limited
+	connections chan struct{}
+	// bandwidth is nil when the bandwidth is not limited
+	bandwidth   *ratelimiter.RateLimiter
+	waitTimeout time.Duration
+	// refs is the number of the users of the limiter, protected by the mutex of sourceLimiter
+	refs     int
+	lastUsed time.Time
+}
+
+func newSourceLimiter(limits []config.SourceLimit) (*sourceLimiter, error) {
+	rules := make([]*sourceLimitRule, 0, len(limits))
+	for _, limit := range limits {
+		pattern, err := regexp.Compile(limit.URLPattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid url pattern %s of source limit", limit.URLPattern)
+		}
+		rules = append(rules, &sourceLimitRule{
+			SourceLimit: limit,
+			pattern:     pattern,
+		})
+	}
+	return &sourceLimiter{
+		rules:       rules,
+		hosts:       make(map[string]*hostLimiter),
+		idleTimeout: hostLimiterIdleTimeout,
+	}, nil
+}
+
+// getHostLimiter returns the limiter of the host of rawURL, returns nil if no rule matched.
+// The limiter must be put back by putHostLimiter after use.
+func (sl *sourceLimiter) getHostLimiter(rawURL string) *hostLimiter {
+	for i, rule := range sl.rules {
+		if !rule.pattern.MatchString(rawURL) {
+			continue
+		}
+		host := rawURL
+		if u, err := url.Parse(rawURL); err == nil {
+			host = u.Host
+		}
+		key := strconv.Itoa(i) + "/" + host
+		sl.mu.Lock()
+		defer sl.mu.Unlock()
+		sl.evictIdle(time.Now())
+		if limiter, ok := sl.hosts[key]; ok {
+			limiter.refs++
+			return limiter
+		}
+		limiter := &hostLimiter{
+			waitTimeout: rule.WaitTimeout,
+			refs:        1,
+		}
+		if rule.Concurrency > 0 {
+			limiter.connections = make(chan struct{}, rule.Concurrency)
+		}
+		if rule.Bandwidth > 0 {
+			limiter.bandwidth = ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(rule.Bandwidth)), 2)
+		}
+		sl.hosts[key] = limiter
+		return limiter
+	}
+	return nil
+}
+
+// putHostLimiter puts back the limiter got by getHostLimiter.
+func (sl *sourceLimiter) putHostLimiter(limiter *hostLimiter) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	limiter.refs--
+	limiter.lastUsed = time.Now()
+}
+
+// evictIdle removes the host limiters which are not used for idleTimeout, it is called with the mutex held.
+func (sl *sourceLimiter) evictIdle(now time.Time) {
+	if now.Sub(sl.lastEvict) < sl.idleTimeout {
+		return
+	}
+	sl.lastEvict = now
+	for key, limiter := range sl.hosts {
+		if limiter.refs == 0 && now.Sub(limiter.lastUsed) >= sl.idleTimeout {
+			delete(sl.hosts, key)
+		}
+	}
+}
+
+// acquire waits for a connection to the source of rawURL, and returns the function to release the connection.
+func (sl *sourceLimiter) acquire(ctx context.Context, rawURL string) (*hostLimiter, func(), error) {
+	limiter := sl.getHostLimiter(rawURL)
+	if limiter == nil {
+		return nil, func() {}, nil
+	}
+	var once sync.Once
+	if limiter.connections == nil {
+		return limiter, func() {
+			once.Do(func() {
+				sl.putHostLimiter(limiter)
+			})
+		}, nil
+	}
+	var timeout <-chan time.Time
+	if limiter.waitTimeout > 0 {
+		timer := time.NewTimer(limiter.waitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case limiter.connections <- struct{}{}:
+		return limiter, func() {
+			once.Do(func() {
+				<-limiter.connections
+				sl.putHostLimiter(limiter)
+			})
+		}, nil
+	case <-timeout:
+		sl.putHostLimiter(limiter)
+		return nil, nil, cdnerrors.ErrSourceLimited{URL: rawURL}
+	case <-ctx.Done():
+		sl.putHostLimiter(limiter)
+		return nil, nil, ctx.Err()
+	}
+}
+
+// do waits for a connection to the source of rawURL and calls fn with it, so that the requests probing the source
+// such as HEAD are limited as well as the downloads.
+func (sl *sourceLimiter) do(ctx context.Context, rawURL string, fn func() error) error {
+	_, release, err := sl.acquire(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// limitedReadCloser limits the bandwidth of reading from source and releases the connection when closed.
+type limitedReadCloser struct {
+	io.Reader
+	closer  io.Closer
+	release func()
+}
+
+func (r *limitedReadCloser) Close() error {
+	defer r.release()
+	return r.closer.Close()
+}
+
+// wrap limits the bandwidth of the reader with the limiter of host, and releases the connection when the reader is closed.
+func (limiter *hostLimiter) wrap(rc io.ReadCloser, release func()) io.ReadCloser {
+	var reader io.Reader = rc
+	if limiter != nil && limiter.bandwidth != nil {
+		reader = limitreader.NewLimitReaderWithLimiter(limiter.bandwidth, rc, false)
+	}
+	return &limitedReadCloser{
+		Reader:  reader,
+		closer:  rc,
+		release: release,
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/pkg/source"
+	sourceMock "d7y.io/dragonfly/v2/pkg/source/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceLimiter(t *testing.T) {
+	assert := assert.New(t)
+	_, err := newSourceLimiter([]config.SourceLimit{{URLPattern: "("}})
+	assert.NotNil(err)
+
+	sl, err := newSourceLimiter([]config.SourceLimit{
+		{
+			URLPattern:  "^http://limited",
+			Concurrency: 1,
+			WaitTimeout: 10 * time.Millisecond,
+		},
+	})
+	assert.Nil(err)
+
+	// no rule matched
+	limiter, release, err := sl.acquire(context.Background(), "http://unlimited.com/a")
+	assert.Nil(err)
+	assert.Nil(limiter)
+	release()
+
+	limiter, release, err = sl.acquire(context.Background(), "http://limited-a.com/a")
+	assert.Nil(err)
+	assert.NotNil(limiter)
+
+	// every host has its own limit
+	_, releaseB, err := sl.acquire(context.Background(), "http://limited-b.com/a")
+	assert.Nil(err)
+	releaseB()
+
+	// wait too long for the connection of the same host
+	_, _, err = sl.acquire(context.Background(), "http://limited-a.com/b")
+	assert.True(cdnerrors.IsSourceLimited(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = sl.acquire(ctx, "http://limited-a.com/b")
+	assert.Equal(context.Canceled, err)
+
+	// the connection is released when the reader is closed
+	reader := limiter.wrap(ioutil.NopCloser(strings.NewReader("data")), release)
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal("data", string(data))
+	assert.Nil(reader.Close())
+	assert.Nil(reader.Close())
+	_, release, err = sl.acquire(context.Background(), "http://limited-a.com/b")
+	assert.Nil(err)
+
+	// the probes share the limit of the downloads
+	err = sl.do(context.Background(), "http://limited-a.com/c", func() error {
+		return nil
+	})
+	assert.True(cdnerrors.IsSourceLimited(err))
+	release()
+	var called bool
+	err = sl.do(context.Background(), "http://limited-a.com/c", func() error {
+		called = true
+		return nil
+	})
+	assert.Nil(err)
+	assert.True(called)
+}
+
+func TestSourceLimiterEvictIdle(t *testing.T) {
+	assert := assert.New(t)
+	sl, err := newSourceLimiter([]config.SourceLimit{
+		{
+			URLPattern:  "^http://limited",
+			Concurrency: 1,
+		},
+	})
+	assert.Nil(err)
+	sl.idleTimeout = 0
+
+	_, releaseA, err := sl.acquire(context.Background(), "http://limited-a.com/a")
+	assert.Nil(err)
+	_, releaseB, err := sl.acquire(context.Background(), "http://limited-b.com/a")
+	assert.Nil(err)
+	releaseB()
+
+	// the limiter in use is kept, the idle one is evicted
+	_, releaseC, err := sl.acquire(context.Background(), "http://limited-c.com/a")
+	assert.Nil(err)
+	assert.Len(sl.hosts, 2)
+	assert.Contains(sl.hosts, "0/limited-a.com")
+	assert.Contains(sl.hosts, "0/limited-c.com")
+	releaseA()
+	releaseC()
+	assert.Nil(sl.do(context.Background(), "http://limited-d.com/a", func() error {
+		return nil
+	}))
+	assert.Len(sl.hosts, 1)
+	assert.Contains(sl.hosts, "0/limited-d.com")
+}
+
+func TestGetContentLengthWithSourceLimit(t *testing.T) {
+	assert := assert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	sourceClient := sourceMock.NewMockResourceClient(ctrl)
+	source.Register("http", sourceClient)
+	defer source.UnRegister("http")
+	sourceClient.EXPECT().GetContentLength(gomock.Any(), "http://limited.com/a", gomock.Any()).Return(int64(100), nil).Times(1)
+
+	defer func(timeout time.Duration) {
+		sourceProbeTimeout = timeout
+	}(sourceProbeTimeout)
+	sourceProbeTimeout = 10 * time.Millisecond
+	sl, err := newSourceLimiter([]config.SourceLimit{
+		{
+			URLPattern:  "^http://limited",
+			Concurrency: 1,
+			WaitTimeout: 50 * time.Millisecond,
+		},
+	})
+	assert.Nil(err)
+	cm := &Manager{sourceLimiter: sl}
+
+	// the host is saturated, the content length is unknown
+	_, release, err := sl.acquire(context.Background(), "http://limited.com/b")
+	assert.Nil(err)
+	contentLength, err := cm.GetContentLength(context.Background(), "http://limited.com/a", nil)
+	assert.True(cdnerrors.IsSourceLimited(err))
+	assert.Equal(int64(-1), contentLength)
+
+	// waiting for the connection is limited by the wait timeout of rule rather than the probe timeout
+	time.AfterFunc(30*time.Millisecond, release)
+	contentLength, err = cm.GetContentLength(context.Background(), "http://limited.com/a", nil)
+	assert.Nil(err)
+	assert.Equal(int64(100), contentLength)
+}
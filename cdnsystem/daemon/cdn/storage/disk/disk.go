@@ -53,23 +53,35 @@ func newStorageManager(cfg *storage.Config) (storage.Manager, error) {
 	if len(cfg.DriverConfigs) != 1 {
 		return nil, fmt.Errorf("disk storage manager should have only one disk driver, cfg's driver number is wrong config: %v", cfg)
 	}
-	diskDriver, ok := storedriver.Get(local.DiskDriverName)
+	// the disk driver is used by default, it could be replaced by other drivers like s3
+	driverName := local.DiskDriverName
+	for name := range cfg.DriverConfigs {
+		driverName = name
+	}
+	diskDriver, ok := storedriver.Get(driverName)
 	if !ok {
-		return nil, fmt.Errorf("can not find disk driver for disk storage manager, config is %#v", cfg)
+		return nil, fmt.Errorf("can not find %s driver for disk storage manager, config is %#v", driverName, cfg)
 	}
 
 	storageMgr := &diskStorageMgr{
 		cfg:        cfg,
+		driverName: driverName,
 		diskDriver: diskDriver,
 	}
 	gc.Register("diskStorage", cfg.GCInitialDelay, cfg.GCInterval, storageMgr)
+	if cfg.ScrubInterval > 0 {
+		storageMgr.scrubber = storage.NewScrubber("disk", cfg, storageMgr)
+		gc.Register("diskStorageScrubber", cfg.GCInitialDelay, cfg.ScrubInterval, storageMgr.scrubber)
+	}
 	return storageMgr, nil
 }
 
 type diskStorageMgr struct {
 	cfg        *storage.Config
+	driverName string
 	diskDriver storedriver.Driver
 	cleaner    *storage.Cleaner
+	scrubber   *storage.Scrubber
 	taskMgr    daemon.SeedTaskMgr
 }
 
@@ -92,12 +104,15 @@ func (s *diskStorageMgr) getDefaultGcConfig() *storage.GCConfig {
 
 func (s *diskStorageMgr) Initialize(taskMgr daemon.SeedTaskMgr) {
 	s.taskMgr = taskMgr
-	diskGcConfig := s.cfg.DriverConfigs[local.DiskDriverName].GCConfig
+	diskGcConfig := s.cfg.DriverConfigs[s.driverName].GCConfig
 	if diskGcConfig == nil {
 		diskGcConfig = s.getDefaultGcConfig()
 		logger.GcLogger.With("type", "disk").Warnf("disk gc config is nil, use default gcConfig: %v", diskGcConfig)
 	}
 	s.cleaner, _ = storage.NewStorageCleaner(diskGcConfig, s.diskDriver, s, taskMgr)
+	if s.scrubber != nil {
+		s.scrubber.Initialize(taskMgr)
+	}
 }
 
 func (s *diskStorageMgr) AppendPieceMetaData(taskID string, pieceRecord *storage.PieceMetaRecord) error {
@@ -197,6 +212,13 @@ func (s *diskStorageMgr) ReadDownloadFile(taskID string) (io.ReadCloser, error)
 	return s.diskDriver.Get(storage.GetDownloadRaw(taskID))
 }
 
+func (s *diskStorageMgr) ReadDownloadFileRange(taskID string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskID)
+	raw.Offset = offset
+	raw.Length = length
+	return s.diskDriver.Get(raw)
+}
+
 func (s *diskStorageMgr) StatDownloadFile(taskID string) (*storedriver.StorageInfo, error) {
 	return s.diskDriver.Stat(storage.GetDownloadRaw(taskID))
 }
@@ -210,18 +232,27 @@ func (s *diskStorageMgr) CreateUploadLink(taskID string) error {
 	return nil
 }
 
+// DeleteTask deletes the task files from local storage, the data shared with other cdn nodes is kept
 func (s *diskStorageMgr) DeleteTask(taskID string) error {
-	if err := s.diskDriver.Remove(storage.GetTaskMetaDataRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
-		return err
-	}
-	if err := s.diskDriver.Remove(storage.GetPieceMetaDataRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
-		return err
-	}
-	if err := s.diskDriver.Remove(storage.GetDownloadRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
-		return err
-	}
-	if err := s.diskDriver.Remove(storage.GetUploadRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
-		return err
+	return s.deleteTask(taskID, false)
+}
+
+// PurgeTask deletes the task files and the data shared with other cdn nodes
+func (s *diskStorageMgr) PurgeTask(taskID string) error {
+	return s.deleteTask(taskID, true)
+}
+
+func (s *diskStorageMgr) deleteTask(taskID string, shared bool) error {
+	for _, raw := range []*storedriver.Raw{
+		storage.GetTaskMetaDataRaw(taskID),
+		storage.GetPieceMetaDataRaw(taskID),
+		storage.GetDownloadRaw(taskID),
+		storage.GetUploadRaw(taskID),
+	} {
+		raw.Shared = shared
+		if err := s.diskDriver.Remove(raw); err != nil && !cdnerrors.IsFileNotExist(err) {
+			return err
+		}
 	}
 	// try to clean the parent bucket
 	if err := s.diskDriver.Remove(storage.GetParentRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
@@ -230,6 +261,13 @@ func (s *diskStorageMgr) DeleteTask(taskID string) error {
 	return nil
 }
 
+func (s *diskStorageMgr) ListTaskIDs() ([]string, error) {
+	return storage.ListTaskIDs(s.diskDriver)
+}
+
 func (s *diskStorageMgr) ResetRepo(task *types.SeedTask) error {
-	return s.DeleteTask(task.TaskID)
+	if err := s.DeleteTask(task.TaskID); err != nil {
+		return err
+	}
+	return storage.TruncateTaskData(s.diskDriver, s.diskDriver, task.TaskID)
 }
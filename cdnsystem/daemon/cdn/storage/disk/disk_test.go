@@ -0,0 +1,167 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mock"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver/local"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubber(t *testing.T) {
+	assert := assert.New(t)
+	workHome, err := ioutil.TempDir("", "cdn-disk-scrubber-")
+	assert.Nil(err)
+	defer os.RemoveAll(workHome)
+	driver, err := local.NewStorageDriver(&storedriver.Config{BaseDir: workHome})
+	assert.Nil(err)
+	storageMgr := &diskStorageMgr{diskDriver: driver}
+
+	taskID := "scrubber"
+	pieces := []string{"hello", "world"}
+	var (
+		records   []*storage.PieceMetaRecord
+		pieceMd5s []string
+	)
+	for i, piece := range pieces {
+		start := uint64(i * len(pieces[0]))
+		record := &storage.PieceMetaRecord{
+			PieceNum:    int32(i),
+			PieceLen:    int32(len(piece)),
+			Md5:         "sha256:" + digestutils.Sha256(piece),
+			Range:       &rangeutils.Range{StartIndex: start, EndIndex: start + uint64(len(piece)) - 1},
+			OriginRange: &rangeutils.Range{StartIndex: start, EndIndex: start + uint64(len(piece)) - 1},
+		}
+		records = append(records, record)
+		pieceMd5s = append(pieceMd5s, record.Md5)
+	}
+	content := strings.Join(pieces, "")
+	assert.Nil(storageMgr.WriteDownloadFile(taskID, 0, int64(len(content)), strings.NewReader(content)))
+	assert.Nil(storageMgr.WritePieceMetaRecords(taskID, records))
+	assert.Nil(storageMgr.WriteFileMetaData(taskID, &storage.FileMetaData{
+		TaskID:          taskID,
+		CdnFileLength:   int64(len(content)),
+		PieceMd5Sign:    digestutils.Sha256(pieceMd5s...),
+		Finish:          true,
+		Success:         true,
+		TotalPieceCount: int32(len(records)),
+	}))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskMgr := mock.NewMockSeedTaskMgr(ctrl)
+	taskMgr.EXPECT().Get(taskID).Return(nil, cdnerrors.ErrDataNotFound).AnyTimes()
+	scrubber := storage.NewScrubber("disk", &storage.Config{}, storageMgr)
+	scrubber.Initialize(taskMgr)
+
+	// the task is not changed when it is verified
+	assert.Nil(scrubber.GC())
+	metaData, err := storageMgr.ReadFileMetaData(taskID)
+	assert.Nil(err)
+	assert.True(metaData.Success)
+
+	// the corrupted task is quarantined
+	assert.Nil(storageMgr.WriteDownloadFile(taskID, 5, 5, strings.NewReader("w0rld")))
+	taskMgr.EXPECT().Delete(taskID).Return(nil).Times(1)
+	assert.Nil(scrubber.GC())
+	metaData, err = storageMgr.ReadFileMetaData(taskID)
+	assert.Nil(err)
+	assert.True(metaData.Finish)
+	assert.False(metaData.Success)
+
+	// the quarantined task is skipped
+	assert.Nil(scrubber.GC())
+}
+
+// sharedRecorder records whether the removed files are shared with other cdn nodes
+type sharedRecorder struct {
+	storedriver.Driver
+	removed map[string]bool
+}
+
+func (r *sharedRecorder) Remove(raw *storedriver.Raw) error {
+	r.removed[raw.Key] = raw.Shared
+	return r.Driver.Remove(raw)
+}
+
+func TestDeleteAndPurgeTask(t *testing.T) {
+	assert := assert.New(t)
+	workHome, err := ioutil.TempDir("", "cdn-disk-purge-")
+	assert.Nil(err)
+	defer os.RemoveAll(workHome)
+	driver, err := local.NewStorageDriver(&storedriver.Config{BaseDir: workHome})
+	assert.Nil(err)
+	recorder := &sharedRecorder{Driver: driver, removed: map[string]bool{}}
+	storageMgr := &diskStorageMgr{diskDriver: recorder}
+
+	taskID := "purge"
+	dataKey := storage.GetDownloadRaw(taskID).Key
+	pieceKey := storage.GetPieceMetaDataRaw(taskID).Key
+	prepare := func() {
+		assert.Nil(storageMgr.WriteDownloadFile(taskID, 0, 5, strings.NewReader("hello")))
+		assert.Nil(storageMgr.AppendPieceMetaData(taskID, &storage.PieceMetaRecord{
+			PieceLen:    5,
+			Md5:         "sha256:" + digestutils.Sha256("hello"),
+			Range:       &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+			OriginRange: &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+		}))
+		assert.Nil(storageMgr.WriteFileMetaData(taskID, &storage.FileMetaData{TaskID: taskID}))
+	}
+
+	// delete only evicts the local files
+	prepare()
+	assert.Nil(storageMgr.DeleteTask(taskID))
+	assert.False(recorder.removed[dataKey])
+	assert.False(recorder.removed[pieceKey])
+	_, err = storageMgr.StatDownloadFile(taskID)
+	assert.True(cdnerrors.IsFileNotExist(err))
+
+	// purge deletes the shared data too
+	prepare()
+	assert.Nil(storageMgr.PurgeTask(taskID))
+	assert.True(recorder.removed[dataKey])
+	assert.True(recorder.removed[pieceKey])
+
+	// reset evicts the local files and truncates the data to be rewritten
+	prepare()
+	assert.Nil(storageMgr.ResetRepo(&types.SeedTask{TaskID: taskID}))
+	assert.False(recorder.removed[dataKey])
+	info, err := storageMgr.StatDownloadFile(taskID)
+	assert.Nil(err)
+	assert.Equal(int64(0), info.Size)
+	assert.Nil(storageMgr.AppendPieceMetaData(taskID, &storage.PieceMetaRecord{
+		PieceLen:    5,
+		Md5:         "sha256:" + digestutils.Sha256("world"),
+		Range:       &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+		OriginRange: &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+	}))
+	records, err := storageMgr.ReadPieceMetaRecords(taskID)
+	assert.Nil(err)
+	assert.Len(records, 1)
+}
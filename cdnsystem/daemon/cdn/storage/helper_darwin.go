@@ -17,7 +17,7 @@
 package storage
 
 func IsSupport(mode string) bool {
-	if mode == DiskStorageMode {
+	if mode == DiskStorageMode || mode == TieredStorageMode {
 		return true
 	}
 	return false
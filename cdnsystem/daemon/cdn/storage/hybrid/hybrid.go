@@ -73,6 +73,10 @@ func newStorageManager(cfg *storage.Config) (storage.Manager, error) {
 		shmSwitch:    newShmSwitch(),
 	}
 	gc.Register("hybridStorage", cfg.GCInitialDelay, cfg.GCInterval, storageMgr)
+	if cfg.ScrubInterval > 0 {
+		storageMgr.scrubber = storage.NewScrubber("hybrid", cfg, storageMgr)
+		gc.Register("hybridStorageScrubber", cfg.GCInitialDelay, cfg.ScrubInterval, storageMgr.scrubber)
+	}
 	return storageMgr, nil
 }
 
@@ -91,6 +95,9 @@ func (h *hybridStorageMgr) Initialize(taskMgr daemon.SeedTaskMgr) {
 		logger.GcLogger.With("type", "hybrid").Warnf("memory gc config is nil, use default gcConfig: %v", diskGcConfig)
 	}
 	h.memoryDriverCleaner, _ = storage.NewStorageCleaner(memoryGcConfig, h.memoryDriver, h, taskMgr)
+	if h.scrubber != nil {
+		h.scrubber.Initialize(taskMgr)
+	}
 	logger.GcLogger.With("type", "hybrid").Info("success initialize hybrid cleaners")
 }
 
@@ -138,6 +145,7 @@ type hybridStorageMgr struct {
 	diskDriver          storedriver.Driver
 	diskDriverCleaner   *storage.Cleaner
 	memoryDriverCleaner *storage.Cleaner
+	scrubber            *storage.Scrubber
 	taskMgr             daemon.SeedTaskMgr
 	shmSwitch           *shmSwitch
 	hasShm              bool
@@ -214,10 +222,22 @@ func (h *hybridStorageMgr) DeleteTask(taskID string) error {
 	return h.deleteTaskFiles(taskID, true, true)
 }
 
+// PurgeTask deletes the task files like DeleteTask, the local drivers of hybrid storage share no data with other cdn nodes
+func (h *hybridStorageMgr) PurgeTask(taskID string) error {
+	return h.DeleteTask(taskID)
+}
+
 func (h *hybridStorageMgr) ReadDownloadFile(taskID string) (io.ReadCloser, error) {
 	return h.diskDriver.Get(storage.GetDownloadRaw(taskID))
 }
 
+func (h *hybridStorageMgr) ReadDownloadFileRange(taskID string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskID)
+	raw.Offset = offset
+	raw.Length = length
+	return h.diskDriver.Get(raw)
+}
+
 func (h *hybridStorageMgr) ReadPieceMetaRecords(taskID string) ([]*storage.PieceMetaRecord, error) {
 	readBytes, err := h.diskDriver.GetBytes(storage.GetPieceMetaDataRaw(taskID))
 	if err != nil {
@@ -277,6 +297,10 @@ func (h *hybridStorageMgr) CreateUploadLink(taskID string) error {
 	return nil
 }
 
+func (h *hybridStorageMgr) ListTaskIDs() ([]string, error) {
+	return storage.ListTaskIDs(h.diskDriver)
+}
+
 func (h *hybridStorageMgr) ResetRepo(task *types.SeedTask) error {
 	if err := h.deleteTaskFiles(task.TaskID, false, true); err != nil {
 		logger.WithTaskID(task.TaskID).Errorf("reset repo: failed to delete task files: %v", err)
@@ -92,6 +92,35 @@ func (mr *MockManagerMockRecorder) Initialize(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Initialize", reflect.TypeOf((*MockManager)(nil).Initialize), arg0)
 }
 
+// ListTaskIDs mocks base method.
+func (m *MockManager) ListTaskIDs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTaskIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTaskIDs indicates an expected call of ListTaskIDs.
+func (mr *MockManagerMockRecorder) ListTaskIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaskIDs", reflect.TypeOf((*MockManager)(nil).ListTaskIDs))
+}
+
+// PurgeTask mocks base method.
+func (m *MockManager) PurgeTask(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTask", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeTask indicates an expected call of PurgeTask.
+func (mr *MockManagerMockRecorder) PurgeTask(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTask", reflect.TypeOf((*MockManager)(nil).PurgeTask), arg0)
+}
+
 // ReadDownloadFile mocks base method.
 func (m *MockManager) ReadDownloadFile(arg0 string) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -107,6 +136,21 @@ func (mr *MockManagerMockRecorder) ReadDownloadFile(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadDownloadFile", reflect.TypeOf((*MockManager)(nil).ReadDownloadFile), arg0)
 }
 
+// ReadDownloadFileRange mocks base method.
+func (m *MockManager) ReadDownloadFileRange(arg0 string, arg1, arg2 int64) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadDownloadFileRange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadDownloadFileRange indicates an expected call of ReadDownloadFileRange.
+func (mr *MockManagerMockRecorder) ReadDownloadFileRange(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadDownloadFileRange", reflect.TypeOf((*MockManager)(nil).ReadDownloadFileRange), arg0, arg1, arg2)
+}
+
 // ReadFileMetaData mocks base method.
 func (m *MockManager) ReadFileMetaData(arg0 string) (*storage.FileMetaData, error) {
 	m.ctrl.T.Helper()
@@ -17,8 +17,13 @@
 package storage
 
 import (
+	"os"
 	"path"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 )
@@ -29,6 +34,8 @@ const (
 	DownloadHome = "download"
 
 	UploadHome = "upload"
+
+	metaDataSuffix = ".meta"
 )
 
 func getDownloadKey(taskID string) string {
@@ -36,7 +43,7 @@ func getDownloadKey(taskID string) string {
 }
 
 func getTaskMetaDataKey(taskID string) string {
-	return path.Join(getParentKey(taskID), taskID+".meta")
+	return path.Join(getParentKey(taskID), taskID+metaDataSuffix)
 }
 
 func getPieceMetaDataKey(taskID string) string {
@@ -102,3 +109,46 @@ func GetUploadHomeRaw() *storedriver.Raw {
 		Bucket: UploadHome,
 	}
 }
+
+// ListTaskIDs walks the download home of the driver and returns the ids of the tasks which have meta data stored,
+// including the ones shared by other cdn nodes.
+func ListTaskIDs(driver storedriver.Driver) ([]string, error) {
+	var taskIDs []string
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), metaDataSuffix) {
+			return nil
+		}
+		taskIDs = append(taskIDs, strings.TrimSuffix(info.Name(), metaDataSuffix))
+		return nil
+	}
+	raw := GetDownloadHomeRaw()
+	raw.WalkFn = walkFn
+	// the tasks downloaded by other cdn nodes are listed too when the data is shared
+	raw.Shared = true
+	if err := driver.Walk(raw); err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return taskIDs, nil
+}
+
+// TruncateTaskData truncates the piece meta data and the download file of task, the data shared with
+// other cdn nodes is rewritten by the download after reset, so it is truncated instead of being kept
+func TruncateTaskData(metaDriver, dataDriver storedriver.Driver, taskID string) error {
+	pieceRaw := GetPieceMetaDataRaw(taskID)
+	pieceRaw.Trunc = true
+	if err := metaDriver.PutBytes(pieceRaw, nil); err != nil {
+		return errors.Wrap(err, "truncate piece meta data")
+	}
+	dataRaw := GetDownloadRaw(taskID)
+	dataRaw.Trunc = true
+	if err := dataDriver.PutBytes(dataRaw, nil); err != nil {
+		return errors.Wrap(err, "truncate download file")
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"d7y.io/dragonfly/v2/cdnsystem/daemon"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/metrics"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/ratelimiter"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"github.com/pkg/errors"
+)
+
+// errDataCorrupted means the stored data of task does not match its meta data
+var errDataCorrupted = errors.New("data corrupted")
+
+// Scrubber re-verifies the finished tasks in storage with the piece digests and the piece md5 sign in background,
+// the corrupted tasks are quarantined, they are not served any more and will be downloaded from source again.
+type Scrubber struct {
+	storagePattern string
+	storageMgr     Manager
+	taskMgr        daemon.SeedTaskMgr
+	limiter        *ratelimiter.RateLimiter
+}
+
+// NewScrubber creates a scrubber which reads the storage with the rate of cfg.ScrubRate at most
+func NewScrubber(storagePattern string, cfg *Config, storageMgr Manager) *Scrubber {
+	return &Scrubber{
+		storagePattern: storagePattern,
+		storageMgr:     storageMgr,
+		limiter:        ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.ScrubRate)), 2),
+	}
+}
+
+func (s *Scrubber) Initialize(taskMgr daemon.SeedTaskMgr) {
+	s.taskMgr = taskMgr
+}
+
+// GC scrubs all tasks in storage, it is executed by the gc manager with the interval of cfg.ScrubInterval
+func (s *Scrubber) GC() error {
+	log := logger.GcLogger.With("type", s.storagePattern, "action", "scrub")
+	if s.taskMgr == nil {
+		log.Warn("storage is not initialized, skip scrubbing")
+		return nil
+	}
+	taskIDs, err := s.storageMgr.ListTaskIDs()
+	if err != nil {
+		return errors.Wrap(err, "list task ids")
+	}
+	log.Infof("start to scrub %d tasks", len(taskIDs))
+	var scrubbed, corrupted int
+	for _, taskID := range taskIDs {
+		metaData, err := s.scrubTask(taskID)
+		if err != nil && errors.Cause(err) != errDataCorrupted {
+			log.Warnf("failed to scrub task %s: %v", taskID, err)
+			continue
+		}
+		if metaData == nil {
+			continue
+		}
+		scrubbed++
+		metrics.ScrubbedTaskCount.Inc()
+		if err == nil {
+			continue
+		}
+		corrupted++
+		metrics.ScrubCorruptedTaskCount.Inc()
+		log.Errorf("task %s is corrupted: %v", taskID, err)
+		if err := s.quarantine(taskID, metaData.PieceMd5Sign); err != nil {
+			log.Errorf("failed to quarantine task %s: %v", taskID, err)
+			continue
+		}
+		log.Warnf("task %s is quarantined", taskID)
+	}
+	log.Infof("scrubbed %d tasks, %d corrupted", scrubbed, corrupted)
+	return nil
+}
+
+// scrubTask verifies the task when it is downloaded successfully, returns the verified meta data of task,
+// the meta data is nil when the task is skipped.
+func (s *Scrubber) scrubTask(taskID string) (*FileMetaData, error) {
+	metaData, err := s.storageMgr.ReadFileMetaData(taskID)
+	if err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read file meta data")
+	}
+	if !metaData.Finish || !metaData.Success {
+		return nil, nil
+	}
+	// the task is being downloaded again
+	if task, err := s.taskMgr.Get(taskID); err == nil && task.CdnStatus == types.TaskInfoCdnStatusRunning {
+		return nil, nil
+	}
+	return metaData, s.verify(taskID, metaData)
+}
+
+// verify checks the piece meta records and the content of every piece with the meta data
+func (s *Scrubber) verify(taskID string, metaData *FileMetaData) error {
+	records, err := s.storageMgr.ReadPieceMetaRecords(taskID)
+	if err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return errors.Wrap(errDataCorrupted, "piece meta records not found")
+		}
+		return errors.Wrapf(errDataCorrupted, "read piece meta records: %v", err)
+	}
+	if int32(len(records)) != metaData.TotalPieceCount {
+		return errors.Wrapf(errDataCorrupted, "piece count %d is not equals with %d", len(records), metaData.TotalPieceCount)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].PieceNum < records[j].PieceNum
+	})
+	pieceMd5s := make([]string, 0, len(records))
+	for _, record := range records {
+		pieceMd5s = append(pieceMd5s, record.Md5)
+	}
+	if pieceMd5Sign := digestutils.Sha256(pieceMd5s...); pieceMd5Sign != metaData.PieceMd5Sign {
+		return errors.Wrapf(errDataCorrupted, "piece md5 sign %s is not equals with %s", pieceMd5Sign, metaData.PieceMd5Sign)
+	}
+	storageInfo, err := s.storageMgr.StatDownloadFile(taskID)
+	if err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return errors.Wrap(errDataCorrupted, "download file not found")
+		}
+		return errors.Wrap(err, "stat download file")
+	}
+	if storageInfo.Size != metaData.CdnFileLength {
+		return errors.Wrapf(errDataCorrupted, "download file size %d is not equals with %d", storageInfo.Size, metaData.CdnFileLength)
+	}
+	for _, record := range records {
+		if err := s.verifyPiece(taskID, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyPiece reads the content of piece with rate limit and checks it with the piece digest
+func (s *Scrubber) verifyPiece(taskID string, record *PieceMetaRecord) error {
+	algorithm, encoded, err := digestutils.Parse(record.Md5)
+	if err != nil {
+		return errors.Wrapf(errDataCorrupted, "parse digest of piece %d: %v", record.PieceNum, err)
+	}
+	reader, err := s.storageMgr.ReadDownloadFileRange(taskID, int64(record.Range.StartIndex), int64(record.PieceLen))
+	if err != nil {
+		return errors.Wrapf(err, "read piece %d", record.PieceNum)
+	}
+	defer reader.Close()
+	h, _ := digestutils.NewHash(algorithm)
+	n, err := io.Copy(ioutil.Discard, io.TeeReader(limitreader.NewLimitReaderWithLimiter(s.limiter, reader, false), h))
+	metrics.ScrubbedBytes.Add(float64(n))
+	if err != nil {
+		return errors.Wrapf(err, "read piece %d", record.PieceNum)
+	}
+	if n != int64(record.PieceLen) {
+		return errors.Wrapf(errDataCorrupted, "piece %d length %d is not equals with %d", record.PieceNum, n, record.PieceLen)
+	}
+	if actual := digestutils.ToHashString(h); actual != encoded {
+		return errors.Wrapf(errDataCorrupted, "piece %d digest %s is not equals with %s", record.PieceNum,
+			digestutils.Format(algorithm, actual), record.Md5)
+	}
+	return nil
+}
+
+// quarantine marks the task failed and removes it from the task manager, so the corrupted data is not served any more,
+// the data is kept for inspection until the task is downloaded again or reclaimed by gc.
+func (s *Scrubber) quarantine(taskID string, pieceMd5Sign string) error {
+	synclock.Lock(taskID, false)
+	defer synclock.UnLock(taskID, false)
+	metaData, err := s.storageMgr.ReadFileMetaData(taskID)
+	if err != nil {
+		return errors.Wrap(err, "read file meta data")
+	}
+	// the task has been downloaded again during scrubbing
+	if !metaData.Finish || !metaData.Success {
+		return nil
+	}
+	if metaData.PieceMd5Sign != pieceMd5Sign {
+		return nil
+	}
+	metaData.Success = false
+	if err := s.storageMgr.WriteFileMetaData(taskID, metaData); err != nil {
+		return errors.Wrap(err, "write file meta data")
+	}
+	return s.taskMgr.Delete(taskID)
+}
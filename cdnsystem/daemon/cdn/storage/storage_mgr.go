@@ -39,7 +39,7 @@ import (
 type Manager interface {
 	Initialize(taskMgr daemon.SeedTaskMgr)
 
-	// ResetRepo reset the storage of task
+	// ResetRepo reset the storage of task, the task is downloaded again right after
 	ResetRepo(*types.SeedTask) error
 
 	// StatDownloadFile
@@ -48,9 +48,13 @@ type Manager interface {
 	// WriteDownloadFile
 	WriteDownloadFile(taskID string, offset int64, len int64, data io.Reader) error
 
-	// ReadDownloadFile
+	// ReadDownloadFile reads the download file, the reader must be closed by the caller
 	ReadDownloadFile(taskID string) (io.ReadCloser, error)
 
+	// ReadDownloadFileRange reads the download file from offset with length, reads to the end when length <= 0,
+	// the reader must be closed by the caller
+	ReadDownloadFileRange(taskID string, offset int64, length int64) (io.ReadCloser, error)
+
 	// CreateUploadLink
 	CreateUploadLink(taskID string) error
 
@@ -69,25 +73,44 @@ type Manager interface {
 	// ReadPieceMetaRecords
 	ReadPieceMetaRecords(taskID string) ([]*PieceMetaRecord, error)
 
-	// DeleteTask
+	// DeleteTask deletes the task from local storage, the data shared with other cdn nodes is kept
 	DeleteTask(taskID string) error
+
+	// PurgeTask deletes the task from local storage and the data shared with other cdn nodes
+	PurgeTask(taskID string) error
+
+	// ListTaskIDs lists the ids of all tasks whose meta data is stored
+	ListTaskIDs() ([]string, error)
+}
+
+// TierGetter is implemented by the storage manager which stores the data of tasks in tiers
+type TierGetter interface {
+	// GetTier returns the name of the tier where the data of task is stored
+	GetTier(taskID string) string
 }
 
 // FileMetaData
+// URL, Header and RequestDigest are the raw url, headers and digest of request,
+// they are used to download from source again after restoring
 type FileMetaData struct {
-	TaskID          string            `json:"taskId"`
-	TaskURL         string            `json:"taskUrl"`
-	PieceSize       int32             `json:"pieceSize"`
-	SourceFileLen   int64             `json:"sourceFileLen"`
-	AccessTime      int64             `json:"accessTime"`
-	Interval        int64             `json:"interval"`
-	CdnFileLength   int64             `json:"cdnFileLength"`
-	SourceRealMd5   string            `json:"sourceRealMd5"`
-	PieceMd5Sign    string            `json:"pieceMd5Sign"`
-	ExpireInfo      map[string]string `json:"expireInfo"`
-	Finish          bool              `json:"finish"`
-	Success         bool              `json:"success"`
-	TotalPieceCount int32             `json:"totalPieceCount"`
+	TaskID           string            `json:"taskId"`
+	TaskURL          string            `json:"taskUrl"`
+	URL              string            `json:"url"`
+	Header           map[string]string `json:"header"`
+	RequestDigest    string            `json:"requestDigest"`
+	PieceSize        int32             `json:"pieceSize"`
+	SourceFileLen    int64             `json:"sourceFileLen"`
+	AccessTime       int64             `json:"accessTime"`
+	Interval         int64             `json:"interval"`
+	CdnFileLength    int64             `json:"cdnFileLength"`
+	SourceRealDigest string            `json:"sourceRealMd5"` // json name is kept for compatibility, md5 digest is stored without algorithm
+	PieceMd5Sign     string            `json:"pieceMd5Sign"`
+	ExpireInfo       map[string]string `json:"expireInfo"`
+	Finish           bool              `json:"finish"`
+	Success          bool              `json:"success"`
+	TotalPieceCount  int32             `json:"totalPieceCount"`
+	FreshnessPolicy  string            `json:"freshnessPolicy"`
+	FreshUntil       int64             `json:"freshUntil"`
 	//PieceMetaDataSign string            `json:"pieceMetaDataSign"`
 }
 
@@ -115,6 +138,9 @@ func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
 		}
 	}()
 	fields := strings.Split(value, fieldSeparator)
+	if len(fields) < 6 {
+		return nil, errors.Errorf("invalid piece meta record:%s", value)
+	}
 	pieceNum, err := strconv.ParseInt(fields[0], 10, 32)
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid pieceNum:%s", fields[0])
@@ -123,18 +149,20 @@ func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid pieceLen:%s", fields[1])
 	}
-	md5 := fields[2]
-	pieceRange, err := rangeutils.ParseRange(fields[3])
+	// the digest in format of algorithm:encoded contains the separator, so the fields after it are parsed from the end
+	n := len(fields)
+	md5 := strings.Join(fields[2:n-3], fieldSeparator)
+	pieceRange, err := rangeutils.ParseRange(fields[n-3])
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid piece range:%s", fields[3])
+		return nil, errors.Wrapf(err, "invalid piece range:%s", fields[n-3])
 	}
-	originRange, err := rangeutils.ParseRange(fields[4])
+	originRange, err := rangeutils.ParseRange(fields[n-2])
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid origin range:%s", fields[4])
+		return nil, errors.Wrapf(err, "invalid origin range:%s", fields[n-2])
 	}
-	pieceStyle, err := strconv.ParseInt(fields[5], 10, 8)
+	pieceStyle, err := strconv.ParseInt(fields[n-1], 10, 8)
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid pieceStyle:%s", fields[5])
+		return nil, errors.Wrapf(err, "invalid pieceStyle:%s", fields[n-1])
 	}
 	return &PieceMetaRecord{
 		PieceNum:    int32(pieceNum),
@@ -177,6 +205,10 @@ func (m *managerPlugin) ReadDownloadFile(taskID string) (io.ReadCloser, error) {
 	return m.instance.ReadDownloadFile(taskID)
 }
 
+func (m *managerPlugin) ReadDownloadFileRange(taskID string, offset int64, length int64) (io.ReadCloser, error) {
+	return m.instance.ReadDownloadFileRange(taskID, offset, length)
+}
+
 func (m *managerPlugin) CreateUploadLink(taskID string) error {
 	return m.instance.CreateUploadLink(taskID)
 }
@@ -205,6 +237,14 @@ func (m *managerPlugin) DeleteTask(taskID string) error {
 	return m.instance.DeleteTask(taskID)
 }
 
+func (m *managerPlugin) PurgeTask(taskID string) error {
+	return m.instance.PurgeTask(taskID)
+}
+
+func (m *managerPlugin) ListTaskIDs() ([]string, error) {
+	return m.instance.ListTaskIDs()
+}
+
 // ManagerBuilder is a function that creates a new storage manager plugin instant with the giving conf.
 type ManagerBuilder func(cfg *Config) (Manager, error)
 
@@ -270,9 +310,22 @@ func Get(name string) (Manager, bool) {
 }
 
 type Config struct {
-	GCInitialDelay time.Duration            `yaml:"gcInitialDelay"`
-	GCInterval     time.Duration            `yaml:"gcInterval"`
-	DriverConfigs  map[string]*DriverConfig `yaml:"driverConfigs"`
+	GCInitialDelay time.Duration `yaml:"gcInitialDelay"`
+	GCInterval     time.Duration `yaml:"gcInterval"`
+	// ScrubInterval is the interval to re-verify the finished tasks in background, 0 disables scrubbing
+	ScrubInterval time.Duration `yaml:"scrubInterval"`
+	// ScrubRate is the max bandwidth of reading storage when scrubbing, default is 10MB
+	ScrubRate     unit.Bytes               `yaml:"scrubRate"`
+	DriverConfigs map[string]*DriverConfig `yaml:"driverConfigs"`
+	// Tiers are the driver names ordered from the fastest to the slowest, only used by tiered storage manager,
+	// the meta data of tasks is stored in the slowest tier
+	Tiers []string `yaml:"tiers"`
+	// TierInterval is the interval to promote and demote tasks between tiers
+	TierInterval time.Duration `yaml:"tierInterval"`
+	// PromoteAccessCount is the count of accessing a task since it is moved, for the task to be promoted to a faster tier
+	PromoteAccessCount int32 `yaml:"promoteAccessCount"`
+	// DemoteIdleTime is the time since a task is accessed last time, for the task to be demoted to a slower tier
+	DemoteIdleTime time.Duration `yaml:"demoteIdleTime"`
 }
 
 type DriverConfig struct {
@@ -290,4 +343,5 @@ type GCConfig struct {
 const (
 	HybridStorageMode = "hybrid"
 	DiskStorageMode   = "disk"
+	TieredStorageMode = "tiered"
 )
@@ -0,0 +1,554 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tiered
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/daemon"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/gc"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/fileutils"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
+	"github.com/pkg/errors"
+)
+
+const StorageMode = storage.TieredStorageMode
+
+const (
+	defaultTierInterval       = 5 * time.Minute
+	defaultPromoteAccessCount = 3
+	defaultDemoteIdleTime     = time.Hour
+)
+
+var (
+	_ storage.TierGetter = (*tieredStorageMgr)(nil)
+	_ gc.Executor        = (*tieredStorageMgr)(nil)
+	_ gc.Executor        = (*rebalancer)(nil)
+	_ storage.Manager    = (*tieredStorageMgr)(nil)
+)
+
+func init() {
+	storage.Register(StorageMode, newStorageManager)
+}
+
+func newStorageManager(cfg *storage.Config) (storage.Manager, error) {
+	if len(cfg.Tiers) < 2 {
+		return nil, fmt.Errorf("tiered storage manager should have at least two tiers, config is %#v", cfg)
+	}
+	storageMgr := &tieredStorageMgr{
+		cfg:        cfg,
+		dataLocker: synclock.NewLockerPool(),
+	}
+	names := make(map[string]bool)
+	for _, name := range cfg.Tiers {
+		if names[name] {
+			return nil, fmt.Errorf("duplicate tier %s for tiered storage manager", name)
+		}
+		names[name] = true
+		driver, ok := storedriver.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("can not find %s driver for tiered storage manager, config is %#v", name, cfg)
+		}
+		storageMgr.tiers = append(storageMgr.tiers, &tier{
+			name:   name,
+			driver: driver,
+		})
+	}
+	storageMgr.home = storageMgr.tiers[len(storageMgr.tiers)-1]
+	if cfg.TierInterval <= 0 {
+		cfg.TierInterval = defaultTierInterval
+	}
+	if cfg.PromoteAccessCount <= 0 {
+		cfg.PromoteAccessCount = defaultPromoteAccessCount
+	}
+	if cfg.DemoteIdleTime <= 0 {
+		cfg.DemoteIdleTime = defaultDemoteIdleTime
+	}
+
+	gc.Register("tieredStorage", cfg.GCInitialDelay, cfg.GCInterval, storageMgr)
+	gc.Register("tieredStorageRebalancer", cfg.GCInitialDelay, cfg.TierInterval, &rebalancer{storageMgr: storageMgr})
+	if cfg.ScrubInterval > 0 {
+		storageMgr.scrubber = storage.NewScrubber("tiered", cfg, storageMgr)
+		gc.Register("tieredStorageScrubber", cfg.GCInitialDelay, cfg.ScrubInterval, storageMgr.scrubber)
+	}
+	return storageMgr, nil
+}
+
+// tier is a level of storage, the tiers of tiered storage manager are ordered from the fastest to the slowest
+type tier struct {
+	name     string
+	driver   storedriver.Driver
+	gcConfig *storage.GCConfig
+	cleaner  *storage.Cleaner
+}
+
+// hasSpace determines whether the tier can store data of size with reserved space left
+func (t *tier) hasSpace(size int64, reserved unit.Bytes) bool {
+	freeSpace, err := t.driver.GetAvailSpace()
+	if err != nil {
+		logger.GcLogger.With("type", "tiered").Errorf("failed to get available space of tier %s: %v", t.name, err)
+		return false
+	}
+	return freeSpace > unit.Bytes(size)+reserved
+}
+
+// tieredStorageMgr stores the data of each task in one of the tiers, the frequently accessed tasks are promoted
+// to the faster tiers and the cold tasks are demoted to the slower tiers, the meta data is always stored in the slowest tier.
+type tieredStorageMgr struct {
+	cfg   *storage.Config
+	tiers []*tier
+	// home is the slowest tier where the meta data of tasks is stored
+	home     *tier
+	scrubber *storage.Scrubber
+	taskMgr  daemon.SeedTaskMgr
+	// locations caches the index of the tier where the data of task is stored
+	locations sync.Map
+	// movedAccessCounts records the access count of task when it is moved last time
+	movedAccessCounts sync.Map
+	// dataLocker guards the data of task, ResetRepo and WriteDownloadFile hold the read lock,
+	// moving the task between tiers holds the write lock
+	dataLocker *synclock.LockerPool
+}
+
+func (m *tieredStorageMgr) getDefaultGcConfig(t *tier) *storage.GCConfig {
+	totalSpace, err := t.driver.GetTotalSpace()
+	if err != nil {
+		logger.GcLogger.With("type", "tiered").Errorf("get total space of tier %s: %v", t.name, err)
+	}
+	yongGcThreshold := 200 * unit.GB
+	if totalSpace > 0 && totalSpace/4 < yongGcThreshold {
+		yongGcThreshold = totalSpace / 4
+	}
+	fullGcThreshold := 25 * unit.GB
+	if totalSpace > 0 && totalSpace/20 < fullGcThreshold {
+		fullGcThreshold = totalSpace / 20
+	}
+	return &storage.GCConfig{
+		YoungGCThreshold:  yongGcThreshold,
+		FullGCThreshold:   fullGcThreshold,
+		IntervalThreshold: 2 * time.Hour,
+		CleanRatio:        1,
+	}
+}
+
+func (m *tieredStorageMgr) Initialize(taskMgr daemon.SeedTaskMgr) {
+	m.taskMgr = taskMgr
+	for _, t := range m.tiers {
+		if driverConfig := m.cfg.DriverConfigs[t.name]; driverConfig != nil {
+			t.gcConfig = driverConfig.GCConfig
+		}
+		if t.gcConfig == nil {
+			t.gcConfig = m.getDefaultGcConfig(t)
+			logger.GcLogger.With("type", "tiered").Infof("%s gc config is nil, use default gcConfig derived from its size: %v", t.name, t.gcConfig)
+		}
+		t.cleaner, _ = storage.NewStorageCleaner(t.gcConfig, t.driver, m, taskMgr)
+	}
+	if m.scrubber != nil {
+		m.scrubber.Initialize(taskMgr)
+	}
+	logger.GcLogger.With("type", "tiered").Info("success initialize tiered cleaners")
+}
+
+// GC cleans each tier, the tasks cleaned from a tier are demoted to the slower tiers, and deleted from the slowest tier
+func (m *tieredStorageMgr) GC() error {
+	logger.GcLogger.With("type", "tiered").Info("start the tiered storage gc job")
+	for i, t := range m.tiers {
+		gcTaskIDs, err := t.cleaner.GC("tiered", false)
+		if err != nil {
+			logger.GcLogger.With("type", "tiered").Errorf("gc %s: failed to get gcTaskIDs: %v", t.name, err)
+		}
+		var realGCCount int
+		for _, taskID := range gcTaskIDs {
+			if m.gcTask(taskID, i) {
+				realGCCount++
+			}
+		}
+		logger.GcLogger.With("type", "tiered").Infof("at most %d tasks can be cleaned up from %s, actual gc %d tasks", len(gcTaskIDs), t.name, realGCCount)
+	}
+	return nil
+}
+
+// gcTask demotes the task to the slower tiers, and deletes it when it's not demoted
+func (m *tieredStorageMgr) gcTask(taskID string, index int) bool {
+	synclock.Lock(taskID, false)
+	defer synclock.UnLock(taskID, false)
+	m.dataLocker.Lock(taskID, false)
+	defer m.dataLocker.UnLock(taskID, false)
+	// try to ensure the taskID is not using again
+	if _, err := m.taskMgr.Get(taskID); err == nil || !cdnerrors.IsDataNotFound(err) {
+		if err != nil {
+			logger.GcLogger.With("type", "tiered").Errorf("failed to get taskID(%s): %v", taskID, err)
+		}
+		return false
+	}
+	if index < len(m.tiers)-1 && m.locate(taskID) == index {
+		if metaData, err := m.ReadFileMetaData(taskID); err == nil && metaData.Finish && metaData.Success {
+			for to := index + 1; to < len(m.tiers); to++ {
+				if err := m.moveTask(taskID, metaData, index, to, m.tiers[to].gcConfig.FullGCThreshold); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	if err := m.DeleteTask(taskID); err != nil {
+		logger.GcLogger.With("type", "tiered").Errorf("failed to delete files with taskID(%s): %v", taskID, err)
+		return false
+	}
+	return true
+}
+
+// rebalancer promotes and demotes tasks between tiers, it is executed by the gc manager with the interval of cfg.TierInterval
+type rebalancer struct {
+	storageMgr *tieredStorageMgr
+}
+
+func (r *rebalancer) GC() error {
+	return r.storageMgr.rebalance()
+}
+
+// rebalance promotes the task accessed at least cfg.PromoteAccessCount times since it is moved to the faster tier,
+// and demotes the task not accessed within cfg.DemoteIdleTime to the slower tier
+func (m *tieredStorageMgr) rebalance() error {
+	taskIDs, err := m.ListTaskIDs()
+	if err != nil {
+		return errors.Wrap(err, "list task ids")
+	}
+	accessTimeMap, err := m.taskMgr.GetAccessTime()
+	if err != nil {
+		return errors.Wrap(err, "get task access time")
+	}
+	var promoted, demoted int
+	for _, taskID := range taskIDs {
+		from := m.locate(taskID)
+		accessCount := m.taskMgr.GetAccessCount(taskID)
+		movedAccessCount := m.movedAccessCount(taskID)
+		if movedAccessCount > accessCount {
+			// the task has been reloaded and its access count starts from 0 again
+			movedAccessCount = 0
+			m.movedAccessCounts.Store(taskID, movedAccessCount)
+		}
+		if from > 0 && accessCount-movedAccessCount >= m.cfg.PromoteAccessCount {
+			if err := m.tryMoveTask(taskID, from, from-1, m.tiers[from-1].gcConfig.YoungGCThreshold); err != nil {
+				logger.GcLogger.With("type", "tiered").Debugf("failed to promote task %s to %s: %v", taskID, m.tiers[from-1].name, err)
+				continue
+			}
+			promoted++
+			continue
+		}
+		if from < len(m.tiers)-1 && m.idleTime(taskID, accessTimeMap) >= m.cfg.DemoteIdleTime {
+			if err := m.tryMoveTask(taskID, from, from+1, m.tiers[from+1].gcConfig.FullGCThreshold); err != nil {
+				logger.GcLogger.With("type", "tiered").Debugf("failed to demote task %s to %s: %v", taskID, m.tiers[from+1].name, err)
+				continue
+			}
+			demoted++
+		}
+	}
+	logger.GcLogger.With("type", "tiered").Infof("rebalance tiers: %d tasks promoted, %d tasks demoted, total count(%d)", promoted, demoted, len(taskIDs))
+	return nil
+}
+
+func (m *tieredStorageMgr) movedAccessCount(taskID string) int32 {
+	if v, ok := m.movedAccessCounts.Load(taskID); ok {
+		return v.(int32)
+	}
+	return 0
+}
+
+// idleTime returns the time since the task is accessed last time
+func (m *tieredStorageMgr) idleTime(taskID string, accessTimeMap *syncmap.SyncMap) time.Duration {
+	if accessTime, err := accessTimeMap.GetAsTime(taskID); err == nil {
+		return time.Since(accessTime)
+	}
+	// the task has been released from memory, use the access time in meta data instead
+	metaData, err := m.ReadFileMetaData(taskID)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(timeutils.CurrentTimeMillis()-metaData.AccessTime) * time.Millisecond
+}
+
+// tryMoveTask moves the data of task which is downloaded successfully between tiers
+func (m *tieredStorageMgr) tryMoveTask(taskID string, from, to int, reserved unit.Bytes) error {
+	synclock.Lock(taskID, false)
+	defer synclock.UnLock(taskID, false)
+	m.dataLocker.Lock(taskID, false)
+	defer m.dataLocker.UnLock(taskID, false)
+	// the task may be reset or moved by others
+	if m.locate(taskID) != from {
+		return errors.New("task location changed")
+	}
+	metaData, err := m.ReadFileMetaData(taskID)
+	if err != nil {
+		return errors.Wrap(err, "read file meta data")
+	}
+	if !metaData.Finish || !metaData.Success {
+		return errors.Errorf("task is not finished successfully, finish: %t, success: %t", metaData.Finish, metaData.Success)
+	}
+	return m.moveTask(taskID, metaData, from, to, reserved)
+}
+
+// moveTask copies the data of task from one tier to another, it must be called with the task data locked,
+// the move is abandoned when the meta data is changed during the copy
+func (m *tieredStorageMgr) moveTask(taskID string, metaData *storage.FileMetaData, from, to int, reserved unit.Bytes) error {
+	src, dst := m.tiers[from], m.tiers[to]
+	info, err := src.driver.Stat(storage.GetDownloadRaw(taskID))
+	if err != nil {
+		return errors.Wrapf(err, "stat data in %s", src.name)
+	}
+	if !dst.hasSpace(info.Size, reserved) {
+		return errors.Errorf("not enough free space left in %s", dst.name)
+	}
+	reader, err := src.driver.Get(storage.GetDownloadRaw(taskID))
+	if err != nil {
+		return errors.Wrapf(err, "read data from %s", src.name)
+	}
+	defer reader.Close()
+
+	raw := storage.GetDownloadRaw(taskID)
+	raw.Trunc = true
+	raw.TruncSize = 0
+	if err := dst.driver.Put(raw, reader); err != nil {
+		m.removeData(dst, taskID)
+		return errors.Wrapf(err, "write data to %s", dst.name)
+	}
+	if dstInfo, err := dst.driver.Stat(storage.GetDownloadRaw(taskID)); err != nil || dstInfo.Size != info.Size {
+		m.removeData(dst, taskID)
+		return errors.Errorf("data in %s is incomplete", dst.name)
+	}
+	// the task may be reset by others, and its meta data is rewritten
+	if newMetaData, err := m.ReadFileMetaData(taskID); err != nil || !sameFileMetaData(metaData, newMetaData) {
+		m.removeData(dst, taskID)
+		return errors.New("task meta data changed during the move")
+	}
+	// switch to the new tier before removing the old data, so the new readers will read the new data
+	m.locations.Store(taskID, to)
+	m.movedAccessCounts.Store(taskID, m.taskMgr.GetAccessCount(taskID))
+	if m.home.driver.Exits(storage.GetUploadRaw(taskID)) {
+		if err := m.home.driver.Remove(storage.GetUploadRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
+			logger.WithTaskID(taskID).Warnf("failed to remove upload link: %v", err)
+		}
+		if err := m.CreateUploadLink(taskID); err != nil {
+			logger.WithTaskID(taskID).Warnf("failed to create upload link: %v", err)
+		}
+	}
+	m.removeData(src, taskID)
+	logger.WithTaskID(taskID).Infof("move task data from %s to %s", src.name, dst.name)
+	return nil
+}
+
+// sameFileMetaData determines whether the two meta data describe the same data of task
+func sameFileMetaData(a, b *storage.FileMetaData) bool {
+	return a.Finish == b.Finish && a.Success == b.Success && a.CdnFileLength == b.CdnFileLength &&
+		a.SourceRealDigest == b.SourceRealDigest && a.PieceMd5Sign == b.PieceMd5Sign
+}
+
+func (m *tieredStorageMgr) removeData(t *tier, taskID string) {
+	if err := t.driver.Remove(storage.GetDownloadRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
+		logger.WithTaskID(taskID).Warnf("failed to remove data from %s: %v", t.name, err)
+	}
+	if t != m.home {
+		// try to clean the parent bucket
+		if err := t.driver.Remove(storage.GetParentRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
+			logger.WithTaskID(taskID).Debugf("failed to remove parent bucket from %s: %v", t.name, err)
+		}
+	}
+}
+
+// locate returns the index of the tier where the data of task is stored, the slowest tier is returned when not found
+func (m *tieredStorageMgr) locate(taskID string) int {
+	if v, ok := m.locations.Load(taskID); ok {
+		return v.(int)
+	}
+	for i, t := range m.tiers {
+		if t.driver.Exits(storage.GetDownloadRaw(taskID)) {
+			m.locations.Store(taskID, i)
+			return i
+		}
+	}
+	return len(m.tiers) - 1
+}
+
+func (m *tieredStorageMgr) GetTier(taskID string) string {
+	return m.tiers[m.locate(taskID)].name
+}
+
+func (m *tieredStorageMgr) dataDriver(taskID string) storedriver.Driver {
+	return m.tiers[m.locate(taskID)].driver
+}
+
+func (m *tieredStorageMgr) ResetRepo(task *types.SeedTask) error {
+	m.dataLocker.Lock(task.TaskID, true)
+	defer m.dataLocker.UnLock(task.TaskID, true)
+	if err := m.DeleteTask(task.TaskID); err != nil {
+		return err
+	}
+	// the new task is stored in the fastest tier which has enough space
+	index := len(m.tiers) - 1
+	if task.SourceFileLength > 0 {
+		for i, t := range m.tiers[:len(m.tiers)-1] {
+			if t.hasSpace(task.SourceFileLength, t.gcConfig.YoungGCThreshold) {
+				index = i
+				break
+			}
+		}
+	}
+	m.locations.Store(task.TaskID, index)
+	logger.WithTaskID(task.TaskID).Debugf("store task data in %s", m.tiers[index].name)
+	return storage.TruncateTaskData(m.home.driver, m.tiers[index].driver, task.TaskID)
+}
+
+func (m *tieredStorageMgr) StatDownloadFile(taskID string) (*storedriver.StorageInfo, error) {
+	return m.dataDriver(taskID).Stat(storage.GetDownloadRaw(taskID))
+}
+
+func (m *tieredStorageMgr) WriteDownloadFile(taskID string, offset int64, len int64, data io.Reader) error {
+	m.dataLocker.Lock(taskID, true)
+	defer m.dataLocker.UnLock(taskID, true)
+	raw := storage.GetDownloadRaw(taskID)
+	raw.Offset = offset
+	raw.Length = len
+	return m.dataDriver(taskID).Put(raw, data)
+}
+
+func (m *tieredStorageMgr) ReadDownloadFile(taskID string) (io.ReadCloser, error) {
+	return m.dataDriver(taskID).Get(storage.GetDownloadRaw(taskID))
+}
+
+func (m *tieredStorageMgr) ReadDownloadFileRange(taskID string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskID)
+	raw.Offset = offset
+	raw.Length = length
+	return m.dataDriver(taskID).Get(raw)
+}
+
+func (m *tieredStorageMgr) CreateUploadLink(taskID string) error {
+	// create a soft link from the upload file to the download file
+	if err := fileutils.SymbolicLink(m.dataDriver(taskID).GetPath(storage.GetDownloadRaw(taskID)),
+		m.home.driver.GetPath(storage.GetUploadRaw(taskID))); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *tieredStorageMgr) ReadFileMetaData(taskID string) (*storage.FileMetaData, error) {
+	bytes, err := m.home.driver.GetBytes(storage.GetTaskMetaDataRaw(taskID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get metadata bytes")
+	}
+
+	metaData := &storage.FileMetaData{}
+	if err := json.Unmarshal(bytes, metaData); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal metadata bytes")
+	}
+	return metaData, nil
+}
+
+func (m *tieredStorageMgr) WriteFileMetaData(taskID string, metaData *storage.FileMetaData) error {
+	data, err := json.Marshal(metaData)
+	if err != nil {
+		return errors.Wrapf(err, "marshal metadata")
+	}
+	return m.home.driver.PutBytes(storage.GetTaskMetaDataRaw(taskID), data)
+}
+
+func (m *tieredStorageMgr) WritePieceMetaRecords(taskID string, records []*storage.PieceMetaRecord) error {
+	recordStrs := make([]string, 0, len(records))
+	for i := range records {
+		recordStrs = append(recordStrs, records[i].String())
+	}
+	pieceRaw := storage.GetPieceMetaDataRaw(taskID)
+	pieceRaw.Trunc = true
+	pieceRaw.TruncSize = 0
+	return m.home.driver.PutBytes(pieceRaw, []byte(strings.Join(recordStrs, "\n")))
+}
+
+func (m *tieredStorageMgr) AppendPieceMetaData(taskID string, pieceRecord *storage.PieceMetaRecord) error {
+	return m.home.driver.PutBytes(storage.GetAppendPieceMetaDataRaw(taskID), []byte(pieceRecord.String()+"\n"))
+}
+
+func (m *tieredStorageMgr) ReadPieceMetaRecords(taskID string) ([]*storage.PieceMetaRecord, error) {
+	readBytes, err := m.home.driver.GetBytes(storage.GetPieceMetaDataRaw(taskID))
+	if err != nil {
+		return nil, err
+	}
+	pieceMetaRecords := strings.Split(strings.TrimSpace(string(readBytes)), "\n")
+	var result = make([]*storage.PieceMetaRecord, 0, len(pieceMetaRecords))
+	for _, pieceStr := range pieceMetaRecords {
+		record, err := storage.ParsePieceMetaRecord(pieceStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get piece meta record: %v", pieceStr)
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// DeleteTask deletes the task files from all tiers, the data shared with other cdn nodes is kept
+func (m *tieredStorageMgr) DeleteTask(taskID string) error {
+	return m.deleteTask(taskID, false)
+}
+
+// PurgeTask deletes the task files from all tiers and the data shared with other cdn nodes
+func (m *tieredStorageMgr) PurgeTask(taskID string) error {
+	return m.deleteTask(taskID, true)
+}
+
+func (m *tieredStorageMgr) deleteTask(taskID string, shared bool) error {
+	for _, t := range m.tiers {
+		raw := storage.GetDownloadRaw(taskID)
+		raw.Shared = shared
+		if err := t.driver.Remove(raw); err != nil && !cdnerrors.IsFileNotExist(err) {
+			return err
+		}
+	}
+	for _, raw := range []*storedriver.Raw{
+		storage.GetTaskMetaDataRaw(taskID),
+		storage.GetPieceMetaDataRaw(taskID),
+		storage.GetUploadRaw(taskID),
+	} {
+		raw.Shared = shared
+		if err := m.home.driver.Remove(raw); err != nil && !cdnerrors.IsFileNotExist(err) {
+			return err
+		}
+	}
+	m.locations.Delete(taskID)
+	m.movedAccessCounts.Delete(taskID)
+	// try to clean the parent bucket
+	for _, t := range m.tiers {
+		if err := t.driver.Remove(storage.GetParentRaw(taskID)); err != nil && !cdnerrors.IsFileNotExist(err) {
+			logger.WithTaskID(taskID).Warnf("failed to remove parent bucket from %s: %v", t.name, err)
+		}
+	}
+	return nil
+}
+
+func (m *tieredStorageMgr) ListTaskIDs() ([]string, error) {
+	return storage.ListTaskIDs(m.home.driver)
+}
@@ -0,0 +1,162 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tiered
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mock"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver/local"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoteAndDemote(t *testing.T) {
+	assert := assert.New(t)
+	workHome, err := ioutil.TempDir("", "cdn-tiered-")
+	assert.Nil(err)
+	defer os.RemoveAll(workHome)
+
+	storageMgr := &tieredStorageMgr{
+		cfg: &storage.Config{
+			PromoteAccessCount: 2,
+			DemoteIdleTime:     time.Hour,
+		},
+		dataLocker: synclock.NewLockerPool(),
+	}
+	for _, name := range []string{"fast", "slow"} {
+		driver, err := local.NewStorageDriver(&storedriver.Config{BaseDir: filepath.Join(workHome, name)})
+		assert.Nil(err)
+		assert.Nil(driver.CreateBaseDir())
+		storageMgr.tiers = append(storageMgr.tiers, &tier{
+			name:     name,
+			driver:   driver,
+			gcConfig: &storage.GCConfig{},
+		})
+	}
+	storageMgr.home = storageMgr.tiers[1]
+
+	var (
+		accessCount int32
+		accessTimes = syncmap.NewSyncMap()
+	)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskMgr := mock.NewMockSeedTaskMgr(ctrl)
+	taskMgr.EXPECT().GetAccessTime().Return(accessTimes, nil).AnyTimes()
+	taskMgr.EXPECT().GetAccessCount(gomock.Any()).DoAndReturn(func(string) int32 {
+		return accessCount
+	}).AnyTimes()
+	storageMgr.taskMgr = taskMgr
+
+	taskID := "tiered"
+	content := "hello world"
+	// the new task is stored in the fastest tier
+	assert.Nil(storageMgr.ResetRepo(&types.SeedTask{TaskID: taskID, SourceFileLength: int64(len(content))}))
+	assert.Nil(storageMgr.WriteDownloadFile(taskID, 0, int64(len(content)), strings.NewReader(content)))
+	assert.Nil(storageMgr.WriteFileMetaData(taskID, &storage.FileMetaData{
+		TaskID:        taskID,
+		AccessTime:    timeutils.CurrentTimeMillis(),
+		CdnFileLength: int64(len(content)),
+		Finish:        true,
+		Success:       true,
+	}))
+	assert.True(storageMgr.tiers[0].driver.Exits(storage.GetDownloadRaw(taskID)))
+	assert.False(storageMgr.tiers[1].driver.Exits(storage.GetDownloadRaw(taskID)))
+
+	// the idle task is demoted
+	assert.Nil(accessTimes.Add(taskID, time.Now().Add(-2*time.Hour)))
+	assert.Nil(storageMgr.rebalance())
+	assert.Equal(1, storageMgr.locate(taskID))
+	assert.False(storageMgr.tiers[0].driver.Exits(storage.GetDownloadRaw(taskID)))
+	assertContent(t, storageMgr, taskID, content)
+
+	// the frequently accessed task is promoted
+	accessCount = 2
+	assert.Nil(accessTimes.Add(taskID, time.Now()))
+	assert.Nil(storageMgr.rebalance())
+	assert.Equal(0, storageMgr.locate(taskID))
+	assert.False(storageMgr.tiers[1].driver.Exits(storage.GetDownloadRaw(taskID)))
+	assertContent(t, storageMgr, taskID, content)
+
+	// the move is abandoned when the meta data is changed during the move
+	staleMetaData, err := storageMgr.ReadFileMetaData(taskID)
+	assert.Nil(err)
+	staleMetaData.PieceMd5Sign = "stale"
+	assert.NotNil(storageMgr.moveTask(taskID, staleMetaData, 0, 1, 0))
+	assert.Equal(0, storageMgr.locate(taskID))
+	assert.False(storageMgr.tiers[1].driver.Exits(storage.GetDownloadRaw(taskID)))
+	assertContent(t, storageMgr, taskID, content)
+
+	// the task is not promoted again without new access
+	assert.Nil(storageMgr.rebalance())
+	assert.Equal(0, storageMgr.locate(taskID))
+
+	// the location is found again after restart
+	storageMgr.locations.Delete(taskID)
+	assert.Equal(0, storageMgr.locate(taskID))
+
+	// the task cleaned from the fastest tier is demoted, and deleted from the slowest tier
+	taskMgr.EXPECT().Get(taskID).Return(nil, cdnerrors.ErrDataNotFound).AnyTimes()
+	assert.True(storageMgr.gcTask(taskID, 0))
+	assert.Equal(1, storageMgr.locate(taskID))
+	assertContent(t, storageMgr, taskID, content)
+	assert.True(storageMgr.gcTask(taskID, 1))
+	assert.False(storageMgr.tiers[1].driver.Exits(storage.GetDownloadRaw(taskID)))
+	_, err = storageMgr.ReadFileMetaData(taskID)
+	assert.NotNil(err)
+}
+
+func TestDefaultGcConfig(t *testing.T) {
+	assert := assert.New(t)
+	workHome, err := ioutil.TempDir("", "cdn-tiered-")
+	assert.Nil(err)
+	defer os.RemoveAll(workHome)
+
+	driver, err := local.NewStorageDriver(&storedriver.Config{BaseDir: workHome})
+	assert.Nil(err)
+	assert.Nil(driver.CreateBaseDir())
+	totalSpace, err := driver.GetTotalSpace()
+	assert.Nil(err)
+
+	// the reserved space is a part of the tier, so the small tier like memory can still store tasks
+	gcConfig := (&tieredStorageMgr{}).getDefaultGcConfig(&tier{name: "memory", driver: driver})
+	assert.True(gcConfig.YoungGCThreshold <= totalSpace/4)
+	assert.True(gcConfig.FullGCThreshold <= totalSpace/20)
+	assert.True(gcConfig.YoungGCThreshold < totalSpace)
+}
+
+func assertContent(t *testing.T, storageMgr *tieredStorageMgr, taskID string, content string) {
+	reader, err := storageMgr.ReadDownloadFile(taskID)
+	assert.Nil(t, err)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, content, string(data))
+}
@@ -31,7 +31,14 @@ type CDNMgr interface {
 	// TriggerCDN will trigger CDN to download the resource from sourceUrl.
 	TriggerCDN(context.Context, *types.SeedTask) (*types.SeedTask, error)
 
-	// Delete the cdn meta with specified taskID.
-	// The file on the disk will be deleted when the force is true.
+	// Delete the cdn meta and files with specified taskID,
+	// the data shared with other cdn nodes is deleted too, it is only used to purge the task explicitly.
 	Delete(string) error
+
+	// GetContentLength gets the content length of the source with the limit of the source host.
+	GetContentLength(ctx context.Context, url string, header map[string]string) (int64, error)
+
+	// IsFresh checks whether the cache of url stored with the freshness policy is fresh,
+	// the fresh cache is used without revalidating with the source.
+	IsFresh(url string, freshnessPolicy string, freshUntil int64) bool
 }
@@ -18,11 +18,13 @@ package gc
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"github.com/pkg/errors"
 )
 
 type Executor interface {
@@ -33,10 +35,21 @@ type ExecutorWrapper struct {
 	gcInitialDelay time.Duration
 	gcInterval     time.Duration
 	gcExecutor     Executor
+	// mu makes the gc triggered manually not run with the scheduled one at the same time
+	mu sync.Mutex
+}
+
+func (wrapper *ExecutorWrapper) gc() error {
+	wrapper.mu.Lock()
+	defer wrapper.mu.Unlock()
+	return wrapper.gcExecutor.GC()
 }
 
 var (
 	gcExecutorWrappers = make(map[string]*ExecutorWrapper)
+
+	// ErrNotFound represents the gc task is not registered
+	ErrNotFound = errors.New("gc task not found")
 )
 
 // Register a gc task
@@ -68,7 +81,7 @@ func StartGC(ctx context.Context) error {
 					logger.Infof("exit %s gc task", name)
 					return
 				case <-ticker.C:
-					if err := wrapper.gcExecutor.GC(); err != nil {
+					if err := wrapper.gc(); err != nil {
 						logger.Errorf("%s gc task execute failed: %v", name, err)
 					}
 				}
@@ -79,3 +92,37 @@ func StartGC(ctx context.Context) error {
 	logger.Debugf("====all gc jobs have been launched====")
 	return nil
 }
+
+// Exists determines whether the gc task with name is registered, name is empty means all gc tasks.
+func Exists(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, ok := gcExecutorWrappers[strings.ToLower(name)]
+	return ok
+}
+
+// RunGC executes the gc task with name immediately, all gc tasks are executed when name is empty.
+func RunGC(name string) error {
+	name = strings.ToLower(name)
+	if name != "" {
+		if _, ok := gcExecutorWrappers[name]; !ok {
+			return errors.Wrapf(ErrNotFound, "run %s", name)
+		}
+	}
+	var failed []string
+	for n, wrapper := range gcExecutorWrappers {
+		if name != "" && n != name {
+			continue
+		}
+		logger.Infof("run the %s gc task manually", n)
+		if err := wrapper.gc(); err != nil {
+			logger.Errorf("%s gc task execute failed: %v", n, err)
+			failed = append(failed, n)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("gc tasks %s execute failed", strings.Join(failed, ","))
+	}
+	return nil
+}
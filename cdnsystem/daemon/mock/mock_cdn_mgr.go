@@ -49,6 +49,35 @@ func (mr *MockCDNMgrMockRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCDNMgr)(nil).Delete), arg0)
 }
 
+// GetContentLength mocks base method.
+func (m *MockCDNMgr) GetContentLength(ctx context.Context, url string, header map[string]string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContentLength", ctx, url, header)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContentLength indicates an expected call of GetContentLength.
+func (mr *MockCDNMgrMockRecorder) GetContentLength(ctx, url, header interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContentLength", reflect.TypeOf((*MockCDNMgr)(nil).GetContentLength), ctx, url, header)
+}
+
+// IsFresh mocks base method.
+func (m *MockCDNMgr) IsFresh(url, freshnessPolicy string, freshUntil int64) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFresh", url, freshnessPolicy, freshUntil)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsFresh indicates an expected call of IsFresh.
+func (mr *MockCDNMgrMockRecorder) IsFresh(url, freshnessPolicy, freshUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFresh", reflect.TypeOf((*MockCDNMgr)(nil).IsFresh), url, freshnessPolicy, freshUntil)
+}
+
 // TriggerCDN mocks base method.
 func (m *MockCDNMgr) TriggerCDN(arg0 context.Context, arg1 *types.SeedTask) (*types.SeedTask, error) {
 	m.ctrl.T.Helper()
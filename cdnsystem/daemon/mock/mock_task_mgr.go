@@ -65,6 +65,20 @@ func (mr *MockSeedTaskMgrMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSeedTaskMgr)(nil).Get), arg0)
 }
 
+// GetAccessCount mocks base method.
+func (m *MockSeedTaskMgr) GetAccessCount(arg0 string) int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessCount", arg0)
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// GetAccessCount indicates an expected call of GetAccessCount.
+func (mr *MockSeedTaskMgrMockRecorder) GetAccessCount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessCount", reflect.TypeOf((*MockSeedTaskMgr)(nil).GetAccessCount), arg0)
+}
+
 // GetAccessTime mocks base method.
 func (m *MockSeedTaskMgr) GetAccessTime() (*syncmap.SyncMap, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +109,48 @@ func (mr *MockSeedTaskMgrMockRecorder) GetPieces(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieces", reflect.TypeOf((*MockSeedTaskMgr)(nil).GetPieces), arg0, arg1)
 }
 
+// List mocks base method.
+func (m *MockSeedTaskMgr) List() []*types.SeedTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*types.SeedTask)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockSeedTaskMgrMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSeedTaskMgr)(nil).List))
+}
+
+// Purge mocks base method.
+func (m *MockSeedTaskMgr) Purge(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockSeedTaskMgrMockRecorder) Purge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockSeedTaskMgr)(nil).Purge), arg0)
+}
+
+// Refresh mocks base method.
+func (m *MockSeedTaskMgr) Refresh(arg0 context.Context, arg1 *types.TaskRegisterRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockSeedTaskMgrMockRecorder) Refresh(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockSeedTaskMgr)(nil).Refresh), arg0, arg1)
+}
+
 // Register mocks base method.
 func (m *MockSeedTaskMgr) Register(arg0 context.Context, arg1 *types.TaskRegisterRequest) (<-chan *types.SeedPiece, error) {
 	m.ctrl.T.Helper()
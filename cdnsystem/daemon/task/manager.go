@@ -23,6 +23,7 @@ import (
 
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/gc"
 	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
@@ -31,7 +32,9 @@ import (
 	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
 	"d7y.io/dragonfly/v2/pkg/synclock"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
 	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
 // Ensure that Manager implements the SeedTaskMgr and gcExecutor interfaces
@@ -43,6 +46,7 @@ type Manager struct {
 	cfg                     *config.Config
 	taskStore               *syncmap.SyncMap
 	accessTimeMap           *syncmap.SyncMap
+	accessCountMap          *syncmap.SyncMap
 	taskURLUnReachableStore *syncmap.SyncMap
 	cdnMgr                  daemon.CDNMgr
 	progressMgr             daemon.SeedProgressMgr
@@ -54,6 +58,7 @@ func NewManager(cfg *config.Config, cdnMgr daemon.CDNMgr, progressMgr daemon.See
 		cfg:                     cfg,
 		taskStore:               syncmap.NewSyncMap(),
 		accessTimeMap:           syncmap.NewSyncMap(),
+		accessCountMap:          syncmap.NewSyncMap(),
 		taskURLUnReachableStore: syncmap.NewSyncMap(),
 		cdnMgr:                  cdnMgr,
 		progressMgr:             progressMgr,
@@ -75,6 +80,7 @@ func (tm *Manager) Register(ctx context.Context, req *types.TaskRegisterRequest)
 	if err := tm.accessTimeMap.Add(task.TaskID, time.Now()); err != nil {
 		logger.WithTaskID(task.TaskID).Warnf("failed to update accessTime: %v", err)
 	}
+	tm.increaseAccessCount(task.TaskID)
 
 	// trigger CDN
 	if err := tm.triggerCdnSyncAction(ctx, task); err != nil {
@@ -165,20 +171,188 @@ func (tm Manager) GetAccessTime() (*syncmap.SyncMap, error) {
 	return tm.accessTimeMap, nil
 }
 
+func (tm Manager) GetAccessCount(taskID string) int32 {
+	count, err := tm.accessCountMap.GetAsAtomicInt(taskID)
+	if err != nil {
+		return 0
+	}
+	return count.Load()
+}
+
+// increaseAccessCount increases the count of registering the task
+func (tm Manager) increaseAccessCount(taskID string) {
+	v, _ := tm.accessCountMap.LoadOrStore(taskID, atomic.NewInt32(0))
+	v.(*atomic.Int32).Inc()
+}
+
 func (tm Manager) Delete(taskID string) error {
 	tm.accessTimeMap.Delete(taskID)
+	tm.accessCountMap.Delete(taskID)
 	tm.taskURLUnReachableStore.Delete(taskID)
 	tm.taskStore.Delete(taskID)
 	tm.progressMgr.Clear(taskID)
 	return nil
 }
 
+func (tm Manager) List() []*types.SeedTask {
+	var tasks []*types.SeedTask
+	tm.taskStore.Range(func(key, value interface{}) bool {
+		if task, ok := value.(*types.SeedTask); ok {
+			tasks = append(tasks, task)
+		}
+		return true
+	})
+	return tasks
+}
+
+func (tm *Manager) Purge(taskID string) error {
+	synclock.Lock(taskID, false)
+	defer synclock.UnLock(taskID, false)
+	if v, err := tm.taskStore.Get(taskID); err == nil {
+		if task, ok := v.(*types.SeedTask); ok && task.CdnStatus == types.TaskInfoCdnStatusRunning {
+			return errors.Wrapf(cdnerrors.ErrTaskRunning, "purge task %s", taskID)
+		}
+	}
+	if err := tm.cdnMgr.Delete(taskID); err != nil {
+		return errors.Wrapf(err, "delete task %s from storage", taskID)
+	}
+	tm.Delete(taskID)
+	logger.WithTaskID(taskID).Infof("task is purged")
+	return nil
+}
+
+func (tm *Manager) Refresh(ctx context.Context, req *types.TaskRegisterRequest) error {
+	if err := tm.Purge(req.TaskID); err != nil {
+		return err
+	}
+	pieceChan, err := tm.Register(ctx, req)
+	if err != nil {
+		return errors.Wrapf(err, "register task %s", req.TaskID)
+	}
+	// drain the pieces, the task is downloaded in background
+	go func() {
+		for range pieceChan {
+		}
+		logger.WithTaskID(req.TaskID).Infof("task is refreshed")
+	}()
+	return nil
+}
+
 func (tm *Manager) GetPieces(ctx context.Context, taskID string) (pieces []*types.SeedPiece, err error) {
 	synclock.Lock(taskID, true)
 	defer synclock.UnLock(taskID, true)
 	return tm.progressMgr.GetPieces(ctx, taskID)
 }
 
+// Restore loads the tasks which have been downloaded successfully from storage,
+// so that the fresh ones can be served without contacting the origin after a restart.
+func (tm *Manager) Restore(ctx context.Context, storageMgr storage.Manager) error {
+	taskIDs, err := storageMgr.ListTaskIDs()
+	if err != nil {
+		return errors.Wrap(err, "list task ids from storage")
+	}
+	var restoredCount int
+	for _, taskID := range taskIDs {
+		if err := tm.restoreTask(ctx, storageMgr, taskID); err != nil {
+			logger.WithTaskID(taskID).Warnf("failed to restore task from storage: %v", err)
+			continue
+		}
+		restoredCount++
+	}
+	logger.Infof("restore tasks: successfully restore %d tasks from storage, total count(%d)", restoredCount, len(taskIDs))
+	return nil
+}
+
+func (tm *Manager) restoreTask(ctx context.Context, storageMgr storage.Manager, taskID string) error {
+	synclock.Lock(taskID, false)
+	defer synclock.UnLock(taskID, false)
+	if _, err := tm.taskStore.Get(taskID); err == nil {
+		return nil
+	}
+	metaData, err := storageMgr.ReadFileMetaData(taskID)
+	if err != nil {
+		return errors.Wrap(err, "read file meta data")
+	}
+	if !metaData.Finish || !metaData.Success {
+		return errors.Errorf("task is not finished successfully, finish: %t, success: %t", metaData.Finish, metaData.Success)
+	}
+	storageInfo, err := storageMgr.StatDownloadFile(taskID)
+	if err != nil {
+		return errors.Wrap(err, "stat download file")
+	}
+	if storageInfo.Size != metaData.CdnFileLength {
+		return errors.Errorf("file size(%d) of storage is not equal to cdn file length(%d)", storageInfo.Size, metaData.CdnFileLength)
+	}
+	records, err := storageMgr.ReadPieceMetaRecords(taskID)
+	if err != nil {
+		return errors.Wrap(err, "read piece meta records")
+	}
+	if int32(len(records)) != metaData.TotalPieceCount {
+		return errors.Errorf("piece count(%d) is not equal to total piece count(%d)", len(records), metaData.TotalPieceCount)
+	}
+	// the metadata written by old versions has no raw url
+	url := metaData.URL
+	if url == "" {
+		url = metaData.TaskURL
+	}
+	task := &types.SeedTask{
+		TaskID:           taskID,
+		URL:              url,
+		TaskURL:          metaData.TaskURL,
+		Header:           metaData.Header,
+		RequestDigest:    metaData.RequestDigest,
+		SourceFileLength: metaData.SourceFileLen,
+		CdnFileLength:    metaData.CdnFileLength,
+		PieceSize:        metaData.PieceSize,
+		CdnStatus:        types.TaskInfoCdnStatusSuccess,
+		PieceTotal:       metaData.TotalPieceCount,
+		SourceRealDigest: metaData.SourceRealDigest,
+		PieceMd5Sign:     metaData.PieceMd5Sign,
+	}
+	// the stale task is restored waiting, so that it is detected and revalidated with the source on next register
+	if !tm.cdnMgr.IsFresh(url, metaData.FreshnessPolicy, metaData.FreshUntil) {
+		task.CdnStatus = types.TaskInfoCdnStatusWaiting
+		if err := tm.taskStore.Add(taskID, task); err != nil {
+			return errors.Wrap(err, "add task into task store")
+		}
+		tm.restoreAccessTime(taskID, metaData.AccessTime)
+		logger.WithTaskID(taskID).Debugf("success restore stale task:%+v from storage", task)
+		return nil
+	}
+	tm.progressMgr.InitSeedProgress(ctx, taskID)
+	for _, record := range records {
+		if err := tm.progressMgr.PublishPiece(taskID, &types.SeedPiece{
+			PieceStyle:  record.PieceStyle,
+			PieceNum:    record.PieceNum,
+			PieceMd5:    record.Md5,
+			PieceRange:  record.Range,
+			OriginRange: record.OriginRange,
+			PieceLen:    record.PieceLen,
+		}); err != nil {
+			tm.progressMgr.Clear(taskID)
+			return errors.Wrapf(err, "publish piece %d", record.PieceNum)
+		}
+	}
+	if err := tm.taskStore.Add(taskID, task); err != nil {
+		tm.progressMgr.Clear(taskID)
+		return errors.Wrap(err, "add task into task store")
+	}
+	tm.restoreAccessTime(taskID, metaData.AccessTime)
+	logger.WithTaskID(taskID).Debugf("success restore task:%+v from storage", task)
+	return nil
+}
+
+// restoreAccessTime restores the access time of task in millis, the task without access time is accessed now
+func (tm *Manager) restoreAccessTime(taskID string, accessTimeMillis int64) {
+	accessTime := time.Now()
+	if accessTimeMillis > 0 {
+		accessTime = timeutils.MillisUnixTime(accessTimeMillis)
+	}
+	if err := tm.accessTimeMap.Add(taskID, accessTime); err != nil {
+		logger.WithTaskID(taskID).Warnf("failed to update accessTime: %v", err)
+	}
+}
+
 const (
 	// gcTasksTimeout specifies the timeout for tasks gc.
 	// If the actual execution time exceeds this threshold, a warning will be thrown.
@@ -19,12 +19,19 @@ package task
 import (
 	"context"
 	"testing"
+	"time"
 
 	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	storageMock "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/mock"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mock"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/progress"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	"d7y.io/dragonfly/v2/internal/idgen"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 )
@@ -65,7 +72,7 @@ func (suite *TaskManagerTestSuite) TestRegister() {
 				req: &types.TaskRegisterRequest{
 					URL:    dragonflyURL,
 					TaskID: taskID,
-					Md5:    "f1e2488bba4d1267948d9e2f7008571c",
+					Digest: "f1e2488bba4d1267948d9e2f7008571c",
 					Filter: []string{"a", "b"},
 					Header: nil,
 				},
@@ -88,3 +95,154 @@ func (suite *TaskManagerTestSuite) TestRegister() {
 		})
 	}
 }
+
+func (suite *TaskManagerTestSuite) TestRestore() {
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+	cdnMgr := mock.NewMockCDNMgr(ctrl)
+	progressMgr, err := progress.NewManager()
+	suite.Nil(err)
+	tm, err := NewManager(config.New(), cdnMgr, progressMgr)
+	suite.Nil(err)
+
+	storageMgr := storageMock.NewMockManager(ctrl)
+	storageMgr.EXPECT().ListTaskIDs().Return([]string{"success", "stale", "running", "broken"}, nil)
+	storageMgr.EXPECT().ReadFileMetaData("success").Return(&storage.FileMetaData{
+		TaskID:          "success",
+		TaskURL:         "http://dragonfly.io/success",
+		URL:             "http://dragonfly.io/success?token=abc",
+		Header:          map[string]string{"Authorization": "Basic YWJjOmFiYw=="},
+		RequestDigest:   "md5:3b6cfd8bb0b3b4bf0bba57ab6c2d5b39",
+		AccessTime:      1600000000000,
+		PieceSize:       10,
+		SourceFileLen:   15,
+		CdnFileLength:   15,
+		PieceMd5Sign:    "sign",
+		Finish:          true,
+		Success:         true,
+		TotalPieceCount: 2,
+		FreshnessPolicy: "immutable",
+	}, nil)
+	storageMgr.EXPECT().StatDownloadFile("success").Return(&storedriver.StorageInfo{Size: 15}, nil)
+	cdnMgr.EXPECT().IsFresh("http://dragonfly.io/success?token=abc", "immutable", int64(0)).Return(true)
+	storageMgr.EXPECT().ReadPieceMetaRecords("success").Return([]*storage.PieceMetaRecord{
+		{PieceNum: 0, PieceLen: 10, Md5: "md5-0", Range: &rangeutils.Range{StartIndex: 0, EndIndex: 9},
+			OriginRange: &rangeutils.Range{StartIndex: 0, EndIndex: 9}, PieceStyle: types.PlainUnspecified},
+		{PieceNum: 1, PieceLen: 5, Md5: "md5-1", Range: &rangeutils.Range{StartIndex: 10, EndIndex: 14},
+			OriginRange: &rangeutils.Range{StartIndex: 10, EndIndex: 14}, PieceStyle: types.PlainUnspecified},
+	}, nil)
+	storageMgr.EXPECT().ReadFileMetaData("stale").Return(&storage.FileMetaData{
+		TaskID:          "stale",
+		TaskURL:         "http://dragonfly.io/stale",
+		CdnFileLength:   5,
+		Finish:          true,
+		Success:         true,
+		TotalPieceCount: 1,
+		FreshnessPolicy: "ttl",
+		FreshUntil:      1600000000000,
+	}, nil)
+	storageMgr.EXPECT().StatDownloadFile("stale").Return(&storedriver.StorageInfo{Size: 5}, nil)
+	storageMgr.EXPECT().ReadPieceMetaRecords("stale").Return([]*storage.PieceMetaRecord{
+		{PieceNum: 0, PieceLen: 5, Md5: "md5-0", Range: &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+			OriginRange: &rangeutils.Range{StartIndex: 0, EndIndex: 4}, PieceStyle: types.PlainUnspecified},
+	}, nil)
+	cdnMgr.EXPECT().IsFresh("http://dragonfly.io/stale", "ttl", int64(1600000000000)).Return(false)
+	storageMgr.EXPECT().ReadFileMetaData("running").Return(&storage.FileMetaData{TaskID: "running"}, nil)
+	storageMgr.EXPECT().ReadFileMetaData("broken").Return(&storage.FileMetaData{
+		TaskID:          "broken",
+		CdnFileLength:   15,
+		Finish:          true,
+		Success:         true,
+		TotalPieceCount: 2,
+	}, nil)
+	storageMgr.EXPECT().StatDownloadFile("broken").Return(&storedriver.StorageInfo{Size: 10}, nil)
+
+	suite.Nil(tm.Restore(context.Background(), storageMgr))
+
+	// the access time is restored, getting task updates it
+	accessTimeMap, _ := tm.GetAccessTime()
+	accessTime, err := accessTimeMap.GetAsTime("success")
+	suite.Nil(err)
+	suite.Equal(int64(1600000000), accessTime.Unix())
+
+	task, err := tm.Get("success")
+	suite.Nil(err)
+	suite.True(task.IsSuccess())
+	suite.Equal(int64(15), task.SourceFileLength)
+	suite.Equal(int32(2), task.PieceTotal)
+	suite.Equal("sign", task.PieceMd5Sign)
+	// the raw url and headers are restored for downloading from source again
+	suite.Equal("http://dragonfly.io/success?token=abc", task.URL)
+	suite.Equal("http://dragonfly.io/success", task.TaskURL)
+	suite.Equal(map[string]string{"Authorization": "Basic YWJjOmFiYw=="}, task.Header)
+	suite.Equal("md5:3b6cfd8bb0b3b4bf0bba57ab6c2d5b39", task.RequestDigest)
+	pieces, err := tm.GetPieces(context.Background(), "success")
+	suite.Nil(err)
+	suite.Len(pieces, 2)
+
+	// restored tasks are served without contacting the origin
+	pieceChan, err := tm.Register(context.Background(), &types.TaskRegisterRequest{
+		URL:    "http://dragonfly.io/success",
+		TaskID: "success",
+	})
+	suite.Nil(err)
+	var count int
+	for range pieceChan {
+		count++
+	}
+	suite.Equal(2, count)
+	suite.Equal(int32(1), tm.GetAccessCount("success"))
+
+	// the stale task is detected with the source on next register
+	task, err = tm.Get("stale")
+	suite.Nil(err)
+	suite.True(task.IsWait())
+	triggered := make(chan struct{})
+	cdnMgr.EXPECT().TriggerCDN(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, task *types.SeedTask) (*types.SeedTask, error) {
+			close(triggered)
+			return &types.SeedTask{TaskID: "stale", CdnStatus: types.TaskInfoCdnStatusFailed}, nil
+		})
+	_, err = tm.Register(context.Background(), &types.TaskRegisterRequest{
+		URL:    "http://dragonfly.io/stale",
+		TaskID: "stale",
+	})
+	suite.Nil(err)
+	select {
+	case <-triggered:
+	case <-time.After(5 * time.Second):
+		suite.Fail("stale task is not detected")
+	}
+
+	_, err = tm.Get("running")
+	suite.NotNil(err)
+	_, err = tm.Get("broken")
+	suite.NotNil(err)
+}
+
+func (suite *TaskManagerTestSuite) TestPurge() {
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+	cdnMgr := mock.NewMockCDNMgr(ctrl)
+	progressMgr, err := progress.NewManager()
+	suite.Nil(err)
+	tm, err := NewManager(config.New(), cdnMgr, progressMgr)
+	suite.Nil(err)
+
+	tm.taskStore.Add("running", &types.SeedTask{TaskID: "running", CdnStatus: types.TaskInfoCdnStatusRunning})
+	tm.taskStore.Add("success", &types.SeedTask{TaskID: "success", CdnStatus: types.TaskInfoCdnStatusSuccess})
+	suite.Len(tm.List(), 2)
+
+	err = tm.Purge("running")
+	suite.True(cdnerrors.IsTaskRunning(err))
+
+	cdnMgr.EXPECT().Delete("success").Return(nil)
+	suite.Nil(tm.Purge("success"))
+	_, err = tm.Get("success")
+	suite.NotNil(err)
+
+	// the task only in storage is purged too
+	cdnMgr.EXPECT().Delete("stored").Return(nil)
+	suite.Nil(tm.Purge("stored"))
+	suite.Len(tm.List(), 1)
+}
@@ -26,7 +26,6 @@ import (
 	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
-	"d7y.io/dragonfly/v2/pkg/source"
 	"d7y.io/dragonfly/v2/pkg/synclock"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
@@ -62,7 +61,7 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, request *types.TaskRegis
 	newTask := &types.SeedTask{
 		TaskID:           taskID,
 		Header:           request.Header,
-		RequestMd5:       request.Md5,
+		RequestDigest:    request.Digest,
 		URL:              request.URL,
 		TaskURL:          taskURL,
 		CdnStatus:        types.TaskInfoCdnStatusWaiting,
@@ -85,10 +84,8 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, request *types.TaskRegis
 		return task, nil
 	}
 
-	// get sourceContentLength with req.Header
-	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
-	defer cancel()
-	sourceFileLength, err := source.GetContentLength(ctx, task.URL, request.Header)
+	// get sourceContentLength with req.Header, the probe timeout is applied by cdn manager after waiting for the source limit
+	sourceFileLength, err := tm.cdnMgr.GetContentLength(ctx, task.URL, request.Header)
 	if err != nil {
 		logger.WithTaskID(task.TaskID).Errorf("failed to get url (%s) content length: %v", task.URL, err)
 
@@ -153,8 +150,8 @@ func (tm *Manager) updateTask(taskID string, updateTaskInfo *types.SeedTask) (*t
 		task.CdnFileLength = updateTaskInfo.CdnFileLength
 	}
 
-	if !stringutils.IsBlank(updateTaskInfo.SourceRealMd5) {
-		task.SourceRealMd5 = updateTaskInfo.SourceRealMd5
+	if !stringutils.IsBlank(updateTaskInfo.SourceRealDigest) {
+		task.SourceRealDigest = updateTaskInfo.SourceRealDigest
 	}
 
 	if !stringutils.IsBlank(updateTaskInfo.PieceMd5Sign) {
@@ -181,14 +178,14 @@ func isSameTask(task1, task2 *types.SeedTask) bool {
 		return false
 	}
 
-	if !stringutils.IsBlank(task1.RequestMd5) && !stringutils.IsBlank(task2.RequestMd5) {
-		if task1.RequestMd5 != task2.RequestMd5 {
+	if !stringutils.IsBlank(task1.RequestDigest) && !stringutils.IsBlank(task2.RequestDigest) {
+		if task1.RequestDigest != task2.RequestDigest {
 			return false
 		}
 	}
 
-	if !stringutils.IsBlank(task1.RequestMd5) && !stringutils.IsBlank(task2.SourceRealMd5) {
-		return task1.SourceRealMd5 == task2.RequestMd5
+	if !stringutils.IsBlank(task1.RequestDigest) && !stringutils.IsBlank(task2.SourceRealDigest) {
+		return task1.SourceRealDigest == task2.RequestDigest
 	}
 
 	return true
@@ -38,9 +38,21 @@ type SeedTaskMgr interface {
 	// GetAccessTime get all tasks accessTime.
 	GetAccessTime() (*syncmap.SyncMap, error)
 
+	// GetAccessCount get the count of registering the task since it is loaded.
+	GetAccessCount(string) int32
+
 	// Delete delete a task.
 	Delete(string) error
 
+	// List lists all tasks in memory without updating their access time.
+	List() []*types.SeedTask
+
+	// Purge deletes a task from both memory and storage, the running task can not be purged.
+	Purge(string) error
+
+	// Refresh purges the task of request and downloads it from source again with the request.
+	Refresh(context.Context, *types.TaskRegisterRequest) error
+
 	// GetPieces
 	GetPieces(context.Context, string) (pieces []*types.SeedPiece, err error)
 }
@@ -68,6 +68,15 @@ func (e ErrResourceNotSupportRangeRequest) Error() string {
 	return fmt.Sprintf("url %s does not support range request", e.URL)
 }
 
+// ErrSourceLimited represents waiting too long for the connection limit of source
+type ErrSourceLimited struct {
+	URL string
+}
+
+func (e ErrSourceLimited) Error() string {
+	return fmt.Sprintf("url %s is limited, wait too long for connection", e.URL)
+}
+
 // ErrFileNotExist represents the file is not exists
 type ErrFileNotExist struct {
 	File string
@@ -92,6 +101,9 @@ var (
 
 	// ErrConvertFailed represents failed to convert.
 	ErrConvertFailed = errors.New("convert failed")
+
+	// ErrTaskRunning represents the task is being downloaded.
+	ErrTaskRunning = errors.New("task is running")
 )
 
 // IsSystemError checks the error is a system error or not.
@@ -135,6 +147,12 @@ func IsResourceNotSupportRangeRequest(err error) bool {
 	return ok
 }
 
+func IsSourceLimited(err error) bool {
+	err = errors.Cause(err)
+	_, ok := err.(ErrSourceLimited)
+	return ok
+}
+
 func IsDataNotFound(err error) bool {
 	return errors.Cause(err) == ErrDataNotFound
 }
@@ -147,6 +165,10 @@ func IsConvertFailed(err error) bool {
 	return errors.Cause(err) == ErrConvertFailed
 }
 
+func IsTaskRunning(err error) bool {
+	return errors.Cause(err) == ErrTaskRunning
+}
+
 func IsFileNotExist(err error) bool {
 	err = errors.Cause(err)
 	_, ok := err.(ErrFileNotExist)
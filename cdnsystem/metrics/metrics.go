@@ -0,0 +1,63 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "dragonfly"
+	subsystem = "cdn"
+)
+
+var (
+	ScrubbedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrubbed_task_total",
+		Help:      "Counter of the tasks verified by scrubber.",
+	})
+
+	ScrubbedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrubbed_bytes_total",
+		Help:      "Counter of the bytes read by scrubber.",
+	})
+
+	ScrubCorruptedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrub_corrupted_task_total",
+		Help:      "Counter of the corrupted tasks found by scrubber.",
+	})
+)
+
+// New returns the http server exposes metrics on /metrics
+func New(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
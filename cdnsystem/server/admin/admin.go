@@ -0,0 +1,364 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package admin implements the admin api of cdn to inspect, purge and refresh the cached tasks,
+// it is served on its own listener which is only accessible locally by default
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/gc"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/util/timeutils"
+)
+
+// PathPrefix is the url path prefix of the admin api
+const PathPrefix = "/admin/v1"
+
+// Task is the task cached by cdn
+type Task struct {
+	TaskID     string    `json:"taskId"`
+	URL        string    `json:"url"`
+	Size       int64     `json:"size"`
+	Status     string    `json:"status"`
+	AccessTime time.Time `json:"accessTime"`
+	// Tier is the storage tier where the task data is stored, it is the storage mode when the storage is not tiered
+	Tier string `json:"tier"`
+}
+
+// PurgeResult is the result of purging tasks
+type PurgeResult struct {
+	Purged []string `json:"purged"`
+	// Failed are the tasks failed to purge with the reasons
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// Handler handles the admin api requests
+type Handler struct {
+	storageMode string
+	token       string
+	taskMgr     daemon.SeedTaskMgr
+	storageMgr  storage.Manager
+	// gcRunning is set when the gc triggered by admin api is running
+	gcRunning *atomic.Bool
+}
+
+// New creates an admin api handler
+func New(cfg *config.Config, taskMgr daemon.SeedTaskMgr, storageMgr storage.Manager) *Handler {
+	return &Handler{
+		storageMode: cfg.StorageMode,
+		token:       cfg.Admin.Token,
+		taskMgr:     taskMgr,
+		storageMgr:  storageMgr,
+		gcRunning:   atomic.NewBool(false),
+	}
+}
+
+// NewServer creates the http server of admin api listening on cfg.Admin.Addr,
+// the token is required when the address is not a loopback address
+func NewServer(cfg *config.Config, taskMgr daemon.SeedTaskMgr, storageMgr storage.Manager) (*http.Server, error) {
+	if cfg.Admin.Token == "" && !isLoopback(cfg.Admin.Addr) {
+		return nil, errors.Errorf("admin api listens on non-loopback address %s without token", cfg.Admin.Addr)
+	}
+	r := mux.NewRouter()
+	New(cfg, taskMgr, storageMgr).Register(r)
+	return &http.Server{
+		Addr:    cfg.Admin.Addr,
+		Handler: r,
+	}, nil
+}
+
+// Register registers the admin api on router
+func (h *Handler) Register(r *mux.Router) {
+	s := r.PathPrefix(PathPrefix).Subrouter()
+	s.Use(h.authenticate)
+	s.HandleFunc("/tasks", h.handleListTasks).Methods(http.MethodGet)
+	s.HandleFunc("/tasks", h.handlePurgeTasks).Methods(http.MethodDelete)
+	s.HandleFunc("/tasks/{taskID}", h.handlePurgeTask).Methods(http.MethodDelete)
+	s.HandleFunc("/tasks/{taskID}/refresh", h.handleRefreshTask).Methods(http.MethodPost)
+	s.HandleFunc("/gc", h.handleGC).Methods(http.MethodPost)
+}
+
+// authenticate rejects the requests without the bearer token when the token is set
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" {
+			token := strings.TrimPrefix(r.Header.Get(headers.Authorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+				logger.Warnf("reject unauthorized admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleListTasks lists the tasks whose url matches the regular expression of query "url"
+func (h *Handler) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	urlPattern, err := compileURLPattern(r.FormValue("url"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, err := h.listTasks(urlPattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// handlePurgeTasks purges the tasks whose url matches the regular expression of query "url"
+func (h *Handler) handlePurgeTasks(w http.ResponseWriter, r *http.Request) {
+	pattern := r.FormValue("url")
+	if pattern == "" {
+		http.Error(w, "url pattern is required", http.StatusBadRequest)
+		return
+	}
+	urlPattern, err := compileURLPattern(pattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, err := h.listTasks(urlPattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := &PurgeResult{Purged: []string{}}
+	for _, task := range tasks {
+		if err := h.taskMgr.Purge(task.TaskID); err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[task.TaskID] = err.Error()
+			continue
+		}
+		result.Purged = append(result.Purged, task.TaskID)
+	}
+	logger.Infof("purge %d tasks with url pattern %s from %s, %d tasks failed", len(result.Purged), pattern, r.RemoteAddr, len(result.Failed))
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) handlePurgeTask(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	if err := h.taskMgr.Purge(taskID); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+	logger.WithTaskID(taskID).Infof("purge task from %s", r.RemoteAddr)
+	writeJSON(w, http.StatusOK, &PurgeResult{Purged: []string{taskID}})
+}
+
+// handleRefreshTask purges the task and downloads it from source again in background
+func (h *Handler) handleRefreshTask(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	req, err := h.refreshRequest(taskID)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+	// the downloading must not be canceled when the request is done
+	if err := h.taskMgr.Refresh(context.Background(), req); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+	logger.WithTaskID(taskID).Infof("refresh task from %s", r.RemoteAddr)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// refreshRequest builds the request to download the task again, the task which is not in memory
+// is downloaded with the url, headers and digest in its meta data
+func (h *Handler) refreshRequest(taskID string) (*types.TaskRegisterRequest, error) {
+	task, err := h.taskMgr.Get(taskID)
+	if err == nil {
+		return &types.TaskRegisterRequest{
+			URL:    task.URL,
+			TaskID: task.TaskID,
+			Digest: task.RequestDigest,
+			Header: task.Header,
+		}, nil
+	}
+	if !cdnerrors.IsDataNotFound(err) {
+		return nil, err
+	}
+	metaData, err := h.storageMgr.ReadFileMetaData(taskID)
+	if err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return nil, errors.Wrapf(cdnerrors.ErrDataNotFound, "task %s", taskID)
+		}
+		return nil, errors.Wrapf(err, "read file meta data of task %s", taskID)
+	}
+	// the metadata written by old versions has no raw url
+	url := metaData.URL
+	if url == "" {
+		url = metaData.TaskURL
+	}
+	return &types.TaskRegisterRequest{
+		URL:    url,
+		TaskID: taskID,
+		Digest: metaData.RequestDigest,
+		Header: metaData.Header,
+	}, nil
+}
+
+// handleGC runs the gc task of query "name" in background, all gc tasks are run when name is empty
+func (h *Handler) handleGC(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	if !gc.Exists(name) {
+		http.Error(w, errors.Wrapf(gc.ErrNotFound, "run %s", name).Error(), http.StatusNotFound)
+		return
+	}
+	if !h.gcRunning.CAS(false, true) {
+		http.Error(w, "gc is already running", http.StatusConflict)
+		return
+	}
+	logger.Infof("run gc %q from %s", name, r.RemoteAddr)
+	go func() {
+		defer h.gcRunning.Store(false)
+		if err := gc.RunGC(name); err != nil {
+			logger.Errorf("run gc %q failed: %v", name, err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// listTasks lists the tasks in memory and the tasks only in storage, sorted by task id
+func (h *Handler) listTasks(urlPattern *regexp.Regexp) ([]*Task, error) {
+	accessTimeMap, err := h.taskMgr.GetAccessTime()
+	if err != nil {
+		return nil, errors.Wrap(err, "get task access time")
+	}
+	tasks := make(map[string]*Task)
+	for _, seedTask := range h.taskMgr.List() {
+		size := seedTask.CdnFileLength
+		if size <= 0 {
+			size = seedTask.SourceFileLength
+		}
+		accessTime, _ := accessTimeMap.GetAsTime(seedTask.TaskID)
+		tasks[seedTask.TaskID] = &Task{
+			TaskID:     seedTask.TaskID,
+			URL:        seedTask.URL,
+			Size:       size,
+			Status:     seedTask.CdnStatus,
+			AccessTime: accessTime,
+		}
+	}
+	taskIDs, err := h.storageMgr.ListTaskIDs()
+	if err != nil {
+		return nil, errors.Wrap(err, "list task ids from storage")
+	}
+	for _, taskID := range taskIDs {
+		if _, ok := tasks[taskID]; ok {
+			continue
+		}
+		metaData, err := h.storageMgr.ReadFileMetaData(taskID)
+		if err != nil {
+			logger.WithTaskID(taskID).Warnf("failed to read file meta data: %v", err)
+			continue
+		}
+		status := types.TaskInfoCdnStatusFailed
+		if metaData.Finish && metaData.Success {
+			status = types.TaskInfoCdnStatusSuccess
+		}
+		tasks[taskID] = &Task{
+			TaskID:     taskID,
+			URL:        metaData.TaskURL,
+			Size:       metaData.CdnFileLength,
+			Status:     status,
+			AccessTime: timeutils.MillisUnixTime(metaData.AccessTime),
+		}
+	}
+
+	result := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		if urlPattern != nil && !urlPattern.MatchString(task.URL) {
+			continue
+		}
+		task.Tier = h.storageMode
+		if tierGetter, ok := h.storageMgr.(storage.TierGetter); ok {
+			task.Tier = tierGetter.GetTier(task.TaskID)
+		}
+		result = append(result, task)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TaskID < result[j].TaskID
+	})
+	return result, nil
+}
+
+func compileURLPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	urlPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url pattern %s", pattern)
+	}
+	return urlPattern, nil
+}
+
+func statusCode(err error) int {
+	switch {
+	case cdnerrors.IsTaskRunning(err):
+		return http.StatusConflict
+	case cdnerrors.IsDataNotFound(err):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// isLoopback determines whether the listen address only accepts local connections
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set(headers.ContentType, "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("failed to write response: %v", err)
+	}
+}
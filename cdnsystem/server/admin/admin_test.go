@@ -0,0 +1,255 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	storagemock "d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/mock"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/gc"
+	daemonmock "d7y.io/dragonfly/v2/cdnsystem/daemon/mock"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
+)
+
+func newTestServer(t *testing.T, ctrl *gomock.Controller) (*httptest.Server, *daemonmock.MockSeedTaskMgr) {
+	accessTime := syncmap.NewSyncMap()
+	assert.Nil(t, accessTime.Add("running", time.Now()))
+	taskMgr := daemonmock.NewMockSeedTaskMgr(ctrl)
+	taskMgr.EXPECT().GetAccessTime().Return(accessTime, nil).AnyTimes()
+	taskMgr.EXPECT().List().Return([]*types.SeedTask{
+		{TaskID: "running", URL: "http://example.com/a", SourceFileLength: 10, CdnStatus: types.TaskInfoCdnStatusRunning},
+	}).AnyTimes()
+
+	storageMgr := storagemock.NewMockManager(ctrl)
+	storageMgr.EXPECT().ListTaskIDs().Return([]string{"running", "stored"}, nil).AnyTimes()
+	storageMgr.EXPECT().ReadFileMetaData("stored").Return(&storage.FileMetaData{
+		TaskURL:       "http://example.com/b",
+		URL:           "http://example.com/b?token=1",
+		Header:        map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+		RequestDigest: "md5:3b6cfd8bb0b3b4bf0bba57ab6c2d5b39",
+		CdnFileLength: 20,
+		AccessTime:    time.Now().UnixNano() / int64(time.Millisecond),
+		Finish:        true,
+		Success:       true,
+	}, nil).AnyTimes()
+	storageMgr.EXPECT().ReadFileMetaData("missing").Return(nil, cdnerrors.ErrFileNotExist{File: "missing"}).AnyTimes()
+
+	r := mux.NewRouter()
+	New(config.New(), taskMgr, storageMgr).Register(r)
+	return httptest.NewServer(r), taskMgr
+}
+
+func TestHandler_ListTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server, _ := newTestServer(t, ctrl)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "all tasks", query: "", expected: []string{"running", "stored"}},
+		{name: "matched tasks", query: "?url=/b$", expected: []string{"stored"}},
+		{name: "no matched task", query: "?url=/c$", expected: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + PathPrefix + "/tasks" + tt.query)
+			assert.Nil(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var tasks []*Task
+			assert.Nil(t, json.NewDecoder(resp.Body).Decode(&tasks))
+			taskIDs := []string{}
+			for _, task := range tasks {
+				taskIDs = append(taskIDs, task.TaskID)
+			}
+			assert.Equal(t, tt.expected, taskIDs)
+		})
+	}
+
+	resp, err := http.Get(server.URL + PathPrefix + "/tasks")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	var tasks []*Task
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&tasks))
+	assert.Equal(t, int64(10), tasks[0].Size)
+	assert.Equal(t, types.TaskInfoCdnStatusRunning, tasks[0].Status)
+	assert.Equal(t, int64(20), tasks[1].Size)
+	assert.Equal(t, types.TaskInfoCdnStatusSuccess, tasks[1].Status)
+	assert.Equal(t, config.New().StorageMode, tasks[1].Tier)
+
+	resp, err = http.Get(server.URL + PathPrefix + "/tasks?url=(")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandler_PurgeTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server, taskMgr := newTestServer(t, ctrl)
+	defer server.Close()
+
+	runningErr := errors.Wrap(cdnerrors.ErrTaskRunning, "purge running")
+	taskMgr.EXPECT().Purge("running").Return(runningErr).AnyTimes()
+	taskMgr.EXPECT().Purge("stored").Return(nil).AnyTimes()
+	taskMgr.EXPECT().Get(gomock.Any()).Return(nil, cdnerrors.ErrDataNotFound).AnyTimes()
+	// the task not in memory is refreshed with the url, headers and digest in meta data
+	taskMgr.EXPECT().Refresh(gomock.Any(), &types.TaskRegisterRequest{
+		URL:    "http://example.com/b?token=1",
+		TaskID: "stored",
+		Digest: "md5:3b6cfd8bb0b3b4bf0bba57ab6c2d5b39",
+		Header: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+	}).Return(nil).Times(1)
+
+	do := func(method, path string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+PathPrefix+path, nil)
+		assert.Nil(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	resp := do(http.MethodDelete, "/tasks?url=example.com")
+	var result PurgeResult
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&result))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"stored"}, result.Purged)
+	assert.Equal(t, runningErr.Error(), result.Failed["running"])
+
+	resp = do(http.MethodDelete, "/tasks")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodDelete, "/tasks/running")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = do(http.MethodDelete, "/tasks/stored")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/tasks/stored/refresh")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/tasks/missing/refresh")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/gc?name=unknown")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHandler_GC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server, _ := newTestServer(t, ctrl)
+	defer server.Close()
+
+	executed := make(chan struct{})
+	gc.Register("adminTest", 0, time.Hour, gcExecutorFunc(func() error {
+		close(executed)
+		return nil
+	}))
+
+	// the gc is run in background
+	resp, err := http.Post(server.URL+PathPrefix+"/gc?name=adminTest", "", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	select {
+	case <-executed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("gc is not executed")
+	}
+}
+
+type gcExecutorFunc func() error
+
+func (f gcExecutorFunc) GC() error {
+	return f()
+}
+
+func TestNewServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskMgr := daemonmock.NewMockSeedTaskMgr(ctrl)
+	taskMgr.EXPECT().GetAccessTime().Return(syncmap.NewSyncMap(), nil).AnyTimes()
+	taskMgr.EXPECT().List().Return(nil).AnyTimes()
+	storageMgr := storagemock.NewMockManager(ctrl)
+	storageMgr.EXPECT().ListTaskIDs().Return(nil, nil).AnyTimes()
+
+	// the token is required when listening on non-loopback address
+	cfg := config.New()
+	cfg.Admin.Addr = ":8005"
+	_, err := NewServer(cfg, taskMgr, storageMgr)
+	assert.NotNil(t, err)
+
+	cfg.Admin.Addr = "127.0.0.1:8005"
+	_, err = NewServer(cfg, taskMgr, storageMgr)
+	assert.Nil(t, err)
+
+	cfg.Admin.Addr = ":8005"
+	cfg.Admin.Token = "secret"
+	adminServer, err := NewServer(cfg, taskMgr, storageMgr)
+	assert.Nil(t, err)
+	server := httptest.NewServer(adminServer.Handler)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{name: "without token", authorization: "", expected: http.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer wrong", expected: http.StatusUnauthorized},
+		{name: "right token", authorization: "Bearer secret", expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+PathPrefix+"/tasks", nil)
+			assert.Nil(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.Nil(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package download implements the piece server of cdn which serves the task data on download port
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
+	"d7y.io/dragonfly/v2/pkg/ratelimiter/ratelimiter"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+)
+
+// PathPrefix is the url path prefix of the task data, the same as the layout of download directory
+const PathPrefix = "/" + storage.DownloadHome + "/"
+
+// Server serves the task data with http range requests, the data is read through storage manager
+type Server struct {
+	server     *http.Server
+	storageMgr storage.Manager
+	limiter    *ratelimiter.RateLimiter
+}
+
+// New creates a piece server listening on the download port
+func New(cfg *config.Config, storageMgr storage.Manager) *Server {
+	s := &Server{
+		server: &http.Server{
+			Addr: fmt.Sprintf(":%d", cfg.DownloadPort),
+		},
+		storageMgr: storageMgr,
+	}
+	if cfg.DownloadLimit > 0 {
+		s.limiter = ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.DownloadLimit)), 2)
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc(PathPrefix+"{taskPrefix}/{taskID}", s.handleDownload).Methods(http.MethodGet, http.MethodHead)
+	s.server.Handler = r
+	return s
+}
+
+// ListenAndServe listens on the download port and serves the requests
+func (s *Server) ListenAndServe() error {
+	return s.server.ListenAndServe()
+}
+
+// Serve serves the requests on listener
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops the server gracefully
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	var (
+		taskID = mux.Vars(r)["taskID"]
+		start  = time.Now()
+		status = http.StatusOK
+		n      int64
+	)
+	defer func() {
+		logger.AccessLogger.Info("download",
+			zap.String("remote", r.RemoteAddr),
+			zap.String("method", r.Method),
+			zap.String("taskID", taskID),
+			zap.String("peerID", r.FormValue("peerId")),
+			zap.String("range", r.Header.Get(headers.Range)),
+			zap.Int("status", status),
+			zap.Int64("bytes", n),
+			zap.Duration("cost", time.Since(start)))
+	}()
+
+	info, err := s.storageMgr.StatDownloadFile(taskID)
+	if err != nil {
+		status = http.StatusInternalServerError
+		if cdnerrors.IsFileNotExist(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	offset, length := int64(0), info.Size
+	if rangeStr := r.Header.Get(headers.Range); rangeStr != "" {
+		rg, err := rangeutils.ParseHTTPRange(rangeStr)
+		if err != nil {
+			status = http.StatusBadRequest
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if rg.StartIndex >= uint64(info.Size) {
+			status = http.StatusRequestedRangeNotSatisfiable
+			w.Header().Set(headers.ContentRange, fmt.Sprintf("bytes */%d", info.Size))
+			http.Error(w, fmt.Sprintf("range %s is out of file size %d", rg, info.Size), status)
+			return
+		}
+		if rg.EndIndex >= uint64(info.Size) {
+			rg.EndIndex = uint64(info.Size) - 1
+		}
+		offset, length = int64(rg.StartIndex), int64(rg.EndIndex-rg.StartIndex+1)
+		status = http.StatusPartialContent
+		w.Header().Set(headers.ContentRange, fmt.Sprintf("bytes %d-%d/%d", rg.StartIndex, rg.EndIndex, info.Size))
+	}
+	w.Header().Set(headers.ContentType, "application/octet-stream")
+	w.Header().Set(headers.AcceptRanges, "bytes")
+	// add header "Content-Length" to avoid chunked body in http client
+	w.Header().Set(headers.ContentLength, strconv.FormatInt(length, 10))
+	if r.Method == http.MethodHead || length == 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	reader, err := s.storageMgr.ReadDownloadFileRange(taskID, offset, length)
+	if err != nil {
+		status = http.StatusInternalServerError
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer reader.Close()
+
+	w.WriteHeader(status)
+	// copy the reader directly when no limit, local files will be sent with sendfile syscall
+	var src io.Reader = reader
+	if s.limiter != nil {
+		src = limitreader.NewLimitReaderWithLimiter(s.limiter, reader, false)
+	}
+	if n, err = io.Copy(w, src); err != nil {
+		logger.WithTaskID(taskID).Errorf("transfer data to %s failed: %v", r.RemoteAddr, err)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/cdn/storage/mock"
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+)
+
+func TestServer_HandleDownload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	storageMgr := mock.NewMockManager(ctrl)
+	storageMgr.EXPECT().StatDownloadFile("task").Return(&storedriver.StorageInfo{Size: int64(len(data))}, nil).AnyTimes()
+	storageMgr.EXPECT().StatDownloadFile("unknown").Return(nil, cdnerrors.ErrFileNotExist{}).AnyTimes()
+	storageMgr.EXPECT().ReadDownloadFileRange("task", gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(taskID string, offset, length int64) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data[offset : offset+length])), nil
+		})
+
+	cfg := config.New()
+	cfg.DownloadLimit = 1024 * 1024
+	server := httptest.NewServer(New(cfg, storageMgr).server.Handler)
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		taskID       string
+		rangeHeader  string
+		statusCode   int
+		contentRange string
+		expected     []byte
+	}{
+		{
+			name:       "whole file",
+			taskID:     "task",
+			statusCode: http.StatusOK,
+			expected:   data,
+		},
+		{
+			name:         "range",
+			taskID:       "task",
+			rangeHeader:  "bytes=10-19",
+			statusCode:   http.StatusPartialContent,
+			contentRange: "bytes 10-19/36",
+			expected:     data[10:20],
+		},
+		{
+			name:         "range end exceeds file size",
+			taskID:       "task",
+			rangeHeader:  "bytes=30-99",
+			statusCode:   http.StatusPartialContent,
+			contentRange: "bytes 30-35/36",
+			expected:     data[30:],
+		},
+		{
+			name:         "range start exceeds file size",
+			taskID:       "task",
+			rangeHeader:  "bytes=36-99",
+			statusCode:   http.StatusRequestedRangeNotSatisfiable,
+			contentRange: "bytes */36",
+		},
+		{
+			name:        "invalid range",
+			taskID:      "task",
+			rangeHeader: "bytes=9-0",
+			statusCode:  http.StatusBadRequest,
+		},
+		{
+			name:       "task not found",
+			taskID:     "unknown",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+PathPrefix+tt.taskID[:3]+"/"+tt.taskID+"?peerId=peer", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.Nil(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.statusCode, resp.StatusCode)
+			assert.Equal(t, tt.contentRange, resp.Header.Get("Content-Range"))
+			if tt.expected != nil {
+				body, err := ioutil.ReadAll(resp.Body)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expected, body)
+			}
+		})
+	}
+}
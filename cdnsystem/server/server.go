@@ -20,6 +20,8 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"runtime"
 	"time"
 
@@ -29,7 +31,10 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/gc"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/progress"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/task"
+	"d7y.io/dragonfly/v2/cdnsystem/metrics"
 	"d7y.io/dragonfly/v2/cdnsystem/plugins"
+	"d7y.io/dragonfly/v2/cdnsystem/server/admin"
+	"d7y.io/dragonfly/v2/cdnsystem/server/download"
 	"d7y.io/dragonfly/v2/cdnsystem/server/service"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc"
@@ -44,6 +49,9 @@ import (
 type Server struct {
 	config        *config.Config
 	seedServer    server.SeederServer
+	pieceServer   *download.Server
+	adminServer   *http.Server
+	metricsServer *http.Server
 	managerClient manager.ManagerClient
 	managerConn   *grpc.ClientConn
 }
@@ -81,6 +89,10 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, errors.Wrapf(err, "create task manager")
 	}
 	storageMgr.Initialize(taskMgr)
+	// Restore the tasks downloaded successfully before restart
+	if err := taskMgr.Restore(context.Background(), storageMgr); err != nil {
+		logger.Warnf("failed to restore tasks from storage: %v", err)
+	}
 	// GC manager
 	if err != nil {
 		return nil, errors.Wrapf(err, "create gc manager")
@@ -92,6 +104,25 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 	s.seedServer = cdnSeedServer
 
+	// Piece server
+	if cfg.EnableDownloadServer {
+		s.pieceServer = download.New(cfg, storageMgr)
+	}
+
+	// Admin server
+	if cfg.Admin.Addr != "" {
+		adminServer, err := admin.NewServer(cfg, taskMgr, storageMgr)
+		if err != nil {
+			return nil, errors.Wrap(err, "create admin server")
+		}
+		s.adminServer = adminServer
+	}
+
+	// Metrics server
+	if cfg.Metrics != "" {
+		s.metricsServer = metrics.New(cfg.Metrics)
+	}
+
 	// Manager client
 	if cfg.Manager.Addr != "" {
 		managerConn, err := grpc.Dial(
@@ -146,6 +177,34 @@ func (s *Server) Serve() (err error) {
 		)
 	}
 
+	if s.pieceServer != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.DownloadPort))
+		if err != nil {
+			return errors.Wrapf(err, "listen on download port %d for piece server, stop the file server on it or set enableDownloadServer to false", s.config.DownloadPort)
+		}
+		go func() {
+			if err := s.pieceServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("piece server on download port %d stopped: %v", s.config.DownloadPort, err)
+			}
+		}()
+	}
+
+	if s.adminServer != nil {
+		go func() {
+			if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatalf("start admin server on %s failed: %v", s.config.Admin.Addr, err)
+			}
+		}()
+	}
+
+	if s.metricsServer != nil {
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatalf("start metrics server on %s failed: %v", s.config.Metrics, err)
+			}
+		}()
+	}
+
 	err = rpc.StartTCPServer(s.config.ListenPort, s.config.ListenPort, s.seedServer)
 	if err != nil {
 		return errors.Wrap(err, "start tcp server")
@@ -154,7 +213,24 @@ func (s *Server) Serve() (err error) {
 }
 
 func (s *Server) Stop() {
-	s.managerConn.Close()
+	if s.pieceServer != nil {
+		if err := s.pieceServer.Shutdown(context.Background()); err != nil {
+			logger.Errorf("shutdown piece server failed: %v", err)
+		}
+	}
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(context.Background()); err != nil {
+			logger.Errorf("shutdown admin server failed: %v", err)
+		}
+	}
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(context.Background()); err != nil {
+			logger.Errorf("shutdown metrics server failed: %v", err)
+		}
+	}
+	if s.managerConn != nil {
+		s.managerConn.Close()
+	}
 }
 
 func (s *Server) register(ctx context.Context) error {
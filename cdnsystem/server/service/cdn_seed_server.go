@@ -31,6 +31,7 @@ import (
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/cdnsystem"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
@@ -57,10 +58,9 @@ func constructRegisterRequest(req *cdnsystem.SeedRequest) (*types.TaskRegisterRe
 	}
 	meta := req.UrlMeta
 	header := make(map[string]string)
+	var digest string
 	if meta != nil {
-		if !stringutils.IsBlank(meta.Digest) {
-			header["md5"] = meta.Digest
-		}
+		digest = digestutils.Normalize(meta.Digest)
 		if !stringutils.IsBlank(meta.Range) {
 			header["range"] = meta.Range
 		}
@@ -71,7 +71,7 @@ func constructRegisterRequest(req *cdnsystem.SeedRequest) (*types.TaskRegisterRe
 	return &types.TaskRegisterRequest{
 		Header: header,
 		URL:    req.Url,
-		Md5:    header["md5"],
+		Digest: digest,
 		TaskID: req.TaskId,
 		Filter: strings.Split(req.Filter, "&"),
 	}, nil
@@ -85,6 +85,11 @@ func checkSeedRequestParams(req *cdnsystem.SeedRequest) error {
 	if stringutils.IsBlank(req.TaskId) {
 		return errors.New("taskId is empty")
 	}
+	if req.UrlMeta != nil && !stringutils.IsBlank(req.UrlMeta.Digest) {
+		if _, _, err := digestutils.Parse(req.UrlMeta.Digest); err != nil {
+			return errors.Wrapf(err, "digest:%s is invalid", req.UrlMeta.Digest)
+		}
+	}
 	return nil
 }
 
@@ -132,6 +137,9 @@ func (css *CdnSeedServer) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRe
 		}
 
 	}
+	if task.CdnStatus == types.TaskInfoCdnStatusSourceLimited {
+		return dferrors.Newf(dfcodes.CdnSourceLimited, "task(%s) is limited by source, wait too long for connection", req.TaskId)
+	}
 	if task.CdnStatus != types.TaskInfoCdnStatusSuccess {
 		return dferrors.Newf(dfcodes.CdnTaskDownloadFail, "task(%s) status error , status: %s", req.TaskId, task.CdnStatus)
 	}
@@ -164,6 +172,9 @@ func (css *CdnSeedServer) GetPieceTasks(ctx context.Context, req *base.PieceTask
 		}
 		return nil, dferrors.Newf(dfcodes.CdnError, "failed to get task(%s) from cdn: %v", req.TaskId, err)
 	}
+	if task.CdnStatus == types.TaskInfoCdnStatusSourceLimited {
+		return nil, dferrors.Newf(dfcodes.CdnSourceLimited, "task(%s) is limited by source, wait too long for connection", task.TaskID)
+	}
 	if task.IsError() {
 		return nil, dferrors.Newf(dfcodes.CdnTaskDownloadFail, "fail to download task(%s), cdnStatus: %s", task.TaskID, task.CdnStatus)
 	}
@@ -151,7 +151,23 @@ func Test_checkSeedRequestParams(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "valid sha256 digest",
+			args: args{req: &cdnsystem.SeedRequest{TaskId: "task", Url: "http://example.com/a",
+				UrlMeta: &base.UrlMeta{Digest: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}}},
+			wantErr: false,
+		},
+		{
+			name: "unsupported digest algorithm",
+			args: args{req: &cdnsystem.SeedRequest{TaskId: "task", Url: "http://example.com/a",
+				UrlMeta: &base.UrlMeta{Digest: "sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}}},
+			wantErr: true,
+		},
+		{
+			name:    "empty task id",
+			args:    args{req: &cdnsystem.SeedRequest{Url: "http://example.com/a"}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -172,7 +188,18 @@ func Test_constructRegisterRequest(t *testing.T) {
 		want    *types.TaskRegisterRequest
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "digest is normalized and not sent as header",
+			args: args{req: &cdnsystem.SeedRequest{TaskId: "task", Url: "http://example.com/a", Filter: "a",
+				UrlMeta: &base.UrlMeta{Digest: "MD5:5D41402ABC4B2A76B9719D911017C592", Range: "0-9"}}},
+			want: &types.TaskRegisterRequest{
+				URL:    "http://example.com/a",
+				TaskID: "task",
+				Digest: "5d41402abc4b2a76b9719d911017c592",
+				Filter: []string{"a"},
+				Header: map[string]string{"range": "0-9"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -41,6 +41,8 @@ type Driver interface {
 	// Get data from the storage based on raw information.
 	// If the length<=0, the driver should return all data from the raw.offset.
 	// Otherwise, just return the data which starts from raw.offset and the length is raw.length.
+	// The returned reader may hold a read lock of the data until it is read to the end or closed,
+	// so the caller must always close it, otherwise Put and Remove of the same data are blocked.
 	Get(raw *Raw) (io.ReadCloser, error)
 
 	// Get data from the storage based on raw information.
@@ -60,6 +62,7 @@ type Driver interface {
 	PutBytes(raw *Raw, data []byte) error
 
 	// Remove the data from the storage based on raw information.
+	// The data shared with other cdn nodes is removed only when raw.Shared is set.
 	Remove(raw *Raw) error
 
 	// Stat determines whether the data exists based on raw information.
@@ -78,6 +81,7 @@ type Driver interface {
 
 	// Walk walks the file tree rooted at root which determined by raw.Bucket and raw.Key,
 	// calling walkFn for each file or directory in the tree, including root.
+	// The files only in the storage shared with other cdn nodes are walked only when raw.Shared is set.
 	Walk(raw *Raw) error
 
 	// CreateBaseDir
@@ -98,6 +102,32 @@ type Driver interface {
 
 type Config struct {
 	BaseDir string `yaml:"baseDir"`
+
+	// S3 is the config of the object storage which is compatible with s3 api,
+	// only used by s3 driver which caches the data in BaseDir.
+	S3 *S3Config `yaml:"s3"`
+}
+
+// S3Config contains the properties to access the object storage compatible with s3 api.
+type S3Config struct {
+	// Endpoint is the url of the object storage, like http://127.0.0.1:9000.
+	Endpoint string `yaml:"endpoint"`
+
+	// Region is the region of bucket.
+	// default: us-east-1
+	Region string `yaml:"region"`
+
+	// Bucket is the bucket to store data.
+	Bucket string `yaml:"bucket"`
+
+	// Prefix is the prefix of all object keys, the cdn nodes with the same bucket and prefix share the data.
+	Prefix string `yaml:"prefix"`
+
+	// AccessKey is the access key of object storage.
+	AccessKey string `yaml:"accessKey"`
+
+	// SecretKey is the secret key of object storage.
+	SecretKey string `yaml:"secretKey"`
 }
 
 // Raw identifies a piece of data uniquely.
@@ -111,6 +141,9 @@ type Raw struct {
 	TruncSize int64
 	Append    bool
 	WalkFn    filepath.WalkFunc
+	// Shared makes Remove and Walk operate on the data shared with other cdn nodes too,
+	// the drivers which cache the shared data locally, like s3, only operate on the local cache without it.
+	Shared bool
 }
 
 // StorageInfo includes partial meta information of the data.
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 
 	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
@@ -38,6 +39,9 @@ var _ storedriver.Driver = (*driver)(nil)
 const (
 	DiskDriverName   = "disk"
 	MemoryDriverName = "memory"
+	// SSDDriverName and HDDDriverName are used as the tiers of tiered storage manager
+	SSDDriverName = "ssd"
+	HDDDriverName = "hdd"
 )
 
 var fileLocker = synclock.NewLockerPool()
@@ -45,6 +49,8 @@ var fileLocker = synclock.NewLockerPool()
 func init() {
 	storedriver.Register(DiskDriverName, NewStorageDriver)
 	storedriver.Register(MemoryDriverName, NewStorageDriver)
+	storedriver.Register(SSDDriverName, NewStorageDriver)
+	storedriver.Register(HDDDriverName, NewStorageDriver)
 }
 
 // driver is one of the implementations of storage Driver using local file system.
@@ -90,36 +96,28 @@ func (ds *driver) Get(raw *storedriver.Raw) (io.ReadCloser, error) {
 		return nil, err
 	}
 
-	r, w := io.Pipe()
-	go func(w *io.PipeWriter) {
-		defer w.Close()
-
-		lock(path, raw.Offset, true)
-		defer unLock(path, raw.Offset, true)
-
-		f, err := os.Open(path)
-		if err != nil {
-			return
-		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				logger.Error("close file %s: %v", f, err)
-			}
-		}()
-
-		if _, err := f.Seek(raw.Offset, io.SeekStart); err != nil {
-			logger.Errorf("seek file %s: %v", f, err)
-		}
-		var reader io.Reader
-		reader = f
-		if raw.Length > 0 {
-			reader = io.LimitReader(f, raw.Length)
-		}
-		buf := make([]byte, 256*1024)
-		if _, err := io.CopyBuffer(w, reader, buf); err != nil {
-			logger.Errorf("copy buffer from file %s: %v", f, err)
-		}
-	}(w)
+	lock(path, raw.Offset, true)
+	f, err := os.Open(path)
+	if err != nil {
+		unLock(path, raw.Offset, true)
+		return nil, err
+	}
+	if _, err := f.Seek(raw.Offset, io.SeekStart); err != nil {
+		f.Close()
+		unLock(path, raw.Offset, true)
+		return nil, err
+	}
+	// hold the read lock until the data is consumed
+	r := &fileReader{
+		file:   f,
+		reader: f,
+		unlock: func() {
+			unLock(path, raw.Offset, true)
+		},
+	}
+	if raw.Length > 0 {
+		r.reader = io.LimitReader(f, raw.Length)
+	}
 	return r, nil
 }
 
@@ -393,3 +391,40 @@ func unLock(path string, offset int64, ro bool) {
 func LockKey(path string, offset int64) string {
 	return fmt.Sprintf("%s:%d", path, offset)
 }
+
+// fileReader reads the file directly instead of copying through a pipe, so that the data could be sent
+// with sendfile by http server, the file is closed and unlocked when reaching EOF or closed
+type fileReader struct {
+	file   *os.File
+	reader io.Reader
+	unlock func()
+	once   sync.Once
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil {
+		r.release()
+	}
+	return n, err
+}
+
+// WriteTo writes data with the io.ReaderFrom of w, like net.TCPConn which uses sendfile for *os.File
+func (r *fileReader) WriteTo(w io.Writer) (int64, error) {
+	defer r.release()
+	return io.Copy(w, r.reader)
+}
+
+func (r *fileReader) Close() error {
+	r.release()
+	return nil
+}
+
+func (r *fileReader) release() {
+	r.once.Do(func() {
+		if err := r.file.Close(); err != nil {
+			logger.Errorf("close file %s: %v", r.file.Name(), err)
+		}
+		r.unlock()
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"sync"
 	"syscall"
 	"testing"
+	"time"
 
 	"d7y.io/dragonfly/v2/cdnsystem/errors"
 	"d7y.io/dragonfly/v2/cdnsystem/plugins"
@@ -507,6 +508,38 @@ func (s *LocalDriverTestSuite) TestLocalDriverExitsAndRemove() {
 	s.False(s.Exits(raw))
 }
 
+func (s *LocalDriverTestSuite) TestLocalDriverGetAndClose() {
+	raw := &storedriver.Raw{
+		Bucket: "closeTest",
+		Key:    "test",
+	}
+	s.Nil(s.PutBytes(raw, []byte("hello world")))
+	r, err := s.Get(raw)
+	s.Nil(err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(r, buf)
+	s.Nil(err)
+
+	// the read lock is held until the reader is closed
+	removed := make(chan error)
+	go func() {
+		removed <- s.Remove(raw)
+	}()
+	select {
+	case <-removed:
+		s.Fail("remove must wait for the reader")
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.Nil(r.Close())
+	select {
+	case err := <-removed:
+		s.Nil(err)
+	case <-time.After(5 * time.Second):
+		s.Fail("remove must not be blocked after the reader is closed")
+	}
+	s.False(s.Exits(raw))
+}
+
 func (s *LocalDriverTestSuite) TestLocalDriverGetHomePath() {
 	s.Equal(filepath.Join(s.workHome, "repo"), s.GetHomePath())
 }
@@ -0,0 +1,601 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver/local"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+// Ensure driver implements the storedriver.Driver interface
+var _ storedriver.Driver = (*driver)(nil)
+
+const DriverName = "s3"
+
+// partSeparator separates the object key of data and the offset of part
+const partSeparator = "/"
+
+// partIndexTTL is the duration for which the listed parts of a file are reused without listing again,
+// the changes written by other cdn nodes are seen after it at most
+const partIndexTTL = 10 * time.Second
+
+var fetchLocker = synclock.NewLockerPool()
+
+func init() {
+	storedriver.Register(DriverName, NewStorageDriver)
+}
+
+// driver is the implementation of storage Driver using the object storage compatible with s3 api.
+//
+// Every write of a file is uploaded as a part object whose key is the file key and the offset of write,
+// so that the pieces written at different offsets concurrently do not need to be merged.
+// The files are cached in BaseDir with the local driver, the cache is fetched again from object storage
+// when the etags of parts are different from the ones the cache is fetched from, which means the parts
+// are rewritten by other cdn nodes. The caches are fetched again after cdn restarts.
+type driver struct {
+	client *client
+	prefix string
+	// cache is the local driver which caches the data in BaseDir
+	cache storedriver.Driver
+	// indexes caches the part index of files, the key is object key of file and the value is *partIndex
+	indexes sync.Map
+	// indexTTL is the duration for which the part index is reused
+	indexTTL time.Duration
+}
+
+// objectFileInfo is the file info of file in object storage which is not cached locally
+type objectFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *objectFileInfo) Name() string       { return fi.name }
+func (fi *objectFileInfo) Size() int64        { return fi.size }
+func (fi *objectFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *objectFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *objectFileInfo) IsDir() bool        { return false }
+func (fi *objectFileInfo) Sys() interface{}   { return nil }
+
+// partIndex is the parts of a file listed from object storage
+type partIndex struct {
+	sync.Mutex
+	// parts are sorted by offset
+	parts  []object
+	listed time.Time
+	// fetched is the fingerprint of parts which the local cache is consistent with
+	fetched string
+}
+
+// NewStorageDriver performs initialization for s3 Storage and return a storage Driver.
+func NewStorageDriver(cfg *storedriver.Config) (storedriver.Driver, error) {
+	if cfg.S3 == nil {
+		return nil, errors.New("s3 config is empty")
+	}
+	c, err := newClient(cfg.S3.Endpoint, cfg.S3.Region, cfg.S3.Bucket, cfg.S3.AccessKey, cfg.S3.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := local.NewStorageDriver(&storedriver.Config{BaseDir: cfg.BaseDir})
+	if err != nil {
+		return nil, err
+	}
+	return &driver{
+		client:   c,
+		prefix:   strings.Trim(cfg.S3.Prefix, "/"),
+		cache:    cache,
+		indexTTL: partIndexTTL,
+	}, nil
+}
+
+// Get fetches the file into local cache when the cache is stale, then reads from the cache.
+func (d *driver) Get(raw *storedriver.Raw) (io.ReadCloser, error) {
+	if err := d.fetch(raw); err != nil {
+		return nil, err
+	}
+	return d.cache.Get(raw)
+}
+
+// GetBytes fetches the file into local cache when the cache is stale, then reads from the cache.
+func (d *driver) GetBytes(raw *storedriver.Raw) ([]byte, error) {
+	if err := d.fetch(raw); err != nil {
+		return nil, err
+	}
+	return d.cache.GetBytes(raw)
+}
+
+// Put uploads the data as a part object and writes it into local cache.
+func (d *driver) Put(raw *storedriver.Raw, data io.Reader) error {
+	if err := storedriver.CheckPutRaw(raw); err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+	if raw.Length > 0 {
+		data = io.LimitReader(data, raw.Length)
+	}
+	buf, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	return d.PutBytes(raw, buf)
+}
+
+// PutBytes uploads the data as a part object and writes it into local cache.
+func (d *driver) PutBytes(raw *storedriver.Raw, data []byte) error {
+	if err := storedriver.CheckPutRaw(raw); err != nil {
+		return err
+	}
+	if raw.Length > 0 && raw.Length < int64(len(data)) {
+		data = data[:raw.Length]
+	}
+
+	ctx := context.Background()
+	key := d.objectKey(raw)
+	offset := raw.Offset
+	switch {
+	case raw.Trunc:
+		if err := storedriver.CheckTrunc(raw); err != nil {
+			return err
+		}
+		parts, err := d.listPartObjects(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := d.truncateParts(ctx, key, parts, raw.TruncSize); err != nil {
+			return err
+		}
+		// the parts are changed, list them again
+		d.indexes.Delete(key)
+		if _, err := d.listParts(ctx, key); err != nil {
+			return err
+		}
+	case raw.Append:
+		parts, err := d.listParts(ctx, key)
+		if err != nil {
+			return err
+		}
+		offset = partsSize(parts)
+	default:
+		// list the other parts, so the index is complete after adding the part
+		if _, err := d.listParts(ctx, key); err != nil {
+			return err
+		}
+	}
+	part := object{Key: partKey(key, offset), Size: int64(len(data)), LastModified: time.Now()}
+	etag, err := d.client.putObject(ctx, part.Key, data)
+	if err != nil {
+		return errors.Wrapf(err, "upload part %d of %s", offset, key)
+	}
+	part.ETag = etag
+
+	cached := true
+	if err := d.cache.PutBytes(raw, data); err != nil {
+		// the cache will be fetched again when reading
+		logger.Warnf("write cache of %s failed: %v", key, err)
+		cached = false
+	}
+	d.addPart(key, part, cached)
+	return nil
+}
+
+// Remove evicts the local cache of file, the part objects are deleted only when raw.Shared is set,
+// because they are shared with other cdn nodes which may still serve the file.
+func (d *driver) Remove(raw *storedriver.Raw) error {
+	key := d.objectKey(raw)
+	if !raw.Shared {
+		d.indexes.Delete(key)
+		return d.cache.Remove(raw)
+	}
+	ctx := context.Background()
+	parts, err := d.listPartObjects(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, p := range parts {
+		if err := d.client.deleteObject(ctx, p.Key); err != nil {
+			return err
+		}
+	}
+	d.indexes.Delete(key)
+	// the file may be not cached by this cdn node
+	if err := d.cache.Remove(raw); err != nil && !(cdnerrors.IsFileNotExist(err) && len(parts) > 0) {
+		return err
+	}
+	return nil
+}
+
+// Stat returns the size and modified time of file in object storage, the listed parts are reused within indexTTL.
+func (d *driver) Stat(raw *storedriver.Raw) (*storedriver.StorageInfo, error) {
+	key := d.objectKey(raw)
+	parts, err := d.listParts(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, cdnerrors.ErrFileNotExist{File: key}
+	}
+	info := &storedriver.StorageInfo{
+		Path:       path.Join(raw.Bucket, raw.Key),
+		Size:       partsSize(parts),
+		CreateTime: parts[0].LastModified,
+		ModTime:    parts[0].LastModified,
+	}
+	for _, p := range parts {
+		if p.LastModified.Before(info.CreateTime) {
+			info.CreateTime = p.LastModified
+		}
+		if p.LastModified.After(info.ModTime) {
+			info.ModTime = p.LastModified
+		}
+	}
+	return info, nil
+}
+
+// Exits determines whether the file exists in object storage.
+func (d *driver) Exits(raw *storedriver.Raw) bool {
+	_, err := d.Stat(raw)
+	return err == nil
+}
+
+// Walk walks the local cache, the files which are only in object storage are walked too when raw.Shared is set,
+// they are passed to raw.WalkFn with the path of local cache.
+func (d *driver) Walk(raw *storedriver.Raw) error {
+	if !raw.Shared {
+		return d.cache.Walk(raw)
+	}
+	walked := make(map[string]bool)
+	cacheRaw := *raw
+	cacheRaw.WalkFn = func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			walked[path] = true
+		}
+		return raw.WalkFn(path, info, err)
+	}
+	if err := d.cache.Walk(&cacheRaw); err != nil && !cdnerrors.IsFileNotExist(err) {
+		return err
+	}
+	return d.walkObjects(raw, walked)
+}
+
+// walkObjects walks the files in object storage which are not walked in local cache
+func (d *driver) walkObjects(raw *storedriver.Raw, walked map[string]bool) error {
+	prefix := d.objectKey(raw)
+	if prefix != "" {
+		prefix += partSeparator
+	}
+	objects, err := d.client.listObjects(context.Background(), prefix, "")
+	if err != nil {
+		return err
+	}
+	files := make(map[string][]object)
+	for _, o := range objects {
+		key := path.Dir(o.Key)
+		if _, err := partOffset(key, o.Key); err == nil {
+			files[key] = append(files[key], o)
+		}
+	}
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cachePath := d.cache.GetPath(d.cacheRaw(key))
+		if walked[cachePath] {
+			continue
+		}
+		info := &objectFileInfo{name: path.Base(key), size: partsSize(files[key])}
+		for _, p := range files[key] {
+			if p.LastModified.After(info.modTime) {
+				info.modTime = p.LastModified
+			}
+		}
+		if err := raw.WalkFn(cachePath, info, nil); err != nil && err != filepath.SkipDir {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAvailSpace returns the available space of local cache.
+func (d *driver) GetAvailSpace() (unit.Bytes, error) {
+	return d.cache.GetAvailSpace()
+}
+
+// GetTotalAndFreeSpace returns the total and free space of local cache.
+func (d *driver) GetTotalAndFreeSpace() (unit.Bytes, unit.Bytes, error) {
+	return d.cache.GetTotalAndFreeSpace()
+}
+
+// GetTotalSpace returns the total space of local cache.
+func (d *driver) GetTotalSpace() (unit.Bytes, error) {
+	return d.cache.GetTotalSpace()
+}
+
+// CreateBaseDir creates the local cache directory and checks the bucket is accessible.
+func (d *driver) CreateBaseDir() error {
+	if err := d.cache.CreateBaseDir(); err != nil {
+		return err
+	}
+	return d.client.headBucket(context.Background())
+}
+
+// GetPath returns the path of local cache.
+func (d *driver) GetPath(raw *storedriver.Raw) string {
+	return d.cache.GetPath(raw)
+}
+
+// MoveFile moves the file in local cache.
+func (d *driver) MoveFile(src string, dst string) error {
+	return d.cache.MoveFile(src, dst)
+}
+
+// GetHomePath returns the directory of local cache.
+func (d *driver) GetHomePath() string {
+	return d.cache.GetHomePath()
+}
+
+// fetch downloads the parts of file into local cache when the cache is not consistent with the parts in object storage
+func (d *driver) fetch(raw *storedriver.Raw) error {
+	ctx := context.Background()
+	key := d.objectKey(raw)
+	fetchLocker.Lock(key, false)
+	defer fetchLocker.UnLock(key, false)
+
+	parts, err := d.listParts(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return cdnerrors.ErrFileNotExist{File: key}
+	}
+	size := partsSize(parts)
+	fingerprint := partsFingerprint(parts)
+	cacheRaw := &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key}
+	if d.index(key).isFetched(fingerprint) {
+		if info, err := d.cache.Stat(cacheRaw); err == nil && info.Size == size {
+			return nil
+		}
+	}
+
+	// download to a temporary file and replace the cache, avoid readers seeing partial data
+	tmpRaw := &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key + ".fetching"}
+	if err := d.download(ctx, key, parts, tmpRaw); err != nil {
+		if err := d.cache.Remove(tmpRaw); err != nil && !cdnerrors.IsFileNotExist(err) {
+			logger.Warnf("remove temporary cache of %s failed: %v", key, err)
+		}
+		return err
+	}
+	if err := d.cache.MoveFile(d.cache.GetPath(tmpRaw), d.cache.GetPath(cacheRaw)); err != nil {
+		return err
+	}
+	d.index(key).setFetched(fingerprint)
+	return nil
+}
+
+// download writes the parts of file into the local cache file of raw
+func (d *driver) download(ctx context.Context, key string, parts []object, raw *storedriver.Raw) error {
+	if err := d.cache.PutBytes(&storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Trunc: true}, nil); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		offset, err := partOffset(key, p.Key)
+		if err != nil {
+			return err
+		}
+		body, err := d.client.getObject(ctx, p.Key)
+		if err != nil {
+			return errors.Wrapf(err, "download part %d of %s", offset, key)
+		}
+		err = d.cache.Put(&storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: offset}, body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// index returns the part index of file
+func (d *driver) index(key string) *partIndex {
+	v, _ := d.indexes.LoadOrStore(key, &partIndex{})
+	return v.(*partIndex)
+}
+
+// listParts returns the part objects of file sorted by offset, the listed parts are reused within indexTTL
+func (d *driver) listParts(ctx context.Context, key string) ([]object, error) {
+	idx := d.index(key)
+	idx.Lock()
+	defer idx.Unlock()
+	if idx.listed.IsZero() || time.Since(idx.listed) >= d.indexTTL {
+		parts, err := d.listPartObjects(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		idx.parts, idx.listed = parts, time.Now()
+	}
+	return append([]object(nil), idx.parts...), nil
+}
+
+// addPart adds the part written by current cdn node into the index of file,
+// cached indicates the part is written into local cache too
+func (d *driver) addPart(key string, part object, cached bool) {
+	idx := d.index(key)
+	idx.Lock()
+	defer idx.Unlock()
+	if idx.listed.IsZero() {
+		// the other parts are unknown, list them in next reading
+		return
+	}
+	consistent := idx.fetched == partsFingerprint(idx.parts)
+	replaced := false
+	for i := range idx.parts {
+		if idx.parts[i].Key == part.Key {
+			idx.parts[i], replaced = part, true
+			break
+		}
+	}
+	if !replaced {
+		idx.parts = append(idx.parts, part)
+		sort.Slice(idx.parts, func(i, j int) bool {
+			return idx.parts[i].Key < idx.parts[j].Key
+		})
+	}
+	if consistent && cached {
+		idx.fetched = partsFingerprint(idx.parts)
+	}
+}
+
+func (idx *partIndex) isFetched(fingerprint string) bool {
+	idx.Lock()
+	defer idx.Unlock()
+	return idx.fetched == fingerprint
+}
+
+func (idx *partIndex) setFetched(fingerprint string) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.fetched = fingerprint
+}
+
+// listPartObjects lists the part objects of file from object storage and sorts them by offset
+func (d *driver) listPartObjects(ctx context.Context, key string) ([]object, error) {
+	objects, err := d.client.listObjects(ctx, key+partSeparator, partSeparator)
+	if err != nil {
+		return nil, err
+	}
+	parts := objects[:0]
+	for _, o := range objects {
+		if _, err := partOffset(key, o.Key); err == nil {
+			parts = append(parts, o)
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Key < parts[j].Key
+	})
+	return parts, nil
+}
+
+// truncateParts deletes the parts beyond size, the part which across size is rewritten with the data before size
+func (d *driver) truncateParts(ctx context.Context, key string, parts []object, size int64) error {
+	for _, p := range parts {
+		offset, err := partOffset(key, p.Key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case offset >= size:
+			if err := d.client.deleteObject(ctx, p.Key); err != nil {
+				return err
+			}
+		case offset+p.Size > size:
+			body, err := d.client.getObject(ctx, p.Key)
+			if err != nil {
+				return err
+			}
+			data, err := ioutil.ReadAll(io.LimitReader(body, size-offset))
+			body.Close()
+			if err != nil {
+				return err
+			}
+			if _, err := d.client.putObject(ctx, p.Key, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cacheRaw returns the raw of local cache for the key of file in object storage
+func (d *driver) cacheRaw(key string) *storedriver.Raw {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, d.prefix), "/")
+	bucket := key
+	if i := strings.Index(key, "/"); i >= 0 {
+		bucket, key = key[:i], key[i+1:]
+	} else {
+		key = ""
+	}
+	return &storedriver.Raw{Bucket: bucket, Key: key}
+}
+
+// objectKey returns the key of file in object storage
+func (d *driver) objectKey(raw *storedriver.Raw) string {
+	return strings.Trim(path.Join(d.prefix, raw.Bucket, raw.Key), "/")
+}
+
+// partKey returns the key of part object, the offset is padded so that the parts are sorted by offset
+func partKey(key string, offset int64) string {
+	return fmt.Sprintf("%s%s%020d", key, partSeparator, offset)
+}
+
+// partOffset parses the offset of part from the key of part object
+func partOffset(key, part string) (int64, error) {
+	if !strings.HasPrefix(part, key+partSeparator) {
+		return 0, errors.Errorf("%s is not part of %s", part, key)
+	}
+	return strconv.ParseInt(strings.TrimPrefix(part, key+partSeparator), 10, 64)
+}
+
+// partsSize returns the size of file which consists of parts
+func partsSize(parts []object) int64 {
+	var size int64
+	for _, p := range parts {
+		offset, _ := strconv.ParseInt(path.Base(p.Key), 10, 64)
+		if offset+p.Size > size {
+			size = offset + p.Size
+		}
+	}
+	return size
+}
+
+// partsFingerprint returns the fingerprint of parts which changes when any part is rewritten,
+// the etag is used when it is known, otherwise the size and modified time are used
+func partsFingerprint(parts []object) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		if p.ETag != "" {
+			fmt.Fprintf(&b, "%s:%s\n", p.Key, p.ETag)
+		} else {
+			fmt.Fprintf(&b, "%s:%d:%d\n", p.Key, p.Size, p.LastModified.UnixNano())
+		}
+	}
+	return sha256Hex([]byte(b.String()))
+}
@@ -0,0 +1,257 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cdnerrors "d7y.io/dragonfly/v2/cdnsystem/errors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+)
+
+// fakeS3 is an in-memory object storage which serves the subset of s3 api used by driver
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	// lists is the count of list requests
+	lists int
+	// broken is the key of object which fails to download
+	broken string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signAlgorithm+" Credential=ak/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path != f.bucket && !strings.HasPrefix(path, f.bucket+"/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(path, f.bucket), "/")
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+	case key == "" && r.Method == http.MethodGet:
+		f.lists++
+		f.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		f.objects[key] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if key == f.broken {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	result := listBucketResult{}
+	for k, v := range f.objects {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if delimiter != "" && strings.Contains(strings.TrimPrefix(k, prefix), delimiter) {
+			continue
+		}
+		result.Contents = append(result.Contents, object{Key: k, Size: int64(len(v)), ETag: etag(v), LastModified: time.Now()})
+	}
+	sort.Slice(result.Contents, func(i, j int) bool {
+		return result.Contents[i].Key < result.Contents[j].Key
+	})
+	xml.NewEncoder(w).Encode(result)
+}
+
+func etag(data []byte) string {
+	return fmt.Sprintf("\"%x\"", md5.Sum(data))
+}
+
+func newTestDriver(t *testing.T, endpoint string) storedriver.Driver {
+	dir, err := ioutil.TempDir("", "s3-driver")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	d, err := NewStorageDriver(&storedriver.Config{
+		BaseDir: dir,
+		S3: &storedriver.S3Config{
+			Endpoint:  endpoint,
+			Bucket:    "dragonfly",
+			Prefix:    "cdn",
+			AccessKey: "ak",
+			SecretKey: "sk",
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, d.CreateBaseDir())
+	return d
+}
+
+func TestDriver(t *testing.T) {
+	s3 := &fakeS3{bucket: "dragonfly", objects: map[string][]byte{}}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	node1 := newTestDriver(t, server.URL)
+	node2 := newTestDriver(t, server.URL)
+	// list every time, so the changes are seen by the other node immediately
+	node1.(*driver).indexTTL = 0
+	node2.(*driver).indexTTL = 0
+
+	data := &storedriver.Raw{Bucket: "download", Key: "abc/abcdef"}
+	meta := &storedriver.Raw{Bucket: "download", Key: "abc/abcdef.piece"}
+
+	// write pieces at different offsets
+	assert.Nil(t, node1.PutBytes(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 5}, []byte("56789")))
+	assert.Nil(t, node1.Put(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 0, Length: 5}, strings.NewReader("01234xxx")))
+	assert.Contains(t, s3.objects, "cdn/download/abc/abcdef/00000000000000000005")
+
+	info, err := node1.Stat(data)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), info.Size)
+
+	// the other node reads the data without local cache
+	for _, d := range []storedriver.Driver{node1, node2} {
+		result, err := d.GetBytes(data)
+		assert.Nil(t, err)
+		assert.Equal(t, "0123456789", string(result))
+
+		r, err := d.Get(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 3, Length: 4})
+		assert.Nil(t, err)
+		result, _ = ioutil.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "3456", string(result))
+	}
+
+	// append and truncate metadata
+	assert.Nil(t, node1.PutBytes(&storedriver.Raw{Bucket: meta.Bucket, Key: meta.Key, Append: true}, []byte("piece0\n")))
+	assert.Nil(t, node2.PutBytes(&storedriver.Raw{Bucket: meta.Bucket, Key: meta.Key, Append: true}, []byte("piece1\n")))
+	result, err := node1.GetBytes(meta)
+	assert.Nil(t, err)
+	assert.Equal(t, "piece0\npiece1\n", string(result))
+
+	assert.Nil(t, node2.PutBytes(&storedriver.Raw{Bucket: meta.Bucket, Key: meta.Key, Trunc: true}, []byte("all")))
+	result, err = node1.GetBytes(meta)
+	assert.Nil(t, err)
+	assert.Equal(t, "all", string(result))
+
+	// the files written by other nodes are walked only with shared
+	var files []string
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, info.Name())
+		}
+		return err
+	}
+	assert.Nil(t, node2.Walk(&storedriver.Raw{Bucket: "download", WalkFn: walkFn, Shared: true}))
+	assert.Equal(t, []string{"abcdef", "abcdef.piece"}, files)
+	files = nil
+	assert.Nil(t, node1.(*driver).cache.Remove(&storedriver.Raw{Bucket: meta.Bucket, Key: meta.Key}))
+	assert.Nil(t, node1.Walk(&storedriver.Raw{Bucket: "download", WalkFn: walkFn}))
+	assert.Equal(t, []string{"abcdef"}, files)
+
+	// remove without shared only evicts the local cache, the data is kept for all nodes
+	assert.Nil(t, node1.Remove(data))
+	assert.False(t, node1.(*driver).cache.Exits(data))
+	for _, d := range []storedriver.Driver{node1, node2} {
+		result, err := d.GetBytes(data)
+		assert.Nil(t, err)
+		assert.Equal(t, "0123456789", string(result))
+	}
+
+	// remove with shared from one node, the data is gone for all nodes
+	assert.Nil(t, node1.Remove(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Shared: true}))
+	_, err = node2.Stat(data)
+	assert.True(t, cdnerrors.IsFileNotExist(err))
+	_, err = node2.GetBytes(data)
+	assert.True(t, cdnerrors.IsFileNotExist(err))
+	assert.False(t, node2.Exits(data))
+	assert.True(t, node2.Exits(meta))
+}
+
+func TestDriver_Cache(t *testing.T) {
+	s3 := &fakeS3{bucket: "dragonfly", objects: map[string][]byte{}}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	node1 := newTestDriver(t, server.URL)
+	node2 := newTestDriver(t, server.URL)
+	data := &storedriver.Raw{Bucket: "download", Key: "abc/abcdef"}
+
+	// the written parts are indexed, reading does not list again within ttl
+	assert.Nil(t, node1.PutBytes(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key}, []byte("01234")))
+	assert.Nil(t, node1.PutBytes(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 5}, []byte("56789")))
+	lists := s3.lists
+	for i := 0; i < 3; i++ {
+		result, err := node1.GetBytes(data)
+		assert.Nil(t, err)
+		assert.Equal(t, "0123456789", string(result))
+		info, err := node1.Stat(data)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(10), info.Size)
+	}
+	assert.Equal(t, lists, s3.lists)
+
+	// the rewriting with same size by other node is fetched after the index expires
+	result, err := node2.GetBytes(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(result))
+	assert.Nil(t, node2.PutBytes(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 5}, []byte("abcde")))
+	node1.(*driver).indexTTL = 0
+	result, err = node1.GetBytes(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "01234abcde", string(result))
+
+	// the temporary file is removed when fetching fails
+	assert.Nil(t, node2.PutBytes(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key, Offset: 5}, []byte("fghij")))
+	s3.mu.Lock()
+	s3.broken = "cdn/download/abc/abcdef/00000000000000000005"
+	s3.mu.Unlock()
+	_, err = node1.GetBytes(data)
+	assert.NotNil(t, err)
+	assert.False(t, node1.(*driver).cache.Exits(&storedriver.Raw{Bucket: data.Bucket, Key: data.Key + ".fetching"}))
+}
@@ -113,7 +113,7 @@ func TestRegister(t *testing.T) {
 				Name:   pluginName,
 				Enable: true,
 				Config: &Config{
-					baseDir,
+					BaseDir: baseDir,
 				},
 			},
 		},
@@ -26,8 +26,8 @@ type SeedTask struct {
 	Header           map[string]string `json:"header,omitempty"`
 	CdnStatus        string            `json:"cdnStatus,omitempty"`
 	PieceTotal       int32             `json:"pieceTotal,omitempty"`
-	RequestMd5       string            `json:"requestMd5,omitempty"`
-	SourceRealMd5    string            `json:"sourceRealMd5,omitempty"`
+	RequestDigest    string            `json:"requestDigest,omitempty"`
+	SourceRealDigest string            `json:"sourceRealDigest,omitempty"`
 	PieceMd5Sign     string            `json:"pieceMd5Sign,omitempty"`
 }
 
@@ -38,7 +38,8 @@ func (task *SeedTask) IsSuccess() bool {
 
 // IsFrozen
 func (task *SeedTask) IsFrozen() bool {
-	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusWaiting || task.CdnStatus == TaskInfoCdnStatusSourceError
+	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusWaiting || task.CdnStatus == TaskInfoCdnStatusSourceError ||
+		task.CdnStatus == TaskInfoCdnStatusSourceLimited
 }
 
 // IsWait
@@ -48,21 +49,22 @@ func (task *SeedTask) IsWait() bool {
 
 // IsError
 func (task *SeedTask) IsError() bool {
-	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusSourceError
+	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusSourceError || task.CdnStatus == TaskInfoCdnStatusSourceLimited
 }
 
 func (task *SeedTask) IsDone() bool {
-	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusSuccess || task.CdnStatus == TaskInfoCdnStatusSourceError
+	return task.CdnStatus == TaskInfoCdnStatusFailed || task.CdnStatus == TaskInfoCdnStatusSuccess || task.CdnStatus == TaskInfoCdnStatusSourceError ||
+		task.CdnStatus == TaskInfoCdnStatusSourceLimited
 }
 
 func (task *SeedTask) UpdateStatus(cdnStatus string) {
 	task.CdnStatus = cdnStatus
 }
 
-func (task *SeedTask) UpdateTaskInfo(cdnStatus, realMD5, pieceMd5Sign string, sourceFileLength, cdnFileLength int64) {
+func (task *SeedTask) UpdateTaskInfo(cdnStatus, realDigest, pieceMd5Sign string, sourceFileLength, cdnFileLength int64) {
 	task.CdnStatus = cdnStatus
 	task.PieceMd5Sign = pieceMd5Sign
-	task.SourceRealMd5 = realMD5
+	task.SourceRealDigest = realDigest
 	task.SourceFileLength = sourceFileLength
 	task.CdnFileLength = cdnFileLength
 }
@@ -83,4 +85,7 @@ const (
 
 	// TaskInfoCdnStatusSourceError captures enum value "SOURCE_ERROR"
 	TaskInfoCdnStatusSourceError string = "SOURCE_ERROR"
+
+	// TaskInfoCdnStatusSourceLimited captures enum value "SOURCE_LIMITED"
+	TaskInfoCdnStatusSourceLimited string = "SOURCE_LIMITED"
 )
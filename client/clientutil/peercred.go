@@ -0,0 +1,170 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clientutil
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+// PeerCred is the credential of the process on the other side of a unix socket connection
+type PeerCred struct {
+	UID uint32
+	GID uint32
+}
+
+// peerCredAddr is the remote address of unix socket connection with the peer credential,
+// it's available as grpc peer address and http request remote address in context.
+type peerCredAddr struct {
+	net.Addr
+	cred *PeerCred
+}
+
+type peerCredConn struct {
+	net.Conn
+	addr *peerCredAddr
+}
+
+func (c *peerCredConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+type peerCredListener struct {
+	net.Listener
+}
+
+// NewPeerCredListener wraps the unix socket listener to record the peer credential of accepted connections,
+// use PeerCredFromAddr with the remote address of connection to get it.
+func NewPeerCredListener(ln net.Listener) net.Listener {
+	return &peerCredListener{Listener: ln}
+}
+
+func (l *peerCredListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return conn, nil
+	}
+	cred, err := getPeerCred(uc)
+	if err != nil {
+		// the connection is kept without credential, then the requests need credential will be rejected
+		logger.Warnf("get peer credential of unix socket connection error: %s", err)
+		return conn, nil
+	}
+	return &peerCredConn{
+		Conn: conn,
+		addr: &peerCredAddr{
+			Addr: conn.RemoteAddr(),
+			cred: cred,
+		},
+	}, nil
+}
+
+// PeerCredFromAddr returns the peer credential of the connection accepted by NewPeerCredListener
+func PeerCredFromAddr(addr net.Addr) (*PeerCred, bool) {
+	pa, ok := addr.(*peerCredAddr)
+	if !ok {
+		return nil, false
+	}
+	return pa.cred, true
+}
+
+// CheckWritable checks whether the user of cred can create or overwrite the file at path.
+// The daemon may run with a privileged user, writing a file on behalf of a user who can not write it
+// lets the user overwrite any file of the daemon user.
+func CheckWritable(cred *PeerCred, path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path %s must be a full path", path)
+	}
+	if cred.UID == 0 {
+		return nil
+	}
+	groups := userGroups(cred)
+
+	// write the existing file, or create it in the parent directory
+	target := filepath.Clean(path)
+	if _, err := os.Lstat(target); os.IsNotExist(err) {
+		target = filepath.Dir(target)
+	} else if err != nil {
+		return err
+	}
+	realPath, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return err
+	}
+	if !permitted(cred, groups, realPath, 0002) {
+		return fmt.Errorf("%s is not writable by uid %d", path, cred.UID)
+	}
+	for dir := filepath.Dir(realPath); ; dir = filepath.Dir(dir) {
+		if !permitted(cred, groups, dir, 0001) {
+			return fmt.Errorf("directory %s of %s is not accessible by uid %d", dir, path, cred.UID)
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return nil
+}
+
+// permitted checks the mode bits of path for the user, perm is the bits of others
+func permitted(cred *PeerCred, groups map[uint32]bool, path string, perm os.FileMode) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	mode := info.Mode().Perm()
+	switch {
+	case stat.Uid == cred.UID:
+		return mode&(perm<<6) != 0
+	case groups[stat.Gid]:
+		return mode&(perm<<3) != 0
+	default:
+		return mode&perm != 0
+	}
+}
+
+func userGroups(cred *PeerCred) map[uint32]bool {
+	groups := map[uint32]bool{cred.GID: true}
+	u, err := user.LookupId(strconv.Itoa(int(cred.UID)))
+	if err != nil {
+		return groups
+	}
+	ids, err := u.GroupIds()
+	if err != nil {
+		return groups
+	}
+	for _, id := range ids {
+		if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+			groups[uint32(gid)] = true
+		}
+	}
+	return groups
+}
@@ -0,0 +1,52 @@
+// +build darwin
+
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clientutil
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+func getPeerCred(conn *net.UnixConn) (*PeerCred, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		cred   *unix.Xucred
+		optErr error
+	)
+	err = raw.Control(func(fd uintptr) {
+		cred, optErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if optErr != nil {
+		return nil, optErr
+	}
+	peerCred := &PeerCred{
+		UID: cred.Uid,
+	}
+	if cred.Ngroups > 0 {
+		peerCred.GID = cred.Groups[0]
+	}
+	return peerCred, nil
+}
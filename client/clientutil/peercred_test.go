@@ -0,0 +1,89 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clientutil
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestPeerCredListener(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "peercred-test")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ln, err := net.Listen("unix", filepath.Join(dir, "test.sock"))
+	assert.Nil(err)
+	ln = NewPeerCredListener(ln)
+	defer ln.Close()
+
+	go func() {
+		conn, err := net.Dial("unix", ln.Addr().String())
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := ln.Accept()
+	assert.Nil(err)
+	defer conn.Close()
+
+	cred, ok := PeerCredFromAddr(conn.RemoteAddr())
+	assert.True(ok)
+	assert.Equal(uint32(os.Getuid()), cred.UID)
+	assert.Equal(uint32(os.Getgid()), cred.GID)
+}
+
+func TestCheckWritable(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "peercred-test")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(os.Chmod(dir, 0755))
+
+	private := filepath.Join(dir, "private")
+	public := filepath.Join(dir, "public")
+	assert.Nil(os.Mkdir(private, 0700))
+	assert.Nil(os.Mkdir(public, 0777))
+	assert.Nil(os.Chmod(public, 0777))
+
+	// a user who is neither the owner nor in the group of the directories
+	other := &PeerCred{UID: uint32(os.Getuid()) + 54321, GID: uint32(os.Getgid()) + 54321}
+	owner := &PeerCred{UID: uint32(os.Getuid()), GID: uint32(os.Getgid())}
+
+	assert.NotNil(CheckWritable(other, "relative/output"))
+	assert.NotNil(CheckWritable(other, filepath.Join(private, "output")))
+	assert.NotNil(CheckWritable(other, filepath.Join(dir, "output")))
+	assert.Nil(CheckWritable(other, filepath.Join(public, "output")))
+	assert.Nil(CheckWritable(owner, filepath.Join(private, "output")))
+	assert.Nil(CheckWritable(&PeerCred{}, filepath.Join(private, "output")))
+
+	// existing file is checked instead of its directory
+	existing := filepath.Join(public, "existing")
+	assert.Nil(ioutil.WriteFile(existing, nil, 0644))
+	assert.NotNil(CheckWritable(other, existing))
+
+	// symbolic link is resolved
+	link := filepath.Join(public, "link")
+	assert.Nil(os.Symlink(private, link))
+	assert.NotNil(CheckWritable(other, filepath.Join(link, "output")))
+}
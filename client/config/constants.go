@@ -50,8 +50,15 @@ const (
 	DefaultTotalDownloadLimit   = 100 * unit.MB
 	DefaultUploadLimit          = 100 * unit.MB
 	DefaultMinRate              = 64 * unit.KB
+	DefaultScrubRateLimit       = 10 * unit.MB
 )
 
+// RateLimitScheduleTimeFormat is the format of the start and end time of rate limit schedules
+const RateLimitScheduleTimeFormat = "15:04"
+
+// StdoutOutput is the output of dfget which stands writing the downloaded content to stdout
+const StdoutOutput = "-"
+
 /* others */
 const (
 	DefaultTimestampFormat = "2006-01-02 15:04:05"
@@ -65,6 +72,9 @@ const (
 	DefaultDaemonAliveTime = 5 * time.Minute
 	DefaultScheduleTimeout = 5 * time.Minute
 	DefaultDownloadTimeout = 5 * time.Minute
+	DefaultScrubInterval   = 24 * time.Hour
+
+	DefaultDynconfigExpireTime = 30 * time.Second
 
 	DefaultSupernodeSchema = "http"
 	DefaultSupernodeIP     = "127.0.0.1"
@@ -75,3 +85,21 @@ const (
 	SimpleLocalTaskStoreStrategy  = StoreStrategy("io.d7y.storage.v2.simple")
 	AdvanceLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.advance")
 )
+
+const (
+	// FreeSpaceDataDirPlacement places new task in the data directory with the most free space
+	FreeSpaceDataDirPlacement = DataDirPlacement("freeSpace")
+	// RoundRobinDataDirPlacement places new tasks in the data directories in turn
+	RoundRobinDataDirPlacement = DataDirPlacement("roundRobin")
+)
+
+const (
+	// LinkStoreMode hard links task data to the output in the same filesystem, otherwise copies it
+	LinkStoreMode = StoreMode("link")
+	// ReflinkStoreMode clones task data to the output with FICLONE on btrfs or xfs,
+	// the output shares data blocks with task data until either is modified,
+	// it falls back to hard link and copy when reflink is not supported
+	ReflinkStoreMode = StoreMode("reflink")
+	// CopyStoreMode always copies task data to the output
+	CopyStoreMode = StoreMode("copy")
+)
@@ -35,6 +35,7 @@ import (
 
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	"d7y.io/dragonfly/v2/pkg/basic"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 )
@@ -126,6 +127,12 @@ func (cfg *ClientOption) Validate() error {
 		return errors.Wrapf(dferrors.ErrInvalidArgument, "output: %v", err)
 	}
 
+	if cfg.Digest != "" {
+		if _, _, err := digestutils.Parse(cfg.Digest); err != nil {
+			return errors.Wrapf(dferrors.ErrInvalidArgument, "digest: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -134,6 +141,14 @@ func (cfg *ClientOption) Convert(args []string) error {
 		cfg.URL = args[0]
 	}
 
+	// compatible with the deprecated md5 and the split digest method and value
+	if cfg.Digest == "" && cfg.DigestMethod != "" && cfg.DigestValue != "" {
+		cfg.Digest = cfg.DigestMethod + ":" + cfg.DigestValue
+	}
+	if cfg.Digest == "" && cfg.Md5 != "" {
+		cfg.Digest = digestutils.AlgorithmMD5 + ":" + cfg.Md5
+	}
+
 	if cfg.Digest != "" {
 		cfg.Identifier = ""
 	}
@@ -152,6 +167,10 @@ func (cfg *ClientOption) String() string {
 
 // This function must be called after checkURL
 func (cfg *ClientOption) checkOutput() error {
+	if cfg.Output == StdoutOutput {
+		return nil
+	}
+
 	if stringutils.IsBlank(cfg.Output) {
 		url := strings.TrimRight(cfg.URL, "/")
 		idx := strings.LastIndexByte(url, '/')
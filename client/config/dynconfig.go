@@ -0,0 +1,226 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/internal/dfpath"
+	dc "d7y.io/dragonfly/v2/internal/dynconfig"
+	"d7y.io/dragonfly/v2/internal/rpc/manager"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
+)
+
+var (
+	DaemonDynconfigCachePath = filepath.Join(dfpath.WorkHome, "dynconfig/daemon")
+)
+
+var (
+	watchInterval = 10 * time.Second
+)
+
+// DynamicOption is the client config of scheduler cluster delivered by manager
+type DynamicOption struct {
+	// RateLimits override the rate limits in daemon config
+	RateLimits RateLimits `json:"rateLimits"`
+	// RateLimitSchedules replace the schedules in daemon config when they are not nil
+	RateLimitSchedules []RateLimitSchedule `json:"rateLimitSchedules"`
+	// Proxy overrides the proxy rules, whitelist, basic auth, hijack hosts, registry mirror and default filter
+	// in daemon config when they are set
+	Proxy *ProxyOption `json:"proxy"`
+}
+
+type DynconfigInterface interface {
+	// Get the dynamic config from manager.
+	Get() (*DynamicOption, error)
+
+	// Register allows an instance to register itself to listen/observe events.
+	Register(Observer)
+
+	// Deregister allows an instance to remove itself from the collection of observers/listeners.
+	Deregister(Observer)
+
+	// Notify publishes new events to listeners.
+	Notify() error
+
+	// Serve the dynconfig listening service.
+	Serve() error
+
+	// Stop the dynconfig listening service.
+	Stop()
+}
+
+type Observer interface {
+	// OnNotify allows an event to be "published" to interface implementations.
+	OnNotify(*DynamicOption)
+}
+
+type dynconfig struct {
+	*dc.Dynconfig
+	observersLock sync.RWMutex
+	observers     map[Observer]struct{}
+	done          chan bool
+}
+
+func NewDynconfig(options ...dc.Option) (DynconfigInterface, error) {
+	client, err := dc.New(dc.ManagerSourceType, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynconfig{
+		Dynconfig: client,
+		observers: map[Observer]struct{}{},
+		done:      make(chan bool),
+	}, nil
+}
+
+func (d *dynconfig) Get() (*DynamicOption, error) {
+	var cluster manager.SchedulerCluster
+	if err := d.Unmarshal(&cluster); err != nil {
+		return nil, err
+	}
+
+	config := &DynamicOption{}
+	if len(cluster.ClientConfig) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(cluster.ClientConfig, config); err != nil {
+		return nil, errors.Wrapf(err, "invalid client config of scheduler cluster %s", cluster.Name)
+	}
+	for _, schedule := range config.RateLimitSchedules {
+		if err := schedule.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid client config of scheduler cluster %s", cluster.Name)
+		}
+	}
+
+	return config, nil
+}
+
+func (d *dynconfig) Register(l Observer) {
+	d.observersLock.Lock()
+	defer d.observersLock.Unlock()
+	d.observers[l] = struct{}{}
+}
+
+func (d *dynconfig) Deregister(l Observer) {
+	d.observersLock.Lock()
+	defer d.observersLock.Unlock()
+	delete(d.observers, l)
+}
+
+func (d *dynconfig) Notify() error {
+	config, err := d.Get()
+	if err != nil {
+		return err
+	}
+
+	// notify without lock, the observer may register or deregister others
+	d.observersLock.RLock()
+	observers := make([]Observer, 0, len(d.observers))
+	for o := range d.observers {
+		observers = append(observers, o)
+	}
+	d.observersLock.RUnlock()
+	for _, o := range observers {
+		o.OnNotify(config)
+	}
+
+	return nil
+}
+
+func (d *dynconfig) Serve() error {
+	if err := d.Notify(); err != nil {
+		return err
+	}
+
+	go d.watch()
+
+	return nil
+}
+
+func (d *dynconfig) watch() {
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			d.Notify()
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *dynconfig) Stop() {
+	close(d.done)
+}
+
+type managerClient struct {
+	manager.ManagerClient
+	schedulers []dfnet.NetAddr
+}
+
+// NewManagerClient returns the manager client which gets the scheduler cluster of daemon,
+// it's the cluster of the schedulers in daemon config, or the first available one
+func NewManagerClient(client manager.ManagerClient, schedulers []dfnet.NetAddr) dc.ManagerClient {
+	return &managerClient{
+		ManagerClient: client,
+		schedulers:    schedulers,
+	}
+}
+
+func (mc *managerClient) Get() (interface{}, error) {
+	resp, err := mc.ListSchedulers(context.Background(), &manager.ListSchedulersRequest{
+		SourceType: manager.SourceType_CLIENT_SOURCE,
+		HostName:   iputils.HostName,
+		Ip:         iputils.HostIP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster *manager.SchedulerCluster
+	for _, scheduler := range resp.Schedulers {
+		if scheduler.SchedulerCluster == nil {
+			continue
+		}
+		if cluster == nil {
+			cluster = scheduler.SchedulerCluster
+		}
+		for _, addr := range mc.schedulers {
+			if addr.Addr == fmt.Sprintf("%s:%d", scheduler.Ip, scheduler.Port) ||
+				addr.Addr == fmt.Sprintf("%s:%d", scheduler.HostName, scheduler.Port) {
+				return scheduler.SchedulerCluster, nil
+			}
+		}
+	}
+	if cluster == nil {
+		return nil, errors.New("no scheduler cluster is found")
+	}
+
+	return cluster, nil
+}
@@ -17,8 +17,9 @@
 package config
 
 const (
-	HeaderDragonflyFilter = "X-Dragonfly-Filter"
-	HeaderDragonflyPeer   = "X-Dragonfly-Peer"
-	HeaderDragonflyTask   = "X-Dragonfly-Task"
-	HeaderDragonflyBiz    = "X-Dragonfly-Biz"
+	HeaderDragonflyFilter     = "X-Dragonfly-Filter"
+	HeaderDragonflyPeer       = "X-Dragonfly-Peer"
+	HeaderDragonflyTask       = "X-Dragonfly-Task"
+	HeaderDragonflyBiz        = "X-Dragonfly-Biz"
+	HeaderDragonflyPieceToken = "X-Dragonfly-Piece-Token"
 )
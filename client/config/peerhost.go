@@ -27,6 +27,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -61,6 +62,11 @@ type PeerHostOption struct {
 	Upload       UploadOption    `mapstructure:"upload" yaml:"upload"`
 	Storage      StorageOption   `mapstructure:"storage" yaml:"storage"`
 	ConfigServer string          `mapstructure:"configServer" yaml:"configServer"`
+	// Metrics is the listen address of prometheus metrics server, metrics server is disabled when it's empty
+	Metrics string `mapstructure:"metrics" yaml:"metrics"`
+
+	// RateLimitSchedules override the rate limits in the time windows of every day
+	RateLimitSchedules []RateLimitSchedule `mapstructure:"rateLimitSchedules" yaml:"rateLimitSchedules"`
 }
 
 func NewDaemonConfig() *PeerHostOption {
@@ -111,6 +117,30 @@ func (p *PeerHostOption) Validate() error {
 	if p.AliveTime.Duration > 0 && p.Scheduler.ScheduleTimeout.Duration > p.AliveTime.Duration {
 		p.Scheduler.ScheduleTimeout.Duration = p.AliveTime.Duration - time.Second
 	}
+	// the download http api writes files with the privilege of daemon, tcp can not tell the caller
+	if p.Download.DownloadHTTP.TCPListen != nil {
+		return errors.New("download http api only supports unix socket, use unixListen instead of tcpListen")
+	}
+	switch p.Storage.DataDirPlacement {
+	case "", FreeSpaceDataDirPlacement, RoundRobinDataDirPlacement:
+	default:
+		return errors.Errorf("not support data dir placement: %s", p.Storage.DataDirPlacement)
+	}
+	switch p.Storage.StoreMode {
+	case "", LinkStoreMode, ReflinkStoreMode, CopyStoreMode:
+	default:
+		return errors.Errorf("not support store mode: %s", p.Storage.StoreMode)
+	}
+	for _, dir := range p.Storage.DataDirs {
+		if stringutils.IsBlank(dir.Path) {
+			return errors.New("empty path of storage data dir")
+		}
+	}
+	for _, schedule := range p.RateLimitSchedules {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -140,9 +170,12 @@ type HostOption struct {
 type DownloadOption struct {
 	TotalRateLimit   clientutil.RateLimit `mapstructure:"totalRateLimit" yaml:"totalRateLimit"`
 	PerPeerRateLimit clientutil.RateLimit `mapstructure:"perPeerRateLimit" yaml:"perPeerRateLimit"`
-	DownloadGRPC     ListenOption         `mapstructure:"downloadGRPC" yaml:"downloadGRPC"`
-	PeerGRPC         ListenOption         `mapstructure:"peerGRPC" yaml:"peerGRPC"`
-	CalculateDigest  bool                 `mapstructure:"calculateDigest" yaml:"calculateDigest"`
+	// BackSourceRateLimit limits the total download from source, back to source shares TotalRateLimit when it's 0
+	BackSourceRateLimit clientutil.RateLimit `mapstructure:"backSourceRateLimit" yaml:"backSourceRateLimit"`
+	DownloadGRPC        ListenOption         `mapstructure:"downloadGRPC" yaml:"downloadGRPC"`
+	DownloadHTTP        ListenOption         `mapstructure:"downloadHTTP" yaml:"downloadHTTP"`
+	PeerGRPC            ListenOption         `mapstructure:"peerGRPC" yaml:"peerGRPC"`
+	CalculateDigest     bool                 `mapstructure:"calculateDigest" yaml:"calculateDigest"`
 }
 
 type ProxyOption struct {
@@ -155,6 +188,81 @@ type ProxyOption struct {
 	WhiteList      []*WhiteList    `mapstructure:"whiteList" yaml:"whiteList"`
 	Proxies        []*Proxy        `mapstructure:"proxies" yaml:"proxies"`
 	HijackHTTPS    *HijackConfig   `mapstructure:"hijackHTTPS" yaml:"hijackHTTPS"`
+
+	// ConfigFile is the file which proxy option is loaded from, proxy option is reloaded when it changes,
+	// it's the daemon config file when proxy option is in the proxy section of daemon config
+	ConfigFile string `mapstructure:"-" yaml:"-" json:"-"`
+}
+
+// LoadProxyOption loads proxy option from the proxy config file, or the proxy section of daemon config file
+func LoadProxyOption(path string) (*ProxyOption, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshal := yaml.Unmarshal
+	if filepath.Ext(path) == ".json" {
+		unmarshal = json.Unmarshal
+	}
+	var section struct {
+		Proxy *ProxyOption `json:"proxy" yaml:"proxy"`
+	}
+	if err := unmarshal(data, &section); err != nil {
+		return nil, err
+	}
+	if section.Proxy != nil {
+		if section.Proxy.ConfigFile == "" {
+			section.Proxy.ConfigFile = path
+		}
+		return section.Proxy, nil
+	}
+
+	p := &ProxyOption{}
+	if err := unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	p.ConfigFile = path
+	return p, nil
+}
+
+// Validate checks the proxy option before it is applied, it's called before the reloaded option replaces the running one
+func (p *ProxyOption) Validate() error {
+	if p.MaxConcurrency < 0 {
+		return errors.Errorf("invalid proxy max concurrency: %d", p.MaxConcurrency)
+	}
+	if p.BasicAuth != nil && stringutils.IsBlank(p.BasicAuth.Username) {
+		return errors.New("empty username of proxy basic auth")
+	}
+	if p.RegistryMirror != nil && p.RegistryMirror.Remote == nil {
+		return errors.New("empty remote url of registry mirror")
+	}
+	for _, proxy := range p.Proxies {
+		if proxy == nil || proxy.Regx == nil {
+			return errors.New("empty regx of proxy rule")
+		}
+	}
+	for _, white := range p.WhiteList {
+		if white == nil || (stringutils.IsBlank(white.Host) && white.Regx == nil) {
+			return errors.New("empty host and regx of white list")
+		}
+		for _, port := range white.Ports {
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return errors.Errorf("invalid port %q of white list", port)
+			}
+		}
+	}
+	if p.HijackHTTPS != nil {
+		if (p.HijackHTTPS.Cert == "") != (p.HijackHTTPS.Key == "") {
+			return errors.New("cert and key of hijack https must be set together")
+		}
+		for _, host := range p.HijackHTTPS.Hosts {
+			if host == nil || host.Regx == nil {
+				return errors.New("empty regx of hijack host")
+			}
+		}
+	}
+	return nil
 }
 
 func (p *ProxyOption) UnmarshalJSON(b []byte) error {
@@ -172,6 +280,7 @@ func (p *ProxyOption) UnmarshalJSON(b []byte) error {
 		if err := json.Unmarshal(file, p); err != nil {
 			return err
 		}
+		p.ConfigFile = value
 		return nil
 	case map[string]interface{}:
 		if err := p.unmarshal(json.Unmarshal, b); err != nil {
@@ -198,6 +307,7 @@ func (p *ProxyOption) UnmarshalYAML(node *yaml.Node) error {
 		if err := yaml.Unmarshal(file, p); err != nil {
 			return err
 		}
+		p.ConfigFile = path
 		return nil
 	case yaml.MappingNode:
 		var m = make(map[string]interface{})
@@ -260,6 +370,59 @@ func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out interface{}) (err
 type UploadOption struct {
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 	RateLimit    clientutil.RateLimit `mapstructure:"rateLimit" yaml:"rateLimit"`
+	// PieceTokenSecret is the secret shared with scheduler to verify piece tokens,
+	// piece requests without a valid token are rejected when it is set
+	PieceTokenSecret string `mapstructure:"pieceTokenSecret" yaml:"pieceTokenSecret"`
+}
+
+// RateLimits are the rate limits of daemon which can be changed at runtime, the zero limit stands not set
+type RateLimits struct {
+	// TotalDownload limits the total download from other peers
+	TotalDownload clientutil.RateLimit `mapstructure:"totalDownload" yaml:"totalDownload" json:"totalDownload"`
+	// PerPeer limits the download of every peer task
+	PerPeer clientutil.RateLimit `mapstructure:"perPeer" yaml:"perPeer" json:"perPeer"`
+	// BackSource limits the total download from source
+	BackSource clientutil.RateLimit `mapstructure:"backSource" yaml:"backSource" json:"backSource"`
+	// Upload limits the total upload to other peers
+	Upload clientutil.RateLimit `mapstructure:"upload" yaml:"upload" json:"upload"`
+}
+
+// RateLimitSchedule overrides the rate limits from Start to End of every day, like 09:00 to 18:00,
+// the time window crosses midnight when End is before Start
+type RateLimitSchedule struct {
+	Start      string `mapstructure:"start" yaml:"start" json:"start"`
+	End        string `mapstructure:"end" yaml:"end" json:"end"`
+	RateLimits `mapstructure:",squash" yaml:",inline"`
+}
+
+func (s *RateLimitSchedule) Validate() error {
+	for _, t := range []string{s.Start, s.End} {
+		if _, err := time.Parse(RateLimitScheduleTimeFormat, t); err != nil {
+			return errors.Errorf("invalid rate limit schedule time %q, format should be %s", t, RateLimitScheduleTimeFormat)
+		}
+	}
+	return nil
+}
+
+// Active returns whether the time is in the time window of schedule
+func (s *RateLimitSchedule) Active(now time.Time) bool {
+	start, err := time.Parse(RateLimitScheduleTimeFormat, s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(RateLimitScheduleTimeFormat, s.End)
+	if err != nil {
+		return false
+	}
+	var (
+		minute   = now.Hour()*60 + now.Minute()
+		startMin = start.Hour()*60 + start.Minute()
+		endMin   = end.Hour()*60 + end.Minute()
+	)
+	if startMin <= endMin {
+		return minute >= startMin && minute < endMin
+	}
+	return minute >= startMin || minute < endMin
 }
 
 type ListenOption struct {
@@ -381,18 +544,45 @@ type SecurityOption struct {
 type StorageOption struct {
 	// DataPath indicates directory which stores temporary files for p2p uploading
 	DataPath string `mapstructure:"dataPath" yaml:"dataPath"`
+	// DataDirs indicates the directories which store task data, usually one directory per disk,
+	// when it's empty, DataPath is the only data directory
+	DataDirs []DataDirOption `mapstructure:"dataDirs" yaml:"dataDirs"`
+	// DataDirPlacement indicates how to select the data directory for new tasks, default is freeSpace
+	DataDirPlacement DataDirPlacement `mapstructure:"dataDirPlacement" yaml:"dataDirPlacement"`
 	// TaskExpireTime indicates caching duration for which cached file keeps no accessed by any process,
 	// after this period cache file will be gc
 	TaskExpireTime clientutil.Duration `mapstructure:"taskExpireTime" yaml:"taskExpireTime"`
 	// DiskGCThreshold indicates the threshold to gc the oldest tasks
 	DiskGCThreshold unit.Bytes `mapstructure:"diskGCThreshold" yaml:"diskGCThreshold"`
+	// BizQuotas indicates the max size of task data per biz, the key is biz id or call system of request,
+	// the oldest tasks of the biz are reclaimed when its quota is exceeded, pinned tasks are not counted
+	BizQuotas map[string]unit.Bytes `mapstructure:"bizQuotas" yaml:"bizQuotas"`
 	// Multiplex indicates reusing underlying storage for same task id
 	Multiplex     bool          `mapstructure:"multiplex" yaml:"multiplex"`
 	StoreStrategy StoreStrategy `mapstructure:"strategy" yaml:"strategy"`
+	// StoreMode indicates how to store task data to the output, default is link
+	StoreMode StoreMode `mapstructure:"storeMode" yaml:"storeMode"`
+	// ScrubInterval indicates the interval to re-verify the finished tasks with the piece md5 in background,
+	// the corrupted tasks are marked invalid and kept for inspection until reclaimed by gc, 0 disables scrubbing
+	ScrubInterval clientutil.Duration `mapstructure:"scrubInterval" yaml:"scrubInterval"`
+	// ScrubRateLimit limits the bandwidth of reading task data when scrubbing
+	ScrubRateLimit clientutil.RateLimit `mapstructure:"scrubRateLimit" yaml:"scrubRateLimit"`
 }
 
 type StoreStrategy string
 
+type DataDirPlacement string
+
+type StoreMode string
+
+type DataDirOption struct {
+	// Path is the directory which stores task data
+	Path string `mapstructure:"path" yaml:"path"`
+	// Quota is the max size of task data in the directory, no new task is placed in the directory
+	// and the oldest tasks are reclaimed when it's exceeded, 0 stands no quota
+	Quota unit.Bytes `mapstructure:"quota" yaml:"quota"`
+}
+
 type FileString string
 
 func (f *FileString) UnmarshalJSON(b []byte) error {
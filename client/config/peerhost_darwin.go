@@ -68,6 +68,12 @@ var peerHostConfig = PeerHostOption{
 				Socket: "/tmp/dfdaemon.sock",
 			},
 		},
+		// download http api is disabled by default, it's enabled when unixListen is configured
+		DownloadHTTP: ListenOption{
+			Security: SecurityOption{
+				Insecure: true,
+			},
+		},
 		PeerGRPC: ListenOption{
 			Security: SecurityOption{
 				Insecure: true,
@@ -115,5 +121,11 @@ var peerHostConfig = PeerHostOption{
 		},
 		StoreStrategy: AdvanceLocalTaskStoreStrategy,
 		Multiplex:     false,
+		ScrubInterval: clientutil.Duration{
+			Duration: DefaultScrubInterval,
+		},
+		ScrubRateLimit: clientutil.RateLimit{
+			Limit: rate.Limit(DefaultScrubRateLimit),
+		},
 	},
 }
@@ -17,7 +17,10 @@
 package config
 
 import (
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -254,6 +257,9 @@ func TestPeerHostOption_Load(t *testing.T) {
 			PerPeerRateLimit: clientutil.RateLimit{
 				Limit: 20971520,
 			},
+			BackSourceRateLimit: clientutil.RateLimit{
+				Limit: 52428800,
+			},
 			DownloadGRPC: ListenOption{
 				Security: SecurityOption{
 					Insecure: true,
@@ -301,6 +307,20 @@ func TestPeerHostOption_Load(t *testing.T) {
 				},
 			},
 		},
+		RateLimitSchedules: []RateLimitSchedule{
+			{
+				Start: "09:00",
+				End:   "18:00",
+				RateLimits: RateLimits{
+					Upload: clientutil.RateLimit{
+						Limit: 10485760,
+					},
+					PerPeer: clientutil.RateLimit{
+						Limit: 5242880,
+					},
+				},
+			},
+		},
 		Storage: StorageOption{
 			DataPath: "/tmp/storage/data",
 			TaskExpireTime: clientutil.Duration{
@@ -362,3 +382,57 @@ func TestPeerHostOption_Load(t *testing.T) {
 
 	assert.EqualValues(peerHostOption, peerHostOptionYAML)
 }
+
+func TestRateLimitSchedule_Active(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	schedule := &RateLimitSchedule{Start: "09:00", End: "18:30"}
+	assert.Nil(schedule.Validate())
+	assert.False(schedule.Active(at(8, 59)))
+	assert.True(schedule.Active(at(9, 0)))
+	assert.True(schedule.Active(at(18, 29)))
+	assert.False(schedule.Active(at(18, 30)))
+
+	// cross midnight
+	schedule = &RateLimitSchedule{Start: "22:00", End: "02:00"}
+	assert.True(schedule.Active(at(23, 0)))
+	assert.True(schedule.Active(at(1, 0)))
+	assert.False(schedule.Active(at(12, 0)))
+
+	schedule = &RateLimitSchedule{Start: "9am", End: "18:00"}
+	assert.NotNil(schedule.Validate())
+}
+
+func TestLoadProxyOption(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	dir, err := ioutil.TempDir("", "proxy-option")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "daemon.yaml")
+	assert.Nil(ioutil.WriteFile(path, []byte("proxy:\n  defaultFilter: Expires&Signature\n  maxConcurrency: 10\n"), 0644))
+	opts, err := LoadProxyOption(path)
+	assert.Nil(err)
+	assert.Equal(path, opts.ConfigFile)
+	assert.Equal("Expires&Signature", opts.DefaultFilter)
+	assert.Nil(opts.Validate())
+
+	path = filepath.Join(dir, "proxy.yaml")
+	assert.Nil(ioutil.WriteFile(path, []byte("maxConcurrency: -1\nwhiteList:\n- host: example.com\n  ports: [\"http\"]\n"), 0644))
+	opts, err = LoadProxyOption(path)
+	assert.Nil(err)
+	assert.Equal(path, opts.ConfigFile)
+	assert.NotNil(opts.Validate())
+
+	opts.MaxConcurrency = 0
+	assert.NotNil(opts.Validate())
+	opts.WhiteList[0].Ports = []string{"80"}
+	assert.Nil(opts.Validate())
+
+	opts.HijackHTTPS = &HijackConfig{Cert: "cert.pem"}
+	assert.NotNil(opts.Validate())
+}
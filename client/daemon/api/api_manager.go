@@ -0,0 +1,352 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package api provides the http download api of daemon for the clients which can not use grpc easily,
+// like shell scripts, python and java.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+)
+
+const (
+	APIPathPrefix = "/api/v1"
+
+	// finished downloads are kept for querying within this duration
+	downloadRetention = 10 * time.Minute
+
+	contentTypeJSON        = "application/json"
+	contentTypeEventStream = "text/event-stream"
+)
+
+// remoteAddrKey is the context key of the remote address of http connection
+type remoteAddrKey struct{}
+
+type Manager interface {
+	clientutil.KeepAlive
+	Serve(lis net.Listener) error
+	Stop() error
+}
+
+type apiManager struct {
+	clientutil.KeepAlive
+	*http.Server
+
+	peerHost        *scheduler.PeerHost
+	peerTaskManager peer.TaskManager
+
+	downloads sync.Map
+}
+
+var _ Manager = (*apiManager)(nil)
+
+func NewAPIManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager) (Manager, error) {
+	am := &apiManager{
+		KeepAlive: clientutil.NewKeepAlive("api manager"),
+		Server: &http.Server{
+			ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+				return context.WithValue(ctx, remoteAddrKey{}, c.RemoteAddr())
+			},
+		},
+		peerHost:        peerHost,
+		peerTaskManager: peerTaskManager,
+	}
+	am.initRouter()
+	return am, nil
+}
+
+func (am *apiManager) initRouter() {
+	r := mux.NewRouter()
+	s := r.PathPrefix(APIPathPrefix).Subrouter()
+	s.HandleFunc("/tasks", am.handleCreateDownload).Methods(http.MethodPost)
+	s.HandleFunc("/tasks", am.handleListDownloads).Methods(http.MethodGet)
+	s.HandleFunc("/tasks/{id}", am.handleGetDownload).Methods(http.MethodGet)
+	s.HandleFunc("/tasks/{id}", am.handleCancelDownload).Methods(http.MethodDelete)
+	s.HandleFunc("/stream", am.handleStream).Methods(http.MethodGet, http.MethodPost)
+	am.Server.Handler = r
+}
+
+// Serve serves the api on the unix socket listener, the peer credential of connection is used to check the output
+func (am *apiManager) Serve(lis net.Listener) error {
+	return am.Server.Serve(clientutil.NewPeerCredListener(lis))
+}
+
+func (am *apiManager) Stop() error {
+	am.downloads.Range(func(key, value interface{}) bool {
+		value.(*download).cancel()
+		return true
+	})
+	return am.Server.Shutdown(context.Background())
+}
+
+// DownloadRequest is the body of creating a download
+type DownloadRequest struct {
+	// URL is the source url of the download
+	URL string `json:"url"`
+	// Output is the destination path, it must be a full path, only used for file download
+	Output string `json:"output,omitempty"`
+	// URLMeta holds digest, tag, range, filter and headers of the url
+	URLMeta *base.UrlMeta `json:"urlMeta,omitempty"`
+}
+
+// ErrorResponse is the body of a failed api request
+type ErrorResponse struct {
+	Code    base.Code `json:"code"`
+	Message string    `json:"message"`
+}
+
+// handleCreateDownload starts a file peer task in background,
+// the progress can be queried or watched with the returned id.
+func (am *apiManager) handleCreateDownload(w http.ResponseWriter, r *http.Request) {
+	am.Keep()
+	var req DownloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, dfcodes.BadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, dfcodes.BadRequest, err.Error())
+		return
+	}
+	if !filepath.IsAbs(req.Output) {
+		writeError(w, http.StatusBadRequest, dfcodes.BadRequest, "output must be a full path")
+		return
+	}
+	// the output is written by daemon, only allow the output which the caller can write
+	addr, _ := r.Context().Value(remoteAddrKey{}).(net.Addr)
+	cred, ok := clientutil.PeerCredFromAddr(addr)
+	if !ok {
+		writeError(w, http.StatusForbidden, dfcodes.BadRequest, "unknown caller, download to output is only allowed through unix socket")
+		return
+	}
+	if err := clientutil.CheckWritable(cred, req.Output); err != nil {
+		writeError(w, http.StatusForbidden, dfcodes.BadRequest, err.Error())
+		return
+	}
+
+	am.purgeExpiredDownloads()
+	d := am.startDownload(&req)
+	writeJSON(w, http.StatusAccepted, d.Progress())
+}
+
+func (am *apiManager) handleListDownloads(w http.ResponseWriter, r *http.Request) {
+	am.Keep()
+	am.purgeExpiredDownloads()
+	var progresses = []*DownloadProgress{}
+	am.downloads.Range(func(key, value interface{}) bool {
+		progresses = append(progresses, value.(*download).Progress())
+		return true
+	})
+	writeJSON(w, http.StatusOK, progresses)
+}
+
+// handleGetDownload returns the download progress as json,
+// when the client accepts text/event-stream or query "watch=true", progress is pushed as server-sent events until done.
+func (am *apiManager) handleGetDownload(w http.ResponseWriter, r *http.Request) {
+	am.Keep()
+	d, ok := am.loadDownload(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Accept") != contentTypeEventStream && r.FormValue("watch") != "true" {
+		writeJSON(w, http.StatusOK, d.Progress())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, dfcodes.UnknownError, "streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		progress, changed := d.watch()
+		data, err := json.Marshal(progress)
+		if err != nil {
+			logger.Errorf("marshal download progress error: %s", err)
+			return
+		}
+		if _, err = fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+		if progress.Done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (am *apiManager) handleCancelDownload(w http.ResponseWriter, r *http.Request) {
+	am.Keep()
+	d, ok := am.loadDownload(w, r)
+	if !ok {
+		return
+	}
+	d.cancel()
+	writeJSON(w, http.StatusOK, d.Progress())
+}
+
+// handleStream downloads with stream peer task and writes data to response body directly,
+// request with GET uses query parameters, request with POST uses DownloadRequest as body.
+func (am *apiManager) handleStream(w http.ResponseWriter, r *http.Request) {
+	am.Keep()
+	var req DownloadRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, dfcodes.BadRequest, fmt.Sprintf("invalid request body: %s", err))
+			return
+		}
+	} else {
+		req.URL = r.FormValue("url")
+		req.URLMeta = &base.UrlMeta{
+			Digest: r.FormValue("digest"),
+			Tag:    r.FormValue("tag"),
+			Range:  r.FormValue("range"),
+			Filter: r.FormValue("filter"),
+		}
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, dfcodes.BadRequest, err.Error())
+		return
+	}
+
+	peerID := clientutil.GenPeerID(am.peerHost)
+	log := logger.With("peer", peerID, "component", "apiManager")
+	log.Infof("start stream download with url: %s", req.URL)
+	body, attr, err := am.peerTaskManager.StartStreamPeerTask(r.Context(), req.peerTaskRequest(am.peerHost, peerID))
+	if err != nil {
+		log.Errorf("start stream peer task error: %s", err)
+		writeError(w, http.StatusInternalServerError, dfcodes.UnknownError, err.Error())
+		return
+	}
+	defer body.Close()
+
+	for k, v := range attr {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(http.StatusOK)
+	// when start to transfer data, we could not call http.Error with header
+	if n, err := io.Copy(w, body); err != nil {
+		log.Errorf("transfer data failed after %d bytes: %s", n, err)
+		// abort the response, otherwise the client takes the partial data as completed
+		panic(http.ErrAbortHandler)
+	}
+	log.Infof("stream download done")
+}
+
+func (am *apiManager) loadDownload(w http.ResponseWriter, r *http.Request) (*download, bool) {
+	id := mux.Vars(r)["id"]
+	d, ok := am.downloads.Load(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, dfcodes.PeerTaskNotFound, fmt.Sprintf("download %s not found", id))
+		return nil, false
+	}
+	return d.(*download), true
+}
+
+func (am *apiManager) startDownload(req *DownloadRequest) *download {
+	peerID := clientutil.GenPeerID(am.peerHost)
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &download{
+		cancel:  cancel,
+		changed: make(chan struct{}),
+		progress: DownloadProgress{
+			ID:        peerID,
+			URL:       req.URL,
+			Output:    req.Output,
+			State:     DownloadStateRunning,
+			StartTime: time.Now(),
+		},
+		SugaredLoggerOnWith: logger.With("peer", peerID, "component", "apiManager"),
+	}
+	am.downloads.Store(peerID, d)
+
+	go d.run(ctx, am.peerTaskManager, &peer.FilePeerTaskRequest{
+		PeerTaskRequest: *req.peerTaskRequest(am.peerHost, peerID),
+		Output:          req.Output,
+	})
+	return d
+}
+
+func (am *apiManager) purgeExpiredDownloads() {
+	am.downloads.Range(func(key, value interface{}) bool {
+		if value.(*download).expired(downloadRetention) {
+			am.downloads.Delete(key)
+		}
+		return true
+	})
+}
+
+func (req *DownloadRequest) validate() error {
+	if req.URL == "" {
+		return fmt.Errorf("empty url")
+	}
+	if req.URLMeta == nil {
+		req.URLMeta = &base.UrlMeta{}
+	}
+	return nil
+}
+
+func (req *DownloadRequest) peerTaskRequest(peerHost *scheduler.PeerHost, peerID string) *scheduler.PeerTaskRequest {
+	return &scheduler.PeerTaskRequest{
+		Url:      req.URL,
+		Filter:   req.URLMeta.Filter,
+		BizId:    req.URLMeta.Tag,
+		UrlMeta:  req.URLMeta,
+		PeerId:   peerID,
+		PeerHost: peerHost,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("write json response error: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, httpCode int, code base.Code, msg string) {
+	writeJSON(w, httpCode, &ErrorResponse{
+		Code:    code,
+		Message: msg,
+	})
+}
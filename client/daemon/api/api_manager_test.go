@@ -0,0 +1,221 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+)
+
+// setupAPIManager serves the api on a unix socket, requests must be sent with the returned client
+func setupAPIManager(t *testing.T, ptm peer.TaskManager) (Manager, *http.Client, string) {
+	am, err := NewAPIManager(&scheduler.PeerHost{Ip: "127.0.0.1"}, ptm)
+	testifyassert.Nil(t, err, "NewAPIManager")
+
+	dir, err := ioutil.TempDir("", "api-manager-test")
+	testifyassert.Nil(t, err, "TempDir")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "api.sock")
+	listen, err := net.Listen("unix", socket)
+	testifyassert.Nil(t, err, "Listen")
+	go am.Serve(listen)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	return am, client, "http://unix" + APIPathPrefix
+}
+
+func TestAPIManager_Stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	testData, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	ptm := mock_peer.NewMockTaskManager(ctrl)
+	ptm.EXPECT().StartStreamPeerTask(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *scheduler.PeerTaskRequest) (io.ReadCloser, map[string]string, error) {
+			assert.Equal("http://example.com/blob", req.Url)
+			assert.Equal("tag", req.BizId)
+			return ioutil.NopCloser(bytes.NewBuffer(testData)), map[string]string{
+				"Content-Length": fmt.Sprintf("%d", len(testData)),
+			}, nil
+		})
+
+	am, client, base := setupAPIManager(t, ptm)
+	defer am.Stop()
+
+	resp, err := client.Get(base + "/stream?tag=tag&url=" + url.QueryEscape("http://example.com/blob"))
+	assert.Nil(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	data, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(testData, data)
+}
+
+func TestAPIManager_Download(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	ptm := mock_peer.NewMockTaskManager(ctrl)
+	ptm.EXPECT().StartFilePeerTask(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *peer.FilePeerTaskRequest) (chan *peer.FilePeerTaskProgress, *peer.TinyData, error) {
+			assert.Equal("/tmp/output", req.Output)
+			ch := make(chan *peer.FilePeerTaskProgress)
+			go func() {
+				for i := int64(1); i <= 2; i++ {
+					ch <- &peer.FilePeerTaskProgress{
+						State:           &peer.ProgressState{Success: true, Code: dfcodes.Success, Msg: "downloading"},
+						TaskID:          "task-0",
+						PeerID:          req.PeerId,
+						ContentLength:   2,
+						CompletedLength: i,
+						PeerTaskDone:    i == 2,
+						DoneCallback:    func() {},
+					}
+				}
+			}()
+			return ch, nil, nil
+		})
+
+	am, client, base := setupAPIManager(t, ptm)
+	defer am.Stop()
+
+	body, _ := json.Marshal(&DownloadRequest{URL: "http://example.com/blob", Output: "/tmp/output"})
+	resp, err := client.Post(base+"/tasks", contentTypeJSON, bytes.NewBuffer(body))
+	assert.Nil(err)
+	assert.Equal(http.StatusAccepted, resp.StatusCode)
+	var created DownloadProgress
+	assert.Nil(json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/tasks/"+created.ID, nil)
+	req.Header.Set("Accept", contentTypeEventStream)
+	resp, err = client.Do(req)
+	assert.Nil(err)
+	defer resp.Body.Close()
+
+	var last DownloadProgress
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		assert.Nil(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &last))
+	}
+	assert.True(last.Done)
+	assert.Equal(DownloadStateSuccess, last.State)
+	assert.Equal("task-0", last.TaskID)
+	assert.Equal(int64(2), last.CompletedLength)
+}
+
+func TestAPIManager_DownloadWithoutPeerCred(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	ptm := mock_peer.NewMockTaskManager(ctrl)
+	ptm.EXPECT().StartFilePeerTask(gomock.Any(), gomock.Any()).Times(0)
+
+	am, err := NewAPIManager(&scheduler.PeerHost{Ip: "127.0.0.1"}, ptm)
+	assert.Nil(err, "NewAPIManager")
+	defer am.Stop()
+	// the caller of tcp connection is unknown, writing output for it is rejected
+	listen, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(err, "Listen")
+	go am.Serve(listen)
+
+	body, _ := json.Marshal(&DownloadRequest{URL: "http://example.com/blob", Output: "/tmp/output"})
+	resp, err := http.Post(fmt.Sprintf("http://%s%s/tasks", listen.Addr().String(), APIPathPrefix), contentTypeJSON, bytes.NewBuffer(body))
+	assert.Nil(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAPIManager_Cancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	ptm := mock_peer.NewMockTaskManager(ctrl)
+	ptm.EXPECT().StartFilePeerTask(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *peer.FilePeerTaskRequest) (chan *peer.FilePeerTaskProgress, *peer.TinyData, error) {
+			return make(chan *peer.FilePeerTaskProgress), nil, nil
+		})
+
+	am, client, base := setupAPIManager(t, ptm)
+	defer am.Stop()
+
+	req, _ := http.NewRequest(http.MethodDelete, base+"/tasks/not-exist", nil)
+	resp, err := client.Do(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	body, _ := json.Marshal(&DownloadRequest{URL: "http://example.com/blob", Output: "/tmp/output"})
+	resp, err = client.Post(base+"/tasks", contentTypeJSON, bytes.NewBuffer(body))
+	assert.Nil(err)
+	var created DownloadProgress
+	assert.Nil(json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodDelete, base+"/tasks/"+created.ID, nil)
+	resp, err = client.Do(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	var progress DownloadProgress
+	for i := 0; i < 50 && !progress.Done; i++ {
+		time.Sleep(10 * time.Millisecond)
+		resp, err = client.Get(base + "/tasks/" + created.ID)
+		assert.Nil(err)
+		assert.Nil(json.NewDecoder(resp.Body).Decode(&progress))
+		resp.Body.Close()
+	}
+	assert.Equal(DownloadStateCanceled, progress.State)
+}
@@ -0,0 +1,162 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+)
+
+type DownloadState string
+
+const (
+	DownloadStateRunning  DownloadState = "Running"
+	DownloadStateSuccess  DownloadState = "Success"
+	DownloadStateFailed   DownloadState = "Failed"
+	DownloadStateCanceled DownloadState = "Canceled"
+)
+
+// DownloadProgress is the status of a download started by api
+type DownloadProgress struct {
+	ID              string        `json:"id"`
+	TaskID          string        `json:"taskId"`
+	URL             string        `json:"url"`
+	Output          string        `json:"output"`
+	State           DownloadState `json:"state"`
+	Code            base.Code     `json:"code"`
+	Message         string        `json:"message,omitempty"`
+	ContentLength   int64         `json:"contentLength"`
+	CompletedLength int64         `json:"completedLength"`
+	Done            bool          `json:"done"`
+	StartTime       time.Time     `json:"startTime"`
+	EndTime         time.Time     `json:"endTime,omitempty"`
+}
+
+type download struct {
+	*logger.SugaredLoggerOnWith
+	cancel context.CancelFunc
+
+	lock     sync.RWMutex
+	progress DownloadProgress
+	// changed will be closed and replaced when progress updated
+	changed chan struct{}
+}
+
+func (d *download) run(ctx context.Context, ptm peer.TaskManager, req *peer.FilePeerTaskRequest) {
+	defer d.cancel()
+	d.Infof("start download with url: %s, output: %s", req.Url, req.Output)
+	peerTaskProgress, tiny, err := ptm.StartFilePeerTask(ctx, req)
+	if err != nil {
+		d.Errorf("start file peer task error: %s", err)
+		d.finish(DownloadStateFailed, dfcodes.UnknownError, err.Error())
+		return
+	}
+	if tiny != nil {
+		d.update(func(p *DownloadProgress) {
+			p.TaskID = tiny.TaskID
+			p.ContentLength = int64(len(tiny.Content))
+			p.CompletedLength = int64(len(tiny.Content))
+		})
+		d.Infof("tiny file, wrote to output")
+		d.finish(DownloadStateSuccess, dfcodes.Success, "Success")
+		return
+	}
+
+	for {
+		select {
+		case p, ok := <-peerTaskProgress:
+			if !ok {
+				d.Errorf("progress closed unexpected")
+				d.finish(DownloadStateFailed, dfcodes.UnknownError, "progress closed unexpected")
+				return
+			}
+			d.update(func(progress *DownloadProgress) {
+				progress.TaskID = p.TaskID
+				progress.ContentLength = p.ContentLength
+				progress.CompletedLength = p.CompletedLength
+			})
+			if !p.State.Success {
+				d.Errorf("task %s/%s failed: %d/%s", p.PeerID, p.TaskID, p.State.Code, p.State.Msg)
+				if p.DoneCallback != nil {
+					p.DoneCallback()
+				}
+				d.finish(DownloadStateFailed, p.State.Code, p.State.Msg)
+				return
+			}
+			// peer task sets PeerTaskDone to true only once
+			if p.PeerTaskDone {
+				p.DoneCallback()
+				d.Infof("task %s/%s done", p.PeerID, p.TaskID)
+				d.finish(DownloadStateSuccess, p.State.Code, p.State.Msg)
+				return
+			}
+		case <-ctx.Done():
+			d.Infof("context done due to %s", ctx.Err())
+			d.finish(DownloadStateCanceled, dfcodes.ClientContextCanceled, ctx.Err().Error())
+			return
+		}
+	}
+}
+
+// Progress returns a snapshot of current progress
+func (d *download) Progress() *DownloadProgress {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	p := d.progress
+	return &p
+}
+
+// watch returns a snapshot of current progress and a channel which will be closed when progress changed
+func (d *download) watch() (*DownloadProgress, <-chan struct{}) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	p := d.progress
+	return &p, d.changed
+}
+
+func (d *download) update(fn func(p *DownloadProgress)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.progress.Done {
+		return
+	}
+	fn(&d.progress)
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+func (d *download) finish(state DownloadState, code base.Code, msg string) {
+	d.update(func(p *DownloadProgress) {
+		p.State = state
+		p.Code = code
+		p.Message = msg
+		p.Done = true
+		p.EndTime = time.Now()
+	})
+}
+
+func (d *download) expired(retention time.Duration) bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.progress.Done && time.Now().Sub(d.progress.EndTime) > retention
+}
@@ -26,6 +26,11 @@ type GC interface {
 	TryGC() (bool, error)
 }
 
+// Stoppable is the GC which runs in background, it is stopped when the gc manager stops
+type Stoppable interface {
+	Stop()
+}
+
 type Manager interface {
 	Start()
 	Stop()
@@ -76,4 +81,9 @@ func (g gcManager) Start() {
 
 func (g gcManager) Stop() {
 	close(g.done)
+	for _, gc := range allGCTasks {
+		if s, ok := gc.(Stoppable); ok {
+			s.Stop()
+		}
+	}
 }
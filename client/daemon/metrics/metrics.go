@@ -0,0 +1,168 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/base/common"
+)
+
+const (
+	namespace = "dragonfly"
+	subsystem = "dfdaemon"
+)
+
+// Download sources
+const (
+	SourcePeer       = "peer"
+	SourceCDN        = "cdn"
+	SourceBackSource = "back_source"
+)
+
+// Peer task types
+const (
+	PeerTaskTypeFile   = "file"
+	PeerTaskTypeStream = "stream"
+)
+
+// Proxy modes
+const (
+	ProxyModeDragonfly = "dragonfly"
+	ProxyModeDirect    = "direct"
+
+	// ProxyRuleRegistryMirror is the rule label of the requests proxied by registry mirror
+	ProxyRuleRegistryMirror = "registryMirror"
+)
+
+var (
+	DownloadTraffic = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "download_traffic_bytes_total",
+		Help:      "Counter of the bytes downloaded from peers, cdn and source.",
+	}, []string{"source"})
+
+	PieceDownloadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "piece_download_duration_seconds",
+		Help:      "Histogram of the piece download duration.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"source", "success"})
+
+	PeerTaskCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "peer_task_total",
+		Help:      "Counter of the finished peer tasks by result code.",
+	}, []string{"type", "success", "code"})
+
+	UploadTraffic = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "upload_traffic_bytes_total",
+		Help:      "Counter of the bytes uploaded to other peers.",
+	})
+
+	ConcurrentUploads = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "concurrent_uploads",
+		Help:      "Gauge of the uploads in progress.",
+	})
+
+	ProxyRequestCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "proxy_request_total",
+		Help:      "Counter of the proxy requests by matched rule.",
+	}, []string{"rule", "mode"})
+
+	StorageUsage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "storage_usage_bytes",
+		Help:      "Gauge of the task data size in every data dir.",
+	}, []string{"data_dir"})
+
+	GCReclaimedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "gc_reclaimed_task_total",
+		Help:      "Counter of the tasks reclaimed by gc.",
+	})
+
+	GCReclaimedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "gc_reclaimed_bytes_total",
+		Help:      "Counter of the bytes reclaimed by gc.",
+	})
+
+	ScrubbedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrubbed_task_total",
+		Help:      "Counter of the tasks verified by scrubber.",
+	})
+
+	ScrubbedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrubbed_bytes_total",
+		Help:      "Counter of the bytes read by scrubber.",
+	})
+
+	ScrubCorruptedTaskCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "scrub_corrupted_task_total",
+		Help:      "Counter of the corrupted tasks found by scrubber.",
+	})
+)
+
+// PeerSource returns the download source of the piece from the peer
+func PeerSource(peerID string) string {
+	if strings.HasSuffix(peerID, common.CdnSuffix) {
+		return SourceCDN
+	}
+	return SourcePeer
+}
+
+// ObservePeerTask counts the finished peer task
+func ObservePeerTask(typ string, code base.Code) {
+	PeerTaskCount.WithLabelValues(typ, strconv.FormatBool(code == dfcodes.Success), strconv.Itoa(int(code))).Inc()
+}
+
+// New returns the http server exposes metrics on /metrics
+func New(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
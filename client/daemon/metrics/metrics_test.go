@@ -0,0 +1,47 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+)
+
+func TestPeerSource(t *testing.T) {
+	assert := testifyassert.New(t)
+	assert.Equal(SourceCDN, PeerSource("host-7a1c9f_CDN"))
+	assert.Equal(SourcePeer, PeerSource("192.168.1.1-1234-7a1c9f"))
+}
+
+func TestMetricsHandler(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	DownloadTraffic.WithLabelValues(SourceBackSource).Add(1024)
+	ObservePeerTask(PeerTaskTypeFile, dfcodes.ClientError)
+
+	w := httptest.NewRecorder()
+	New(":0").Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(w.Body)
+	assert.Nil(err)
+	assert.Contains(string(body), `dragonfly_dfdaemon_download_traffic_bytes_total{source="back_source"} 1024`)
+	assert.Contains(string(body), `dragonfly_dfdaemon_peer_task_total{code="4000",success="false",type="file"} 1`)
+}
@@ -33,6 +33,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -80,6 +81,8 @@ type peerTask struct {
 	totalPiece      int32
 	completedLength *atomic.Int64
 	usedTraffic     *atomic.Int64
+	// pieceMd5Sign is the piece md5 sign pushed by the parent, it's verified when storing the task
+	pieceMd5Sign *atomic.String
 
 	//sizeScope   base.SizeScope
 	singlePiece *scheduler.SinglePiece
@@ -119,6 +122,8 @@ type peerTask struct {
 	lock sync.Mutex
 	// limiter will be used when enable per peer task rate limit
 	limiter *rate.Limiter
+	// perPeerRateLimit returns the current limit of limiter
+	perPeerRateLimit func() rate.Limit
 }
 
 var _ Task = (*peerTask)(nil)
@@ -143,6 +148,24 @@ func (pt *peerTask) GetContentLength() int64 {
 	return pt.contentLength
 }
 
+func (pt *peerTask) GetCompletedLength() int64 {
+	return pt.completedLength.Load()
+}
+
+func (pt *peerTask) Cancel(reason string) {
+	pt.lock.Lock()
+	if pt.failedCode == failedCodeNotSet {
+		pt.failedCode = dfcodes.ClientContextCanceled
+		pt.failedReason = reason
+	}
+	pt.lock.Unlock()
+	// cancel is set when peer task starts
+	if pt.cancel != nil {
+		pt.Infof("cancel peer task: %s", reason)
+		pt.cancel()
+	}
+}
+
 func (pt *peerTask) SetContentLength(i int64) error {
 	panic("implement me")
 }
@@ -155,6 +178,10 @@ func (pt *peerTask) GetTraffic() int64 {
 	return pt.usedTraffic.Load()
 }
 
+func (pt *peerTask) GetPieceMd5Sign() string {
+	return pt.pieceMd5Sign.Load()
+}
+
 func (pt *peerTask) GetTotalPieces() int32 {
 	return pt.totalPiece
 }
@@ -288,7 +315,7 @@ func (pt *peerTask) isExitPeerPacketCode(pp *scheduler.PeerPacket) bool {
 		pt.failedReason = reasonPeerGoneFromScheduler
 		pt.failedCode = dfcodes.SchedPeerGone
 		return true
-	case dfcodes.CdnError, dfcodes.CdnTaskRegistryFail, dfcodes.CdnTaskDownloadFail:
+	case dfcodes.CdnError, dfcodes.CdnTaskRegistryFail, dfcodes.CdnTaskDownloadFail, dfcodes.CdnSourceLimited:
 		// 6xxx
 		pt.failedCode = pp.Code
 		pt.failedReason = fmt.Sprintf("receive exit peer packet with code %d", pp.Code)
@@ -316,10 +343,11 @@ func (pt *peerTask) pullSinglePiece(pti Task, cleanUnfinishedFunc func()) {
 	}
 
 	request := &DownloadPieceRequest{
-		TaskID:  pt.GetTaskID(),
-		DstPid:  pt.singlePiece.DstPid,
-		DstAddr: pt.singlePiece.DstAddr,
-		piece:   pt.singlePiece.PieceInfo,
+		TaskID:     pt.GetTaskID(),
+		DstPid:     pt.singlePiece.DstPid,
+		DstAddr:    pt.singlePiece.DstAddr,
+		PieceToken: pt.singlePiece.PieceToken,
+		piece:      pt.singlePiece.PieceInfo,
 	}
 	if pt.pieceManager.DownloadPiece(ctx, pti, request) {
 		pt.Infof("single piece download success")
@@ -355,12 +383,22 @@ func (pt *peerTask) pullPiecesFromPeers(pti Task, cleanUnfinishedFunc func()) {
 		return
 	}
 	var (
-		num             int32
+		// skip the restored pieces of resumed task
+		num             = pt.getNextPieceNum(0)
 		limit           int32
 		initialized     bool
 		pieceRequestCh  chan *DownloadPieceRequest
 		pieceBufferSize = int32(16)
+		// syncer receives the pieces pushed by main peer, polling is used when it's nil
+		syncer *pieceSyncer
+		// syncStopped stands the main peers which do not support syncing or all pieces synced
+		syncStopped = map[string]bool{}
 	)
+	defer func() {
+		if syncer != nil {
+			syncer.cancel()
+		}
+	}()
 loop:
 	for {
 		limit = pieceBufferSize
@@ -389,14 +427,68 @@ loop:
 		default:
 		}
 
-		pt.Debugf("try to get pieces, number: %d, limit: %d", num, limit)
-		piecePacket, err := pt.preparePieceTasks(
-			&base.PieceTaskRequest{
-				TaskId:   pt.taskID,
-				SrcPid:   pt.peerID,
-				StartNum: num,
-				Limit:    limit,
-			})
+		var (
+			piecePacket *base.PiecePacket
+			err         error
+		)
+		// the failed piece is retried by polling
+		if limit == pieceBufferSize {
+			if syncer != nil && syncer.peerPacket != pt.peerPacket {
+				syncer.cancel()
+				syncer = nil
+			}
+			if mainPeer := pt.peerPacket.MainPeer; syncer == nil && mainPeer != nil && !syncStopped[mainPeer.PeerId] {
+				if syncer = pt.syncPieceTasks(num); syncer == nil {
+					syncStopped[mainPeer.PeerId] = true
+				}
+			}
+		}
+		if syncer != nil && limit == pieceBufferSize {
+			select {
+			case <-pt.done:
+				continue loop
+			case <-pt.ctx.Done():
+				continue loop
+			case failed := <-pt.failedPieceCh:
+				pt.Warnf("download piece/%d failed, retry", failed)
+				num = failed
+				limit = 1
+			case <-pt.peerPacketReady:
+				// subscribe the new main peer in next loop
+				pt.Infof("new peer client ready, main peer: %s", pt.peerPacket.MainPeer)
+				num = pt.getNextPieceNum(0)
+				continue loop
+			case p, ok := <-syncer.packets:
+				if !ok {
+					mainPeer := syncer.peerPacket.MainPeer.PeerId
+					if syncer.err == io.EOF {
+						pt.Infof("all pieces synced from peer %s", mainPeer)
+					} else {
+						pt.Warnf("sync piece tasks from peer %s error: %s, fall back to get piece tasks", mainPeer, syncer.err)
+					}
+					syncStopped[mainPeer] = true
+					syncer.cancel()
+					syncer = nil
+					continue loop
+				}
+				if p.PieceMd5Sign != "" {
+					pt.Debugf("receive piece md5 sign %s from peer %s", p.PieceMd5Sign, p.DstPid)
+					pt.pieceMd5Sign.Store(p.PieceMd5Sign)
+				}
+				piecePacket = p
+			}
+		}
+
+		if piecePacket == nil {
+			pt.Debugf("try to get pieces, number: %d, limit: %d", num, limit)
+			piecePacket, err = pt.preparePieceTasks(
+				&base.PieceTaskRequest{
+					TaskId:   pt.taskID,
+					SrcPid:   pt.peerID,
+					StartNum: num,
+					Limit:    limit,
+				})
+		}
 
 		if err != nil {
 			pt.Warnf("get piece task error: %s, wait available peers from scheduler", err)
@@ -456,15 +548,23 @@ loop:
 
 		// trigger DownloadPiece
 		for _, piece := range piecePacket.PieceInfos {
+			pt.lock.Lock()
+			ready := pt.readyPieces.IsSet(piece.PieceNum)
+			pt.lock.Unlock()
+			if ready {
+				pt.Debugf("piece %d is already ready, skip it", piece.PieceNum)
+				continue
+			}
 			pt.Infof("get piece %d from %s/%s", piece.PieceNum, piecePacket.DstAddr, piecePacket.DstPid)
 			if !pt.requestedPieces.IsSet(piece.PieceNum) {
 				pt.requestedPieces.Set(piece.PieceNum)
 			}
 			req := &DownloadPieceRequest{
-				TaskID:  pt.GetTaskID(),
-				DstPid:  piecePacket.DstPid,
-				DstAddr: piecePacket.DstAddr,
-				piece:   piece,
+				TaskID:     pt.GetTaskID(),
+				DstPid:     piecePacket.DstPid,
+				DstAddr:    piecePacket.DstAddr,
+				PieceToken: pt.pieceToken(piecePacket.DstPid),
+				piece:      piece,
 			}
 			select {
 			case pieceRequestCh <- req:
@@ -512,6 +612,22 @@ loop:
 	}
 }
 
+// pieceToken returns the piece token issued by scheduler for downloading pieces from the dest peer
+func (pt *peerTask) pieceToken(dstPid string) string {
+	if pt.peerPacket == nil {
+		return ""
+	}
+	if mainPeer := pt.peerPacket.MainPeer; mainPeer != nil && mainPeer.PeerId == dstPid {
+		return mainPeer.PieceToken
+	}
+	for _, peer := range pt.peerPacket.StealPeers {
+		if peer.PeerId == dstPid {
+			return peer.PieceToken
+		}
+	}
+	return ""
+}
+
 func (pt *peerTask) downloadPieceWorker(id int32, pti Task, requests chan *DownloadPieceRequest) {
 	for {
 		select {
@@ -520,8 +636,9 @@ func (pt *peerTask) downloadPieceWorker(id int32, pti Task, requests chan *Downl
 			span.SetAttributes(config.AttributePiece.Int(int(request.piece.PieceNum)))
 			span.SetAttributes(config.AttributePieceWorker.Int(int(id)))
 			if pt.limiter != nil {
+				ratelimit.SetLimit(pt.limiter, pt.perPeerRateLimit())
 				_, waitSpan := tracer.Start(ctx, config.SpanWaitPieceLimit)
-				if err := pt.limiter.WaitN(pt.ctx, int(request.piece.RangeSize)); err != nil {
+				if err := ratelimit.WaitN(pt.ctx, pt.limiter, int(request.piece.RangeSize)); err != nil {
 					pt.Errorf("request limiter error: %s", err)
 					waitSpan.RecordError(err)
 					waitSpan.End()
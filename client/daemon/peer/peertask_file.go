@@ -28,16 +28,24 @@ import (
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/internal/rpc/scheduler/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 type FilePeerTaskRequest struct {
 	scheduler.PeerTaskRequest
 	Output string
+	// CallSystem is the system name of caller, it's the owner of task for storage quota when biz id is empty
+	CallSystem string
+	// resume is the unfinished task in storage, the stored pieces will not be downloaded again
+	resume *storage.ResumePeerTask
 }
 
 // FilePeerTask represents a peer task to download a file
@@ -78,7 +86,7 @@ func newFilePeerTask(ctx context.Context,
 	request *scheduler.PeerTaskRequest,
 	schedulerClient schedulerclient.SchedulerClient,
 	schedulerOption config.SchedulerOption,
-	perPeerRateLimit rate.Limit) (context.Context, FilePeerTask, *TinyData, error) {
+	perPeerRateLimit func() rate.Limit) (context.Context, FilePeerTask, *TinyData, error) {
 	ctx, span := tracer.Start(ctx, config.SpanFilePeerTask, trace.WithSpanKind(trace.SpanKindClient))
 	span.SetAttributes(config.AttributePeerHost.String(host.Uuid))
 	span.SetAttributes(semconv.NetHostIPKey.String(host.Ip))
@@ -154,8 +162,8 @@ func newFilePeerTask(ctx context.Context,
 	}
 
 	var limiter *rate.Limiter
-	if perPeerRateLimit > 0 {
-		limiter = rate.NewLimiter(perPeerRateLimit, int(perPeerRateLimit))
+	if perPeerRateLimit != nil {
+		limiter = ratelimit.NewLimiter(perPeerRateLimit())
 	}
 	return ctx, &filePeerTask{
 		progressCh:     make(chan *FilePeerTaskProgress),
@@ -182,8 +190,10 @@ func newFilePeerTask(ctx context.Context,
 			totalPiece:          -1,
 			schedulerOption:     schedulerOption,
 			limiter:             limiter,
+			perPeerRateLimit:    perPeerRateLimit,
 			completedLength:     atomic.NewInt64(0),
 			usedTraffic:         atomic.NewInt64(0),
+			pieceMd5Sign:        atomic.NewString(""),
 			SugaredLoggerOnWith: logger.With("peer", request.PeerId, "task", result.TaskId, "component", "filePeerTask"),
 		},
 	}, nil, nil
@@ -196,9 +206,13 @@ func (pt *filePeerTask) Start(ctx context.Context) (chan *FilePeerTaskProgress,
 		_ = pt.callback.Init(pt)
 		go func() {
 			defer pt.cleanUnfinished()
-			err := pt.pieceManager.DownloadSource(ctx, pt, pt.request)
+			err := pt.pieceManager.DownloadSource(pt.ctx, pt, pt.request)
 			if err != nil {
 				pt.Errorf("download from source error: %s", err)
+				if errors.Is(err, digestutils.ErrDigestNotMatch) {
+					pt.failedCode = dfcodes.ClientDigestNotMatch
+					pt.failedReason = err.Error()
+				}
 				return
 			}
 			pt.Infof("download from source ok")
@@ -292,8 +306,12 @@ func (pt *filePeerTask) finish() error {
 			pt.span.RecordError(err)
 			success = false
 			code = dfcodes.ClientError
+			if errors.Is(err, digestutils.ErrDigestNotMatch) {
+				code = dfcodes.ClientDigestNotMatch
+			}
 			message = err.Error()
 		}
+		metrics.ObservePeerTask(metrics.PeerTaskTypeFile, code)
 
 		pg := &FilePeerTaskProgress{
 			State: &ProgressState{
@@ -349,6 +367,7 @@ func (pt *filePeerTask) cleanUnfinished() {
 		_ = pt.peerPacketStream.Send(
 			scheduler.NewEndPieceResult(pt.taskID, pt.peerID, pt.readyPieces.Settled()))
 		pt.Debugf("clean up end piece result sent")
+		metrics.ObservePeerTask(metrics.PeerTaskTypeFile, pt.failedCode)
 
 		pg := &FilePeerTaskProgress{
 			State: &ProgressState{
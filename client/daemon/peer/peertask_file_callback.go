@@ -50,6 +50,8 @@ func (p *filePeerTaskCallback) Init(pt Task) error {
 			},
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			OriginRequest: newOriginRequest(&p.req.PeerTaskRequest, p.req.Output),
+			Biz:           quotaBiz(&p.req.PeerTaskRequest, p.req.CallSystem),
 		})
 	if err != nil {
 		pt.Log().Errorf("register task to storage manager failed: %s", err)
@@ -85,8 +87,11 @@ func (p *filePeerTaskCallback) Done(pt Task) error {
 				TaskID:      pt.GetTaskID(),
 				Destination: p.req.Output,
 			},
-			MetadataOnly: false,
+			// resumed stream task has no output
+			MetadataOnly: p.req.Output == "",
 			TotalPieces:  pt.GetTotalPieces(),
+			Digest:       urlDigest(&p.req.PeerTaskRequest),
+			PieceMd5Sign: pt.GetPieceMd5Sign(),
 		})
 	if e != nil {
 		return e
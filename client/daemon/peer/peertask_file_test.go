@@ -115,7 +115,7 @@ func TestFilePeerTask_BackSource_WithContentLength(t *testing.T) {
 		&req.PeerTaskRequest,
 		ptm.schedulerClient,
 		ptm.schedulerOption,
-		0)
+		nil)
 	assert.Nil(err, "new file peer task")
 	pt.(*filePeerTask).backSource = true
 
@@ -225,7 +225,7 @@ func TestFilePeerTask_BackSource_WithoutContentLength(t *testing.T) {
 		&req.PeerTaskRequest,
 		ptm.schedulerClient,
 		ptm.schedulerOption,
-		0)
+		nil)
 	assert.Nil(err, "new file peer task")
 	pt.(*filePeerTask).backSource = true
 
@@ -0,0 +1,235 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/idgen"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+// ImportTaskRequest imports a local file into storage as a completed peer task
+type ImportTaskRequest struct {
+	scheduler.PeerTaskRequest
+	// Path is the full path of the local file
+	Path string
+	// TaskID is used to import the file with the task id directly,
+	// the task will not be announced to scheduler, because url is unknown
+	TaskID string
+}
+
+type ImportTaskResult struct {
+	storage.PeerTaskMetaData
+	ContentLength int64
+	TotalPieces   int32
+}
+
+func (ptm *peerTaskManager) ImportTask(ctx context.Context, req *ImportTaskRequest) (*ImportTaskResult, error) {
+	taskID := req.TaskID
+	if taskID == "" {
+		taskID = idgen.TaskID(req.Url, req.Filter, req.UrlMeta, req.BizId)
+	}
+	log := logger.With("peer", req.PeerId, "task", taskID, "component", "importTask")
+
+	if reuse := ptm.storageManager.FindCompletedTask(taskID); reuse != nil {
+		log.Infof("task already exists in peer %s, skip importing", reuse.PeerID)
+		return &ImportTaskResult{
+			PeerTaskMetaData: reuse.PeerTaskMetaData,
+			ContentLength:    reuse.ContentLength,
+			TotalPieces:      reuse.TotalPieces,
+		}, nil
+	}
+
+	file, err := os.Open(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, errors.Errorf("%s is not a regular file", req.Path)
+	}
+
+	var (
+		contentLength = stat.Size()
+		pieceSize     = computePieceSize(contentLength)
+		totalPieces   = int32(math.Ceil(float64(contentLength) / float64(pieceSize)))
+		meta          = storage.PeerTaskMetaData{
+			PeerID: req.PeerId,
+			TaskID: taskID,
+		}
+	)
+	log.Infof("start to import %s, content length: %d, piece size: %d", req.Path, contentLength, pieceSize)
+	err = ptm.storageManager.RegisterTask(ctx,
+		storage.RegisterTaskRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: req.PeerId,
+				TaskID: taskID,
+			},
+			ContentLength: contentLength,
+			TotalPieces:   totalPieces,
+			Biz:           quotaBiz(&req.PeerTaskRequest, ""),
+		})
+	if err != nil {
+		log.Errorf("register task to storage manager failed: %s", err)
+		return nil, err
+	}
+
+	if err = ptm.importPieces(ctx, meta, file, contentLength, pieceSize, totalPieces); err != nil {
+		log.Errorf("import pieces failed: %s", err)
+		if e := ptm.storageManager.DeleteTask(ctx, meta); e != nil {
+			log.Warnf("clean imported task failed: %s", e)
+		}
+		return nil, err
+	}
+	log.Infof("import %d pieces ok", totalPieces)
+
+	if req.TaskID != "" {
+		log.Infof("import with task id, skip announcing task to scheduler")
+	} else {
+		if err = ptm.announceTask(ctx, req, taskID, contentLength, totalPieces); err != nil {
+			// local data is ok, keep it for other peers which get it from scheduler later
+			log.Errorf("announce task to scheduler failed: %s", err)
+			return nil, err
+		}
+		log.Infof("announce task to scheduler ok")
+	}
+	return &ImportTaskResult{
+		PeerTaskMetaData: meta,
+		ContentLength:    contentLength,
+		TotalPieces:      totalPieces,
+	}, nil
+}
+
+func (ptm *peerTaskManager) importPieces(ctx context.Context, meta storage.PeerTaskMetaData,
+	reader io.Reader, contentLength int64, pieceSize int32, totalPieces int32) error {
+	for pieceNum := int32(0); pieceNum < totalPieces; pieceNum++ {
+		size := pieceSize
+		offset := uint64(pieceNum) * uint64(pieceSize)
+		// calculate piece size for last piece
+		if int64(offset)+int64(size) > contentLength {
+			size = int32(contentLength - int64(offset))
+		}
+		n, err := ptm.storageManager.WritePiece(ctx,
+			&storage.WritePieceRequest{
+				PeerTaskMetaData: meta,
+				PieceMetaData: storage.PieceMetaData{
+					Num: pieceNum,
+					// storage manager will get digest from DigestReader, keep empty here is ok
+					Md5:    "",
+					Offset: offset,
+					Range: clientutil.Range{
+						Start:  int64(offset),
+						Length: int64(size),
+					},
+				},
+				Reader: digestutils.NewDigestReader(io.LimitReader(reader, int64(size))),
+			})
+		if err != nil {
+			return err
+		}
+		if n != int64(size) {
+			return storage.ErrShortRead
+		}
+	}
+	return ptm.storageManager.Store(ctx,
+		&storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: meta.PeerID,
+				TaskID: meta.TaskID,
+			},
+			MetadataOnly: true,
+			TotalPieces:  totalPieces,
+		})
+}
+
+// announceTask registers the imported task to scheduler and reports all pieces are ready,
+// then scheduler will select current peer as parent for other peers.
+func (ptm *peerTaskManager) announceTask(ctx context.Context, req *ImportTaskRequest, taskID string, contentLength int64, totalPieces int32) error {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// register as seed, scheduler takes current peer as the source without triggering cdn
+	req.IsSeed = true
+	result, err := ptm.schedulerClient.RegisterPeerTask(ctx, &req.PeerTaskRequest)
+	if err != nil {
+		// back source is expected when the scheduler does not support seed peers and there is no cdn
+		if de, ok := err.(*dferrors.DfError); !ok || de.Code != dfcodes.SchedNeedBackSource {
+			return err
+		}
+	}
+	if result != nil && result.TaskId != taskID {
+		return errors.Errorf("task id mismatch, scheduler: %s, local: %s", result.TaskId, taskID)
+	}
+
+	peerPacketStream, err := ptm.schedulerClient.ReportPieceResult(ctx, taskID, &req.PeerTaskRequest)
+	if err != nil {
+		return err
+	}
+	for pieceNum := int32(0); pieceNum < totalPieces; pieceNum++ {
+		end := time.Now().UnixNano()
+		err = peerPacketStream.Send(&scheduler.PieceResult{
+			TaskId:        taskID,
+			SrcPid:        req.PeerId,
+			DstPid:        req.PeerId,
+			PieceNum:      pieceNum,
+			BeginTime:     uint64(start.UnixNano()),
+			EndTime:       uint64(end),
+			Success:       true,
+			Code:          dfcodes.Success,
+			FinishedCount: pieceNum + 1,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// send EOF piece result to scheduler
+	if err = peerPacketStream.Send(scheduler.NewEndPieceResult(taskID, req.PeerId, totalPieces)); err != nil {
+		return err
+	}
+
+	return ptm.schedulerClient.ReportPeerResult(ctx, &scheduler.PeerResult{
+		TaskId:         taskID,
+		PeerId:         req.PeerId,
+		SrcIp:          ptm.host.Ip,
+		SecurityDomain: ptm.host.SecurityDomain,
+		Idc:            ptm.host.Idc,
+		Url:            req.Url,
+		ContentLength:  contentLength,
+		Traffic:        0,
+		Cost:           uint32(time.Now().Sub(start).Milliseconds()),
+		Success:        true,
+		Code:           dfcodes.Success,
+	})
+}
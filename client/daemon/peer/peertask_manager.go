@@ -23,6 +23,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"sync"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/internal/rpc/scheduler/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 // TaskManager processes all peer tasks request
@@ -53,6 +55,18 @@ type TaskManager interface {
 
 	IsPeerTaskRunning(pid string) bool
 
+	// ListRunningPeerTasks returns all running peer tasks
+	ListRunningPeerTasks() []Task
+
+	// CancelPeerTask cancels a running peer task, return false when the peer task is not running
+	CancelPeerTask(pid string, reason string) bool
+
+	// ImportTask imports a local file as a completed peer task and announces it to scheduler
+	ImportTask(ctx context.Context, req *ImportTaskRequest) (*ImportTaskResult, error)
+
+	// ResumePeerTasks resumes the unfinished peer tasks in storage after restart
+	ResumePeerTasks(ctx context.Context)
+
 	// Stop stops the PeerTaskManager
 	Stop(ctx context.Context) error
 }
@@ -66,11 +80,16 @@ type Task interface {
 	GetTaskID() string
 	GetTotalPieces() int32
 	GetContentLength() int64
+	GetCompletedLength() int64
 	// SetContentLength will called after download completed, when download from source without content length
 	SetContentLength(int64) error
 	SetCallback(TaskCallback)
 	AddTraffic(int64)
 	GetTraffic() int64
+	// GetPieceMd5Sign returns the piece md5 sign pushed by the parent, empty when not received
+	GetPieceMd5Sign() string
+	// Cancel stops the running peer task with the reason
+	Cancel(reason string)
 }
 
 // TaskCallback inserts some operations for peer task download lifecycle
@@ -105,7 +124,8 @@ type peerTaskManager struct {
 
 	runningPeerTasks sync.Map
 
-	perPeerRateLimit rate.Limit
+	// perPeerRateLimit returns the download limit of every peer task, it can be changed at runtime
+	perPeerRateLimit func() rate.Limit
 
 	// enableMultiplex indicates reusing completed peer task storage
 	// currently, only check completed peer task after register to scheduler
@@ -119,7 +139,7 @@ func NewPeerTaskManager(
 	storageManager storage.Manager,
 	schedulerClient schedulerclient.SchedulerClient,
 	schedulerOption config.SchedulerOption,
-	perPeerRateLimit rate.Limit,
+	perPeerRateLimit func() rate.Limit,
 	multiplex bool) (TaskManager, error) {
 
 	ptm := &peerTaskManager{
@@ -138,7 +158,8 @@ func NewPeerTaskManager(
 var _ TaskManager = (*peerTaskManager)(nil)
 
 func (ptm *peerTaskManager) StartFilePeerTask(ctx context.Context, req *FilePeerTaskRequest) (chan *FilePeerTaskProgress, *TinyData, error) {
-	if ptm.enableMultiplex {
+	// the resumed task continues with the stored pieces, do not reuse others
+	if ptm.enableMultiplex && req.resume == nil {
 		progress, ok := ptm.tryReuseFilePeerTask(ctx, req)
 		if ok {
 			return progress, nil, nil
@@ -155,28 +176,25 @@ func (ptm *peerTaskManager) StartFilePeerTask(ctx context.Context, req *FilePeer
 	if tiny != nil {
 		defer tiny.span.End()
 		log := logger.With("peer", tiny.PeerID, "task", tiny.TaskID, "component", "peerTaskManager")
-		_, err = os.Stat(req.Output)
-		if err == nil {
-			// remove exist file
-			log.Infof("destination file %q exists, purge it first", req.Output)
-			tiny.span.AddEvent("purge exist output")
-			os.Remove(req.Output)
+		if digest := urlDigest(&req.PeerTaskRequest); digest != "" {
+			if err = digestutils.Verify(bytes.NewReader(tiny.Content), digest); err != nil {
+				tiny.span.RecordError(err)
+				tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
+				log.Errorf("verify tiny task data with digest %s error: %s", digest, err)
+				return nil, nil, err
+			}
 		}
-		dstFile, err := os.OpenFile(req.Output, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
-		if err != nil {
-			tiny.span.RecordError(err)
-			tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
-			log.Errorf("open task destination file error: %s", err)
-			return nil, nil, err
+		// resumed stream task has no output
+		if req.Output != "" {
+			n, err := writeTinyOutput(req.Output, tiny.Content)
+			if err != nil {
+				tiny.span.RecordError(err)
+				tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
+				log.Errorf("write task destination file error: %s", err)
+				return nil, nil, err
+			}
+			log.Debugf("copied tasks data %d bytes to %s", n, req.Output)
 		}
-		defer dstFile.Close()
-		n, err := dstFile.Write(tiny.Content)
-		if err != nil {
-			tiny.span.RecordError(err)
-			tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
-			return nil, nil, err
-		}
-		log.Debugf("copied tasks data %d bytes to %s", n, req.Output)
 		tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(true))
 		return nil, tiny, nil
 	}
@@ -187,6 +205,10 @@ func (ptm *peerTaskManager) StartFilePeerTask(ctx context.Context, req *FilePeer
 		start: start,
 	})
 
+	if req.resume != nil {
+		pt.(*filePeerTask).restorePieces(req.resume.Pieces)
+	}
+
 	ptm.runningPeerTasks.Store(req.PeerId, pt)
 
 	// FIXME when failed due to schedulerClient error, relocate schedulerClient and retry
@@ -198,7 +220,7 @@ func (ptm *peerTaskManager) StartStreamPeerTask(ctx context.Context, req *schedu
 	if ptm.enableMultiplex {
 		r, attr, ok := ptm.tryReuseStreamPeerTask(ctx, req)
 		if ok {
-			return r, attr, nil
+			return verifyStream(r, urlDigest(req)), attr, nil
 		}
 	}
 
@@ -210,6 +232,14 @@ func (ptm *peerTaskManager) StartStreamPeerTask(ctx context.Context, req *schedu
 	}
 	// tiny file content is returned by scheduler, just write to output
 	if tiny != nil {
+		if digest := urlDigest(req); digest != "" {
+			if err = digestutils.Verify(bytes.NewReader(tiny.Content), digest); err != nil {
+				tiny.span.RecordError(err)
+				tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
+				logger.Errorf("verify tiny task data with digest %s error: %s", digest, err)
+				return nil, nil, err
+			}
+		}
 		logger.Infof("copied tasks data %d bytes to buffer", len(tiny.Content))
 		tiny.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(true))
 		return ioutil.NopCloser(bytes.NewBuffer(tiny.Content)), map[string]string{
@@ -228,7 +258,21 @@ func (ptm *peerTaskManager) StartStreamPeerTask(ctx context.Context, req *schedu
 
 	// FIXME when failed due to schedulerClient error, relocate schedulerClient and retry
 	reader, attribute, err := pt.Start(ctx)
-	return ioutil.NopCloser(reader), attribute, err
+	return verifyStream(ioutil.NopCloser(reader), urlDigest(req)), attribute, err
+}
+
+// verifyStream verifies the whole content of stream with digest, the last data is held back until it's verified
+func verifyStream(rc io.ReadCloser, digest string) io.ReadCloser {
+	if rc == nil || digest == "" {
+		return rc
+	}
+	return &struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: digestutils.NewVerifiedReader(rc, digest),
+		Closer: rc,
+	}
 }
 
 func (ptm *peerTaskManager) Stop(ctx context.Context) error {
@@ -244,3 +288,58 @@ func (ptm *peerTaskManager) IsPeerTaskRunning(peerID string) bool {
 	_, ok := ptm.runningPeerTasks.Load(peerID)
 	return ok
 }
+
+func (ptm *peerTaskManager) ListRunningPeerTasks() []Task {
+	var tasks []Task
+	ptm.runningPeerTasks.Range(func(key, value interface{}) bool {
+		tasks = append(tasks, value.(Task))
+		return true
+	})
+	return tasks
+}
+
+func (ptm *peerTaskManager) CancelPeerTask(peerID string, reason string) bool {
+	pt, ok := ptm.runningPeerTasks.Load(peerID)
+	if !ok {
+		return false
+	}
+	pt.(Task).Cancel(reason)
+	return true
+}
+
+// urlDigest returns the digest of the whole content in url meta, it's empty when not specified
+func urlDigest(req *scheduler.PeerTaskRequest) string {
+	if req.UrlMeta == nil {
+		return ""
+	}
+	return req.UrlMeta.Digest
+}
+
+// quotaBiz returns the owner of task for storage quota, call system is used when biz id is empty
+func quotaBiz(req *scheduler.PeerTaskRequest, callSystem string) string {
+	if req.BizId != "" {
+		return req.BizId
+	}
+	return callSystem
+}
+
+// writeTinyOutput writes content to a temporary file in the same directory, then renames it to output
+func writeTinyOutput(output string, content []byte) (int, error) {
+	dir, name := path.Split(output)
+	tmpFile, err := ioutil.TempFile(dir, "."+name+".dfget-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpFile.Name())
+	n, err := tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Chmod(0644)
+	}
+	if e := tmpFile.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmpFile.Name(), output)
+}
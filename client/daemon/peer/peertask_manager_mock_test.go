@@ -221,6 +221,40 @@ func (mr *MockPeerTaskMockRecorder) GetTraffic() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTraffic", reflect.TypeOf((*MockPeerTask)(nil).GetTraffic))
 }
 
+// GetPieceMd5Sign mocks base method
+func (m *MockPeerTask) GetPieceMd5Sign() string {
+	ret := m.ctrl.Call(m, "GetPieceMd5Sign")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetPieceMd5Sign indicates an expected call of GetPieceMd5Sign
+func (mr *MockPeerTaskMockRecorder) GetPieceMd5Sign() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceMd5Sign", reflect.TypeOf((*MockPeerTask)(nil).GetPieceMd5Sign))
+}
+
+// GetCompletedLength mocks base method
+func (m *MockPeerTask) GetCompletedLength() int64 {
+	ret := m.ctrl.Call(m, "GetCompletedLength")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GetCompletedLength indicates an expected call of GetCompletedLength
+func (mr *MockPeerTaskMockRecorder) GetCompletedLength() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedLength", reflect.TypeOf((*MockPeerTask)(nil).GetCompletedLength))
+}
+
+// Cancel mocks base method
+func (m *MockPeerTask) Cancel(reason string) {
+	m.ctrl.Call(m, "Cancel", reason)
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockPeerTaskMockRecorder) Cancel(reason interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockPeerTask)(nil).Cancel), reason)
+}
+
 // GetContext mocks base method
 func (m *MockPeerTask) Context() context.Context {
 	ret := m.ctrl.Call(m, "Context")
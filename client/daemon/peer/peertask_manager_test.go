@@ -32,6 +32,8 @@ import (
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
@@ -40,12 +42,16 @@ import (
 	mock_daemon "d7y.io/dragonfly/v2/client/daemon/test/mock/daemon"
 	mock_scheduler "d7y.io/dragonfly/v2/client/daemon/test/mock/scheduler"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	"d7y.io/dragonfly/v2/internal/idgen"
 	"d7y.io/dragonfly/v2/internal/rpc"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/base/common"
 	daemonserver "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/server"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/internal/rpc/scheduler/client"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 var _ daemonserver.DaemonServer = mock_daemon.NewMockDaemonServer(nil)
@@ -56,10 +62,22 @@ func setupPeerTaskManagerComponents(
 	contentLength int64,
 	pieceSize, pieceParallelCount int32) (
 	schedulerclient.SchedulerClient, storage.Manager) {
+	return setupPushingPeerTaskManagerComponents(ctrl, taskID, contentLength, pieceSize, pieceParallelCount, nil)
+}
+
+// setupPushingPeerTaskManagerComponents is same as setupPeerTaskManagerComponents,
+// except the parent pushes pieces of pushData through SyncPieceTasks when pushData is not nil
+func setupPushingPeerTaskManagerComponents(
+	ctrl *gomock.Controller,
+	taskID string,
+	contentLength int64,
+	pieceSize, pieceParallelCount int32,
+	pushData []byte) (
+	schedulerclient.SchedulerClient, storage.Manager) {
 	port := int32(freeport.GetPort())
 	// 1. setup a mock daemon server for uploading pieces info
 	var daemon = mock_daemon.NewMockDaemonServer(ctrl)
-	daemon.EXPECT().GetPieceTasks(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, request *base.PieceTaskRequest) (*base.PiecePacket, error) {
+	getPieceTasks := daemon.EXPECT().GetPieceTasks(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, request *base.PieceTaskRequest) (*base.PiecePacket, error) {
 		var tasks []*base.PieceInfo
 		for i := int32(0); i < request.Limit; i++ {
 			start := pieceSize * (request.StartNum + i)
@@ -88,6 +106,55 @@ func setupPeerTaskManagerComponents(
 			TotalPiece:    int32(math.Ceil(float64(contentLength) / float64(pieceSize))),
 		}, nil
 	})
+	if pushData == nil {
+		// parent without pushing support, children fall back to get piece tasks
+		getPieceTasks.AnyTimes()
+		daemon.EXPECT().SyncPieceTasks(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(
+			status.Error(codes.Unimplemented, "unknown method SyncPieceTasks"))
+	} else {
+		// all pieces are pushed, children must not poll
+		getPieceTasks.Times(0)
+		daemon.EXPECT().SyncPieceTasks(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, request *base.PieceTaskRequest, ppc chan<- *base.PiecePacket) error {
+			// push all pieces in batches with piece md5, the last batch carries the piece md5 sign
+			totalPiece := int32(math.Ceil(float64(contentLength) / float64(pieceSize)))
+			var md5s []string
+			for i := int32(0); i < totalPiece; i++ {
+				end := int64(pieceSize * (i + 1))
+				if end > contentLength {
+					end = contentLength
+				}
+				md5s = append(md5s, digestutils.Md5Bytes(pushData[pieceSize*i:end]))
+			}
+			for num := request.StartNum; num < totalPiece; num += 16 {
+				var tasks []*base.PieceInfo
+				for i := num; i < num+16 && i < totalPiece; i++ {
+					start := pieceSize * i
+					size := pieceSize
+					if int64(start+pieceSize) > contentLength {
+						size = int32(contentLength) - start
+					}
+					tasks = append(tasks, &base.PieceInfo{
+						PieceNum:   i,
+						RangeStart: uint64(start),
+						RangeSize:  size,
+						PieceMd5:   md5s[i],
+					})
+				}
+				packet := &base.PiecePacket{
+					TaskId:        request.TaskId,
+					DstPid:        "peer-x",
+					PieceInfos:    tasks,
+					ContentLength: contentLength,
+					TotalPiece:    totalPiece,
+				}
+				if num+16 >= totalPiece {
+					packet.PieceMd5Sign = digestutils.Sha256(md5s...)
+				}
+				ppc <- packet
+			}
+			return nil
+		})
+	}
 	ln, _ := rpc.Listen(dfnet.NetAddr{
 		Type: "tcp",
 		Addr: fmt.Sprintf("0.0.0.0:%d", port),
@@ -229,6 +296,102 @@ func TestPeerTaskManager_StartFilePeerTask(t *testing.T) {
 	assert.Equal(testBytes, outputBytes, "output and desired output must match")
 }
 
+func TestPeerTaskManager_StartFilePeerTask_PushPieces(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		pieceParallelCount = int32(4)
+		pieceSize          = 1024
+
+		mockContentLength = len(testBytes)
+
+		peerID = "peer-push"
+		taskID = "task-push"
+
+		output = "../test/testdata/test.push.output"
+	)
+	defer os.Remove(output)
+
+	schedulerClient, storageManager := setupPushingPeerTaskManagerComponents(
+		ctrl, taskID, int64(mockContentLength), int32(pieceSize), pieceParallelCount, testBytes)
+	defer storageManager.CleanUp()
+
+	downloader := NewMockPieceDownloader(ctrl)
+	downloader.EXPECT().DownloadPiece(gomock.Any(), gomock.Any()).Times(
+		int(math.Ceil(float64(len(testBytes)) / float64(pieceSize)))).DoAndReturn(
+		func(ctx context.Context, task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+			rc := ioutil.NopCloser(
+				bytes.NewBuffer(
+					testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)],
+				))
+			return rc, rc, nil
+		})
+
+	ptm := &peerTaskManager{
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		runningPeerTasks: sync.Map{},
+		pieceManager: &pieceManager{
+			storageManager:  storageManager,
+			pieceDownloader: downloader,
+		},
+		storageManager:  storageManager,
+		schedulerClient: schedulerClient,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+	progress, _, err := ptm.StartFilePeerTask(context.Background(), &FilePeerTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      "http://localhost/test/push/data",
+			Filter:   "",
+			BizId:    "d7y-test",
+			PeerId:   peerID,
+			PeerHost: &scheduler.PeerHost{},
+		},
+		Output: output,
+	})
+	assert.Nil(err, "start file peer task")
+
+	var p *FilePeerTaskProgress
+	for p = range progress {
+		assert.True(p.State.Success)
+		if p.PeerTaskDone {
+			p.DoneCallback()
+			break
+		}
+	}
+	assert.NotNil(p)
+	assert.True(p.PeerTaskDone)
+
+	outputBytes, err := ioutil.ReadFile(output)
+	assert.Nil(err, "load output file")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+
+	// the pushed piece md5 sign is verified and stored
+	var md5s []string
+	for start := 0; start < len(testBytes); start += pieceSize {
+		end := start + pieceSize
+		if end > len(testBytes) {
+			end = len(testBytes)
+		}
+		md5s = append(md5s, digestutils.Md5Bytes(testBytes[start:end]))
+	}
+	packet, err := storageManager.GetPieces(context.Background(), &base.PieceTaskRequest{
+		TaskId: taskID,
+		DstPid: peerID,
+		Limit:  1,
+	})
+	assert.Nil(err, "get pieces")
+	assert.Equal(digestutils.Sha256(md5s...), packet.PieceMd5Sign)
+}
+
 func TestPeerTaskManager_StartStreamPeerTask(t *testing.T) {
 	assert := testifyassert.New(t)
 	ctrl := gomock.NewController(t)
@@ -289,3 +452,91 @@ func TestPeerTaskManager_StartStreamPeerTask(t *testing.T) {
 	assert.Nil(err, "load read data")
 	assert.Equal(testBytes, outputBytes, "output and desired output must match")
 }
+
+func TestPeerTaskManager_ImportTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		url         = "http://example.com/imported"
+		peerID      = "peer-import"
+		taskID      = idgen.TaskID(url, "", &base.UrlMeta{}, "")
+		totalPieces = int32(math.Ceil(float64(len(testBytes)) / float64(computePieceSize(int64(len(testBytes))))))
+		sentPieces  int32
+	)
+
+	pps := mock_scheduler.NewMockPeerPacketStream(ctrl)
+	pps.EXPECT().Send(gomock.Any()).Times(int(totalPieces) + 1).DoAndReturn(
+		func(pr *scheduler.PieceResult) error {
+			// the end piece result is sent after all pieces
+			if sentPieces == totalPieces {
+				assert.Equal(common.EndOfPiece, pr.PieceNum)
+				assert.Equal(totalPieces, pr.FinishedCount)
+				return nil
+			}
+			assert.True(pr.Success)
+			assert.Equal(sentPieces, pr.PieceNum)
+			sentPieces++
+			return nil
+		})
+	sched := mock_scheduler.NewMockSchedulerClient(ctrl)
+	sched.EXPECT().RegisterPeerTask(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, ptr *scheduler.PeerTaskRequest, opts ...grpc.CallOption) (*scheduler.RegisterResult, error) {
+			assert.True(ptr.IsSeed)
+			return &scheduler.RegisterResult{TaskId: taskID}, dferrors.New(dfcodes.SchedNeedBackSource, "need back source")
+		})
+	sched.EXPECT().ReportPieceResult(gomock.Any(), taskID, gomock.Any()).Times(1).Return(pps, nil)
+	sched.EXPECT().ReportPeerResult(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, pr *scheduler.PeerResult, opts ...grpc.CallOption) error {
+			assert.True(pr.Success)
+			assert.Equal(int64(len(testBytes)), pr.ContentLength)
+			return nil
+		})
+
+	storageManager, err := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	assert.Nil(err)
+	defer storageManager.CleanUp()
+
+	ptm := &peerTaskManager{
+		host:            &scheduler.PeerHost{Ip: "127.0.0.1"},
+		storageManager:  storageManager,
+		schedulerClient: sched,
+	}
+	req := &ImportTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:     url,
+			UrlMeta: &base.UrlMeta{},
+			PeerId:  peerID,
+		},
+		Path: test.File,
+	}
+	result, err := ptm.ImportTask(context.Background(), req)
+	assert.Nil(err)
+	assert.Equal(taskID, result.TaskID)
+	assert.Equal(peerID, result.PeerID)
+	assert.Equal(int64(len(testBytes)), result.ContentLength)
+	assert.Equal(totalPieces, result.TotalPieces)
+
+	rc, err := storageManager.ReadAllPieces(context.Background(), &result.PeerTaskMetaData)
+	assert.Nil(err)
+	data, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	assert.Equal(testBytes, data)
+
+	// import again, the completed task is reused without announcing
+	req.PeerId = "peer-import-again"
+	result, err = ptm.ImportTask(context.Background(), req)
+	assert.Nil(err)
+	assert.Equal(peerID, result.PeerID)
+}
@@ -0,0 +1,190 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+)
+
+// newOriginRequest makes the origin request which is persisted for resuming the task after restart
+func newOriginRequest(req *scheduler.PeerTaskRequest, output string) *storage.OriginRequest {
+	return &storage.OriginRequest{
+		Url:         req.Url,
+		Filter:      req.Filter,
+		BizID:       req.BizId,
+		UrlMeta:     req.UrlMeta,
+		Destination: output,
+	}
+}
+
+// ResumePeerTasks resumes the unfinished peer tasks in storage with the same peer ids,
+// the stored pieces are reported to scheduler and only the missing pieces are downloaded.
+// stream tasks are resumed as file tasks without output, the content is kept in storage for reusing.
+func (ptm *peerTaskManager) ResumePeerTasks(ctx context.Context) {
+	tasks := ptm.storageManager.FindUnfinishedTasks()
+	logger.Infof("found %d unfinished peer tasks to resume", len(tasks))
+	for _, task := range tasks {
+		go ptm.resumePeerTask(ctx, task)
+	}
+}
+
+func (ptm *peerTaskManager) resumePeerTask(ctx context.Context, task *storage.ResumePeerTask) {
+	log := logger.With("peer", task.PeerID, "task", task.TaskID, "component", "resumePeerTask")
+	origin := task.OriginRequest
+	req := &FilePeerTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      origin.Url,
+			Filter:   origin.Filter,
+			BizId:    origin.BizID,
+			UrlMeta:  origin.UrlMeta,
+			PeerId:   task.PeerID,
+			PeerHost: ptm.host,
+		},
+		Output: origin.Destination,
+		resume: task,
+	}
+	if req.UrlMeta == nil {
+		req.UrlMeta = &base.UrlMeta{}
+	}
+
+	var storedLength int64
+	for _, piece := range task.Pieces {
+		storedLength += piece.Range.Length
+	}
+	log.Infof("resume peer task with %d stored pieces, stored length: %d, content length: %d",
+		len(task.Pieces), storedLength, task.ContentLength)
+
+	// the task with unknown content length is downloaded from source as a stream, the stored pieces
+	// can not be completed by others, download it again from the beginning
+	if task.ContentLength < 0 {
+		log.Infof("content length is unknown, discard the stored pieces")
+		ptm.deleteResumedTask(ctx, task)
+		// resumed stream task only keeps the content in storage, it's useless when the content exists
+		if origin.Destination == "" && ptm.storageManager.FindCompletedTask(task.TaskID) != nil {
+			log.Infof("task is completed by another peer, done")
+			return
+		}
+		req.resume = nil
+	}
+
+	// all pieces were stored before restart, just store it without scheduler
+	if req.resume != nil && storedLength == task.ContentLength {
+		err := ptm.storageManager.Store(ctx, &storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID:      task.PeerID,
+				TaskID:      task.TaskID,
+				Destination: origin.Destination,
+			},
+			MetadataOnly: origin.Destination == "",
+			TotalPieces:  task.TotalPieces,
+			Digest:       urlDigest(&req.PeerTaskRequest),
+		})
+		if err != nil {
+			log.Errorf("store completed peer task error: %s", err)
+			ptm.deleteResumedTask(ctx, task)
+			return
+		}
+		log.Infof("all pieces were stored, peer task done")
+		return
+	}
+
+	progress, tiny, err := ptm.StartFilePeerTask(ctx, req)
+	if err != nil {
+		log.Errorf("resume peer task error: %s", err)
+		ptm.deleteResumedTask(ctx, task)
+		return
+	}
+	if tiny != nil {
+		// tiny content is returned by scheduler, the stored pieces are useless
+		log.Infof("resumed peer task is tiny, done")
+		ptm.deleteResumedTask(ctx, task)
+		return
+	}
+
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				log.Warnf("progress closed unexpected")
+				return
+			}
+			if !p.PeerTaskDone {
+				continue
+			}
+			p.DoneCallback()
+			if !p.State.Success {
+				log.Errorf("resume peer task failed: %d/%s", p.State.Code, p.State.Msg)
+				// keep the stored pieces for the next restart when canceled
+				if p.State.Code != dfcodes.ClientContextCanceled {
+					ptm.deleteResumedTask(ctx, task)
+				}
+				return
+			}
+			log.Infof("resume peer task done, completed length: %d", p.CompletedLength)
+			return
+		case <-ctx.Done():
+			log.Warnf("context done due to %s", ctx.Err())
+			return
+		}
+	}
+}
+
+// deleteResumedTask deletes the stored pieces of the resumed task which can not be resumed again
+func (ptm *peerTaskManager) deleteResumedTask(ctx context.Context, task *storage.ResumePeerTask) {
+	if err := ptm.storageManager.DeleteTask(ctx, task.PeerTaskMetaData); err != nil {
+		logger.Warnf("delete resumed peer task %s/%s error: %s", task.TaskID, task.PeerID, err)
+	}
+}
+
+// restorePieces marks the stored pieces ready and reports them to scheduler,
+// then other peers can download these pieces from current peer.
+func (pt *peerTask) restorePieces(pieces []storage.PieceMetaData) {
+	pt.lock.Lock()
+	for _, piece := range pieces {
+		pt.readyPieces.Set(piece.Num)
+		pt.requestedPieces.Set(piece.Num)
+		pt.completedLength.Add(piece.Range.Length)
+	}
+	pt.lock.Unlock()
+
+	now := uint64(time.Now().UnixNano())
+	for i, piece := range pieces {
+		err := pt.peerPacketStream.Send(&scheduler.PieceResult{
+			TaskId:        pt.taskID,
+			SrcPid:        pt.peerID,
+			DstPid:        pt.peerID,
+			PieceNum:      piece.Num,
+			BeginTime:     now,
+			EndTime:       now,
+			Success:       true,
+			Code:          dfcodes.Success,
+			FinishedCount: int32(i + 1),
+		})
+		if err != nil {
+			pt.Warnf("report restored piece %d error: %s", piece.Num, err)
+			return
+		}
+	}
+	pt.Infof("restored %d pieces, completed length: %d", len(pieces), pt.completedLength.Load())
+}
@@ -22,12 +22,15 @@ import (
 	"io"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/idgen"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"github.com/go-http-utils/headers"
 	"go.opentelemetry.io/otel/semconv"
 	"go.opentelemetry.io/otel/trace"
@@ -67,9 +70,16 @@ func (ptm *peerTaskManager) tryReuseFilePeerTask(ctx context.Context,
 			MetadataOnly: false,
 			StoreOnly:    true,
 			TotalPieces:  reuse.TotalPieces,
+			Digest:       urlDigest(&request.PeerTaskRequest),
 		})
 	if err != nil {
 		log.Errorf("store error when reuse peer task: %s", err)
+		if errors.Is(err, digestutils.ErrDigestNotMatch) {
+			// the digest is a part of task id, mismatch means the stored data is broken
+			if e := ptm.storageManager.DeleteTask(ctx, reuse.PeerTaskMetaData); e != nil {
+				log.Warnf("delete broken peer task %s error: %s", reuse.PeerID, e)
+			}
+		}
 		span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
 		span.RecordError(err)
 		return nil, false
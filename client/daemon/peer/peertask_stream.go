@@ -30,12 +30,15 @@ import (
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/internal/rpc/scheduler/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 // StreamPeerTask represents a peer task with stream io for reading directly without once more disk io
@@ -60,7 +63,7 @@ func newStreamPeerTask(ctx context.Context,
 	request *scheduler.PeerTaskRequest,
 	schedulerClient schedulerclient.SchedulerClient,
 	schedulerOption config.SchedulerOption,
-	perPeerRateLimit rate.Limit) (context.Context, StreamPeerTask, *TinyData, error) {
+	perPeerRateLimit func() rate.Limit) (context.Context, StreamPeerTask, *TinyData, error) {
 	ctx, span := tracer.Start(ctx, config.SpanStreamPeerTask, trace.WithSpanKind(trace.SpanKindClient))
 	span.SetAttributes(config.AttributePeerHost.String(host.Uuid))
 	span.SetAttributes(semconv.NetHostIPKey.String(host.Ip))
@@ -133,8 +136,8 @@ func newStreamPeerTask(ctx context.Context,
 		return ctx, nil, nil, err
 	}
 	var limiter *rate.Limiter
-	if perPeerRateLimit > 0 {
-		limiter = rate.NewLimiter(perPeerRateLimit, int(perPeerRateLimit))
+	if perPeerRateLimit != nil {
+		limiter = ratelimit.NewLimiter(perPeerRateLimit())
 	}
 	return ctx, &streamPeerTask{
 		peerTask: peerTask{
@@ -159,8 +162,10 @@ func newStreamPeerTask(ctx context.Context,
 			totalPiece:          -1,
 			schedulerOption:     schedulerOption,
 			limiter:             limiter,
+			perPeerRateLimit:    perPeerRateLimit,
 			completedLength:     atomic.NewInt64(0),
 			usedTraffic:         atomic.NewInt64(0),
+			pieceMd5Sign:        atomic.NewString(""),
 			SugaredLoggerOnWith: logger.With("peer", request.PeerId, "task", result.TaskId, "component", "streamPeerTask"),
 		},
 		successPieceCh: make(chan int32, 4),
@@ -211,9 +216,13 @@ func (s *streamPeerTask) Start(ctx context.Context) (io.Reader, map[string]strin
 		go func() {
 			s.contentLength = -1
 			_ = s.callback.Init(s)
-			err := s.pieceManager.DownloadSource(ctx, s, s.request)
+			err := s.pieceManager.DownloadSource(s.ctx, s, s.request)
 			if err != nil {
 				s.Errorf("download from source error: %s", err)
+				if errors.Is(err, digestutils.ErrDigestNotMatch) {
+					s.failedCode = dfcodes.ClientDigestNotMatch
+					s.failedReason = err.Error()
+				}
 				s.cleanUnfinished()
 				return
 			}
@@ -364,10 +373,19 @@ func (s *streamPeerTask) finish() error {
 		s.Debugf("end piece result sent")
 		close(s.done)
 		//close(s.successPieceCh)
+		code := dfcodes.Success
 		if err := s.callback.Done(s); err != nil {
 			s.span.RecordError(err)
 			s.Errorf("done callback error: %s", err)
+			code = dfcodes.ClientError
+			var de *dferrors.DfError
+			if errors.Is(err, digestutils.ErrDigestNotMatch) {
+				code = dfcodes.ClientDigestNotMatch
+			} else if errors.As(err, &de) {
+				code = de.Code
+			}
 		}
+		metrics.ObservePeerTask(metrics.PeerTaskTypeStream, code)
 		s.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(true))
 	})
 	return nil
@@ -386,6 +404,7 @@ func (s *streamPeerTask) cleanUnfinished() {
 			s.span.RecordError(err)
 			s.Errorf("fail callback error: %s", err)
 		}
+		metrics.ObservePeerTask(metrics.PeerTaskTypeStream, s.failedCode)
 		s.span.SetAttributes(config.AttributePeerTaskSuccess.Bool(false))
 		s.span.SetAttributes(config.AttributePeerTaskCode.Int(int(s.failedCode)))
 		s.span.SetAttributes(config.AttributePeerTaskMessage.String(s.failedReason))
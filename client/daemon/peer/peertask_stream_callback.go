@@ -50,6 +50,8 @@ func (p *streamPeerTaskCallback) Init(pt Task) error {
 			},
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			OriginRequest: newOriginRequest(p.req, ""),
+			Biz:           quotaBiz(p.req, ""),
 		})
 	if err != nil {
 		pt.Log().Errorf("register task to storage manager failed: %s", err)
@@ -86,6 +88,7 @@ func (p *streamPeerTaskCallback) Done(pt Task) error {
 			},
 			MetadataOnly: true,
 			TotalPieces:  pt.GetTotalPieces(),
+			PieceMd5Sign: pt.GetPieceMd5Sign(),
 		})
 	if e != nil {
 		return e
@@ -116,7 +116,7 @@ func TestStreamPeerTask_BackSource_WithContentLength(t *testing.T) {
 		req,
 		ptm.schedulerClient,
 		ptm.schedulerOption,
-		0)
+		nil)
 	assert.Nil(err, "new stream peer task")
 	pt.SetCallback(&streamPeerTaskCallback{
 		ctx:   ctx,
@@ -214,7 +214,7 @@ func TestStreamPeerTask_BackSource_WithoutContentLength(t *testing.T) {
 		req,
 		schedulerClient,
 		ptm.schedulerOption,
-		0)
+		nil)
 	assert.Nil(err, "new stream peer task")
 	pt.SetCallback(&streamPeerTaskCallback{
 		ctx:   ctx,
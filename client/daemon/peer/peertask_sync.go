@@ -0,0 +1,76 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	dfclient "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+)
+
+// pieceSyncer receives the pieces pushed by the main peer, instead of polling GetPieceTasks
+type pieceSyncer struct {
+	peerPacket *scheduler.PeerPacket
+	cancel     context.CancelFunc
+	// packets is closed when syncing stopped, io.EOF in err means all pieces are received
+	packets chan *base.PiecePacket
+	err     error
+}
+
+// syncPieceTasks subscribes the pieces since num from the main peer, returns nil when subscribing failed,
+// the main peer which does not support syncing, like cdn, is detected by the closed packets with error
+func (pt *peerTask) syncPieceTasks(num int32) *pieceSyncer {
+	peerPacket := pt.peerPacket
+	ctx, cancel := context.WithCancel(pt.ctx)
+	stream, err := dfclient.SyncPieceTasks(ctx, peerPacket.MainPeer, &base.PieceTaskRequest{
+		TaskId:   pt.taskID,
+		SrcPid:   pt.peerID,
+		DstPid:   peerPacket.MainPeer.PeerId,
+		StartNum: num,
+	})
+	if err != nil {
+		cancel()
+		pt.Debugf("sync piece tasks from peer %s error: %s", peerPacket.MainPeer.PeerId, err)
+		return nil
+	}
+	pt.Debugf("sync piece tasks from peer %s, piece num: %d", peerPacket.MainPeer.PeerId, num)
+
+	s := &pieceSyncer{
+		peerPacket: peerPacket,
+		cancel:     cancel,
+		packets:    make(chan *base.PiecePacket, 4),
+	}
+	go func() {
+		defer close(s.packets)
+		for {
+			p, err := stream.Recv()
+			if err != nil {
+				s.err = err
+				return
+			}
+			select {
+			case s.packets <- p:
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	return s
+}
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"time"
 
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/upload"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
@@ -36,6 +37,7 @@ type DownloadPieceRequest struct {
 	TaskID     string
 	DstPid     string
 	DstAddr    string
+	PieceToken string
 	CalcDigest bool
 	piece      *base.PieceInfo
 }
@@ -127,5 +129,8 @@ func buildDownloadPieceHTTPRequest(ctx context.Context, d *DownloadPieceRequest)
 	// TODO use string.Builder
 	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d",
 		d.piece.RangeStart, d.piece.RangeStart+uint64(d.piece.RangeSize)-1))
+	if d.PieceToken != "" {
+		req.Header.Add(config.HeaderDragonflyPieceToken, d.PieceToken)
+	}
 	return req
 }
@@ -81,6 +81,11 @@ func (o optimizedPieceDownloader) DownloadPiece(ctx context.Context, request *Do
 		cancel()
 		return nil, nil, err
 	}
+	if resp.StatusCode > 299 {
+		cancel()
+		_ = conn.Close()
+		return nil, nil, errors.Errorf("download piece failed with http code: %s", resp.Status)
+	}
 	if resp.ContentLength <= 0 {
 		cancel()
 		logger.Errorf("can not get ContentLength, addr: %s, task: %s, peer: %s, piece: %d",
@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 	"math"
+	"strconv"
 	"time"
 
 	"d7y.io/dragonfly/v2/pkg/source"
@@ -29,6 +30,8 @@ import (
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -45,9 +48,11 @@ type PieceManager interface {
 
 type pieceManager struct {
 	*rate.Limiter
-	storageManager   storage.TaskStorageDriver
-	pieceDownloader  PieceDownloader
-	computePieceSize func(contentLength int64) int32
+	// backSourceLimiter returns the limiter of the download from source, Limiter is used when it's nil
+	backSourceLimiter func() *rate.Limiter
+	storageManager    storage.TaskStorageDriver
+	pieceDownloader   PieceDownloader
+	computePieceSize  func(contentLength int64) int32
 
 	calculateDigest bool
 }
@@ -91,6 +96,14 @@ func WithLimiter(limiter *rate.Limiter) func(*pieceManager) {
 	}
 }
 
+// WithBackSourceLimiter sets the getter of back source rate limiter, the limiter may change at runtime,
+// the burst size must big than piece size
+func WithBackSourceLimiter(limiter func() *rate.Limiter) func(*pieceManager) {
+	return func(manager *pieceManager) {
+		manager.backSourceLimiter = limiter
+	}
+}
+
 func (pm *pieceManager) DownloadPiece(ctx context.Context, pt Task, request *DownloadPieceRequest) (success bool) {
 	var (
 		start = time.Now().UnixNano()
@@ -99,6 +112,8 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt Task, request *Dow
 	defer func() {
 		_, rspan := tracer.Start(ctx, config.SpanPushPieceResult)
 		rspan.SetAttributes(config.AttributeWritePieceSuccess.Bool(success))
+		metrics.PieceDownloadDuration.WithLabelValues(metrics.PeerSource(request.DstPid), strconv.FormatBool(success)).
+			Observe(time.Duration(time.Now().UnixNano() - start).Seconds())
 		if success {
 			pm.pushSuccessResult(pt, request.DstPid, request.piece, start, end)
 		} else {
@@ -109,7 +124,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt Task, request *Dow
 
 	// 1. download piece from other peers
 	if pm.Limiter != nil {
-		if err := pm.Limiter.WaitN(ctx, int(request.piece.RangeSize)); err != nil {
+		if err := ratelimit.WaitN(ctx, pm.Limiter, int(request.piece.RangeSize)); err != nil {
 			pt.Log().Errorf("require rate limit access error: %s", err)
 			return
 		}
@@ -150,6 +165,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt Task, request *Dow
 	span.RecordError(err)
 	span.End()
 	pt.AddTraffic(n)
+	metrics.DownloadTraffic.WithLabelValues(metrics.PeerSource(request.DstPid)).Add(float64(n))
 	if err != nil {
 		pt.Log().Errorf("put piece to storage failed, piece num: %d, wrote: %d, error: %s",
 			request.piece.PieceNum, n, err)
@@ -218,6 +234,8 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 	)
 
 	defer func() {
+		metrics.PieceDownloadDuration.WithLabelValues(metrics.SourceBackSource, strconv.FormatBool(success)).
+			Observe(time.Duration(time.Now().UnixNano() - start).Seconds())
 		if success {
 			pm.pushSuccessResult(pt, pt.GetPeerID(),
 				&base.PieceInfo{
@@ -241,8 +259,12 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 		}
 	}()
 
-	if pm.Limiter != nil {
-		if err := pm.Limiter.WaitN(pt.Context(), int(size)); err != nil {
+	limiter := pm.Limiter
+	if pm.backSourceLimiter != nil {
+		limiter = pm.backSourceLimiter()
+	}
+	if limiter != nil {
+		if err := ratelimit.WaitN(pt.Context(), limiter, int(size)); err != nil {
 			pt.Log().Errorf("require rate limit access error: %s", err)
 			return 0, err
 		}
@@ -275,6 +297,7 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 	}
 	end = time.Now().UnixNano()
 	pt.AddTraffic(n)
+	metrics.DownloadTraffic.WithLabelValues(metrics.SourceBackSource).Add(float64(n))
 	if err != nil {
 		pt.Log().Errorf("put piece to storage failed, piece num: %d, wrote: %d, error: %s", pieceNum, n, err)
 		return n, err
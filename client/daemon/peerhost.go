@@ -31,23 +31,28 @@ import (
 
 	"d7y.io/dragonfly/v2/internal/dfpath"
 	"d7y.io/dragonfly/v2/internal/idgen"
+	"d7y.io/dragonfly/v2/internal/pieceauth"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/api"
 	"d7y.io/dragonfly/v2/client/daemon/gc"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/proxy"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/client/daemon/service"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/client/daemon/upload"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	dc "d7y.io/dragonfly/v2/internal/dynconfig"
 	"d7y.io/dragonfly/v2/internal/rpc"
+	"d7y.io/dragonfly/v2/internal/rpc/manager"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/internal/rpc/scheduler/client"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
@@ -67,10 +72,19 @@ type peerHost struct {
 	Option config.PeerHostOption
 
 	ServiceManager service.Manager
+	APIManager     api.Manager
 	UploadManager  upload.Manager
 	ProxyManager   proxy.Manager
 	StorageManager storage.Manager
 	GCManager      gc.Manager
+	RateLimits     ratelimit.Manager
+
+	// dynconfig watches the client config from manager, it's nil when config server is not set
+	dynconfig     config.DynconfigInterface
+	dynconfigConn *grpc.ClientConn
+
+	// metricsServer exposes prometheus metrics, it's nil when metrics is not set
+	metricsServer *http.Server
 
 	PeerTaskManager peer.TaskManager
 	PieceManager    peer.PieceManager
@@ -115,14 +129,16 @@ func New(opt *config.PeerHostOption) (PeerHost, error) {
 		return nil, err
 	}
 
+	rateLimits := ratelimit.NewManager(opt)
 	pieceManager, err := peer.NewPieceManager(storageManager,
-		peer.WithLimiter(rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))),
+		peer.WithLimiter(rateLimits.DownloadLimiter()),
+		peer.WithBackSourceLimiter(rateLimits.BackSourceLimiter),
 		peer.WithCalculateDigest(opt.Download.CalculateDigest))
 	if err != nil {
 		return nil, err
 	}
 	peerTaskManager, err := peer.NewPeerTaskManager(host, pieceManager, storageManager, sched, opt.Scheduler,
-		opt.Download.PerPeerRateLimit.Limit, opt.Storage.Multiplex)
+		rateLimits.PerPeerLimit, opt.Storage.Multiplex)
 	if err != nil {
 		return nil, err
 	}
@@ -144,7 +160,12 @@ func New(opt *config.PeerHostOption) (PeerHost, error) {
 		}
 		peerServerOption = append(peerServerOption, grpc.Creds(tlsCredentials))
 	}
-	serviceManager, err := service.NewManager(host, peerTaskManager, storageManager, downloadServerOption, peerServerOption)
+	serviceManager, err := service.NewManager(host, peerTaskManager, storageManager, rateLimits, downloadServerOption, peerServerOption)
+	if err != nil {
+		return nil, err
+	}
+
+	apiManager, err := api.NewAPIManager(host, peerTaskManager)
 	if err != nil {
 		return nil, err
 	}
@@ -155,12 +176,47 @@ func New(opt *config.PeerHostOption) (PeerHost, error) {
 		return nil, err
 	}
 
+	var pieceTokenSigner *pieceauth.Signer
+	if opt.Upload.PieceTokenSecret != "" {
+		pieceTokenSigner = pieceauth.NewSigner(opt.Upload.PieceTokenSecret, 0)
+	}
 	uploadManager, err := upload.NewUploadManager(storageManager,
-		upload.WithLimiter(rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))))
+		upload.WithLimiter(rateLimits.UploadLimiter()),
+		upload.WithPieceTokenSigner(pieceTokenSigner))
 	if err != nil {
 		return nil, err
 	}
 
+	// the client config from manager is optional, daemon works with local config when manager is unavailable
+	var (
+		dynconfig     config.DynconfigInterface
+		dynconfigConn *grpc.ClientConn
+	)
+	if opt.ConfigServer != "" {
+		dynconfigConn, err = grpc.Dial(opt.ConfigServer, grpc.WithInsecure())
+		if err != nil {
+			return nil, err
+		}
+		dynconfig, err = config.NewDynconfig(
+			dc.WithManagerClient(config.NewManagerClient(manager.NewManagerClient(dynconfigConn), opt.Scheduler.NetAddrs)),
+			dc.WithCachePath(config.DaemonDynconfigCachePath),
+			dc.WithExpireTime(config.DefaultDynconfigExpireTime),
+		)
+		if err != nil {
+			logger.Warnf("get client config from manager %s error: %s", opt.ConfigServer, err)
+			dynconfigConn.Close()
+			dynconfig, dynconfigConn = nil, nil
+		} else {
+			dynconfig.Register(rateLimits)
+			dynconfig.Register(proxyManager)
+		}
+	}
+
+	var metricsServer *http.Server
+	if opt.Metrics != "" {
+		metricsServer = metrics.New(opt.Metrics)
+	}
+
 	return &peerHost{
 		once:          &sync.Once{},
 		done:          make(chan bool),
@@ -168,12 +224,18 @@ func New(opt *config.PeerHostOption) (PeerHost, error) {
 		Option:        *opt,
 
 		ServiceManager:  serviceManager,
+		APIManager:      apiManager,
 		PeerTaskManager: peerTaskManager,
 		PieceManager:    pieceManager,
 		ProxyManager:    proxyManager,
 		UploadManager:   uploadManager,
 		StorageManager:  storageManager,
 		GCManager:       gc.NewManager(opt.GCInterval.Duration),
+		RateLimits:      rateLimits,
+
+		dynconfig:     dynconfig,
+		dynconfigConn: dynconfigConn,
+		metricsServer: metricsServer,
 	}, nil
 }
 
@@ -272,8 +334,32 @@ func (*peerHost) prepareTCPListener(opt config.ListenOption, withTLS bool) (net.
 	return tls.NewListener(ln, tlsConfig), port, nil
 }
 
+// prepareAPIListener listens unix socket, returns nil listener when it is not configured
+func (ph *peerHost) prepareAPIListener() (net.Listener, error) {
+	opt := ph.Option.Download.DownloadHTTP
+	if opt.UnixListen != nil {
+		// do not change the socket in option, it may be reloaded or shared
+		socket := opt.UnixListen.Socket
+		if socket == "" {
+			socket = dfpath.DaemonHTTPSockPath
+		}
+		_ = os.Remove(socket)
+		return rpc.Listen(dfnet.NetAddr{
+			Type: dfnet.UNIX,
+			Addr: socket,
+		})
+	}
+	return nil, nil
+}
+
 func (ph *peerHost) Serve() error {
 	ph.GCManager.Start()
+	ph.RateLimits.Start()
+	if ph.dynconfig != nil {
+		if err := ph.dynconfig.Serve(); err != nil {
+			logger.Warnf("serve dynconfig error: %s", err)
+		}
+	}
 	// todo remove this field, and use directly dfpath.DaemonSockPath
 	ph.Option.Download.DownloadGRPC.UnixListen.Socket = dfpath.DaemonSockPath
 	// prepare download service listen
@@ -290,6 +376,13 @@ func (ph *peerHost) Serve() error {
 		return err
 	}
 
+	// prepare download http api listen, it's optional
+	apiListener, err := ph.prepareAPIListener()
+	if err != nil {
+		logger.Errorf("failed to listen for download http api: %v", err)
+		return err
+	}
+
 	// prepare peer service listen
 	if ph.Option.Download.PeerGRPC.TCPListen == nil {
 		return errors.New("peer grpc tcp listen option is empty")
@@ -335,6 +428,21 @@ func (ph *peerHost) Serve() error {
 		return nil
 	})
 
+	if apiListener != nil {
+		// serve download http api
+		g.Go(func() error {
+			defer apiListener.Close()
+			logger.Infof("serve download http api at %s://%s", apiListener.Addr().Network(), apiListener.Addr().String())
+			if err := ph.APIManager.Serve(apiListener); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("failed to serve for download http api: %v", err)
+				return err
+			} else if err == http.ErrServerClosed {
+				logger.Infof("download http api closed")
+			}
+			return nil
+		})
+	}
+
 	if ph.ProxyManager.IsEnabled() {
 		// prepare proxy service listen
 		if ph.Option.Proxy.TCPListen == nil {
@@ -359,6 +467,20 @@ func (ph *peerHost) Serve() error {
 		})
 	}
 
+	if ph.metricsServer != nil {
+		// serve metrics service
+		g.Go(func() error {
+			logger.Infof("serve metrics at http://%s/metrics", ph.metricsServer.Addr)
+			if err := ph.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("failed to serve for metrics service: %v", err)
+				return err
+			} else if err == http.ErrServerClosed {
+				logger.Infof("metrics service closed")
+			}
+			return nil
+		})
+	}
+
 	// serve upload service
 	g.Go(func() error {
 		defer uploadListener.Close()
@@ -372,6 +494,9 @@ func (ph *peerHost) Serve() error {
 		return nil
 	})
 
+	// resume the unfinished peer tasks after all services are serving, other peers can download the stored pieces
+	ph.PeerTaskManager.ResumePeerTasks(context.Background())
+
 	if ph.Option.AliveTime.Duration > 0 {
 		g.Go(func() error {
 			select {
@@ -379,6 +504,7 @@ func (ph *peerHost) Serve() error {
 				var keepalives = []clientutil.KeepAlive{
 					ph.StorageManager,
 					ph.ServiceManager,
+					ph.APIManager,
 				}
 				var keep bool
 				for _, keepalive := range keepalives {
@@ -406,13 +532,23 @@ func (ph *peerHost) Stop() {
 	ph.once.Do(func() {
 		close(ph.done)
 		ph.GCManager.Stop()
+		ph.RateLimits.Stop()
+		if ph.dynconfig != nil {
+			ph.dynconfig.Stop()
+			ph.dynconfigConn.Close()
+		}
 		ph.ServiceManager.Stop()
+		ph.APIManager.Stop()
 		ph.UploadManager.Stop()
 
 		if ph.ProxyManager.IsEnabled() {
 			ph.ProxyManager.Stop()
 		}
 
+		if ph.metricsServer != nil {
+			_ = ph.metricsServer.Shutdown(context.Background())
+		}
+
 		if !ph.Option.KeepStorage {
 			logger.Infof("keep storage disabled")
 			ph.StorageManager.CleanUp()
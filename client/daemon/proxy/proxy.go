@@ -17,6 +17,7 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
@@ -29,6 +30,7 @@ import (
 	"time"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/transport"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -135,12 +137,10 @@ func WithCert(cert *tls.Certificate) Option {
 	}
 }
 
-// WithDirectHandler sets the handler for non-proxy requests
-func WithDirectHandler(h *http.ServeMux) Option {
+// WithDirectHandler sets the handler for non-proxy requests, the handler is shared by the reloaded proxies,
+// the proxy which handles the request can be got by proxyFromContext
+func WithDirectHandler(h http.Handler) Option {
 	return func(p *Proxy) *Proxy {
-		// Make sure the root handler of the given server mux is the
-		// registry mirror reverse proxy
-		h.HandleFunc("/", p.mirrorRegistry)
 		p.directHandler = h
 		return p
 	}
@@ -172,6 +172,14 @@ func WithMaxConcurrency(con int64) Option {
 	}
 }
 
+// withSemaphore shares the semaphore of the proxy before reloading, so max concurrency is not exceeded during reloading
+func withSemaphore(s *semaphore.Weighted) Option {
+	return func(p *Proxy) *Proxy {
+		p.semaphore = s
+		return p
+	}
+}
+
 // WithDefaultFilter sets default filter for http requests without X-Dragonfly-Filter Header
 func WithDefaultFilter(f string) Option {
 	return func(p *Proxy) *Proxy {
@@ -230,7 +238,6 @@ func (proxy *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			logger.Debugf("empty auth info: %s, url：%s", r.Host, r.URL.String())
 			return
 		}
-		// TODO dynamic auth config via manager
 		if user != proxy.basicAuth.Username || pass != proxy.basicAuth.Password {
 			status := http.StatusUnauthorized
 			http.Error(w, http.StatusText(status), status)
@@ -263,7 +270,7 @@ func (proxy *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		proxy.handleHTTPS(w, r)
 	} else if r.URL.Scheme == "" {
 		// handle direct requests
-		proxy.directHandler.ServeHTTP(w, r)
+		proxy.directHandler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), proxyContextKey{}, proxy)))
 	} else {
 		// handle http proxy requests
 		proxy.handleHTTP(span, w, r)
@@ -312,6 +319,8 @@ func (proxy *Proxy) handleHTTP(span trace.Span, w http.ResponseWriter, req *http
 	if n, err := io.Copy(w, resp.Body); err != nil && err != io.EOF {
 		logger.Errorf("failed to write http body: %v", err)
 		span.RecordError(err)
+		// abort the response, otherwise the client takes the partial data as completed, eg: digest not match
+		panic(http.ErrAbortHandler)
 	} else {
 		span.SetAttributes(semconv.HTTPResponseContentLengthKey.Int64(n))
 	}
@@ -409,6 +418,24 @@ func (proxy *Proxy) newTransport(tlsConfig *tls.Config) http.RoundTripper {
 	return rt
 }
 
+// proxyContextKey is the context key of the proxy which handles the direct request
+type proxyContextKey struct{}
+
+func proxyFromContext(ctx context.Context) (*Proxy, bool) {
+	proxy, ok := ctx.Value(proxyContextKey{}).(*Proxy)
+	return proxy, ok
+}
+
+// mirrorRegistry is the handler of direct requests which proxies requests to the registry mirror
+func mirrorRegistry(w http.ResponseWriter, r *http.Request) {
+	proxy, ok := proxyFromContext(r.Context())
+	if !ok || proxy.registry == nil {
+		http.NotFound(w, r)
+		return
+	}
+	proxy.mirrorRegistry(w, r)
+}
+
 func (proxy *Proxy) mirrorRegistry(w http.ResponseWriter, r *http.Request) {
 	reverseProxy := httputil.NewSingleHostReverseProxy(proxy.registry.Remote.URL)
 	t, err := transport.New(
@@ -483,6 +510,7 @@ func (proxy *Proxy) checkWhiteList(r *http.Request) bool {
 // UseHTTPS = true
 func (proxy *Proxy) shouldUseDragonfly(req *http.Request) bool {
 	if req.Method != http.MethodGet {
+		metrics.ProxyRequestCount.WithLabelValues("", metrics.ProxyModeDirect).Inc()
 		return false
 	}
 
@@ -495,16 +523,28 @@ func (proxy *Proxy) shouldUseDragonfly(req *http.Request) bool {
 				req.URL.Host = rule.Redirect
 				req.Host = rule.Redirect
 			}
+			mode := metrics.ProxyModeDragonfly
+			if rule.Direct {
+				mode = metrics.ProxyModeDirect
+			}
+			metrics.ProxyRequestCount.WithLabelValues(rule.Regx.String(), mode).Inc()
 			return !rule.Direct
 		}
 	}
+	metrics.ProxyRequestCount.WithLabelValues("", metrics.ProxyModeDirect).Inc()
 	return false
 }
 
 // shouldUseDragonflyForMirror returns whether we should use dragonfly to proxy a request
 // when we use registry mirror.
 func (proxy *Proxy) shouldUseDragonflyForMirror(req *http.Request) bool {
-	return proxy.registry != nil && !proxy.registry.Direct && transport.NeedUseDragonfly(req)
+	use := proxy.registry != nil && !proxy.registry.Direct && transport.NeedUseDragonfly(req)
+	mode := metrics.ProxyModeDirect
+	if use {
+		mode = metrics.ProxyModeDragonfly
+	}
+	metrics.ProxyRequestCount.WithLabelValues(metrics.ProxyRuleRegistryMirror, mode).Inc()
+	return use
 }
 
 // tunnelHTTPS handles a CONNECT request and proxy an https request through an
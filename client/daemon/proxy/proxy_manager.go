@@ -17,6 +17,7 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -26,6 +27,9 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -36,44 +40,142 @@ import (
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 )
 
+var watchInterval = 10 * time.Second
+
 type Manager interface {
+	config.Observer
 	Serve(lis net.Listener) error
 	Stop() error
 	IsEnabled() bool
+
+	// Reload replaces the proxy rules, whitelist, basic auth, hijack hosts, registry mirror and default filter
+	// with the options atomically, the requests in flight finish with the old ones
+	Reload(opts *config.ProxyOption) error
 }
 
 type proxyManager struct {
 	*http.Server
-	*Proxy
 	config.ListenOption
+
+	peerHost        *scheduler.PeerHost
+	peerTaskManager peer.TaskManager
+	directHandler   http.Handler
+
+	// proxy is the current *Proxy, it's replaced when proxy option changes
+	proxy atomic.Value
+
+	mu sync.Mutex
+	// local is the proxy option from config file, dynamic is the proxy option from manager
+	local   *config.ProxyOption
+	dynamic *config.ProxyOption
+	// applied is the reloadable proxy option of current proxy in json
+	applied []byte
+
+	done chan bool
 }
 
 var _ Manager = (*proxyManager)(nil)
 
 func NewProxyManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager, opts *config.ProxyOption) (Manager, error) {
-	registry := opts.RegistryMirror
-	proxies := opts.Proxies
-	hijackHTTPS := opts.HijackHTTPS
-	whiteList := opts.WhiteList
+	pm := &proxyManager{
+		Server:          &http.Server{},
+		ListenOption:    opts.ListenOption,
+		peerHost:        peerHost,
+		peerTaskManager: peerTaskManager,
+		local:           opts,
+		done:            make(chan bool),
+	}
+	if err := pm.reload(); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
+
+// reloadableOption is the part of proxy option which can be reloaded
+type reloadableOption struct {
+	BasicAuth      *config.BasicAuth      `json:"basicAuth"`
+	DefaultFilter  string                 `json:"defaultFilter"`
+	RegistryMirror *config.RegistryMirror `json:"registryMirror"`
+	WhiteList      []*config.WhiteList    `json:"whiteList"`
+	Proxies        []*config.Proxy        `json:"proxies"`
+	HijackHTTPS    *config.HijackConfig   `json:"hijackHTTPS"`
+}
+
+// effectiveOption returns the local proxy option overridden by the options set in the dynamic one
+func (pm *proxyManager) effectiveOption() *reloadableOption {
+	opts := &reloadableOption{
+		BasicAuth:      pm.local.BasicAuth,
+		DefaultFilter:  pm.local.DefaultFilter,
+		RegistryMirror: pm.local.RegistryMirror,
+		WhiteList:      pm.local.WhiteList,
+		Proxies:        pm.local.Proxies,
+		HijackHTTPS:    pm.local.HijackHTTPS,
+	}
+	dynamic := pm.dynamic
+	if dynamic == nil {
+		return opts
+	}
+	if dynamic.BasicAuth != nil {
+		opts.BasicAuth = dynamic.BasicAuth
+	}
+	if dynamic.DefaultFilter != "" {
+		opts.DefaultFilter = dynamic.DefaultFilter
+	}
+	if dynamic.RegistryMirror != nil {
+		opts.RegistryMirror = dynamic.RegistryMirror
+	}
+	if dynamic.WhiteList != nil {
+		opts.WhiteList = dynamic.WhiteList
+	}
+	if dynamic.Proxies != nil {
+		opts.Proxies = dynamic.Proxies
+	}
+	if dynamic.HijackHTTPS != nil {
+		opts.HijackHTTPS = dynamic.HijackHTTPS
+	}
+	return opts
+}
+
+// reload creates a new proxy with the effective proxy option and replaces the current one when the option changes,
+// it must be called with mu locked except in NewProxyManager
+func (pm *proxyManager) reload() error {
+	opts := pm.effectiveOption()
+	applied, err := json.Marshal(opts)
+	if err != nil {
+		return errors.Wrap(err, "marshal proxy option")
+	}
+	if bytes.Equal(applied, pm.applied) {
+		return nil
+	}
 
 	options := []Option{
-		WithPeerHost(peerHost),
-		WithPeerTaskManager(peerTaskManager),
-		WithRules(proxies),
-		WithWhiteList(whiteList),
-		WithMaxConcurrency(opts.MaxConcurrency),
+		WithPeerHost(pm.peerHost),
+		WithPeerTaskManager(pm.peerTaskManager),
+		WithRules(opts.Proxies),
+		WithWhiteList(opts.WhiteList),
 		WithDefaultFilter(opts.DefaultFilter),
 		WithBasicAuth(opts.BasicAuth),
 	}
 
-	if registry != nil {
-		logger.Infof("registry mirror: %s", registry.Remote)
-		options = append(options, WithRegistryMirror(registry))
+	// the max concurrency can not be changed at runtime
+	if current, ok := pm.proxy.Load().(*Proxy); ok {
+		options = append(options, withSemaphore(current.semaphore))
+	} else {
+		options = append(options, WithMaxConcurrency(pm.local.MaxConcurrency))
+	}
+
+	if pm.directHandler != nil {
+		options = append(options, WithDirectHandler(pm.directHandler))
 	}
 
-	if len(proxies) > 0 {
-		logger.Infof("load %d proxy rules", len(proxies))
-		for i, r := range proxies {
+	if opts.RegistryMirror != nil {
+		logger.Infof("registry mirror: %s", opts.RegistryMirror.Remote)
+		options = append(options, WithRegistryMirror(opts.RegistryMirror))
+	}
+
+	if len(opts.Proxies) > 0 {
+		logger.Infof("load %d proxy rules", len(opts.Proxies))
+		for i, r := range opts.Proxies {
 			method := "with dragonfly"
 			if r.Direct {
 				method = "directly"
@@ -86,12 +188,12 @@ func NewProxyManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskMana
 		}
 	}
 
-	if hijackHTTPS != nil {
+	if hijackHTTPS := opts.HijackHTTPS; hijackHTTPS != nil {
 		options = append(options, WithHTTPSHosts(hijackHTTPS.Hosts...))
 		if hijackHTTPS.Cert != "" && hijackHTTPS.Key != "" {
 			cert, err := certFromFile(hijackHTTPS.Cert, hijackHTTPS.Key)
 			if err != nil {
-				return nil, errors.Wrap(err, "cert from file")
+				return errors.Wrap(err, "cert from file")
 			}
 
 			options = append(options, WithCert(cert))
@@ -100,23 +202,107 @@ func NewProxyManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskMana
 
 	p, err := NewProxy(options...)
 	if err != nil {
-		return nil, errors.Wrap(err, "create proxy")
+		return errors.Wrap(err, "create proxy")
+	}
+
+	if pm.applied != nil {
+		logger.Infof("proxy option reloaded")
+	}
+	pm.proxy.Store(p)
+	pm.applied = applied
+	return nil
+}
+
+func (pm *proxyManager) Reload(opts *config.ProxyOption) error {
+	if err := opts.Validate(); err != nil {
+		return errors.Wrap(err, "invalid proxy option")
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	local := pm.local
+	pm.local = opts
+	if err := pm.reload(); err != nil {
+		pm.local = local
+		return err
+	}
+	return nil
+}
+
+func (pm *proxyManager) OnNotify(dynamic *config.DynamicOption) {
+	if dynamic.Proxy != nil {
+		if err := dynamic.Proxy.Validate(); err != nil {
+			logger.Errorf("invalid proxy option from manager: %s", err)
+			return
+		}
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	previous := pm.dynamic
+	pm.dynamic = dynamic.Proxy
+	if err := pm.reload(); err != nil {
+		logger.Errorf("reload proxy option from manager error: %s", err)
+		pm.dynamic = previous
+	}
+}
+
+// watch reloads the proxy option when the config file changes
+func (pm *proxyManager) watch(path string) {
+	stat, _ := os.Stat(path)
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			current, err := os.Stat(path)
+			if err != nil {
+				logger.Warnf("stat proxy config file %s error: %s", path, err)
+				continue
+			}
+			if stat != nil && current.ModTime().Equal(stat.ModTime()) && current.Size() == stat.Size() {
+				continue
+			}
+			stat = current
+
+			opts, err := config.LoadProxyOption(path)
+			if err != nil {
+				logger.Errorf("load proxy config file %s error: %s", path, err)
+				continue
+			}
+			if err := pm.Reload(opts); err != nil {
+				logger.Errorf("reload proxy config file %s error: %s", path, err)
+			}
+		case <-pm.done:
+			return
+		}
 	}
+}
 
-	return &proxyManager{
-		Server:       &http.Server{},
-		Proxy:        p,
-		ListenOption: opts.ListenOption,
-	}, nil
+func (pm *proxyManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pm.proxy.Load().(*Proxy).ServeHTTP(w, r)
 }
 
 func (pm *proxyManager) Serve(lis net.Listener) error {
-	_ = WithDirectHandler(newDirectHandler())(pm.Proxy)
-	pm.Server.Handler = pm.Proxy
+	pm.mu.Lock()
+	pm.directHandler = newDirectHandler()
+	_ = WithDirectHandler(pm.directHandler)(pm.proxy.Load().(*Proxy))
+	pm.mu.Unlock()
+
+	if path := pm.local.ConfigFile; path != "" {
+		logger.Infof("watch proxy config file %s", path)
+		go pm.watch(path)
+	}
+
+	pm.Server.Handler = pm
 	return pm.Server.Serve(lis)
 }
 
 func (pm *proxyManager) Stop() error {
+	close(pm.done)
 	return pm.Server.Shutdown(context.Background())
 }
 
@@ -128,6 +314,8 @@ func newDirectHandler() *http.ServeMux {
 	s := http.DefaultServeMux
 	s.HandleFunc("/args", getArgs)
 	s.HandleFunc("/env", getEnv)
+	// the root handler is the registry mirror reverse proxy
+	s.HandleFunc("/", mirrorRegistry)
 	return s
 }
 
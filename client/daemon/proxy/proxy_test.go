@@ -167,3 +167,45 @@ func TestMatch(t *testing.T) {
 		WithTest("http://index.docker.io/v2/blobs/sha256/xxx", true, false, "").
 		TestMirror(t)
 }
+
+func TestProxyManagerReload(t *testing.T) {
+	a := assert.New(t)
+
+	rule, err := config.NewProxy("/blobs/sha256/", false, false, "")
+	a.Nil(err)
+	m, err := NewProxyManager(nil, nil, &config.ProxyOption{
+		Proxies:        []*config.Proxy{rule},
+		MaxConcurrency: 8,
+	})
+	if !a.Nil(err) {
+		return
+	}
+	pm := m.(*proxyManager)
+	old := pm.proxy.Load().(*Proxy)
+
+	req, _ := http.NewRequest("GET", "http://h/a/b", nil)
+	a.False(old.shouldUseDragonfly(req))
+
+	// the same option does not create a new proxy
+	a.Nil(pm.Reload(&config.ProxyOption{Proxies: []*config.Proxy{rule}}))
+	a.Equal(old, pm.proxy.Load().(*Proxy))
+
+	rule, err = config.NewProxy("/a/", false, false, "")
+	a.Nil(err)
+	a.Nil(pm.Reload(&config.ProxyOption{Proxies: []*config.Proxy{rule}}))
+	current := pm.proxy.Load().(*Proxy)
+	a.NotEqual(old, current)
+	a.True(current.shouldUseDragonfly(req))
+	a.Equal(old.semaphore, current.semaphore)
+	// requests in flight keep using the old proxy
+	a.False(old.shouldUseDragonfly(req))
+	a.Nil(old.basicAuth)
+
+	// options from manager override the local ones
+	pm.OnNotify(&config.DynamicOption{
+		Proxy: &config.ProxyOption{BasicAuth: &config.BasicAuth{Username: "foo", Password: "bar"}},
+	})
+	current = pm.proxy.Load().(*Proxy)
+	a.Equal("foo", current.basicAuth.Username)
+	a.True(current.shouldUseDragonfly(req))
+}
@@ -0,0 +1,286 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+var scheduleInterval = 10 * time.Second
+
+// Manager holds the rate limiters of daemon, the limits in daemon config are overridden by
+// the client config from manager, then by the active schedules, then by the limits set through api
+type Manager interface {
+	config.Observer
+
+	// DownloadLimiter limits the total download from other peers
+	DownloadLimiter() *rate.Limiter
+
+	// BackSourceLimiter limits the total download from source, it's the download limiter when back source limit is not set
+	BackSourceLimiter() *rate.Limiter
+
+	// UploadLimiter limits the total upload to other peers
+	UploadLimiter() *rate.Limiter
+
+	// PerPeerLimit returns the download limit of every peer task
+	PerPeerLimit() rate.Limit
+
+	// Get returns the effective rate limits
+	Get() config.RateLimits
+
+	// Set overrides the rate limits, the zero limits are unchanged and the negative limits stand no limit,
+	// reset clears the limits set before
+	Set(limits config.RateLimits, reset bool) config.RateLimits
+
+	// Start applies the schedules in background
+	Start()
+
+	Stop()
+}
+
+type manager struct {
+	sync.RWMutex
+	// base is the rate limits in daemon config
+	base      config.RateLimits
+	schedules []config.RateLimitSchedule
+	// dynamic is the client config from manager
+	dynamic *config.DynamicOption
+	// override is the rate limits set through api
+	override  config.RateLimits
+	effective config.RateLimits
+
+	download   *rate.Limiter
+	backSource *rate.Limiter
+	upload     *rate.Limiter
+	// backSourceShared indicates back source shares the download limiter when back source limit is not set
+	backSourceShared bool
+
+	now  func() time.Time
+	done chan bool
+}
+
+var _ Manager = (*manager)(nil)
+
+func NewManager(opt *config.PeerHostOption) Manager {
+	m := &manager{
+		base: config.RateLimits{
+			TotalDownload: opt.Download.TotalRateLimit,
+			PerPeer:       opt.Download.PerPeerRateLimit,
+			BackSource:    opt.Download.BackSourceRateLimit,
+			Upload:        opt.Upload.RateLimit,
+		},
+		schedules:  opt.RateLimitSchedules,
+		download:   NewLimiter(0),
+		backSource: NewLimiter(0),
+		upload:     NewLimiter(0),
+		now:        time.Now,
+		done:       make(chan bool),
+	}
+	m.apply()
+	return m
+}
+
+// NewLimiter returns a limiter whose burst is same with limit, the limit less than or equal to 0 stands no limit
+func NewLimiter(limit rate.Limit) *rate.Limiter {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	SetLimit(limiter, limit)
+	return limiter
+}
+
+// SetLimit changes the limit and burst of limiter, the limit less than or equal to 0 stands no limit
+func SetLimit(limiter *rate.Limiter, limit rate.Limit) {
+	if limit <= 0 {
+		limit = rate.Inf
+	}
+	if limiter.Limit() == limit {
+		return
+	}
+	burst := 0
+	if limit != rate.Inf {
+		burst = int(limit)
+		// at least one event is permitted with the limit less than 1
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	limiter.SetLimit(limit)
+	limiter.SetBurst(burst)
+}
+
+// WaitN blocks until limiter permits n events, the burst of limiter is same with limit which may be
+// less than the piece size, so the events are acquired in chunks not larger than burst
+func WaitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		chunk := n
+		// the burst may be changed at runtime, check it every time
+		if burst := limiter.Burst(); limiter.Limit() != rate.Inf && burst > 0 && chunk > burst {
+			chunk = burst
+		}
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// override returns the limits which are overridden by the limits set in o, the negative limit stands no limit
+func override(limits, o config.RateLimits) config.RateLimits {
+	for _, l := range []struct {
+		dst *clientutil.RateLimit
+		src clientutil.RateLimit
+	}{
+		{&limits.TotalDownload, o.TotalDownload},
+		{&limits.PerPeer, o.PerPeer},
+		{&limits.BackSource, o.BackSource},
+		{&limits.Upload, o.Upload},
+	} {
+		if l.src.Limit != 0 {
+			*l.dst = l.src
+		}
+	}
+	return limits
+}
+
+// apply calculates the effective limits and updates the limiters
+func (m *manager) apply() {
+	m.Lock()
+	defer m.Unlock()
+
+	limits, schedules := m.base, m.schedules
+	if m.dynamic != nil {
+		limits = override(limits, m.dynamic.RateLimits)
+		if m.dynamic.RateLimitSchedules != nil {
+			schedules = m.dynamic.RateLimitSchedules
+		}
+	}
+	// the former schedule takes precedence when the time windows overlap
+	now := m.now()
+	for i := len(schedules) - 1; i >= 0; i-- {
+		if schedules[i].Active(now) {
+			limits = override(limits, schedules[i].RateLimits)
+		}
+	}
+	limits = override(limits, m.override)
+
+	// back to source shares the limiter of total download when it's not set
+	backSourceShared := limits.BackSource.Limit == 0
+	if backSourceShared {
+		limits.BackSource = limits.TotalDownload
+	}
+	for _, l := range []*clientutil.RateLimit{&limits.TotalDownload, &limits.PerPeer, &limits.BackSource, &limits.Upload} {
+		if l.Limit <= 0 {
+			l.Limit = rate.Inf
+		}
+	}
+
+	if limits != m.effective {
+		logger.Infof("rate limits changed, total download: %s, per peer: %s, back source: %s, upload: %s",
+			format(limits.TotalDownload.Limit), format(limits.PerPeer.Limit),
+			format(limits.BackSource.Limit), format(limits.Upload.Limit))
+	}
+	m.effective = limits
+	m.backSourceShared = backSourceShared
+	SetLimit(m.download, limits.TotalDownload.Limit)
+	SetLimit(m.backSource, limits.BackSource.Limit)
+	SetLimit(m.upload, limits.Upload.Limit)
+}
+
+func format(limit rate.Limit) string {
+	if limit == rate.Inf {
+		return "unlimited"
+	}
+	return units.BytesSize(float64(limit)) + "/s"
+}
+
+func (m *manager) DownloadLimiter() *rate.Limiter {
+	return m.download
+}
+
+func (m *manager) BackSourceLimiter() *rate.Limiter {
+	m.RLock()
+	defer m.RUnlock()
+	if m.backSourceShared {
+		return m.download
+	}
+	return m.backSource
+}
+
+func (m *manager) UploadLimiter() *rate.Limiter {
+	return m.upload
+}
+
+func (m *manager) PerPeerLimit() rate.Limit {
+	m.RLock()
+	defer m.RUnlock()
+	return m.effective.PerPeer.Limit
+}
+
+func (m *manager) Get() config.RateLimits {
+	m.RLock()
+	defer m.RUnlock()
+	return m.effective
+}
+
+func (m *manager) Set(limits config.RateLimits, reset bool) config.RateLimits {
+	m.Lock()
+	if reset {
+		m.override = config.RateLimits{}
+	}
+	m.override = override(m.override, limits)
+	m.Unlock()
+
+	m.apply()
+	return m.Get()
+}
+
+func (m *manager) OnNotify(dynamic *config.DynamicOption) {
+	m.Lock()
+	m.dynamic = dynamic
+	m.Unlock()
+
+	m.apply()
+}
+
+func (m *manager) Start() {
+	go func() {
+		tick := time.NewTicker(scheduleInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				m.apply()
+			case <-m.done:
+				logger.Infof("rate limit manager exited")
+				return
+			}
+		}
+	}()
+}
+
+func (m *manager) Stop() {
+	close(m.done)
+}
@@ -0,0 +1,164 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+)
+
+func limit(l rate.Limit) clientutil.RateLimit {
+	return clientutil.RateLimit{Limit: l}
+}
+
+func TestRateLimitManager(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	opt := &config.PeerHostOption{
+		Download: config.DownloadOption{
+			TotalRateLimit:   limit(100),
+			PerPeerRateLimit: limit(20),
+		},
+		Upload: config.UploadOption{
+			RateLimit: limit(50),
+		},
+		RateLimitSchedules: []config.RateLimitSchedule{
+			{
+				Start:      "09:00",
+				End:        "18:00",
+				RateLimits: config.RateLimits{Upload: limit(10)},
+			},
+			{
+				Start:      "22:00",
+				End:        "02:00",
+				RateLimits: config.RateLimits{TotalDownload: limit(1000)},
+			},
+		},
+	}
+	m := NewManager(opt).(*manager)
+	now := time.Date(2021, 1, 1, 8, 0, 0, 0, time.Local)
+	m.now = func() time.Time {
+		return now
+	}
+	m.apply()
+
+	// back source shares the limit of total download when it's not set
+	assert.Equal(config.RateLimits{
+		TotalDownload: limit(100),
+		PerPeer:       limit(20),
+		BackSource:    limit(100),
+		Upload:        limit(50),
+	}, m.Get())
+	assert.Equal(rate.Limit(100), m.DownloadLimiter().Limit())
+	assert.Equal(rate.Limit(100), m.BackSourceLimiter().Limit())
+	assert.Equal(100, m.BackSourceLimiter().Burst())
+	assert.True(m.BackSourceLimiter() == m.DownloadLimiter(), "back source shares the download limiter")
+	assert.Equal(rate.Limit(20), m.PerPeerLimit())
+
+	// schedule in business hours
+	now = time.Date(2021, 1, 1, 10, 0, 0, 0, time.Local)
+	m.apply()
+	assert.Equal(rate.Limit(10), m.UploadLimiter().Limit())
+	assert.Equal(10, m.UploadLimiter().Burst())
+
+	// schedule crosses midnight
+	now = time.Date(2021, 1, 1, 1, 0, 0, 0, time.Local)
+	m.apply()
+	assert.Equal(rate.Limit(50), m.UploadLimiter().Limit())
+	assert.Equal(rate.Limit(1000), m.DownloadLimiter().Limit())
+
+	// client config from manager replaces the schedules
+	m.OnNotify(&config.DynamicOption{
+		RateLimits: config.RateLimits{
+			BackSource: limit(30),
+			Upload:     limit(40),
+		},
+		RateLimitSchedules: []config.RateLimitSchedule{},
+	})
+	assert.Equal(config.RateLimits{
+		TotalDownload: limit(100),
+		PerPeer:       limit(20),
+		BackSource:    limit(30),
+		Upload:        limit(40),
+	}, m.Get())
+	assert.False(m.BackSourceLimiter() == m.DownloadLimiter(), "back source is limited separately when it's set")
+	assert.Equal(rate.Limit(30), m.BackSourceLimiter().Limit())
+
+	// limits set through api take precedence
+	limits := m.Set(config.RateLimits{PerPeer: limit(rate.Inf), Upload: limit(5)}, false)
+	assert.Equal(rate.Inf, limits.PerPeer.Limit)
+	assert.Equal(rate.Limit(5), m.UploadLimiter().Limit())
+	limits = m.Set(config.RateLimits{TotalDownload: limit(200)}, false)
+	assert.Equal(rate.Limit(200), limits.TotalDownload.Limit)
+	assert.Equal(rate.Limit(5), limits.Upload.Limit)
+	// negative limit stands no limit
+	limits = m.Set(config.RateLimits{Upload: limit(-1)}, false)
+	assert.Equal(rate.Inf, limits.Upload.Limit)
+	assert.Equal(rate.Inf, m.UploadLimiter().Limit())
+
+	// clear the limits set through api
+	limits = m.Set(config.RateLimits{}, true)
+	assert.Equal(config.RateLimits{
+		TotalDownload: limit(100),
+		PerPeer:       limit(20),
+		BackSource:    limit(30),
+		Upload:        limit(40),
+	}, limits)
+}
+
+func TestSetLimit(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	limiter := NewLimiter(0)
+	assert.Equal(rate.Inf, limiter.Limit())
+	assert.True(limiter.AllowN(time.Now(), 1024*1024), "no limit")
+
+	SetLimit(limiter, 1024)
+	assert.Equal(rate.Limit(1024), limiter.Limit())
+	assert.Equal(1024, limiter.Burst())
+}
+
+func TestWaitN(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	// the events more than burst are acquired in chunks
+	limiter := NewLimiter(1000)
+	assert.NotNil(limiter.WaitN(context.Background(), 1500), "more than burst")
+	start := time.Now()
+	assert.Nil(WaitN(context.Background(), limiter, 1500))
+	assert.True(time.Since(start) >= 400*time.Millisecond, "wait for the tokens more than burst")
+
+	// the limit less than 1 permits one event at least
+	SetLimit(limiter, 0.5)
+	assert.Equal(1, limiter.Burst())
+
+	// no limit
+	SetLimit(limiter, 0)
+	assert.Nil(WaitN(context.Background(), limiter, 1024*1024))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	SetLimit(limiter, 1000)
+	assert.NotNil(WaitN(ctx, limiter, 1500), "context canceled")
+}
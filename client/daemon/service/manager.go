@@ -19,27 +19,46 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	grpcpeer "google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/idgen"
 	"d7y.io/dragonfly/v2/internal/rpc"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	dfdaemongrpc "d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
 	dfdaemonserver "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/server"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
+// taskDataBufferSize is the max data size in one TaskData message, it's less than the default grpc message limit
+const taskDataBufferSize = 512 * 1024
+
+// pieceSyncBatchSize is the max piece count in one pushed PiecePacket
+const pieceSyncBatchSize = 64
+
+// pieceSyncCheckInterval is the interval of checking the dst peer task when its pieces do not change
+var pieceSyncCheckInterval = time.Second
+
 type Manager interface {
 	clientutil.KeepAlive
 	ServeDownload(listener net.Listener) error
@@ -52,6 +71,7 @@ type manager struct {
 	peerHost        *scheduler.PeerHost
 	peerTaskManager peer.TaskManager
 	storageManager  storage.Manager
+	rateLimits      ratelimit.Manager
 
 	downloadServer rpc.Server
 	peerServer     rpc.Server
@@ -61,20 +81,79 @@ type manager struct {
 var _ dfdaemonserver.DaemonServer = (*manager)(nil)
 var _ Manager = (*manager)(nil)
 
-func NewManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager, storageManager storage.Manager, downloadOpts []grpc.ServerOption, peerOpts []grpc.ServerOption) (Manager, error) {
+func NewManager(peerHost *scheduler.PeerHost, peerTaskManager peer.TaskManager, storageManager storage.Manager,
+	rateLimits ratelimit.Manager, downloadOpts []grpc.ServerOption, peerOpts []grpc.ServerOption) (Manager, error) {
 	mgr := &manager{
 		KeepAlive:       clientutil.NewKeepAlive("service manager"),
 		peerHost:        peerHost,
 		peerTaskManager: peerTaskManager,
 		storageManager:  storageManager,
+		rateLimits:      rateLimits,
 	}
 	mgr.downloadServer = rpc.NewServer(mgr, downloadOpts...)
-	mgr.peerServer = rpc.NewServer(mgr, peerOpts...)
+	mgr.peerServer = rpc.NewServer(&peerManager{manager: mgr}, peerOpts...)
 	return mgr, nil
 }
 
+// peerManager is the daemon service served on the peer port, which is reachable by any host.
+// The task admin rpcs are only served on the download unix socket.
+type peerManager struct {
+	*manager
+}
+
+var _ dfdaemonserver.DaemonServer = (*peerManager)(nil)
+
+// errPeerPermissionDenied is returned when the rpcs only for local clients are called on the peer port
+var errPeerPermissionDenied = status.Error(codes.PermissionDenied, "only allowed through the download unix socket")
+
+func (pm *peerManager) ListTasks(context.Context, *dfdaemongrpc.ListTasksRequest) (*dfdaemongrpc.ListTasksResult, error) {
+	return nil, errPeerPermissionDenied
+}
+
+func (pm *peerManager) StatTask(context.Context, *dfdaemongrpc.TaskTarget) (*dfdaemongrpc.TaskInfo, error) {
+	return nil, errPeerPermissionDenied
+}
+
+func (pm *peerManager) CancelTask(context.Context, *dfdaemongrpc.TaskTarget) error {
+	return errPeerPermissionDenied
+}
+
+func (pm *peerManager) DeleteTask(context.Context, *dfdaemongrpc.TaskTarget) error {
+	return errPeerPermissionDenied
+}
+
+func (pm *peerManager) ImportTask(context.Context, *dfdaemongrpc.ImportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	return nil, errPeerPermissionDenied
+}
+
+func (pm *peerManager) ExportTask(context.Context, *dfdaemongrpc.ExportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	return nil, errPeerPermissionDenied
+}
+
+// ReadTask is denied on peer port, peers download pieces with piece token from upload server
+func (pm *peerManager) ReadTask(context.Context, *dfdaemongrpc.ExportTaskRequest, chan<- *dfdaemongrpc.TaskData) error {
+	return errPeerPermissionDenied
+}
+
+func (pm *peerManager) PinTask(context.Context, *dfdaemongrpc.PinTaskRequest) error {
+	return errPeerPermissionDenied
+}
+
+func (pm *peerManager) StreamDownload(context.Context, *dfdaemongrpc.DownRequest, chan<- *dfdaemongrpc.TaskData) error {
+	return errPeerPermissionDenied
+}
+
+func (pm *peerManager) GetRateLimits(context.Context) (*dfdaemongrpc.RateLimits, error) {
+	return nil, errPeerPermissionDenied
+}
+
+func (pm *peerManager) SetRateLimits(context.Context, *dfdaemongrpc.SetRateLimitsRequest) (*dfdaemongrpc.RateLimits, error) {
+	return nil, errPeerPermissionDenied
+}
+
+// ServeDownload serves on the unix socket listener, the peer credential of connection is used to check the output of export
 func (m *manager) ServeDownload(listener net.Listener) error {
-	return m.downloadServer.Serve(listener)
+	return m.downloadServer.Serve(clientutil.NewPeerCredListener(listener))
 }
 
 func (m *manager) ServePeer(listener net.Listener) error {
@@ -128,11 +207,481 @@ func (m *manager) GetPieceTasks(ctx context.Context, request *base.PieceTaskRequ
 	return p, nil
 }
 
+// pieceSync stands the pieces sent to the subscriber
+type pieceSync struct {
+	request *base.PieceTaskRequest
+	// next is the first piece number not sent since request.StartNum
+	next          int32
+	sent          map[int32]bool
+	totalPiece    int32
+	contentLength int64
+	pieceMd5Sign  string
+}
+
+func (m *manager) SyncPieceTasks(ctx context.Context, request *base.PieceTaskRequest, ppc chan<- *base.PiecePacket) error {
+	m.Keep()
+	logger.Infof("receive sync piece tasks request, task id: %s, src peer: %s, dst peer: %s, piece num: %d",
+		request.TaskId, request.SrcPid, request.DstPid, request.StartNum)
+	ps := &pieceSync{
+		request:    request,
+		next:       request.StartNum,
+		sent:       map[int32]bool{},
+		totalPiece: -1,
+	}
+	meta := storage.PeerTaskMetaData{
+		TaskID: request.TaskId,
+		PeerID: request.DstPid,
+	}
+	ticker := time.NewTicker(pieceSyncCheckInterval)
+	defer ticker.Stop()
+	for {
+		// watch before reading pieces, the pieces written during pushing are not missed
+		changed, err := m.storageManager.WatchTask(meta)
+		if err != nil && err != storage.ErrTaskNotFound {
+			return dferrors.New(dfcodes.UnknownError, err.Error())
+		}
+		running := m.peerTaskManager.IsPeerTaskRunning(request.DstPid)
+		if err == storage.ErrTaskNotFound {
+			if !running {
+				return dferrors.New(dfcodes.PeerTaskNotFound, "peer task not found")
+			}
+		} else if err = m.pushPieces(ctx, ps, ppc); err != nil {
+			return err
+		}
+
+		if ps.totalPiece > 0 && ps.next >= ps.totalPiece && (ps.pieceMd5Sign != "" || !running) {
+			// all pieces are sent, send the piece md5 sign and finish syncing
+			logger.Infof("all pieces are synced, task id: %s, src peer: %s, dst peer: %s, total piece: %d",
+				request.TaskId, request.SrcPid, request.DstPid, ps.totalPiece)
+			return m.sendPiecePacket(ctx, ps, nil, ppc)
+		}
+		if !running {
+			return dferrors.New(dfcodes.PeerTaskNotFound, "peer task is not running and pieces are incomplete")
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pushPieces sends the pieces in storage which are not sent
+func (m *manager) pushPieces(ctx context.Context, ps *pieceSync, ppc chan<- *base.PiecePacket) error {
+	for num := ps.next; ps.totalPiece <= 0 || num < ps.totalPiece; num += pieceSyncBatchSize {
+		p, err := m.storageManager.GetPieces(ctx, &base.PieceTaskRequest{
+			TaskId:   ps.request.TaskId,
+			SrcPid:   ps.request.SrcPid,
+			DstPid:   ps.request.DstPid,
+			StartNum: num,
+			Limit:    pieceSyncBatchSize,
+		})
+		if err == dferrors.ErrInvalidArgument {
+			// start num exceeds total piece
+			break
+		}
+		if err != nil {
+			return dferrors.New(dfcodes.UnknownError, err.Error())
+		}
+		ps.totalPiece, ps.contentLength, ps.pieceMd5Sign = p.TotalPiece, p.ContentLength, p.PieceMd5Sign
+
+		var pieces []*base.PieceInfo
+		for _, piece := range p.PieceInfos {
+			if !ps.sent[piece.PieceNum] {
+				ps.sent[piece.PieceNum] = true
+				pieces = append(pieces, piece)
+			}
+		}
+		if len(pieces) > 0 {
+			if err = m.sendPiecePacket(ctx, ps, pieces, ppc); err != nil {
+				return err
+			}
+		}
+		// the total piece is unknown, stop at the first empty batch
+		if ps.totalPiece <= 0 && len(p.PieceInfos) == 0 {
+			break
+		}
+	}
+	for ps.sent[ps.next] {
+		ps.next++
+	}
+	return nil
+}
+
+func (m *manager) sendPiecePacket(ctx context.Context, ps *pieceSync, pieces []*base.PieceInfo, ppc chan<- *base.PiecePacket) error {
+	select {
+	case ppc <- &base.PiecePacket{
+		TaskId:        ps.request.TaskId,
+		DstPid:        ps.request.DstPid,
+		DstAddr:       m.uploadAddr,
+		PieceInfos:    pieces,
+		TotalPiece:    ps.totalPiece,
+		ContentLength: ps.contentLength,
+		PieceMd5Sign:  ps.pieceMd5Sign,
+	}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (m *manager) CheckHealth(context.Context) error {
 	m.Keep()
 	return nil
 }
 
+func (m *manager) ListTasks(ctx context.Context, req *dfdaemongrpc.ListTasksRequest) (*dfdaemongrpc.ListTasksResult, error) {
+	m.Keep()
+	var (
+		tasks   []*dfdaemongrpc.TaskInfo
+		running = map[string]bool{}
+	)
+	for _, pt := range m.peerTaskManager.ListRunningPeerTasks() {
+		running[pt.GetPeerID()] = true
+		tasks = append(tasks, runningTaskInfo(pt))
+	}
+	if req.RunningOnly {
+		return &dfdaemongrpc.ListTasksResult{Tasks: tasks}, nil
+	}
+	for _, stat := range m.storageManager.ListTasks() {
+		if running[stat.PeerID] {
+			continue
+		}
+		tasks = append(tasks, storedTaskInfo(stat))
+	}
+	return &dfdaemongrpc.ListTasksResult{Tasks: tasks}, nil
+}
+
+func (m *manager) StatTask(ctx context.Context, req *dfdaemongrpc.TaskTarget) (*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	if req.TaskId == "" && req.PeerId == "" {
+		return nil, dferrors.New(dfcodes.BadRequest, "empty task id and peer id")
+	}
+	for _, pt := range m.peerTaskManager.ListRunningPeerTasks() {
+		if matchTaskTarget(req, pt.GetTaskID(), pt.GetPeerID()) {
+			return runningTaskInfo(pt), nil
+		}
+	}
+	for _, stat := range m.storageManager.ListTasks() {
+		if matchTaskTarget(req, stat.TaskID, stat.PeerID) {
+			return storedTaskInfo(stat), nil
+		}
+	}
+	return nil, dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("task %s/%s not found", req.TaskId, req.PeerId))
+}
+
+func (m *manager) CancelTask(ctx context.Context, req *dfdaemongrpc.TaskTarget) error {
+	m.Keep()
+	if req.TaskId == "" && req.PeerId == "" {
+		return dferrors.New(dfcodes.BadRequest, "empty task id and peer id")
+	}
+	var canceled int
+	for _, pt := range m.peerTaskManager.ListRunningPeerTasks() {
+		if !matchTaskTarget(req, pt.GetTaskID(), pt.GetPeerID()) {
+			continue
+		}
+		if m.peerTaskManager.CancelPeerTask(pt.GetPeerID(), "canceled by daemon client") {
+			logger.Infof("task %s/%s canceled", pt.GetTaskID(), pt.GetPeerID())
+			canceled++
+		}
+	}
+	if canceled == 0 {
+		return dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("running task %s/%s not found", req.TaskId, req.PeerId))
+	}
+	return nil
+}
+
+func (m *manager) DeleteTask(ctx context.Context, req *dfdaemongrpc.TaskTarget) error {
+	m.Keep()
+	if req.TaskId == "" {
+		return dferrors.New(dfcodes.BadRequest, "empty task id")
+	}
+	// running task data is still used by peer task, cancel it first
+	for _, pt := range m.peerTaskManager.ListRunningPeerTasks() {
+		if matchTaskTarget(req, pt.GetTaskID(), pt.GetPeerID()) {
+			return dferrors.New(dfcodes.BadRequest, fmt.Sprintf("task %s/%s is running", pt.GetTaskID(), pt.GetPeerID()))
+		}
+	}
+	err := m.storageManager.DeleteTask(ctx, storage.PeerTaskMetaData{
+		TaskID: req.TaskId,
+		PeerID: req.PeerId,
+	})
+	if err == storage.ErrTaskNotFound {
+		return dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("task %s/%s not found", req.TaskId, req.PeerId))
+	}
+	if err != nil {
+		return dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	return nil
+}
+
+func (m *manager) PinTask(ctx context.Context, req *dfdaemongrpc.PinTaskRequest) error {
+	m.Keep()
+	if req.Target == nil || req.Target.TaskId == "" {
+		return dferrors.New(dfcodes.BadRequest, "empty task id")
+	}
+	err := m.storageManager.PinTask(ctx, storage.PeerTaskMetaData{
+		TaskID: req.Target.TaskId,
+		PeerID: req.Target.PeerId,
+	}, req.Pinned)
+	if err == storage.ErrTaskNotFound {
+		return dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("task %s/%s not found", req.Target.TaskId, req.Target.PeerId))
+	}
+	if err != nil {
+		return dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	return nil
+}
+
+func (m *manager) ImportTask(ctx context.Context, req *dfdaemongrpc.ImportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	if (req.Url == "" && req.TaskId == "") || req.Path == "" {
+		return nil, dferrors.New(dfcodes.BadRequest, "empty url or path")
+	}
+	// task id is used as directory name in storage
+	if req.TaskId != "" && (filepath.Base(req.TaskId) != req.TaskId || req.TaskId == "..") {
+		return nil, dferrors.New(dfcodes.BadRequest, fmt.Sprintf("invalid task id %q", req.TaskId))
+	}
+	path, err := checkImportPath(req.Path)
+	if err != nil {
+		return nil, dferrors.New(dfcodes.BadRequest, err.Error())
+	}
+	if req.UrlMeta == nil {
+		req.UrlMeta = &base.UrlMeta{}
+	}
+	result, err := m.peerTaskManager.ImportTask(ctx, &peer.ImportTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      req.Url,
+			Filter:   req.UrlMeta.Filter,
+			BizId:    req.UrlMeta.Tag,
+			UrlMeta:  req.UrlMeta,
+			PeerId:   clientutil.GenPeerID(m.peerHost),
+			PeerHost: m.peerHost,
+		},
+		Path:   path,
+		TaskID: req.TaskId,
+	})
+	if err != nil {
+		return nil, dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	return &dfdaemongrpc.TaskInfo{
+		TaskId:          result.TaskID,
+		PeerId:          result.PeerID,
+		Done:            true,
+		ContentLength:   result.ContentLength,
+		CompletedLength: uint64(result.ContentLength),
+		TotalPiece:      result.TotalPieces,
+		AccessTime:      time.Now().UnixNano(),
+	}, nil
+}
+
+func (m *manager) ExportTask(ctx context.Context, req *dfdaemongrpc.ExportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	if !filepath.IsAbs(req.Output) {
+		return nil, dferrors.New(dfcodes.BadRequest, "output must be a full path")
+	}
+	// the output is written by daemon, only allow the output which the caller can write
+	var cred *clientutil.PeerCred
+	if p, ok := grpcpeer.FromContext(ctx); ok {
+		cred, _ = clientutil.PeerCredFromAddr(p.Addr)
+	}
+	if cred == nil {
+		return nil, status.Error(codes.PermissionDenied, "unknown caller, export is only allowed through the download unix socket")
+	}
+	if err := clientutil.CheckWritable(cred, req.Output); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	stat, err := m.findCompletedTask(req)
+	if err != nil {
+		return nil, err
+	}
+	err = m.storageManager.Store(ctx, &storage.StoreRequest{
+		CommonTaskRequest: storage.CommonTaskRequest{
+			PeerID:      stat.PeerID,
+			TaskID:      stat.TaskID,
+			Destination: req.Output,
+		},
+		StoreOnly: true,
+		Digest:    req.UrlMeta.GetDigest(),
+	})
+	if err != nil {
+		logger.Errorf("export task %s/%s to %s error: %s", stat.TaskID, stat.PeerID, req.Output, err)
+		return nil, dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	logger.Infof("export task %s/%s to %s", stat.TaskID, stat.PeerID, req.Output)
+	return storedTaskInfo(stat), nil
+}
+
+func (m *manager) ReadTask(ctx context.Context, req *dfdaemongrpc.ExportTaskRequest, results chan<- *dfdaemongrpc.TaskData) error {
+	m.Keep()
+	stat, err := m.findCompletedTask(req)
+	if err != nil {
+		return err
+	}
+	rc, err := m.storageManager.ReadAllPieces(ctx, &stat.PeerTaskMetaData)
+	if err != nil {
+		return dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	defer rc.Close()
+
+	if err = sendTaskData(ctx, io.LimitReader(rc, stat.ContentLength), results); err != nil {
+		logger.Errorf("read task %s/%s error: %s", stat.TaskID, stat.PeerID, err)
+		return err
+	}
+	return nil
+}
+
+func (m *manager) StreamDownload(ctx context.Context, req *dfdaemongrpc.DownRequest, results chan<- *dfdaemongrpc.TaskData) error {
+	m.Keep()
+	if req.UrlMeta == nil {
+		req.UrlMeta = &base.UrlMeta{}
+	}
+	peerID := clientutil.GenPeerID(m.peerHost)
+	log := logger.With("peer", peerID, "component", "downloadService")
+
+	rc, _, err := m.peerTaskManager.StartStreamPeerTask(ctx, &scheduler.PeerTaskRequest{
+		Url:      req.Url,
+		Filter:   req.UrlMeta.Filter,
+		BizId:    req.UrlMeta.Tag,
+		UrlMeta:  req.UrlMeta,
+		PeerId:   peerID,
+		PeerHost: m.peerHost,
+	})
+	if err != nil {
+		log.Errorf("start stream peer task error: %s", err)
+		if errors.Is(err, digestutils.ErrDigestNotMatch) {
+			return dferrors.New(dfcodes.ClientDigestNotMatch, err.Error())
+		}
+		return dferrors.New(dfcodes.UnknownError, err.Error())
+	}
+	defer rc.Close()
+
+	// the whole content is verified by peer task manager, the last data is not sent when digest mismatch
+	if err = sendTaskData(ctx, rc, results); err != nil {
+		log.Errorf("stream download error: %s", err)
+		return err
+	}
+	log.Infof("stream download done")
+	return nil
+}
+
+// sendTaskData reads all data from reader and sends them to results in order
+func sendTaskData(ctx context.Context, reader io.Reader, results chan<- *dfdaemongrpc.TaskData) error {
+	for {
+		buf := make([]byte, taskDataBufferSize)
+		n, err := io.ReadFull(reader, buf)
+		// do not send the data read with error
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			if errors.Is(err, digestutils.ErrDigestNotMatch) {
+				return dferrors.New(dfcodes.ClientDigestNotMatch, err.Error())
+			}
+			return dferrors.New(dfcodes.UnknownError, err.Error())
+		}
+		if n > 0 {
+			select {
+			case results <- &dfdaemongrpc.TaskData{Data: buf[:n]}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// findCompletedTask finds the completed task in storage with target, or with url when task id and peer id are empty
+func (m *manager) findCompletedTask(req *dfdaemongrpc.ExportTaskRequest) (*storage.TaskStoreStat, error) {
+	target := req.Target
+	if target == nil {
+		target = &dfdaemongrpc.TaskTarget{}
+	}
+	if target.TaskId == "" && target.PeerId == "" {
+		if req.Url == "" {
+			return nil, dferrors.New(dfcodes.BadRequest, "empty url and task target")
+		}
+		if req.UrlMeta == nil {
+			req.UrlMeta = &base.UrlMeta{}
+		}
+		target = &dfdaemongrpc.TaskTarget{
+			TaskId: idgen.TaskID(req.Url, req.UrlMeta.Filter, req.UrlMeta, req.UrlMeta.Tag),
+		}
+	}
+	for _, stat := range m.storageManager.ListTasks() {
+		if stat.Done && matchTaskTarget(target, stat.TaskID, stat.PeerID) {
+			return stat, nil
+		}
+	}
+	return nil, dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("completed task %s/%s not found", target.TaskId, target.PeerId))
+}
+
+func matchTaskTarget(target *dfdaemongrpc.TaskTarget, taskID, peerID string) bool {
+	if target.PeerId != "" && target.PeerId != peerID {
+		return false
+	}
+	return target.TaskId == "" || target.TaskId == taskID
+}
+
+// checkImportPath resolves the file to import and checks it's readable by all users.
+// The daemon may run with a privileged user, importing a file the caller can not read will leak it.
+func checkImportPath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path %s must be a full path", path)
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	stat, err := os.Stat(realPath)
+	if err != nil {
+		return "", err
+	}
+	if stat.Mode().Perm()&0004 == 0 {
+		return "", fmt.Errorf("%s is not readable by all users", path)
+	}
+	for dir := filepath.Dir(realPath); ; dir = filepath.Dir(dir) {
+		stat, err = os.Stat(dir)
+		if err != nil {
+			return "", err
+		}
+		if stat.Mode().Perm()&0001 == 0 {
+			return "", fmt.Errorf("directory %s of %s is not accessible by all users", dir, path)
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return realPath, nil
+}
+
+func runningTaskInfo(pt peer.Task) *dfdaemongrpc.TaskInfo {
+	return &dfdaemongrpc.TaskInfo{
+		TaskId:          pt.GetTaskID(),
+		PeerId:          pt.GetPeerID(),
+		Running:         true,
+		ContentLength:   pt.GetContentLength(),
+		CompletedLength: uint64(pt.GetCompletedLength()),
+		TotalPiece:      pt.GetTotalPieces(),
+		// AccessTime is left zero, access time of running task is unknown until it's stored
+	}
+}
+
+func storedTaskInfo(stat *storage.TaskStoreStat) *dfdaemongrpc.TaskInfo {
+	return &dfdaemongrpc.TaskInfo{
+		TaskId:          stat.TaskID,
+		PeerId:          stat.PeerID,
+		Done:            stat.Done,
+		ContentLength:   stat.ContentLength,
+		CompletedLength: uint64(stat.DataLength),
+		TotalPiece:      stat.TotalPieces,
+		AccessTime:      stat.LastAccess,
+		Pinned:          stat.Pinned,
+		Biz:             stat.Biz,
+	}
+}
+
 func (m *manager) Download(ctx context.Context,
 	req *dfdaemongrpc.DownRequest, results chan<- *dfdaemongrpc.DownResult) error {
 	m.Keep()
@@ -146,12 +695,16 @@ func (m *manager) Download(ctx context.Context,
 			PeerId:   clientutil.GenPeerID(m.peerHost),
 			PeerHost: m.peerHost,
 		},
-		Output: req.Output,
+		Output:     req.Output,
+		CallSystem: req.Callsystem,
 	}
 	log := logger.With("peer", peerTask.PeerId, "component", "downloadService")
 
 	peerTaskProgress, tiny, err := m.peerTaskManager.StartFilePeerTask(ctx, peerTask)
 	if err != nil {
+		if errors.Is(err, digestutils.ErrDigestNotMatch) {
+			return dferrors.New(dfcodes.ClientDigestNotMatch, err.Error())
+		}
 		return dferrors.New(dfcodes.UnknownError, fmt.Sprintf("%s", err))
 	}
 	if tiny != nil {
@@ -212,3 +765,37 @@ func (m *manager) Download(ctx context.Context,
 		}
 	}
 }
+
+func (m *manager) GetRateLimits(ctx context.Context) (*dfdaemongrpc.RateLimits, error) {
+	m.Keep()
+	return toRateLimits(m.rateLimits.Get()), nil
+}
+
+func (m *manager) SetRateLimits(ctx context.Context, req *dfdaemongrpc.SetRateLimitsRequest) (*dfdaemongrpc.RateLimits, error) {
+	m.Keep()
+	var limits config.RateLimits
+	if l := req.Limits; l != nil {
+		for _, v := range []float64{l.TotalDownload, l.PerPeer, l.BackSource, l.Upload} {
+			if math.IsNaN(v) {
+				return nil, dferrors.New(dfcodes.BadRequest, fmt.Sprintf("invalid rate limit %f", v))
+			}
+		}
+		limits = config.RateLimits{
+			TotalDownload: clientutil.RateLimit{Limit: rate.Limit(l.TotalDownload)},
+			PerPeer:       clientutil.RateLimit{Limit: rate.Limit(l.PerPeer)},
+			BackSource:    clientutil.RateLimit{Limit: rate.Limit(l.BackSource)},
+			Upload:        clientutil.RateLimit{Limit: rate.Limit(l.Upload)},
+		}
+	}
+	logger.Infof("set rate limits: %#v, clear: %t", req.Limits, req.Clear)
+	return toRateLimits(m.rateLimits.Set(limits, req.Clear)), nil
+}
+
+func toRateLimits(limits config.RateLimits) *dfdaemongrpc.RateLimits {
+	return &dfdaemongrpc.RateLimits{
+		TotalDownload: float64(limits.TotalDownload.Limit),
+		PerPeer:       float64(limits.PerPeer.Limit),
+		BackSource:    float64(limits.BackSource.Limit),
+		Upload:        float64(limits.Upload.Limit),
+	}
+}
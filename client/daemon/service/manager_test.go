@@ -17,20 +17,28 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
 	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
 	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
 	"d7y.io/dragonfly/v2/internal/rpc"
@@ -232,3 +240,479 @@ func TestDownloadManager_ServePeer(t *testing.T) {
 		assert.Equal(tc.responsePieceSize, len(response.PieceInfos))
 	}
 }
+
+func TestDownloadManager_SyncPieceTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		maxPieceNum int32 = 10
+		pieceSize         = int32(1024)
+		lock        sync.Mutex
+		ready       int32 = 3
+		changed           = make(chan struct{})
+	)
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().WatchTask(gomock.Any()).AnyTimes().DoAndReturn(func(req storage.PeerTaskMetaData) (<-chan struct{}, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return changed, nil
+	})
+	mockStorageManger.EXPECT().GetPieces(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		var pieces []*base.PieceInfo
+		for i := req.StartNum; i < req.Limit+req.StartNum && i < ready; i++ {
+			pieces = append(pieces, &base.PieceInfo{
+				PieceNum:    i,
+				RangeStart:  uint64(i * pieceSize),
+				RangeSize:   pieceSize,
+				PieceOffset: uint64(i * pieceSize),
+				PieceStyle:  base.PieceStyle_PLAIN,
+			})
+		}
+		var sign string
+		if ready == maxPieceNum {
+			sign = "sign"
+		}
+		return &base.PiecePacket{
+			TaskId:        req.TaskId,
+			DstPid:        req.DstPid,
+			PieceInfos:    pieces,
+			TotalPiece:    maxPieceNum,
+			ContentLength: int64(maxPieceNum * pieceSize),
+			PieceMd5Sign:  sign,
+		}, nil
+	})
+	mockTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	mockTaskManager.EXPECT().IsPeerTaskRunning(gomock.Any()).AnyTimes().Return(true)
+
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockTaskManager,
+		storageManager:  mockStorageManger,
+	}
+	m.peerServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServePeer(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	target := dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: fmt.Sprintf("127.0.0.1:%d", port),
+	}
+	client, err := dfclient.GetClientByAddr([]dfnet.NetAddr{target})
+	assert.Nil(err, "grpc dial should be ok")
+
+	stream, err := client.SyncPieceTasks(context.Background(), target, &base.PieceTaskRequest{
+		TaskId: "task-1",
+		SrcPid: "peer-0",
+		DstPid: "peer-1",
+	})
+	assert.Nil(err, "client sync piece tasks grpc call should be ok")
+
+	var (
+		pieces []int32
+		last   *base.PiecePacket
+	)
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if err != nil {
+			break
+		}
+		for _, piece := range p.PieceInfos {
+			pieces = append(pieces, piece.PieceNum)
+		}
+		// the pieces written later are pushed
+		if last == nil {
+			lock.Lock()
+			ready = maxPieceNum
+			close(changed)
+			changed = make(chan struct{})
+			lock.Unlock()
+		}
+		last = p
+	}
+	assert.Equal([]int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, pieces)
+	assert.Equal("sign", last.PieceMd5Sign)
+	assert.Empty(last.PieceInfos)
+}
+
+func TestDownloadManager_Tasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runningTask := mock_peer.NewMockTask(ctrl)
+	runningTask.EXPECT().GetTaskID().AnyTimes().Return("task-1")
+	runningTask.EXPECT().GetPeerID().AnyTimes().Return("peer-1")
+	runningTask.EXPECT().GetContentLength().AnyTimes().Return(int64(100))
+	runningTask.EXPECT().GetCompletedLength().AnyTimes().Return(int64(50))
+	runningTask.EXPECT().GetTotalPieces().AnyTimes().Return(int32(2))
+
+	mockPeerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().ListRunningPeerTasks().AnyTimes().Return([]peer.Task{runningTask})
+	mockPeerTaskManager.EXPECT().CancelPeerTask("peer-1", gomock.Any()).Times(1).Return(true)
+
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().ListTasks().AnyTimes().Return([]*storage.TaskStoreStat{
+		{
+			PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-1", TaskID: "task-1"},
+			ContentLength:    100,
+			DataLength:       50,
+		},
+		{
+			PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-2", TaskID: "task-2"},
+			ContentLength:    100,
+			TotalPieces:      2,
+			DataLength:       100,
+			Done:             true,
+		},
+	})
+	mockStorageManger.EXPECT().DeleteTask(gomock.Any(), storage.PeerTaskMetaData{TaskID: "task-2"}).Times(1).Return(nil)
+	mockStorageManger.EXPECT().PinTask(gomock.Any(), storage.PeerTaskMetaData{TaskID: "task-2"}, true).Times(1).Return(nil)
+	mockStorageManger.EXPECT().PinTask(gomock.Any(), storage.PeerTaskMetaData{TaskID: "task-3"}, true).Times(1).Return(storage.ErrTaskNotFound)
+
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+		storageManager:  mockStorageManger,
+	}
+	ctx := context.Background()
+
+	result, err := m.ListTasks(ctx, &dfdaemongrpc.ListTasksRequest{})
+	assert.Nil(err)
+	assert.Equal(2, len(result.Tasks))
+	assert.True(result.Tasks[0].Running)
+	assert.Equal(uint64(50), result.Tasks[0].CompletedLength)
+	assert.True(result.Tasks[1].Done)
+
+	result, err = m.ListTasks(ctx, &dfdaemongrpc.ListTasksRequest{RunningOnly: true})
+	assert.Nil(err)
+	assert.Equal(1, len(result.Tasks))
+
+	task, err := m.StatTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-2"})
+	assert.Nil(err)
+	assert.Equal("peer-2", task.PeerId)
+	_, err = m.StatTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-3"})
+	assert.NotNil(err)
+
+	assert.Nil(m.CancelTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-1"}))
+	assert.NotNil(m.CancelTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-2"}))
+
+	// running task can not be deleted
+	assert.NotNil(m.DeleteTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-1"}))
+	assert.Nil(m.DeleteTask(ctx, &dfdaemongrpc.TaskTarget{TaskId: "task-2"}))
+
+	assert.Nil(m.PinTask(ctx, &dfdaemongrpc.PinTaskRequest{Target: &dfdaemongrpc.TaskTarget{TaskId: "task-2"}, Pinned: true}))
+	assert.NotNil(m.PinTask(ctx, &dfdaemongrpc.PinTaskRequest{Target: &dfdaemongrpc.TaskTarget{TaskId: "task-3"}, Pinned: true}))
+	assert.NotNil(m.PinTask(ctx, &dfdaemongrpc.PinTaskRequest{}))
+}
+
+func TestDownloadManager_PeerPermissionDenied(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// task admin rpcs must not reach peer task manager and storage manager through the peer port
+	mockPeerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	m, err := NewManager(&scheduler.PeerHost{}, mockPeerTaskManager, mockStorageManger, nil, nil, nil)
+	assert.Nil(err)
+
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServePeer(ln)
+	}()
+	defer m.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	target := dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: fmt.Sprintf("127.0.0.1:%d", port),
+	}
+	client, err := dfclient.GetClientByAddr([]dfnet.NetAddr{target})
+	assert.Nil(err, "grpc dial should be ok")
+	ctx := context.Background()
+
+	_, err = client.ListTasks(ctx, target, &dfdaemongrpc.ListTasksRequest{})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "list tasks")
+	_, err = client.StatTask(ctx, target, &dfdaemongrpc.TaskTarget{TaskId: "task-1"})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "stat task")
+	err = client.CancelTask(ctx, target, &dfdaemongrpc.TaskTarget{TaskId: "task-1"})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "cancel task")
+	err = client.DeleteTask(ctx, target, &dfdaemongrpc.TaskTarget{TaskId: "task-1"})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "delete task")
+	_, err = client.ImportTask(ctx, target, &dfdaemongrpc.ImportTaskRequest{Url: "http://localhost/test", Path: test.File})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "import task")
+	_, err = client.ExportTask(ctx, target, &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"},
+		Output: "/tmp/output",
+	})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "export task")
+	stream, err := client.ReadTask(ctx, target, &dfdaemongrpc.ExportTaskRequest{Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assert.Equal(codes.PermissionDenied, status.Code(err), "read task")
+	err = client.PinTask(ctx, target, &dfdaemongrpc.PinTaskRequest{Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"}, Pinned: true})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "pin task")
+	downStream, err := client.StreamDownload(ctx, &dfdaemongrpc.DownRequest{Url: "http://localhost/test", UrlMeta: &base.UrlMeta{}})
+	if err == nil {
+		_, err = downStream.Recv()
+	}
+	assert.Equal(codes.PermissionDenied, status.Code(err), "stream download")
+	_, err = client.GetRateLimits(ctx, target)
+	assert.Equal(codes.PermissionDenied, status.Code(err), "get rate limits")
+	_, err = client.SetRateLimits(ctx, target, &dfdaemongrpc.SetRateLimitsRequest{})
+	assert.Equal(codes.PermissionDenied, status.Code(err), "set rate limits")
+}
+
+func TestDownloadManager_ReadTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().ListTasks().AnyTimes().Return([]*storage.TaskStoreStat{
+		{
+			PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-1", TaskID: "task-1"},
+			ContentLength:    int64(len(testBytes)),
+			DataLength:       int64(len(testBytes)),
+			Done:             true,
+		},
+	})
+	mockStorageManger.EXPECT().ReadAllPieces(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *storage.PeerTaskMetaData) (io.ReadCloser, error) {
+			assert.Equal("peer-1", req.PeerID)
+			return ioutil.NopCloser(bytes.NewBuffer(testBytes)), nil
+		})
+	m := &manager{
+		KeepAlive:      clientutil.NewKeepAlive("test"),
+		peerHost:       &scheduler.PeerHost{},
+		storageManager: mockStorageManger,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	target := dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: fmt.Sprintf("127.0.0.1:%d", port),
+	}
+	client, err := dfclient.GetClientByAddr([]dfnet.NetAddr{target})
+	assert.Nil(err, "grpc dial should be ok")
+
+	stream, err := client.ReadTask(context.Background(), target, &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"},
+	})
+	assert.Nil(err, "client read task grpc call should be ok")
+	var data []byte
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if err != nil {
+			break
+		}
+		data = append(data, result.Data...)
+	}
+	assert.Equal(testBytes, data)
+
+	// export without peer credential is denied
+	_, err = m.ExportTask(context.Background(), &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"},
+		Output: "/tmp/not-exist",
+	})
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func TestDownloadManager_ExportTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "output")
+
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().ListTasks().AnyTimes().Return([]*storage.TaskStoreStat{
+		{
+			PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-1", TaskID: "task-1"},
+			ContentLength:    100,
+			DataLength:       100,
+			Done:             true,
+		},
+	})
+	mockStorageManger.EXPECT().Store(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *storage.StoreRequest) error {
+			assert.Equal("task-1", req.TaskID)
+			assert.Equal(output, req.Destination)
+			return nil
+		})
+	m := &manager{
+		KeepAlive:      clientutil.NewKeepAlive("test"),
+		peerHost:       &scheduler.PeerHost{},
+		storageManager: mockStorageManger,
+	}
+	m.downloadServer = rpc.NewServer(m)
+
+	// the caller is known through unix socket
+	socket := filepath.Join(dir, "daemon.sock")
+	ln, err := net.Listen("unix", socket)
+	assert.Nil(err)
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	defer m.downloadServer.GracefulStop()
+	time.Sleep(100 * time.Millisecond)
+
+	target := dfnet.NetAddr{
+		Type: dfnet.UNIX,
+		Addr: socket,
+	}
+	client, err := dfclient.GetClientByAddr([]dfnet.NetAddr{target})
+	assert.Nil(err, "grpc dial should be ok")
+
+	task, err := client.ExportTask(context.Background(), target, &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{TaskId: "task-1"},
+		Output: output,
+	})
+	assert.Nil(err)
+	assert.Equal("peer-1", task.PeerId)
+
+	_, err = client.ExportTask(context.Background(), target, &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{TaskId: "task-2"},
+		Output: output,
+	})
+	assert.NotNil(err, "task not found")
+}
+
+func TestDownloadManager_StreamDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	mockPeerTaskManager := mock_peer.NewMockTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().StartStreamPeerTask(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, req *scheduler.PeerTaskRequest) (io.ReadCloser, map[string]string, error) {
+			assert.Equal("http://localhost/test", req.Url)
+			return ioutil.NopCloser(bytes.NewBuffer(testBytes)), nil, nil
+		})
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := dfclient.GetClientByAddr([]dfnet.NetAddr{
+		{
+			Type: dfnet.TCP,
+			Addr: fmt.Sprintf(":%d", port),
+		},
+	})
+	assert.Nil(err, "grpc dial should be ok")
+
+	stream, err := client.StreamDownload(context.Background(), &dfdaemongrpc.DownRequest{
+		Url: "http://localhost/test",
+		UrlMeta: &base.UrlMeta{
+			Tag: "unit test",
+		},
+	})
+	assert.Nil(err, "client stream download grpc call should be ok")
+	var data []byte
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if err != nil {
+			break
+		}
+		data = append(data, result.Data...)
+	}
+	assert.Equal(testBytes, data)
+}
+
+func TestDownloadManager_CheckImportPath(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "import-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(os.Chmod(dir, 0755))
+
+	public := dir + "/public"
+	assert.Nil(ioutil.WriteFile(public, []byte("public"), 0644))
+	private := dir + "/private"
+	assert.Nil(ioutil.WriteFile(private, []byte("private"), 0600))
+	link := dir + "/link"
+	assert.Nil(os.Symlink(private, link))
+
+	path, err := checkImportPath(public)
+	assert.Nil(err)
+	assert.Equal(public, path)
+
+	_, err = checkImportPath("public")
+	assert.NotNil(err)
+	_, err = checkImportPath(private)
+	assert.NotNil(err)
+	_, err = checkImportPath(link)
+	assert.NotNil(err)
+
+	// the file in a private directory is not readable by other users either
+	assert.Nil(os.Chmod(dir, 0700))
+	_, err = checkImportPath(public)
+	assert.NotNil(err)
+}
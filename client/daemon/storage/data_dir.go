@@ -0,0 +1,198 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/util/fileutils"
+)
+
+var ErrNoAvailableDataDir = errors.New("no available data dir")
+
+// dataDir is one of the directories which store task data, usually one directory per disk
+type dataDir struct {
+	path string
+	// quota is the max size of task data in the directory, 0 stands no quota
+	quota int64
+	// dev is the device of the directory when daemon starts, it's changed when the disk is unmounted
+	dev uint64
+	// available is false when the directory is read-only or disappeared, no new task is placed in it
+	available atomic.Bool
+}
+
+func newDataDir(opt config.DataDirOption) (*dataDir, error) {
+	dir := opt.Path
+	if !path.IsAbs(dir) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		dir = abs
+	}
+	stat, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, defaultDirectoryMode); err != nil {
+			return nil, err
+		}
+		stat, err = os.Stat(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &dataDir{
+		path:  dir,
+		quota: int64(opt.Quota),
+		dev:   uint64(stat.Sys().(*syscall.Stat_t).Dev),
+	}
+	d.probe()
+	return d, nil
+}
+
+// lost returns true when the directory disappeared or the disk is unmounted, the task data in it can not be read any more
+func (d *dataDir) lost() bool {
+	stat, err := os.Stat(d.path)
+	if err != nil {
+		return true
+	}
+	return uint64(stat.Sys().(*syscall.Stat_t).Dev) != d.dev
+}
+
+// probe checks whether new task can be written into the directory and updates the availability
+func (d *dataDir) probe() bool {
+	var err error
+	if d.lost() {
+		err = errors.Errorf("data dir %s is lost", d.path)
+	} else {
+		var f *os.File
+		if f, err = ioutil.TempFile(d.path, ".probe-"); err == nil {
+			f.Close()
+			err = os.Remove(f.Name())
+		}
+	}
+	available := err == nil
+	if d.available.Swap(available) != available {
+		if available {
+			logger.Infof("data dir %s is available", d.path)
+		} else {
+			logger.Warnf("data dir %s is unavailable: %s", d.path, err)
+		}
+	}
+	return available
+}
+
+// freeSpace returns the free space for new task data, which is limited by the remaining quota
+func (d *dataDir) freeSpace(used int64) (int64, error) {
+	free, err := fileutils.GetFreeSpace(d.path)
+	if err != nil {
+		return 0, err
+	}
+	if d.quota > 0 && d.quota-used < int64(free) {
+		return d.quota - used, nil
+	}
+	return int64(free), nil
+}
+
+// newDataDirs makes the data dirs from storage option, DataPath is the only data dir when DataDirs is empty
+func newDataDirs(opt *config.StorageOption) ([]*dataDir, error) {
+	opts := opt.DataDirs
+	if len(opts) == 0 {
+		opts = []config.DataDirOption{{Path: opt.DataPath}}
+	}
+	var dirs []*dataDir
+	for _, o := range opts {
+		d, err := newDataDir(o)
+		if err != nil {
+			return nil, errors.Wrapf(err, "init data dir %s", o.Path)
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// dataDirUsage returns the size of task data in every data dir
+func (s *storageManager) dataDirUsage() map[*dataDir]int64 {
+	usage := map[*dataDir]int64{}
+	s.tasks.Range(func(key, value interface{}) bool {
+		t := value.(*localTaskStore)
+		if t.reclaimMarked.Load() || t.ContentLength <= 0 {
+			return true
+		}
+		usage[t.disk] += t.exclusiveSize()
+		return true
+	})
+	return usage
+}
+
+// selectDataDirs returns the available data dirs for new task in the order of placement,
+// the data dirs without enough space are at the end, the oldest tasks in them will be reclaimed by gc.
+func (s *storageManager) selectDataDirs(contentLength int64) []*dataDir {
+	var (
+		usage   = s.dataDirUsage()
+		free    = map[*dataDir]int64{}
+		dirs    []*dataDir
+		offset  = s.nextDataDir.Inc()
+		ordered = make([]*dataDir, 0, len(s.dataDirs))
+	)
+	// round robin starts from the next data dir
+	for i := range s.dataDirs {
+		ordered = append(ordered, s.dataDirs[(offset+uint64(i))%uint64(len(s.dataDirs))])
+	}
+	for _, d := range ordered {
+		if !d.available.Load() {
+			continue
+		}
+		f, err := d.freeSpace(usage[d])
+		if err != nil {
+			logger.Warnf("get free space of data dir %s error: %s", d.path, err)
+			d.probe()
+			continue
+		}
+		free[d] = f
+		dirs = append(dirs, d)
+	}
+	if s.storeOption.DataDirPlacement != config.RoundRobinDataDirPlacement {
+		sort.SliceStable(dirs, func(i, j int) bool {
+			return free[dirs[i]] > free[dirs[j]]
+		})
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return free[dirs[i]] >= contentLength && free[dirs[j]] < contentLength
+	})
+	return dirs
+}
+
+// probeDataDirs updates the availability of all data dirs, then returns the lost data dirs
+func (s *storageManager) probeDataDirs() map[*dataDir]bool {
+	lost := map[*dataDir]bool{}
+	for _, d := range s.dataDirs {
+		if !d.probe() && d.lost() {
+			lost[d] = true
+		}
+	}
+	return lost
+}
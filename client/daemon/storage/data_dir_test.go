@@ -0,0 +1,159 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+func newMultiDiskStorageManager(t *testing.T, placement config.DataDirPlacement, quotas ...int64) (*storageManager, []string) {
+	var (
+		dirs []string
+		opts []config.DataDirOption
+	)
+	for i, quota := range quotas {
+		dir, err := ioutil.TempDir("", fmt.Sprintf("dragonfly-disk-%d-", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+		opts = append(opts, config.DataDirOption{Path: dir, Quota: unit.Bytes(quota)})
+	}
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataDirs:         opts,
+			DataDirPlacement: placement,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sm.(*storageManager), dirs
+}
+
+func createTestTask(s *storageManager, index int, contentLength int64) (*localTaskStore, error) {
+	req := RegisterTaskRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: fmt.Sprintf("peer-%d", index),
+			TaskID: fmt.Sprintf("task-%d", index),
+		},
+		ContentLength: contentLength,
+	}
+	if err := s.CreateTask(req); err != nil {
+		return nil, err
+	}
+	t, _ := s.LoadTask(PeerTaskMetaData{PeerID: req.PeerID, TaskID: req.TaskID})
+	return t.(*localTaskStore), nil
+}
+
+func TestStorageManager_DataDirPlacement(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	// round robin
+	s, dirs := newMultiDiskStorageManager(t, config.RoundRobinDataDirPlacement, 0, 0)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+	count := map[string]int{}
+	for i := 0; i < 4; i++ {
+		task, err := createTestTask(s, i, 100)
+		assert.Nil(err, "create task")
+		count[task.disk.path]++
+	}
+	assert.Equal(2, count[dirs[0]], "tasks should be placed in turn")
+	assert.Equal(2, count[dirs[1]], "tasks should be placed in turn")
+
+	// free space limited by quota
+	s, dirs = newMultiDiskStorageManager(t, config.FreeSpaceDataDirPlacement, 100, 10000)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+	for i := 0; i < 3; i++ {
+		task, err := createTestTask(s, i, 500)
+		assert.Nil(err, "create task")
+		assert.Equal(dirs[1], task.disk.path, "task should be placed in the data dir with more free space")
+	}
+
+	// lost data dir
+	s, dirs = newMultiDiskStorageManager(t, config.RoundRobinDataDirPlacement, 0, 0)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+	lostTask, err := createTestTask(s, 0, 100)
+	assert.Nil(err, "create task")
+	assert.Nil(os.RemoveAll(lostTask.disk.path), "remove data dir")
+	for i := 1; i < 3; i++ {
+		task, err := createTestTask(s, i, 100)
+		assert.Nil(err, "create task")
+		assert.NotEqual(lostTask.disk.path, task.disk.path, "task should not be placed in the lost data dir")
+	}
+	assert.False(lostTask.disk.available.Load(), "lost data dir should be unavailable")
+	_, err = s.TryGC()
+	assert.Nil(err, "gc")
+	assert.True(lostTask.reclaimMarked.Load(), "task in lost data dir should be reclaimed")
+}
+
+func TestStorageManager_DataDirQuotaGC(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, dirs := newMultiDiskStorageManager(t, config.RoundRobinDataDirPlacement, 0, 0)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+
+	var tasks []*localTaskStore
+	for i := 0; i < 4; i++ {
+		task, err := createTestTask(s, i, 600)
+		assert.Nil(err, "create task")
+		task.lastAccess.Store(time.Now().Add(time.Duration(i) * time.Second).UnixNano())
+		tasks = append(tasks, task)
+	}
+	// the quota is exceeded by the tasks in the first data dir
+	s.dataDirs[0].quota = 1000
+	_, err := s.TryGC()
+	assert.Nil(err, "gc")
+	for _, task := range tasks {
+		// only the oldest task in the data dir with quota should be reclaimed
+		expected := task.disk.path == dirs[0] && task == oldestTask(tasks, task.disk)
+		assert.Equal(expected, task.reclaimMarked.Load(), path.Join(task.disk.path, task.TaskID))
+	}
+}
+
+func oldestTask(tasks []*localTaskStore, disk *dataDir) *localTaskStore {
+	var oldest *localTaskStore
+	for _, t := range tasks {
+		if t.disk != disk {
+			continue
+		}
+		if oldest == nil || t.lastAccess.Load() < oldest.lastAccess.Load() {
+			oldest = t
+		}
+	}
+	return oldest
+}
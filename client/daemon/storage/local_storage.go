@@ -23,15 +23,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"sync"
 	"time"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"github.com/pkg/errors"
 	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
 )
 
 type localTaskStore struct {
@@ -41,6 +45,10 @@ type localTaskStore struct {
 	sync.RWMutex
 
 	dataDir string
+	// disk is the data dir where the task data is placed
+	disk *dataDir
+	// storeMode indicates how to store task data to the output
+	storeMode config.StoreMode
 
 	metadataFile     *os.File
 	metadataFilePath string
@@ -49,8 +57,16 @@ type localTaskStore struct {
 	lastAccess    atomic.Int64
 	reclaimMarked atomic.Bool
 	gcCallback    func(CommonTaskRequest)
+	// lastPersist is the last time in unix nano of saving metadata for unfinished task
+	lastPersist atomic.Int64
+	// changed is closed when the pieces or the state of task change, it's guarded by RWMutex
+	changed chan struct{}
 }
 
+// persistUnfinishedInterval is the min interval of saving metadata for unfinished task,
+// the pieces written after the last saving will be downloaded again when resuming
+const persistUnfinishedInterval = 5 * time.Second
+
 var _ TaskStorageDriver = (*localTaskStore)(nil)
 var _ Reclaimer = (*localTaskStore)(nil)
 
@@ -66,6 +82,10 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 	t.RLock()
 	if piece, ok := t.Pieces[req.Num]; ok {
 		t.RUnlock()
+		// drain the piece content, the reader may be shared by the following pieces, like downloading from source
+		if _, err := io.CopyN(ioutil.Discard, req.Reader, piece.Range.Length); err != nil && err != io.EOF {
+			return 0, err
+		}
 		return piece.Range.Length, nil
 	}
 	t.RUnlock()
@@ -122,12 +142,15 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 	t.Debugf("wrote %d bytes to file %s, piece %d, start %d, length: %d",
 		n, t.DataFilePath, req.Num, req.Range.Start, req.Range.Length)
 	t.Lock()
-	defer t.Unlock()
 	// double check
 	if _, ok := t.Pieces[req.Num]; ok {
+		t.Unlock()
 		return n, nil
 	}
 	t.Pieces[req.Num] = req.PieceMetaData
+	t.notifyChanged()
+	t.Unlock()
+	t.persistUnfinished()
 	return n, nil
 }
 
@@ -139,11 +162,69 @@ func (t *localTaskStore) UpdateTask(ctx context.Context, req *UpdateTaskRequest)
 	if t.TotalPieces == 0 {
 		t.TotalPieces = req.TotalPieces
 	}
+	t.notifyChanged()
 	return nil
 }
 
+// watch returns a channel which is closed when the pieces or the state of task change
+func (t *localTaskStore) watch() <-chan struct{} {
+	t.Lock()
+	defer t.Unlock()
+	if t.changed == nil {
+		t.changed = make(chan struct{})
+	}
+	return t.changed
+}
+
+// notifyChanged wakes up the watchers, it must be called with lock held
+func (t *localTaskStore) notifyChanged() {
+	if t.changed != nil {
+		close(t.changed)
+		t.changed = nil
+	}
+}
+
+// pieceMd5Sign returns the sha256 of all piece md5 in order, returns empty when any piece md5 is unknown,
+// it must be called with lock held
+func (t *localTaskStore) pieceMd5Sign() string {
+	if t.TotalPieces <= 0 || int32(len(t.Pieces)) != t.TotalPieces {
+		return ""
+	}
+	md5s := make([]string, 0, t.TotalPieces)
+	for i := int32(0); i < t.TotalPieces; i++ {
+		piece, ok := t.Pieces[i]
+		if !ok || piece.Md5 == "" {
+			return ""
+		}
+		md5s = append(md5s, piece.Md5)
+	}
+	return digestutils.Sha256(md5s...)
+}
+
+// persistUnfinished saves metadata of the unfinished task periodically for resuming after restart
+func (t *localTaskStore) persistUnfinished() {
+	// OriginRequest is only set when creating or reloading task
+	t.RLock()
+	unfinished := t.OriginRequest != nil && !t.Done
+	t.RUnlock()
+	if !unfinished {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := t.lastPersist.Load()
+	if now-last < int64(persistUnfinishedInterval) || !t.lastPersist.CAS(last, now) {
+		return
+	}
+	if err := t.saveMetadata(); err != nil {
+		t.Warnf("save unfinished task metadata error: %s", err)
+	}
+}
+
 // ReadPiece get a LimitReadCloser from task data with seeked, caller should read bytes and close it.
 func (t *localTaskStore) ReadPiece(ctx context.Context, req *ReadPieceRequest) (io.Reader, io.Closer, error) {
+	if err := t.checkValid(); err != nil {
+		return nil, nil, err
+	}
 	t.touch()
 	file, err := os.Open(t.DataFilePath)
 	if err != nil {
@@ -167,6 +248,9 @@ func (t *localTaskStore) ReadPiece(ctx context.Context, req *ReadPieceRequest) (
 }
 
 func (t *localTaskStore) ReadAllPieces(ctx context.Context, req *PeerTaskMetaData) (io.ReadCloser, error) {
+	if err := t.checkValid(); err != nil {
+		return nil, err
+	}
 	t.touch()
 	file, err := os.Open(t.DataFilePath)
 	if err != nil {
@@ -180,12 +264,35 @@ func (t *localTaskStore) ReadAllPieces(ctx context.Context, req *PeerTaskMetaDat
 }
 
 func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
+	// verify before marking done, the task with mismatched data must not be reused
+	if req.Digest != "" {
+		if err := digestutils.VerifyFile(t.DataFilePath, req.Digest); err != nil {
+			t.Errorf("verify task data with digest %s error: %s", req.Digest, err)
+			return err
+		}
+		t.Infof("verify task data with digest %s ok", req.Digest)
+	}
 	// Store is be called in callback.Done, mark local task store done, for fast search
-	t.Done = true
-	t.touch()
+	t.Lock()
 	if req.TotalPieces > 0 {
 		t.TotalPieces = req.TotalPieces
 	}
+	if req.PieceMd5Sign != "" {
+		// the sign can not be verified when some piece md5 is unknown, keep the pushed one for children
+		if actual := t.pieceMd5Sign(); actual != "" && actual != req.PieceMd5Sign {
+			t.Unlock()
+			t.Errorf("verify task data with piece md5 sign %s error, actual: %s", req.PieceMd5Sign, actual)
+			return errors.Wrapf(digestutils.ErrDigestNotMatch, "piece md5 sign not match, desired: %s, actual: %s", req.PieceMd5Sign, actual)
+		}
+		t.PieceMd5Sign = req.PieceMd5Sign
+	}
+	t.Done = true
+	t.touch()
+	if t.PieceMd5Sign == "" {
+		t.PieceMd5Sign = t.pieceMd5Sign()
+	}
+	t.notifyChanged()
+	t.Unlock()
 	if !req.StoreOnly {
 		err := t.saveMetadata()
 		if err != nil {
@@ -196,46 +303,267 @@ func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
 	if req.MetadataOnly {
 		return nil
 	}
-	_, err := os.Stat(req.Destination)
-	if err == nil {
-		// remove exist file
-		t.Infof("destination file %q exists, purge it first", req.Destination)
-		os.Remove(req.Destination)
+
+	// store to a temporary file in the same directory, then rename it to destination,
+	// so the destination is always complete when it exists
+	dir, name := path.Split(req.Destination)
+	tmpFile, err := ioutil.TempFile(dir, "."+name+".dfget-")
+	if err != nil {
+		t.Errorf("create temporary file for destination %q error: %s", req.Destination, err)
+		return err
 	}
-	// 1. try to link
-	err = os.Link(t.DataFilePath, req.Destination)
-	if err == nil {
-		t.Infof("task data link to file %q success", req.Destination)
-		return nil
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	linked, err := t.storeTo(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, req.Destination); err != nil {
+		t.Errorf("rename %q to destination %q error: %s", tmpPath, req.Destination, err)
+		os.Remove(tmpPath)
+		return err
+	}
+	// record the hard linked output, gc checks whether the task data is modified through the output
+	if linked {
+		t.addLink(req.Destination)
+		if !req.StoreOnly {
+			if err = t.saveMetadata(); err != nil {
+				t.Warnf("save task metadata error: %s", err)
+			}
+		}
 	}
-	t.Warnf("task data link to file %q error: %s", req.Destination, err)
-	// 2. link failed, copy it
+	t.Infof("task data stored to file %q", req.Destination)
+	return nil
+}
+
+// storeTo clones, links or copies task data to the target file by store mode,
+// returns true when the target is hard linked to task data
+func (t *localTaskStore) storeTo(target string) (bool, error) {
+	os.Remove(target)
+	// 1. try to reflink, the target is independent of task data
+	if t.storeMode == config.ReflinkStoreMode {
+		err := reflink(t.DataFilePath, target)
+		if err == nil {
+			t.Debugf("task data reflink to file %q success", target)
+			return false, nil
+		}
+		t.Warnf("task data reflink to file %q error: %s", target, err)
+	}
+	// 2. try to link
+	if t.storeMode != config.CopyStoreMode {
+		err := os.Link(t.DataFilePath, target)
+		if err == nil {
+			t.Debugf("task data link to file %q success", target)
+			return true, nil
+		}
+		t.Warnf("task data link to file %q error: %s", target, err)
+	}
+	// 3. copy it
 	file, err := os.Open(t.DataFilePath)
 	if err != nil {
 		t.Debugf("open tasks data error: %s", err)
-		return err
+		return false, err
 	}
 	defer file.Close()
 
 	_, err = file.Seek(0, io.SeekStart)
 	if err != nil {
 		t.Debugf("task seek file error: %s", err)
-		return err
+		return false, err
 	}
-	dstFile, err := os.OpenFile(req.Destination, os.O_CREATE|os.O_RDWR|os.O_TRUNC, defaultFileMode)
+	dstFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, defaultFileMode)
 	if err != nil {
 		t.Errorf("open tasks destination file error: %s", err)
-		return err
+		return false, err
 	}
 	defer dstFile.Close()
 	// copy_file_range is valid in linux
 	// https://go-review.googlesource.com/c/go/+/229101/
 	n, err := io.Copy(dstFile, file)
-	t.Debugf("copied tasks data %d bytes to %s", n, req.Destination)
-	return err
+	t.Debugf("copied tasks data %d bytes to %s", n, target)
+	return false, err
+}
+
+// setPinned pins or unpins the task, then saves metadata
+func (t *localTaskStore) setPinned(pinned bool) error {
+	t.Lock()
+	t.Pinned = pinned
+	t.Unlock()
+	return t.saveMetadata()
+}
+
+// markInvalid marks the corrupted task invalid and leaves the task, so that it is not reused or uploaded any more,
+// the data is kept for inspection until reclaimed by gc
+func (t *localTaskStore) markInvalid() error {
+	t.Lock()
+	if t.Invalid {
+		t.Unlock()
+		return nil
+	}
+	t.Invalid = true
+	t.Unlock()
+	t.gcCallback(CommonTaskRequest{
+		PeerID: t.PeerID,
+		TaskID: t.TaskID,
+	})
+	return t.saveMetadata()
+}
+
+func (t *localTaskStore) isInvalid() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Invalid
+}
+
+// checkValid returns ErrTaskInvalid when the task is invalid, the task with data modified through
+// the hard linked outputs is marked invalid here, so that the modified data is never served
+func (t *localTaskStore) checkValid() error {
+	if t.isInvalid() {
+		return ErrTaskInvalid
+	}
+	if t.linkedDataModified() {
+		t.Warnf("task data is modified through hard linked outputs, mark it invalid")
+		if err := t.markInvalid(); err != nil {
+			t.Errorf("mark modified task invalid error: %s", err)
+		}
+		return ErrTaskInvalid
+	}
+	return nil
+}
+
+func (t *localTaskStore) isPinned() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Pinned
+}
+
+// addLink records the output which is hard linked to task data
+func (t *localTaskStore) addLink(output string) {
+	t.Lock()
+	defer t.Unlock()
+	if t.DataModTime == 0 {
+		if stat, err := os.Stat(t.DataFilePath); err == nil {
+			t.DataModTime = stat.ModTime().UnixNano()
+		}
+	}
+	for _, link := range t.Links {
+		if link == output {
+			return
+		}
+	}
+	t.Links = append(t.Links, output)
+}
+
+// linkedDataModified returns true when the hard linked task data is modified through the outputs,
+// the task data must not be shared with other peers any more
+func (t *localTaskStore) linkedDataModified() bool {
+	t.RLock()
+	modTime, contentLength := t.DataModTime, t.ContentLength
+	t.RUnlock()
+	if modTime == 0 {
+		return false
+	}
+	stat, err := os.Stat(t.DataFilePath)
+	if err != nil {
+		return false
+	}
+	return stat.ModTime().UnixNano() != modTime || (contentLength >= 0 && stat.Size() != contentLength)
+}
+
+// exclusiveSize returns the size of disk space which is only used by task data,
+// it's 0 when the task data is still hard linked by any output, reclaiming it frees nothing
+func (t *localTaskStore) exclusiveSize() int64 {
+	t.RLock()
+	links := t.Links
+	t.RUnlock()
+	if len(links) > 0 {
+		if data, err := os.Stat(t.DataFilePath); err == nil {
+			for _, link := range links {
+				if stat, err := os.Stat(link); err == nil && os.SameFile(data, stat) {
+					return 0
+				}
+			}
+		}
+	}
+	return t.ContentLength
+}
+
+// scrubBufferSize is the size of each read when verifying task data
+const scrubBufferSize = 64 * 1024
+
+// verify re-hashes all pieces of task data and compares them with the piece md5 and the piece md5 sign,
+// it returns the bytes read and ErrDataCorrupted when any mismatch is found, reading is throttled by limiter
+func (t *localTaskStore) verify(ctx context.Context, limiter *rate.Limiter) (int64, error) {
+	t.RLock()
+	pieces := make([]PieceMetaData, 0, len(t.Pieces))
+	for _, piece := range t.Pieces {
+		pieces = append(pieces, piece)
+	}
+	expectedSign, actualSign := t.PieceMd5Sign, t.pieceMd5Sign()
+	contentLength := t.ContentLength
+	t.RUnlock()
+
+	if expectedSign != "" && actualSign != "" && expectedSign != actualSign {
+		return 0, errors.Wrapf(ErrDataCorrupted, "piece md5 sign not match, desired: %s, actual: %s", expectedSign, actualSign)
+	}
+
+	file, err := os.Open(t.DataFilePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if contentLength >= 0 && stat.Size() < contentLength {
+		return 0, errors.Wrapf(ErrDataCorrupted, "data size %d is less than content length %d", stat.Size(), contentLength)
+	}
+
+	sort.Slice(pieces, func(i, j int) bool {
+		return pieces[i].Num < pieces[j].Num
+	})
+	var (
+		read int64
+		buf  = make([]byte, scrubBufferSize)
+	)
+	for _, piece := range pieces {
+		if piece.Md5 == "" {
+			continue
+		}
+		algorithm, encoded, err := digestutils.Parse(piece.Md5)
+		if err != nil {
+			return read, errors.Wrapf(ErrDataCorrupted, "piece %d with invalid md5 %q", piece.Num, piece.Md5)
+		}
+		h, _ := digestutils.NewHash(algorithm)
+		reader := io.NewSectionReader(file, piece.Range.Start, piece.Range.Length)
+		for {
+			if err = limiter.WaitN(ctx, len(buf)); err != nil {
+				return read, err
+			}
+			n, err := reader.Read(buf)
+			h.Write(buf[:n])
+			read += int64(n)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return read, err
+			}
+		}
+		if actual := digestutils.ToHashString(h); actual != encoded {
+			return read, errors.Wrapf(ErrDataCorrupted, "piece %d %s not match, desired: %s, actual: %s",
+				piece.Num, algorithm, encoded, actual)
+		}
+	}
+	return read, nil
 }
 
 func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
+	if err := t.checkValid(); err != nil {
+		return nil, err
+	}
 	var pieces []*base.PieceInfo
 	t.RLock()
 	defer t.RUnlock()
@@ -266,7 +594,63 @@ func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskReque
 	}, nil
 }
 
+func (t *localTaskStore) stat() *TaskStoreStat {
+	t.RLock()
+	defer t.RUnlock()
+	var dataLength int64
+	for _, p := range t.Pieces {
+		dataLength += p.Range.Length
+	}
+	return &TaskStoreStat{
+		PeerTaskMetaData: PeerTaskMetaData{
+			PeerID: t.PeerID,
+			TaskID: t.TaskID,
+		},
+		ContentLength: t.ContentLength,
+		TotalPieces:   t.TotalPieces,
+		DataLength:    dataLength,
+		Done:          t.Done,
+		LastAccess:    t.lastAccess.Load(),
+		Pinned:        t.Pinned,
+		Biz:           t.Biz,
+	}
+}
+
+// resumeTask returns the resume information when the task is unfinished and has origin request
+func (t *localTaskStore) resumeTask() *ResumePeerTask {
+	t.RLock()
+	defer t.RUnlock()
+	if t.Done || t.OriginRequest == nil {
+		return nil
+	}
+	var pieces []PieceMetaData
+	for _, p := range t.Pieces {
+		pieces = append(pieces, p)
+	}
+	sort.Slice(pieces, func(i, j int) bool {
+		return pieces[i].Num < pieces[j].Num
+	})
+	return &ResumePeerTask{
+		PeerTaskMetaData: PeerTaskMetaData{
+			PeerID: t.PeerID,
+			TaskID: t.TaskID,
+		},
+		ContentLength: t.ContentLength,
+		TotalPieces:   t.TotalPieces,
+		OriginRequest: t.OriginRequest,
+		Pieces:        pieces,
+	}
+}
+
 func (t *localTaskStore) CanReclaim() bool {
+	if t.linkedDataModified() {
+		t.Warnf("task data is modified through the hard linked outputs %q", t.Links)
+		return true
+	}
+	// the invalid task is reclaimed after expired even it is pinned
+	if t.isPinned() && !t.isInvalid() {
+		return false
+	}
 	access := time.Unix(0, t.lastAccess.Load())
 	return access.Add(t.expireTime).Before(time.Now())
 }
@@ -381,6 +765,8 @@ func (t *localTaskStore) saveMetadata() error {
 	_, err = t.metadataFile.Write(data)
 	if err != nil {
 		logger.Errorf("save metadata error: %s", err)
+		return err
 	}
-	return err
+	// metadata may be shorter than the last saved one
+	return t.metadataFile.Truncate(int64(len(data)))
 }
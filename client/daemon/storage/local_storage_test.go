@@ -21,6 +21,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -30,6 +31,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	testifyassert "github.com/stretchr/testify/assert"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
@@ -38,6 +40,8 @@ import (
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	_ "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/server"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 func TestMain(m *testing.M) {
@@ -205,6 +209,19 @@ func TestLocalTaskStore_StoreTaskData_Simple(t *testing.T) {
 			TaskID:      ts.TaskID,
 			Destination: dst,
 		},
+		Digest: "md5:" + digestutils.Md5Bytes([]byte("other data")),
+	})
+	assert.Equal(digestutils.ErrDigestNotMatch, err, "digest must not match")
+	assert.False(ts.Done, "task must not be done when digest mismatch")
+	_, err = os.Stat(dst)
+	assert.True(os.IsNotExist(err), "output must not exist when digest mismatch")
+
+	err = ts.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			TaskID:      ts.TaskID,
+			Destination: dst,
+		},
+		Digest: "sha256:" + digestutils.Sha256(string(testData)),
 	})
 	assert.Nil(err, "store test data")
 	bs, err := ioutil.ReadFile(dst)
@@ -212,8 +229,254 @@ func TestLocalTaskStore_StoreTaskData_Simple(t *testing.T) {
 	assert.Equal(testData, bs, "data must match")
 }
 
+func TestLocalTaskStore_StoreTaskData_StoreMode(t *testing.T) {
+	assert := testifyassert.New(t)
+	testData := []byte("test data")
+	for _, mode := range []config.StoreMode{config.LinkStoreMode, config.ReflinkStoreMode, config.CopyStoreMode} {
+		src := path.Join(test.DataDir, taskData)
+		dst := path.Join(test.DataDir, taskData+".copy")
+		meta := path.Join(test.DataDir, taskData+".meta")
+		err := ioutil.WriteFile(src, testData, defaultFileMode)
+		assert.Nil(err, "prepare test data")
+
+		metadata, err := os.OpenFile(meta, os.O_RDWR|os.O_CREATE, defaultFileMode)
+		assert.Nil(err, "open test meta data")
+		ts := &localTaskStore{
+			SugaredLoggerOnWith: logger.With("test", "localTaskStore"),
+			persistentMetadata: persistentMetadata{
+				TaskID:        "test",
+				DataFilePath:  src,
+				ContentLength: int64(len(testData)),
+			},
+			dataDir:      test.DataDir,
+			metadataFile: metadata,
+			storeMode:    mode,
+			expireTime:   time.Minute,
+			gcCallback:   func(CommonTaskRequest) {},
+		}
+		ts.touch()
+		err = ts.Store(context.Background(), &StoreRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				TaskID:      ts.TaskID,
+				Destination: dst,
+			},
+		})
+		assert.Nil(err, "store test data with mode %s", mode)
+		bs, err := ioutil.ReadFile(dst)
+		assert.Nil(err, "read output test data")
+		assert.Equal(testData, bs, "data must match")
+
+		srcStat, _ := os.Stat(src)
+		dstStat, _ := os.Stat(dst)
+		if os.SameFile(srcStat, dstStat) {
+			// reflink falls back to hard link when the filesystem does not support it
+			assert.NotEqual(config.CopyStoreMode, mode, "output must not be hard linked with copy mode")
+			assert.Equal([]string{dst}, ts.Links, "hard linked output must be recorded")
+			assert.Equal(int64(0), ts.exclusiveSize(), "hard linked task data is owned by output")
+			assert.False(ts.CanReclaim(), "task data is not modified")
+
+			// modify the task data through output
+			time.Sleep(10 * time.Millisecond)
+			assert.Nil(ioutil.WriteFile(dst, []byte("modified by user"), defaultFileMode), "modify output")
+			assert.True(ts.linkedDataModified(), "modified task data must be detected")
+			assert.True(ts.CanReclaim(), "modified task data must be reclaimed")
+			_, _, err = ts.ReadPiece(context.Background(), &ReadPieceRequest{PieceMetaData: PieceMetaData{Num: -1, Range: clientutil.Range{Length: 1}}})
+			assert.Equal(ErrTaskInvalid, err, "modified task data must not be read")
+			assert.True(ts.isInvalid(), "modified task must be marked invalid")
+			_, err = ts.GetPieces(context.Background(), &base.PieceTaskRequest{TaskId: ts.TaskID, Limit: 1})
+			assert.Equal(ErrTaskInvalid, err, "modified task must not be uploaded")
+
+			// the output is replaced by user, task data is not shared any more
+			os.Remove(dst)
+			assert.Equal(int64(len(testData)), ts.exclusiveSize(), "task data is owned by task")
+		} else {
+			assert.NotEqual(config.LinkStoreMode, mode, "output must be hard linked with link mode")
+			assert.Empty(ts.Links, "output is not hard linked")
+			assert.Equal(int64(len(testData)), ts.exclusiveSize(), "task data is owned by task")
+		}
+
+		metadata.Close()
+		os.Remove(src)
+		os.Remove(dst)
+		os.Remove(meta)
+	}
+}
+
+func TestLocalTaskStore_WatchAndPieceMd5Sign(t *testing.T) {
+	assert := testifyassert.New(t)
+	src := path.Join(test.DataDir, taskData)
+	meta := path.Join(test.DataDir, taskData+".meta")
+	testData := []byte("test data")
+	err := ioutil.WriteFile(src, testData, defaultFileMode)
+	assert.Nil(err, "prepare test data")
+	defer os.Remove(src)
+	defer os.Remove(meta)
+
+	metadata, err := os.OpenFile(meta, os.O_RDWR|os.O_CREATE, defaultFileMode)
+	assert.Nil(err, "open test meta data")
+	defer metadata.Close()
+	ts := &localTaskStore{
+		SugaredLoggerOnWith: logger.With("test", "localTaskStore"),
+		persistentMetadata: persistentMetadata{
+			TaskID:       "test",
+			DataFilePath: src,
+			Pieces:       map[int32]PieceMetaData{},
+		},
+		dataDir:      test.DataDir,
+		metadataFile: metadata,
+	}
+
+	changed := ts.watch()
+	for i, data := range [][]byte{testData[:4], testData[4:]} {
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PieceMetaData: PieceMetaData{
+				Num: int32(i),
+				Md5: digestutils.Md5Bytes(data),
+				Range: clientutil.Range{
+					Start:  int64(i * 4),
+					Length: int64(len(data)),
+				},
+			},
+			Reader: bytes.NewBuffer(data),
+		})
+		assert.Nil(err, "write piece")
+		select {
+		case <-changed:
+		default:
+			assert.Fail("watcher must be notified after writing piece")
+		}
+		changed = ts.watch()
+	}
+
+	// the piece md5 sign pushed by parent must match the pieces
+	err = ts.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{TaskID: ts.TaskID},
+		MetadataOnly:      true,
+		TotalPieces:       2,
+		PieceMd5Sign:      "mismatched",
+	})
+	assert.True(errors.Is(err, digestutils.ErrDigestNotMatch), "store with mismatched piece md5 sign")
+	assert.False(ts.Done)
+	assert.Equal("", ts.PieceMd5Sign)
+
+	sign := digestutils.Sha256(digestutils.Md5Bytes(testData[:4]), digestutils.Md5Bytes(testData[4:]))
+	err = ts.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{TaskID: ts.TaskID},
+		MetadataOnly:      true,
+		TotalPieces:       2,
+		PieceMd5Sign:      sign,
+	})
+	assert.Nil(err, "store metadata")
+	<-changed
+	assert.True(ts.Done)
+	assert.Equal(sign, ts.PieceMd5Sign)
+}
+
 func TestLocalTaskStore_ReloadPersistentTask_Simple(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	dataDir, err := ioutil.TempDir("", "dragonfly-resume-test")
+	assert.Nil(err, "create data dir")
+	defer os.RemoveAll(dataDir)
+
+	var (
+		taskID    = "task-d4bb1c273a9889fea14abd4651994fe8"
+		peerID    = "peer-d4bb1c273a9889fea14abd4651994fe8"
+		pieceSize = 512
+		origin    = &OriginRequest{
+			Url:     "http://example.com/file",
+			UrlMeta: &base.UrlMeta{Tag: "d7y-test"},
+		}
+		storageOption = &config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, storageOption, func(request CommonTaskRequest) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sm.(*storageManager).CreateTask(
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testBytes)),
+			OriginRequest: origin,
+		})
+	assert.Nil(err, "create task storage")
+	ts, ok := sm.(*storageManager).LoadTask(PeerTaskMetaData{
+		PeerID: peerID,
+		TaskID: taskID,
+	})
+	assert.True(ok, "")
 
+	// put the even pieces only, like the daemon exits during downloading
+	var written []int32
+	for i := 0; i*pieceSize < len(testBytes); i += 2 {
+		start := i * pieceSize
+		end := start + pieceSize
+		if end > len(testBytes) {
+			end = len(testBytes)
+		}
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetaData: PeerTaskMetaData{
+				TaskID: taskID,
+			},
+			PieceMetaData: PieceMetaData{
+				Num:    int32(i),
+				Offset: uint64(start),
+				Range: clientutil.Range{
+					Start:  int64(start),
+					Length: int64(end - start),
+				},
+				Style: base.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(testBytes[start:end]),
+		})
+		assert.Nil(err, "put piece")
+		written = append(written, int32(i))
+	}
+	// skip the throttle for saving the latest pieces
+	ts.(*localTaskStore).lastPersist.Store(0)
+	ts.(*localTaskStore).persistUnfinished()
+
+	// reload from disk
+	sm, err = NewStorageManager(config.SimpleLocalTaskStoreStrategy, storageOption, func(request CommonTaskRequest) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := sm.FindUnfinishedTasks()
+	assert.Equal(1, len(tasks), "unfinished task should be found")
+	if len(tasks) != 1 {
+		return
+	}
+	assert.Equal(peerID, tasks[0].PeerID)
+	assert.Equal(taskID, tasks[0].TaskID)
+	assert.Equal(int64(len(testBytes)), tasks[0].ContentLength)
+	assert.Equal(origin.Url, tasks[0].OriginRequest.Url)
+	assert.Equal(origin.UrlMeta.Tag, tasks[0].OriginRequest.UrlMeta.Tag)
+	var resumed []int32
+	for _, p := range tasks[0].Pieces {
+		resumed = append(resumed, p.Num)
+	}
+	assert.Equal(written, resumed, "stored pieces should be resumed")
+
+	// the completed task should not be resumed
+	err = sm.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: peerID,
+			TaskID: taskID,
+		},
+		MetadataOnly: true,
+	})
+	assert.Nil(err, "store task")
+	assert.Equal(0, len(sm.FindUnfinishedTasks()), "completed task should not be resumed")
 }
 
 func TestLocalTaskStore_PutAndGetPiece_Advance(t *testing.T) {
@@ -351,6 +614,64 @@ func TestLocalTaskStore_ReloadPersistentTask_Advance(t *testing.T) {
 
 }
 
+func TestStorageManager_PinTaskAndBizQuota(t *testing.T) {
+	assert := testifyassert.New(t)
+	dataDir, err := ioutil.TempDir("", "dragonfly-pin-test")
+	assert.Nil(err, "create data dir")
+	defer os.RemoveAll(dataDir)
+
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+			BizQuotas: map[string]unit.Bytes{
+				"ci": 1000,
+			},
+		}, func(request CommonTaskRequest) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s = sm.(*storageManager)
+
+	// 3 tasks of biz ci exceed the quota without the pinned one which is the oldest, 1 task of other biz
+	var tasks []*localTaskStore
+	for i, biz := range []string{"ci", "ci", "ci", "ci", "other"} {
+		req := RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: fmt.Sprintf("peer-%d", i),
+				TaskID: fmt.Sprintf("task-%d", i),
+			},
+			ContentLength: 400,
+			Biz:           biz,
+		}
+		assert.Nil(s.CreateTask(req), "create task storage")
+		task, _ := s.LoadTask(PeerTaskMetaData{PeerID: req.PeerID, TaskID: req.TaskID})
+		task.(*localTaskStore).lastAccess.Store(time.Now().Add(time.Duration(i) * time.Second).UnixNano())
+		tasks = append(tasks, task.(*localTaskStore))
+	}
+	assert.Equal(ErrTaskNotFound, s.PinTask(context.Background(), PeerTaskMetaData{TaskID: "not-exist"}, true))
+	assert.Nil(s.PinTask(context.Background(), PeerTaskMetaData{TaskID: "task-0"}, true))
+	for _, stat := range s.ListTasks() {
+		assert.Equal(stat.TaskID == "task-0", stat.Pinned)
+	}
+
+	_, err = s.TryGC()
+	assert.Nil(err, "gc")
+	assert.False(tasks[0].reclaimMarked.Load(), "pinned task should not be reclaimed")
+	assert.True(tasks[1].reclaimMarked.Load(), "the oldest task not pinned of biz should be reclaimed")
+	assert.False(tasks[2].reclaimMarked.Load(), "biz quota is not exceeded any more")
+	assert.False(tasks[3].reclaimMarked.Load(), "biz quota is not exceeded any more")
+	assert.False(tasks[4].reclaimMarked.Load(), "task of other biz should not be reclaimed")
+
+	// expired pinned task should not be reclaimed
+	tasks[0].lastAccess.Store(time.Now().Add(-time.Hour).UnixNano())
+	assert.False(tasks[0].CanReclaim(), "pinned task should not be reclaimed")
+	assert.Nil(s.PinTask(context.Background(), PeerTaskMetaData{TaskID: "task-0", PeerID: "peer-0"}, false))
+	assert.True(tasks[0].CanReclaim(), "unpinned task should be reclaimed")
+}
+
 func calcFileMd5(filePath string) (string, error) {
 	var md5String string
 	file, err := os.Open(filePath)
@@ -367,3 +688,152 @@ func calcFileMd5(filePath string) (string, error) {
 	md5String = hex.EncodeToString(hashInBytes)
 	return md5String, nil
 }
+
+func TestStorageManager_ListAndDeleteTask(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	var (
+		taskID = "task-list-and-delete"
+		peerID = "peer-list-and-delete"
+		left   []CommonTaskRequest
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {
+			left = append(left, request)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s = sm.(*storageManager)
+	err = s.CreateTask(
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: 1024,
+			TotalPieces:   1,
+		})
+	assert.Nil(err, "create task storage")
+
+	stats := s.ListTasks()
+	assert.Equal(1, len(stats))
+	assert.Equal(taskID, stats[0].TaskID)
+	assert.Equal(peerID, stats[0].PeerID)
+	assert.Equal(int64(1024), stats[0].ContentLength)
+	assert.False(stats[0].Done)
+
+	assert.Equal(ErrTaskNotFound, s.DeleteTask(context.Background(), PeerTaskMetaData{TaskID: "not-exist"}))
+	assert.Nil(s.DeleteTask(context.Background(), PeerTaskMetaData{TaskID: taskID}))
+	assert.Equal(0, len(s.ListTasks()))
+	assert.Equal([]CommonTaskRequest{{PeerID: peerID, TaskID: taskID}}, left)
+	_, ok := s.LoadTask(PeerTaskMetaData{PeerID: peerID, TaskID: taskID})
+	assert.False(ok)
+}
+
+func TestStorageManager_Scrub(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	dataDir, err := ioutil.TempDir("", "dragonfly-scrub-test")
+	assert.Nil(err, "create data dir")
+	defer os.RemoveAll(dataDir)
+
+	var (
+		taskID   = "task-scrub"
+		peerID   = "peer-scrub"
+		testData = []byte("test scrub data")
+		left     []CommonTaskRequest
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {
+			left = append(left, request)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s = sm.(*storageManager)
+	err = s.CreateTask(
+		RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testData)),
+			TotalPieces:   2,
+		})
+	assert.Nil(err, "create task storage")
+
+	for i, data := range [][]byte{testData[:8], testData[8:]} {
+		_, err = s.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetaData: PeerTaskMetaData{PeerID: peerID, TaskID: taskID},
+			PieceMetaData: PieceMetaData{
+				Num: int32(i),
+				Md5: digestutils.Md5Bytes(data),
+				Range: clientutil.Range{
+					Start:  int64(i * 8),
+					Length: int64(len(data)),
+				},
+			},
+			Reader: bytes.NewBuffer(data),
+		})
+		assert.Nil(err, "write piece")
+	}
+	err = s.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{PeerID: peerID, TaskID: taskID},
+		MetadataOnly:      true,
+		TotalPieces:       2,
+	})
+	assert.Nil(err, "store metadata")
+
+	sc := newScrubber(s, time.Hour, 0)
+	sc.scrub(context.Background())
+	ts, ok := s.LoadTask(PeerTaskMetaData{PeerID: peerID, TaskID: taskID})
+	assert.True(ok, "intact task must be kept")
+
+	// corrupt the second piece
+	file, err := os.OpenFile(ts.(*localTaskStore).DataFilePath, os.O_WRONLY, defaultFileMode)
+	assert.Nil(err, "open task data")
+	_, err = file.WriteAt([]byte("x"), 10)
+	assert.Nil(err, "corrupt task data")
+	file.Close()
+
+	_, err = ts.(*localTaskStore).verify(context.Background(), sc.limiter)
+	assert.True(errors.Is(err, ErrDataCorrupted))
+
+	sc.scrub(context.Background())
+	ts, ok = s.LoadTask(PeerTaskMetaData{PeerID: peerID, TaskID: taskID})
+	assert.True(ok, "corrupted task must be kept for inspection")
+	assert.True(ts.(*localTaskStore).isInvalid(), "corrupted task must be marked invalid")
+	_, err = os.Stat(ts.(*localTaskStore).DataFilePath)
+	assert.Nil(err, "corrupted task data must be kept")
+	assert.Nil(s.FindCompletedTask(taskID), "invalid task must not be reused")
+	_, _, err = s.ReadPiece(context.Background(), &ReadPieceRequest{
+		PeerTaskMetaData: PeerTaskMetaData{PeerID: peerID, TaskID: taskID},
+		PieceMetaData:    PieceMetaData{Num: 0},
+	})
+	assert.Equal(ErrTaskInvalid, err, "invalid task must not be uploaded")
+	assert.Equal([]CommonTaskRequest{{PeerID: peerID, TaskID: taskID}}, left)
+
+	// the invalid task is skipped
+	sc.scrub(context.Background())
+	assert.Len(left, 1)
+
+	// the stopped scrubber never starts
+	sc.lastScrub.Store(0)
+	sc.Stop()
+	started, err := sc.TryGC()
+	assert.Nil(err)
+	assert.False(started)
+}
@@ -34,6 +34,29 @@ type persistentMetadata struct {
 	PieceMd5Sign  string                  `json:"pieceMd5Sign"`
 	DataFilePath  string                  `json:"dataFilePath"`
 	Done          bool                    `json:"done"`
+	OriginRequest *OriginRequest          `json:"originRequest,omitempty"`
+	// Links are the outputs which are hard linked to the task data, they share the same file with task data
+	Links []string `json:"links,omitempty"`
+	// DataModTime is the modification time in unix nano of the task data when it's hard linked to outputs,
+	// the task data is modified by user when it changes
+	DataModTime int64 `json:"dataModTime,omitempty"`
+	// Pinned task is never reclaimed by gc
+	Pinned bool `json:"pinned,omitempty"`
+	// Biz is the owner of the task for storage quota
+	Biz string `json:"biz,omitempty"`
+	// Invalid task is found corrupted by scrubber, it is not reused or uploaded any more,
+	// the data is kept for inspection until reclaimed by gc
+	Invalid bool `json:"invalid,omitempty"`
+}
+
+// OriginRequest is the request which starts the task, it's persisted for resuming the unfinished task after restart
+type OriginRequest struct {
+	Url     string        `json:"url"`
+	Filter  string        `json:"filter,omitempty"`
+	BizID   string        `json:"bizID,omitempty"`
+	UrlMeta *base.UrlMeta `json:"urlMeta,omitempty"`
+	// Destination is the output of file task, it's empty for stream task
+	Destination string `json:"destination,omitempty"`
 }
 
 type PeerTaskMetaData struct {
@@ -60,6 +83,10 @@ type RegisterTaskRequest struct {
 	ContentLength int64
 	TotalPieces   int32
 	GCCallback    func(CommonTaskRequest)
+	// OriginRequest is used to resume the task after restart, the task will not be resumed when it's nil
+	OriginRequest *OriginRequest
+	// Biz is the owner of the task for storage quota, it's the biz id or call system of request
+	Biz string
 }
 
 type WritePieceRequest struct {
@@ -74,6 +101,10 @@ type StoreRequest struct {
 	MetadataOnly bool
 	StoreOnly    bool
 	TotalPieces  int32
+	// Digest is used to verify the whole task data before storing, in format of algorithm:encoded
+	Digest string
+	// PieceMd5Sign is the piece md5 sign pushed by the parent, it's verified with the piece md5 before storing
+	PieceMd5Sign string
 }
 
 type ReadPieceRequest struct {
@@ -88,3 +119,27 @@ type UpdateTaskRequest struct {
 }
 
 type ReusePeerTask = UpdateTaskRequest
+
+// ResumePeerTask is an unfinished task in storage which can be resumed
+type ResumePeerTask struct {
+	PeerTaskMetaData
+	ContentLength int64
+	TotalPieces   int32
+	OriginRequest *OriginRequest
+	// Pieces are the pieces already stored
+	Pieces []PieceMetaData
+}
+
+// TaskStoreStat is the status of a task data in storage
+type TaskStoreStat struct {
+	PeerTaskMetaData
+	ContentLength int64
+	TotalPieces   int32
+	// DataLength is the length of all stored pieces
+	DataLength int64
+	Done       bool
+	// LastAccess is the last access time in unix nano
+	LastAccess int64
+	Pinned     bool
+	Biz        string
+}
@@ -0,0 +1,134 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/gc"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+// scrubber verifies the data of finished tasks in background, the corrupted tasks are marked invalid,
+// so that they will not be uploaded to other peers and will be downloaded again,
+// their data is kept for inspection until reclaimed by gc
+type scrubber struct {
+	manager  *storageManager
+	interval time.Duration
+	limiter  *rate.Limiter
+	// ctx is canceled when the scrubber stops
+	ctx    context.Context
+	cancel context.CancelFunc
+	// running indicates a scrub is in progress, at most one scrub runs at the same time
+	running atomic.Bool
+	// lastScrub is the last time in unix nano of starting a scrub
+	lastScrub atomic.Int64
+}
+
+var _ gc.GC = (*scrubber)(nil)
+var _ gc.Stoppable = (*scrubber)(nil)
+
+func newScrubber(manager *storageManager, interval time.Duration, limit rate.Limit) *scrubber {
+	if limit <= 0 {
+		limit = rate.Limit(config.DefaultScrubRateLimit)
+	}
+	burst := scrubBufferSize
+	if int(limit) > burst {
+		burst = int(limit)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scrubber{
+		manager:  manager,
+		interval: interval,
+		limiter:  rate.NewLimiter(limit, burst),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	s.lastScrub.Store(time.Now().UnixNano())
+	return s
+}
+
+// TryGC starts a scrub in background when the interval elapses, it never blocks the other gc
+func (s *scrubber) TryGC() (bool, error) {
+	now := time.Now().UnixNano()
+	last := s.lastScrub.Load()
+	if s.ctx.Err() != nil || now-last < int64(s.interval) || !s.running.CAS(false, true) {
+		return false, nil
+	}
+	s.lastScrub.Store(now)
+	go func() {
+		defer s.running.Store(false)
+		s.scrub(s.ctx)
+	}()
+	return true, nil
+}
+
+// Stop cancels the running scrub
+func (s *scrubber) Stop() {
+	s.cancel()
+}
+
+// scrub verifies all finished tasks and marks the corrupted ones invalid
+func (s *scrubber) scrub(ctx context.Context) {
+	var tasks []*localTaskStore
+	s.manager.tasks.Range(func(key, value interface{}) bool {
+		t := value.(*localTaskStore)
+		// skip reclaimed task, unfinished task, invalid task and task data modified by user via hard link
+		if t.reclaimMarked.Load() || !t.Done || t.isInvalid() || t.linkedDataModified() {
+			return true
+		}
+		tasks = append(tasks, t)
+		return true
+	})
+
+	var scrubbed, corrupted int
+	for _, t := range tasks {
+		if ctx.Err() != nil {
+			logger.Infof("scrub canceled, %d tasks scrubbed, %d tasks corrupted", scrubbed, corrupted)
+			return
+		}
+		if t.reclaimMarked.Load() {
+			continue
+		}
+		read, err := t.verify(ctx, s.limiter)
+		metrics.ScrubbedBytes.Add(float64(read))
+		if err != nil && !errors.Is(err, ErrDataCorrupted) {
+			// task may be reclaimed during verifying
+			t.Warnf("scrub task error: %s", err)
+			continue
+		}
+		scrubbed++
+		metrics.ScrubbedTaskCount.Add(1)
+		if err == nil {
+			continue
+		}
+		corrupted++
+		metrics.ScrubCorruptedTaskCount.Add(1)
+		t.Errorf("task data corrupted, mark it invalid: %s", err)
+		if err = t.markInvalid(); err != nil {
+			t.Errorf("mark corrupted task invalid error: %s", err)
+		}
+	}
+	logger.Infof("scrub done, %d tasks scrubbed, %d tasks corrupted", scrubbed, corrupted)
+}
@@ -24,7 +24,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -35,10 +34,12 @@ import (
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/gc"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 )
@@ -81,6 +82,17 @@ type Manager interface {
 	RegisterTask(ctx context.Context, req RegisterTaskRequest) error
 	// FindCompletedTask try to find a completed task for fast path
 	FindCompletedTask(taskID string) *ReusePeerTask
+	// FindUnfinishedTasks returns the unfinished tasks with origin request, which can be resumed after restart
+	FindUnfinishedTasks() []*ResumePeerTask
+	// ListTasks returns the status of all tasks in storage
+	ListTasks() []*TaskStoreStat
+	// DeleteTask reclaims the task data immediately, when peer id is empty, all peers of the task will be reclaimed
+	DeleteTask(ctx context.Context, req PeerTaskMetaData) error
+	// PinTask pins or unpins the task, the pinned task is never reclaimed by gc,
+	// when peer id is empty, all peers of the task will be pinned or unpinned
+	PinTask(ctx context.Context, req PeerTaskMetaData, pinned bool) error
+	// WatchTask returns a channel which is closed when the pieces or the state of the task change
+	WatchTask(req PeerTaskMetaData) (<-chan struct{}, error)
 	// CleanUp cleans all storage data
 	CleanUp()
 }
@@ -88,10 +100,15 @@ type Manager interface {
 var (
 	ErrTaskNotFound  = errors.New("task not found")
 	ErrPieceNotFound = errors.New("piece not found")
+	// ErrTaskInvalid means the task is found corrupted and kept for inspection only
+	ErrTaskInvalid = errors.New("task is invalid")
+	// ErrDataCorrupted means the task data does not match the piece md5 or the piece md5 sign
+	ErrDataCorrupted = errors.New("task data corrupted")
 )
 
 const (
-	GCName = "StorageManager"
+	GCName       = "StorageManager"
+	ScrubberName = "StorageScrubber"
 )
 
 var tracer trace.Tracer
@@ -107,9 +124,12 @@ type storageManager struct {
 	storeOption        *config.StorageOption
 	tasks              sync.Map
 	markedReclaimTasks []PeerTaskMetaData
-	dataPathStat       *syscall.Stat_t
 	gcCallback         func(CommonTaskRequest)
 
+	// dataDirs stores task data, new task is placed by DataDirPlacement
+	dataDirs    []*dataDir
+	nextDataDir atomic.Uint64
+
 	indexRWMutex       sync.RWMutex
 	indexTask2PeerTask map[string][]*localTaskStore // key: task id, value: slice of localTaskStore
 }
@@ -120,23 +140,6 @@ var _ Manager = (*storageManager)(nil)
 type GCCallback func(request CommonTaskRequest)
 
 func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOption, gcCallback GCCallback, moreOpts ...func(*storageManager) error) (Manager, error) {
-	if !path.IsAbs(opt.DataPath) {
-		abs, err := filepath.Abs(opt.DataPath)
-		if err != nil {
-			return nil, err
-		}
-		opt.DataPath = abs
-	}
-	stat, err := os.Stat(opt.DataPath)
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(opt.DataPath, defaultDirectoryMode); err != nil {
-			return nil, err
-		}
-		stat, err = os.Stat(opt.DataPath)
-	}
-	if err != nil {
-		return nil, err
-	}
 	switch storeStrategy {
 	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy:
 	case config.StoreStrategy(""):
@@ -149,7 +152,6 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		KeepAlive:     clientutil.NewKeepAlive("storage manager"),
 		storeStrategy: storeStrategy,
 		storeOption:   opt,
-		dataPathStat:  stat.Sys().(*syscall.Stat_t),
 		gcCallback:    gcCallback,
 
 		indexTask2PeerTask: map[string][]*localTaskStore{},
@@ -161,11 +163,20 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		}
 	}
 
+	dataDirs, err := newDataDirs(s.storeOption)
+	if err != nil {
+		return nil, err
+	}
+	s.dataDirs = dataDirs
+
 	if err := s.ReloadPersistentTask(gcCallback); err != nil {
 		logger.Warnf("reload tasks error: %s", err)
 	}
 
 	gc.Register(GCName, s)
+	if s.storeOption.ScrubInterval.Duration > 0 {
+		gc.Register(ScrubberName, newScrubber(s, s.storeOption.ScrubInterval.Duration, s.storeOption.ScrubRateLimit.Limit))
+	}
 	return s, nil
 }
 
@@ -262,6 +273,14 @@ func (s *storageManager) GetPieces(ctx context.Context, req *base.PieceTaskReque
 	return t.(TaskStorageDriver).GetPieces(ctx, req)
 }
 
+func (s *storageManager) WatchTask(req PeerTaskMetaData) (<-chan struct{}, error) {
+	t, ok := s.LoadTask(req)
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return t.(*localTaskStore).watch(), nil
+}
+
 func (s *storageManager) LoadTask(meta PeerTaskMetaData) (TaskStorageDriver, bool) {
 	s.Keep()
 	d, ok := s.tasks.Load(meta)
@@ -287,7 +306,28 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 	s.Keep()
 	logger.Debugf("init local task storage, peer id: %s, task id: %s", req.PeerID, req.TaskID)
 
-	dataDir := path.Join(s.storeOption.DataPath, req.TaskID, req.PeerID)
+	var (
+		disk     *dataDir
+		dataDir  string
+		metadata *os.File
+		err      error
+	)
+	// try the next data dir when the disk is read-only or disappeared
+	for _, disk = range s.selectDataDirs(req.ContentLength) {
+		dataDir = path.Join(disk.path, req.TaskID, req.PeerID)
+		if metadata, err = createTaskDir(dataDir); err == nil {
+			break
+		}
+		logger.Warnf("create task %s/%s in data dir %s error: %s", req.TaskID, req.PeerID, disk.path, err)
+		disk.probe()
+	}
+	if metadata == nil {
+		if err == nil {
+			err = ErrNoAvailableDataDir
+		}
+		return err
+	}
+
 	t := &localTaskStore{
 		persistentMetadata: persistentMetadata{
 			StoreStrategy: string(s.storeStrategy),
@@ -297,23 +337,20 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 			TotalPieces:   req.TotalPieces,
 			PeerID:        req.PeerID,
 			Pieces:        map[int32]PieceMetaData{},
+			OriginRequest: req.OriginRequest,
+			Biz:           req.Biz,
 		},
 		gcCallback:       s.gcCallback,
 		dataDir:          dataDir,
+		disk:             disk,
+		storeMode:        s.storeOption.StoreMode,
+		metadataFile:     metadata,
 		metadataFilePath: path.Join(dataDir, taskMetaData),
 		expireTime:       s.storeOption.TaskExpireTime.Duration,
 
 		SugaredLoggerOnWith: logger.With("task", req.TaskID, "peer", req.PeerID, "component", "localTaskStore"),
 	}
-	if err := os.MkdirAll(t.dataDir, defaultDirectoryMode); err != nil && !os.IsExist(err) {
-		return err
-	}
 	t.touch()
-	metadata, err := os.OpenFile(t.metadataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
-	if err != nil {
-		return err
-	}
-	t.metadataFile = metadata
 
 	// fallback to simple strategy for proxy
 	if req.Destination == "" {
@@ -344,7 +381,7 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 
 		stat := dirStat.Sys().(*syscall.Stat_t)
 		// same dev, can hard link
-		if stat.Dev == s.dataPathStat.Dev {
+		if uint64(stat.Dev) == disk.dev {
 			logger.Debugf("same device, try to hard link")
 			if err := os.Link(t.DataFilePath, data); err != nil {
 				logger.Warnf("hard link failed for same device: %s, fallback to symbol link", err)
@@ -363,6 +400,10 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 			}
 		}
 	}
+	// save metadata at first, the task can be resumed even no piece is written
+	if t.OriginRequest != nil {
+		t.persistUnfinished()
+	}
 	s.tasks.Store(
 		PeerTaskMetaData{
 			PeerID: req.PeerID,
@@ -380,6 +421,14 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 	return nil
 }
 
+// createTaskDir creates the data directory and the metadata file of task
+func createTaskDir(dataDir string) (*os.File, error) {
+	if err := os.MkdirAll(dataDir, defaultDirectoryMode); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	return os.OpenFile(path.Join(dataDir, taskMetaData), os.O_CREATE|os.O_RDWR, defaultFileMode)
+}
+
 func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 	s.indexRWMutex.RLock()
 	defer s.indexRWMutex.RUnlock()
@@ -395,7 +444,8 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 			continue
 		}
 
-		if !t.Done {
+		// the task data modified through hard linked outputs and the invalid task will be reclaimed by gc
+		if !t.Done || t.isInvalid() || t.linkedDataModified() {
 			continue
 		}
 		return &ReusePeerTask{
@@ -410,6 +460,105 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 	return nil
 }
 
+func (s *storageManager) FindUnfinishedTasks() []*ResumePeerTask {
+	var tasks []*ResumePeerTask
+	s.tasks.Range(func(key, value interface{}) bool {
+		t := value.(*localTaskStore)
+		if t.reclaimMarked.Load() {
+			return true
+		}
+		if task := t.resumeTask(); task != nil {
+			tasks = append(tasks, task)
+		}
+		return true
+	})
+	return tasks
+}
+
+func (s *storageManager) ListTasks() []*TaskStoreStat {
+	var stats []*TaskStoreStat
+	s.tasks.Range(func(key, task interface{}) bool {
+		t := task.(*localTaskStore)
+		// skip reclaimed task
+		if t.reclaimMarked.Load() {
+			return true
+		}
+		stats = append(stats, t.stat())
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].LastAccess > stats[j].LastAccess
+	})
+	return stats
+}
+
+func (s *storageManager) DeleteTask(ctx context.Context, req PeerTaskMetaData) error {
+	var tasks []*localTaskStore
+	if req.PeerID != "" {
+		t, ok := s.tasks.Load(req)
+		if !ok {
+			return ErrTaskNotFound
+		}
+		tasks = append(tasks, t.(*localTaskStore))
+	} else {
+		s.indexRWMutex.RLock()
+		tasks = append(tasks, s.indexTask2PeerTask[req.TaskID]...)
+		s.indexRWMutex.RUnlock()
+		if len(tasks) == 0 {
+			return ErrTaskNotFound
+		}
+	}
+
+	_, span := tracer.Start(ctx, config.SpanPeerGC)
+	defer span.End()
+	span.SetAttributes(config.AttributeTaskID.String(req.TaskID))
+	// delete all tasks even some of them failed, the failed tasks are already removed from index
+	var errs []string
+	for _, task := range tasks {
+		key := PeerTaskMetaData{PeerID: task.PeerID, TaskID: task.TaskID}
+		s.tasks.Delete(key)
+		s.cleanIndex(task.TaskID, task.PeerID)
+		task.MarkReclaim()
+		if err := task.Reclaim(); err != nil {
+			logger.Errorf("delete task %s/%s error: %s", key.TaskID, key.PeerID, err)
+			span.RecordError(err)
+			errs = append(errs, fmt.Sprintf("%s/%s: %s", key.TaskID, key.PeerID, err))
+			continue
+		}
+		logger.Infof("task %s/%s deleted", key.TaskID, key.PeerID)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("delete %d of %d tasks error: %s", len(errs), len(tasks), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *storageManager) PinTask(ctx context.Context, req PeerTaskMetaData, pinned bool) error {
+	var tasks []*localTaskStore
+	if req.PeerID != "" {
+		t, ok := s.tasks.Load(req)
+		if !ok {
+			return ErrTaskNotFound
+		}
+		tasks = append(tasks, t.(*localTaskStore))
+	} else {
+		s.indexRWMutex.RLock()
+		tasks = append(tasks, s.indexTask2PeerTask[req.TaskID]...)
+		s.indexRWMutex.RUnlock()
+		if len(tasks) == 0 {
+			return ErrTaskNotFound
+		}
+	}
+	for _, task := range tasks {
+		if err := task.setPinned(pinned); err != nil {
+			logger.Errorf("pin task %s/%s error: %s", task.TaskID, task.PeerID, err)
+			return err
+		}
+		logger.Infof("task %s/%s pinned: %t", task.TaskID, task.PeerID, pinned)
+	}
+	return nil
+}
+
 func (s *storageManager) cleanIndex(taskID, peerID string) {
 	s.indexRWMutex.Lock()
 	defer s.indexRWMutex.Unlock()
@@ -430,28 +579,81 @@ func (s *storageManager) cleanIndex(taskID, peerID string) {
 }
 
 func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
-	dirs, err := ioutil.ReadDir(s.storeOption.DataPath)
-	if os.IsNotExist(err) {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
 	var (
 		loadErrs    []error
 		loadErrDirs []string
 	)
+	for _, disk := range s.dataDirs {
+		errs, errDirs := s.reloadDataDir(disk, gcCallback)
+		loadErrs = append(loadErrs, errs...)
+		loadErrDirs = append(loadErrDirs, errDirs...)
+	}
+	// remove load error peer tasks
+	for _, dir := range loadErrDirs {
+		// remove metadata
+		if err := os.Remove(path.Join(dir, taskMetaData)); err != nil {
+			logger.Warnf("remove load error file %s error: %s", path.Join(dir, taskMetaData), err)
+		} else {
+			logger.Warnf("remove load error file %s ok", path.Join(dir, taskMetaData))
+		}
+
+		// remove data
+		data := path.Join(dir, taskData)
+		stat, err := os.Lstat(data)
+		if err == nil {
+			// remove sym link file
+			if stat.Mode()&os.ModeSymlink == os.ModeSymlink {
+				dest, err0 := os.Readlink(data)
+				if err0 == nil {
+					if err = os.Remove(dest); err != nil {
+						logger.Warnf("remove load error file %s error: %s", data, err)
+					}
+				}
+			}
+			if err = os.Remove(data); err != nil {
+				logger.Warnf("remove load error file %s error: %s", data, err)
+			} else {
+				logger.Warnf("remove load error file %s ok", data)
+			}
+		}
+
+		if err = os.Remove(dir); err != nil {
+			logger.Warnf("remove load error directory %s error: %s", dir, err)
+		}
+		logger.Warnf("remove load error directory %s ok", dir)
+	}
+	if len(loadErrs) > 0 {
+		var sb strings.Builder
+		for _, err := range loadErrs {
+			sb.WriteString(err.Error())
+		}
+		return fmt.Errorf("load tasks from disk error: %q", sb.String())
+	}
+	return nil
+}
+
+// reloadDataDir loads the tasks in the data dir, returns the errors and the directories of tasks which can not be loaded
+func (s *storageManager) reloadDataDir(disk *dataDir, gcCallback GCCallback) (loadErrs []error, loadErrDirs []string) {
+	dirs, err := ioutil.ReadDir(disk.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			loadErrs = append(loadErrs, err)
+		}
+		return
+	}
 	for _, dir := range dirs {
 		taskID := dir.Name()
-		peerDirs, err := ioutil.ReadDir(path.Join(s.storeOption.DataPath, taskID))
+		peerDirs, err := ioutil.ReadDir(path.Join(disk.path, taskID))
 		if err != nil {
 			continue
 		}
 		for _, peerDir := range peerDirs {
 			peerID := peerDir.Name()
-			dataDir := path.Join(s.storeOption.DataPath, taskID, peerID)
+			dataDir := path.Join(disk.path, taskID, peerID)
 			t := &localTaskStore{
 				dataDir:             dataDir,
+				disk:                disk,
+				storeMode:           s.storeOption.StoreMode,
 				metadataFilePath:    path.Join(dataDir, taskMetaData),
 				expireTime:          s.storeOption.TaskExpireTime.Duration,
 				gcCallback:          gcCallback,
@@ -459,7 +661,8 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			}
 			t.touch()
 
-			if t.metadataFile, err = os.Open(t.metadataFilePath); err != nil {
+			// metadata of unfinished task will be updated when it's resumed
+			if t.metadataFile, err = os.OpenFile(t.metadataFilePath, os.O_RDWR, defaultFileMode); err != nil {
 				loadErrs = append(loadErrs, err)
 				loadErrDirs = append(loadErrDirs, dataDir)
 				logger.With("action", "reload", "stage", "read metadata", "taskID", taskID, "peerID", peerID).
@@ -498,90 +701,92 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			}
 		}
 	}
-	// remove load error peer tasks
-	for _, dir := range loadErrDirs {
-		// remove metadata
-		if err = os.Remove(path.Join(dir, taskMetaData)); err != nil {
-			logger.Warnf("remove load error file %s error: %s", path.Join(dir, taskMetaData), err)
-		} else {
-			logger.Warnf("remove load error file %s ok", path.Join(dir, taskMetaData))
-		}
-
-		// remove data
-		data := path.Join(dir, taskData)
-		stat, err := os.Lstat(data)
-		if err == nil {
-			// remove sym link file
-			if stat.Mode()&os.ModeSymlink == os.ModeSymlink {
-				dest, err0 := os.Readlink(data)
-				if err0 == nil {
-					if err = os.Remove(dest); err != nil {
-						logger.Warnf("remove load error file %s error: %s", data, err)
-					}
-				}
-			}
-			if err = os.Remove(data); err != nil {
-				logger.Warnf("remove load error file %s error: %s", data, err)
-			} else {
-				logger.Warnf("remove load error file %s ok", data)
-			}
-		}
-
-		if err = os.Remove(dir); err != nil {
-			logger.Warnf("remove load error directory %s error: %s", dir, err)
-		}
-		logger.Warnf("remove load error directory %s ok", dir)
-	}
-	if len(loadErrs) > 0 {
-		var sb strings.Builder
-		for _, err := range loadErrs {
-			sb.WriteString(err.Error())
-		}
-		return fmt.Errorf("load tasks from disk error: %q", sb.String())
-	}
-	return nil
+	return
 }
 
 func (s *storageManager) TryGC() (bool, error) {
 	var markedTasks []PeerTaskMetaData
 	var totalNotMarkedSize int64
+	// the tasks in lost data dirs can not be read any more, reclaim them directly
+	lostDataDirs := s.probeDataDirs()
+	diskNotMarkedSize := map[*dataDir]int64{}
+	bizNotMarkedSize := map[string]int64{}
 	s.tasks.Range(func(key, task interface{}) bool {
-		if task.(*localTaskStore).CanReclaim() {
-			task.(*localTaskStore).MarkReclaim()
+		t := task.(*localTaskStore)
+		if t.reclaimMarked.Load() {
+			return true
+		}
+		if lostDataDirs[t.disk] {
+			logger.Warnf("data dir %s is lost, mark task %s/%s reclaimed",
+				t.disk.path, key.(PeerTaskMetaData).TaskID, key.(PeerTaskMetaData).PeerID)
+			t.MarkReclaim()
+			markedTasks = append(markedTasks, key.(PeerTaskMetaData))
+		} else if t.CanReclaim() {
+			t.MarkReclaim()
 			markedTasks = append(markedTasks, key.(PeerTaskMetaData))
 		} else {
-			// just calculate not reclaimed task
-			totalNotMarkedSize += task.(*localTaskStore).ContentLength
+			// just calculate not reclaimed task, the hard linked task data is owned by outputs
+			size := t.exclusiveSize()
+			totalNotMarkedSize += size
+			diskNotMarkedSize[t.disk] += size
+			// pinned tasks can not be reclaimed, they do not count in the biz quota,
+			// otherwise the pinned tasks force all other tasks of the biz to be reclaimed
+			if !t.isPinned() {
+				bizNotMarkedSize[t.Biz] += size
+			}
 			logger.Debugf("task %s/%s not reach gc time",
 				key.(PeerTaskMetaData).TaskID, key.(PeerTaskMetaData).PeerID)
 		}
 		return true
 	})
 
-	if s.storeOption.DiskGCThreshold > 0 && totalNotMarkedSize > int64(s.storeOption.DiskGCThreshold) {
-		logger.Infof("quota threshold reached, start gc oldest task")
-		var tasks []*localTaskStore
-		s.tasks.Range(func(key, task interface{}) bool {
-			// skip reclaimed task
-			if task.(*localTaskStore).reclaimMarked.Load() {
-				return true
-			}
-			tasks = append(tasks, task.(*localTaskStore))
+	// exceeded returns whether the quota of all tasks, the data dir or the biz of the task is exceeded
+	exceeded := func(t *localTaskStore) bool {
+		if s.storeOption.DiskGCThreshold > 0 && totalNotMarkedSize > int64(s.storeOption.DiskGCThreshold) {
 			return true
-		})
+		}
+		if t.disk.quota > 0 && diskNotMarkedSize[t.disk] > t.disk.quota {
+			return true
+		}
+		quota := int64(s.storeOption.BizQuotas[t.Biz])
+		return quota > 0 && bizNotMarkedSize[t.Biz] > quota
+	}
+	var (
+		needGC bool
+		tasks  []*localTaskStore
+	)
+	s.tasks.Range(func(key, task interface{}) bool {
+		t := task.(*localTaskStore)
+		// skip reclaimed and pinned task
+		if t.reclaimMarked.Load() || t.isPinned() {
+			return true
+		}
+		if exceeded(t) {
+			needGC = true
+		}
+		tasks = append(tasks, t)
+		return true
+	})
+
+	if needGC {
+		logger.Infof("quota threshold reached, start gc oldest task")
 		sort.SliceStable(tasks, func(i, j int) bool {
 			return tasks[i].lastAccess.Load() < tasks[j].lastAccess.Load()
 		})
 		for _, task := range tasks {
+			size := task.exclusiveSize()
+			// reclaiming the hard linked task data frees nothing
+			if !exceeded(task) || size <= 0 {
+				continue
+			}
 			task.MarkReclaim()
 			markedTasks = append(markedTasks, PeerTaskMetaData{task.PeerID, task.TaskID})
-			logger.Infof("quota threshold reached, mark task %s/%s reclaimed, last access: %s, size: %s",
-				task.TaskID, task.PeerID, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
-				units.BytesSize(float64(task.ContentLength)))
-			totalNotMarkedSize -= task.ContentLength
-			if totalNotMarkedSize < int64(s.storeOption.DiskGCThreshold) {
-				break
-			}
+			logger.Infof("quota threshold reached, mark task %s/%s of biz %q in %s reclaimed, last access: %s, size: %s",
+				task.TaskID, task.PeerID, task.Biz, task.disk.path, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
+				units.BytesSize(float64(size)))
+			totalNotMarkedSize -= size
+			diskNotMarkedSize[task.disk] -= size
+			bizNotMarkedSize[task.Biz] -= size
 		}
 	}
 
@@ -598,6 +803,7 @@ func (s *storageManager) TryGC() (bool, error) {
 
 		s.tasks.Delete(key)
 		s.cleanIndex(task.TaskID, task.PeerID)
+		size := task.exclusiveSize()
 		if err := task.Reclaim(); err != nil {
 			// FIXME: retry later or push to queue
 			logger.Errorf("gc task %s/%s error: %s", key.TaskID, key.PeerID, err)
@@ -606,11 +812,18 @@ func (s *storageManager) TryGC() (bool, error) {
 			continue
 		}
 		logger.Infof("task %s/%s reclaimed", key.TaskID, key.PeerID)
+		metrics.GCReclaimedTaskCount.Inc()
+		metrics.GCReclaimedBytes.Add(float64(size))
 
 		span.End()
 	}
 	logger.Infof("marked %d task(s), reclaimed %d task(s)", len(markedTasks), len(s.markedReclaimTasks))
 	s.markedReclaimTasks = markedTasks
+
+	usage := s.dataDirUsage()
+	for _, disk := range s.dataDirs {
+		metrics.StorageUsage.WithLabelValues(disk.path).Set(float64(usage[disk]))
+	}
 	return true, nil
 }
 
@@ -36,6 +36,20 @@ func (m *MockDaemonServer) EXPECT() *MockDaemonServerMockRecorder {
 	return m.recorder
 }
 
+// CancelTask mocks base method.
+func (m *MockDaemonServer) CancelTask(arg0 context.Context, arg1 *dfdaemon.TaskTarget) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelTask indicates an expected call of CancelTask.
+func (mr *MockDaemonServerMockRecorder) CancelTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTask", reflect.TypeOf((*MockDaemonServer)(nil).CancelTask), arg0, arg1)
+}
+
 // CheckHealth mocks base method.
 func (m *MockDaemonServer) CheckHealth(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -50,6 +64,20 @@ func (mr *MockDaemonServerMockRecorder) CheckHealth(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckHealth", reflect.TypeOf((*MockDaemonServer)(nil).CheckHealth), arg0)
 }
 
+// DeleteTask mocks base method.
+func (m *MockDaemonServer) DeleteTask(arg0 context.Context, arg1 *dfdaemon.TaskTarget) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockDaemonServerMockRecorder) DeleteTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockDaemonServer)(nil).DeleteTask), arg0, arg1)
+}
+
 // Download mocks base method.
 func (m *MockDaemonServer) Download(arg0 context.Context, arg1 *dfdaemon.DownRequest, arg2 chan<- *dfdaemon.DownResult) error {
 	m.ctrl.T.Helper()
@@ -64,6 +92,21 @@ func (mr *MockDaemonServerMockRecorder) Download(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockDaemonServer)(nil).Download), arg0, arg1, arg2)
 }
 
+// ExportTask mocks base method.
+func (m *MockDaemonServer) ExportTask(arg0 context.Context, arg1 *dfdaemon.ExportTaskRequest) (*dfdaemon.TaskInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportTask", arg0, arg1)
+	ret0, _ := ret[0].(*dfdaemon.TaskInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportTask indicates an expected call of ExportTask.
+func (mr *MockDaemonServerMockRecorder) ExportTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportTask", reflect.TypeOf((*MockDaemonServer)(nil).ExportTask), arg0, arg1)
+}
+
 // GetPieceTasks mocks base method.
 func (m *MockDaemonServer) GetPieceTasks(arg0 context.Context, arg1 *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +121,134 @@ func (mr *MockDaemonServerMockRecorder) GetPieceTasks(arg0, arg1 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceTasks", reflect.TypeOf((*MockDaemonServer)(nil).GetPieceTasks), arg0, arg1)
 }
+
+// GetRateLimits mocks base method.
+func (m *MockDaemonServer) GetRateLimits(arg0 context.Context) (*dfdaemon.RateLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateLimits", arg0)
+	ret0, _ := ret[0].(*dfdaemon.RateLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRateLimits indicates an expected call of GetRateLimits.
+func (mr *MockDaemonServerMockRecorder) GetRateLimits(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimits", reflect.TypeOf((*MockDaemonServer)(nil).GetRateLimits), arg0)
+}
+
+// ImportTask mocks base method.
+func (m *MockDaemonServer) ImportTask(arg0 context.Context, arg1 *dfdaemon.ImportTaskRequest) (*dfdaemon.TaskInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportTask", arg0, arg1)
+	ret0, _ := ret[0].(*dfdaemon.TaskInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportTask indicates an expected call of ImportTask.
+func (mr *MockDaemonServerMockRecorder) ImportTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportTask", reflect.TypeOf((*MockDaemonServer)(nil).ImportTask), arg0, arg1)
+}
+
+// ListTasks mocks base method.
+func (m *MockDaemonServer) ListTasks(arg0 context.Context, arg1 *dfdaemon.ListTasksRequest) (*dfdaemon.ListTasksResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", arg0, arg1)
+	ret0, _ := ret[0].(*dfdaemon.ListTasksResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockDaemonServerMockRecorder) ListTasks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockDaemonServer)(nil).ListTasks), arg0, arg1)
+}
+
+// PinTask mocks base method.
+func (m *MockDaemonServer) PinTask(arg0 context.Context, arg1 *dfdaemon.PinTaskRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinTask indicates an expected call of PinTask.
+func (mr *MockDaemonServerMockRecorder) PinTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinTask", reflect.TypeOf((*MockDaemonServer)(nil).PinTask), arg0, arg1)
+}
+
+// ReadTask mocks base method.
+func (m *MockDaemonServer) ReadTask(arg0 context.Context, arg1 *dfdaemon.ExportTaskRequest, arg2 chan<- *dfdaemon.TaskData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTask", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadTask indicates an expected call of ReadTask.
+func (mr *MockDaemonServerMockRecorder) ReadTask(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTask", reflect.TypeOf((*MockDaemonServer)(nil).ReadTask), arg0, arg1, arg2)
+}
+
+// SetRateLimits mocks base method.
+func (m *MockDaemonServer) SetRateLimits(arg0 context.Context, arg1 *dfdaemon.SetRateLimitsRequest) (*dfdaemon.RateLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRateLimits", arg0, arg1)
+	ret0, _ := ret[0].(*dfdaemon.RateLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRateLimits indicates an expected call of SetRateLimits.
+func (mr *MockDaemonServerMockRecorder) SetRateLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRateLimits", reflect.TypeOf((*MockDaemonServer)(nil).SetRateLimits), arg0, arg1)
+}
+
+// StatTask mocks base method.
+func (m *MockDaemonServer) StatTask(arg0 context.Context, arg1 *dfdaemon.TaskTarget) (*dfdaemon.TaskInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatTask", arg0, arg1)
+	ret0, _ := ret[0].(*dfdaemon.TaskInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatTask indicates an expected call of StatTask.
+func (mr *MockDaemonServerMockRecorder) StatTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatTask", reflect.TypeOf((*MockDaemonServer)(nil).StatTask), arg0, arg1)
+}
+
+// StreamDownload mocks base method.
+func (m *MockDaemonServer) StreamDownload(arg0 context.Context, arg1 *dfdaemon.DownRequest, arg2 chan<- *dfdaemon.TaskData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamDownload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamDownload indicates an expected call of StreamDownload.
+func (mr *MockDaemonServerMockRecorder) StreamDownload(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamDownload", reflect.TypeOf((*MockDaemonServer)(nil).StreamDownload), arg0, arg1, arg2)
+}
+
+// SyncPieceTasks mocks base method.
+func (m *MockDaemonServer) SyncPieceTasks(arg0 context.Context, arg1 *base.PieceTaskRequest, arg2 chan<- *base.PiecePacket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncPieceTasks", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncPieceTasks indicates an expected call of SyncPieceTasks.
+func (mr *MockDaemonServerMockRecorder) SyncPieceTasks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncPieceTasks", reflect.TypeOf((*MockDaemonServer)(nil).SyncPieceTasks), arg0, arg1, arg2)
+}
@@ -40,6 +40,35 @@ func (m *MockTaskManager) EXPECT() *MockTaskManagerMockRecorder {
 	return m.recorder
 }
 
+// CancelPeerTask mocks base method.
+func (m *MockTaskManager) CancelPeerTask(pid, reason string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPeerTask", pid, reason)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CancelPeerTask indicates an expected call of CancelPeerTask.
+func (mr *MockTaskManagerMockRecorder) CancelPeerTask(pid, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPeerTask", reflect.TypeOf((*MockTaskManager)(nil).CancelPeerTask), pid, reason)
+}
+
+// ImportTask mocks base method.
+func (m *MockTaskManager) ImportTask(ctx context.Context, req *peer.ImportTaskRequest) (*peer.ImportTaskResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportTask", ctx, req)
+	ret0, _ := ret[0].(*peer.ImportTaskResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportTask indicates an expected call of ImportTask.
+func (mr *MockTaskManagerMockRecorder) ImportTask(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportTask", reflect.TypeOf((*MockTaskManager)(nil).ImportTask), ctx, req)
+}
+
 // IsPeerTaskRunning mocks base method.
 func (m *MockTaskManager) IsPeerTaskRunning(pid string) bool {
 	m.ctrl.T.Helper()
@@ -54,6 +83,32 @@ func (mr *MockTaskManagerMockRecorder) IsPeerTaskRunning(pid interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPeerTaskRunning", reflect.TypeOf((*MockTaskManager)(nil).IsPeerTaskRunning), pid)
 }
 
+// ListRunningPeerTasks mocks base method.
+func (m *MockTaskManager) ListRunningPeerTasks() []peer.Task {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunningPeerTasks")
+	ret0, _ := ret[0].([]peer.Task)
+	return ret0
+}
+
+// ListRunningPeerTasks indicates an expected call of ListRunningPeerTasks.
+func (mr *MockTaskManagerMockRecorder) ListRunningPeerTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunningPeerTasks", reflect.TypeOf((*MockTaskManager)(nil).ListRunningPeerTasks))
+}
+
+// ResumePeerTasks mocks base method.
+func (m *MockTaskManager) ResumePeerTasks(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResumePeerTasks", ctx)
+}
+
+// ResumePeerTasks indicates an expected call of ResumePeerTasks.
+func (mr *MockTaskManagerMockRecorder) ResumePeerTasks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumePeerTasks", reflect.TypeOf((*MockTaskManager)(nil).ResumePeerTasks), ctx)
+}
+
 // StartFilePeerTask mocks base method.
 func (m *MockTaskManager) StartFilePeerTask(ctx context.Context, req *peer.FilePeerTaskRequest) (chan *peer.FilePeerTaskProgress, *peer.TinyData, error) {
 	m.ctrl.T.Helper()
@@ -135,6 +190,18 @@ func (mr *MockTaskMockRecorder) AddTraffic(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTraffic", reflect.TypeOf((*MockTask)(nil).AddTraffic), arg0)
 }
 
+// Cancel mocks base method.
+func (m *MockTask) Cancel(reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Cancel", reason)
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockTaskMockRecorder) Cancel(reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockTask)(nil).Cancel), reason)
+}
+
 // Context mocks base method.
 func (m *MockTask) Context() context.Context {
 	m.ctrl.T.Helper()
@@ -149,6 +216,20 @@ func (mr *MockTaskMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockTask)(nil).Context))
 }
 
+// GetCompletedLength mocks base method.
+func (m *MockTask) GetCompletedLength() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompletedLength")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GetCompletedLength indicates an expected call of GetCompletedLength.
+func (mr *MockTaskMockRecorder) GetCompletedLength() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedLength", reflect.TypeOf((*MockTask)(nil).GetCompletedLength))
+}
+
 // GetContentLength mocks base method.
 func (m *MockTask) GetContentLength() int64 {
 	m.ctrl.T.Helper()
@@ -219,6 +300,20 @@ func (mr *MockTaskMockRecorder) GetTraffic() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTraffic", reflect.TypeOf((*MockTask)(nil).GetTraffic))
 }
 
+// GetPieceMd5Sign mocks base method.
+func (m *MockTask) GetPieceMd5Sign() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPieceMd5Sign")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetPieceMd5Sign indicates an expected call of GetPieceMd5Sign.
+func (mr *MockTaskMockRecorder) GetPieceMd5Sign() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceMd5Sign", reflect.TypeOf((*MockTask)(nil).GetPieceMd5Sign))
+}
+
 // Log mocks base method.
 func (m *MockTask) Log() *logger.SugaredLoggerOnWith {
 	m.ctrl.T.Helper()
@@ -239,6 +239,20 @@ func (mr *MockManagerMockRecorder) CleanUp() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUp", reflect.TypeOf((*MockManager)(nil).CleanUp))
 }
 
+// DeleteTask mocks base method.
+func (m *MockManager) DeleteTask(ctx context.Context, req storage.PeerTaskMetaData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockManagerMockRecorder) DeleteTask(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockManager)(nil).DeleteTask), ctx, req)
+}
+
 // FindCompletedTask mocks base method.
 func (m *MockManager) FindCompletedTask(taskID string) *storage.ReusePeerTask {
 	m.ctrl.T.Helper()
@@ -253,6 +267,20 @@ func (mr *MockManagerMockRecorder) FindCompletedTask(taskID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedTask", reflect.TypeOf((*MockManager)(nil).FindCompletedTask), taskID)
 }
 
+// FindUnfinishedTasks mocks base method.
+func (m *MockManager) FindUnfinishedTasks() []*storage.ResumePeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnfinishedTasks")
+	ret0, _ := ret[0].([]*storage.ResumePeerTask)
+	return ret0
+}
+
+// FindUnfinishedTasks indicates an expected call of FindUnfinishedTasks.
+func (mr *MockManagerMockRecorder) FindUnfinishedTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnfinishedTasks", reflect.TypeOf((*MockManager)(nil).FindUnfinishedTasks))
+}
+
 // GetPieces mocks base method.
 func (m *MockManager) GetPieces(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	m.ctrl.T.Helper()
@@ -280,6 +308,34 @@ func (mr *MockManagerMockRecorder) Keep() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keep", reflect.TypeOf((*MockManager)(nil).Keep))
 }
 
+// ListTasks mocks base method.
+func (m *MockManager) ListTasks() []*storage.TaskStoreStat {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks")
+	ret0, _ := ret[0].([]*storage.TaskStoreStat)
+	return ret0
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockManagerMockRecorder) ListTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockManager)(nil).ListTasks))
+}
+
+// PinTask mocks base method.
+func (m *MockManager) PinTask(ctx context.Context, req storage.PeerTaskMetaData, pinned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinTask", ctx, req, pinned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinTask indicates an expected call of PinTask.
+func (mr *MockManagerMockRecorder) PinTask(ctx, req, pinned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinTask", reflect.TypeOf((*MockManager)(nil).PinTask), ctx, req, pinned)
+}
+
 // ReadAllPieces mocks base method.
 func (m *MockManager) ReadAllPieces(ctx context.Context, req *storage.PeerTaskMetaData) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -353,6 +409,21 @@ func (mr *MockManagerMockRecorder) UpdateTask(ctx, req interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockManager)(nil).UpdateTask), ctx, req)
 }
 
+// WatchTask mocks base method.
+func (m *MockManager) WatchTask(req storage.PeerTaskMetaData) (<-chan struct{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchTask", req)
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchTask indicates an expected call of WatchTask.
+func (mr *MockManagerMockRecorder) WatchTask(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchTask", reflect.TypeOf((*MockManager)(nil).WatchTask), req)
+}
+
 // WritePiece mocks base method.
 func (m *MockManager) WritePiece(ctx context.Context, req *storage.WritePieceRequest) (int64, error) {
 	m.ctrl.T.Helper()
@@ -29,8 +29,12 @@ import (
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
+	"d7y.io/dragonfly/v2/client/daemon/ratelimit"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/pieceauth"
 )
 
 type Manager interface {
@@ -42,6 +46,8 @@ type uploadManager struct {
 	*http.Server
 	*rate.Limiter
 	StorageManager storage.Manager
+	// signer verifies the piece tokens issued by scheduler, nil when piece token is disabled
+	signer *pieceauth.Signer
 }
 
 var _ Manager = (*uploadManager)(nil)
@@ -69,6 +75,13 @@ func WithLimiter(limiter *rate.Limiter) func(*uploadManager) {
 	}
 }
 
+// WithPieceTokenSigner sets the signer to verify piece tokens, requests without a valid token will be rejected
+func WithPieceTokenSigner(signer *pieceauth.Signer) func(*uploadManager) {
+	return func(manager *uploadManager) {
+		manager.signer = signer
+	}
+}
+
 func (um *uploadManager) initRouter() {
 	r := mux.NewRouter()
 	r.HandleFunc(PeerDownloadHTTPPathPrefix+"{taskPrefix:.*}/"+"{task:.*}", um.handleUpload).Queries("peerId", "{.*}").Methods("GET")
@@ -88,11 +101,20 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 	var (
 		task = mux.Vars(r)["task"]
 		peer = r.FormValue("peerId")
-		//cdnSource = r.Header.Get("X-Dragonfly-CDN-Source")
 	)
 
 	log := logger.With("peer", peer, "task", task, "component", "uploadManager")
 	log.Debugf("upload piece for task %s/%s to %s, request header: %#v", task, peer, r.RemoteAddr, r.Header)
+	if um.signer != nil {
+		// only the children scheduled to this peer hold a valid token
+		srcPid, err := um.signer.Verify(r.Header.Get(config.HeaderDragonflyPieceToken), task, peer)
+		if err != nil {
+			log.Warnf("reject piece request from %s: %s", r.RemoteAddr, err)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		log.Debugf("piece request authorized for peer %s", srcPid)
+	}
 	rg, err := clientutil.ParseRange(r.Header.Get(headers.Range), math.MaxInt64)
 	if err != nil {
 		log.Error("parse range with error: %s", err)
@@ -125,16 +147,21 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer closer.Close()
 	if um.Limiter != nil {
-		if err = um.Limiter.WaitN(r.Context(), int(rg[0].Length)); err != nil {
+		if err = ratelimit.WaitN(r.Context(), um.Limiter, int(rg[0].Length)); err != nil {
 			log.Errorf("get limit failed: %s", err)
 			http.Error(w, fmt.Sprintf("get limit error: %s", err), http.StatusInternalServerError)
 			return
 		}
 	}
 
+	metrics.ConcurrentUploads.Inc()
+	defer metrics.ConcurrentUploads.Dec()
+
 	// if w is a socket, golang will use sendfile or splice syscall for zero copy feature
 	// when start to transfer data, we could not call http.Error with header
-	if n, err := io.Copy(w, reader); err != nil {
+	n, err := io.Copy(w, reader)
+	metrics.UploadTraffic.Add(float64(n))
+	if err != nil {
 		log.Errorf("transfer data failed: %s", err)
 		return
 	} else if n != rg[0].Length {
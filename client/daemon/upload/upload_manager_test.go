@@ -26,14 +26,17 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	testifyassert "github.com/stretchr/testify/assert"
 	"golang.org/x/time/rate"
 
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/client/daemon/test"
 	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
+	"d7y.io/dragonfly/v2/internal/pieceauth"
 	_ "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/server"
 )
 
@@ -106,3 +109,81 @@ func TestUploadManager_Serve(t *testing.T) {
 		assert.Equal(tt.targetPieceData, data)
 	}
 }
+
+func TestUploadManager_PieceToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	testData, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	mockStorageManager := mock_storage.NewMockManager(ctrl)
+	mockStorageManager.EXPECT().ReadPiece(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, req *storage.ReadPieceRequest) (io.Reader, io.Closer, error) {
+			return bytes.NewBuffer(testData[req.Range.Start : req.Range.Start+req.Range.Length]),
+				ioutil.NopCloser(nil), nil
+		})
+
+	signer := pieceauth.NewSigner("secret", time.Minute)
+	um, err := NewUploadManager(mockStorageManager, WithPieceTokenSigner(signer))
+	assert.Nil(err, "NewUploadManager")
+
+	listen, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(err, "Listen")
+	addr := listen.Addr().String()
+
+	go func() {
+		um.Serve(listen)
+	}()
+	defer um.Stop()
+
+	tests := []struct {
+		name       string
+		peerID     string
+		token      string
+		statusCode int
+	}{
+		{
+			name:       "valid token",
+			peerID:     "peer-0",
+			token:      signer.Sign("task-0", "child", "peer-0"),
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "without token",
+			peerID:     "peer-0",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "token for other peer",
+			peerID:     "peer-0",
+			token:      signer.Sign("task-0", "child", "peer-1"),
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "token signed with other secret",
+			peerID:     "peer-0",
+			token:      pieceauth.NewSigner("other", time.Minute).Sign("task-0", "child", "peer-0"),
+			statusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet,
+			fmt.Sprintf("http://%s%s%s/%s?peerId=%s", addr, PeerDownloadHTTPPathPrefix, "666", "task-0", tt.peerID), nil)
+		req.Header.Add("Range", "bytes=0-9")
+		if tt.token != "" {
+			req.Header.Add(config.HeaderDragonflyPieceToken, tt.token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(err, tt.name)
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(tt.statusCode, resp.StatusCode, tt.name)
+		if tt.statusCode == http.StatusOK {
+			assert.Equal(testData[0:10], data, tt.name)
+		}
+	}
+}
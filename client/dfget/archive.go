@@ -0,0 +1,350 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dfget
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	dfdaemongrpc "d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+// archive layout, every task has two entries, metadata entry is written before data entry:
+//
+//	<task id>/task.json
+//	<task id>/data
+const (
+	archiveTaskMetadata = "task.json"
+	archiveTaskData     = "data"
+
+	// archivePieceLimit is the max count of pieces got from daemon at once when exporting
+	archivePieceLimit = 1024
+)
+
+// ArchiveTask is the metadata of a task in archive
+type ArchiveTask struct {
+	TaskID        string `json:"taskID"`
+	ContentLength int64  `json:"contentLength"`
+	// Pieces and PieceMd5Sign are used to verify the data when importing
+	Pieces       []*ArchivePiece `json:"pieces"`
+	PieceMd5Sign string          `json:"pieceMd5Sign"`
+}
+
+// ArchivePiece is the metadata of a piece in archive
+type ArchivePiece struct {
+	Num   int32  `json:"num"`
+	Start int64  `json:"start"`
+	Size  int32  `json:"size"`
+	Md5   string `json:"md5"`
+}
+
+// ExportArchive writes completed tasks in daemon storage into a tar archive,
+// when taskIDs is empty, all completed tasks will be exported.
+func ExportArchive(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr, taskIDs []string, w io.Writer) ([]*ArchiveTask, error) {
+	result, err := client.ListTasks(ctx, target, &dfdaemongrpc.ListTasksRequest{})
+	if err != nil {
+		return nil, err
+	}
+	completed := map[string]*dfdaemongrpc.TaskInfo{}
+	var allTaskIDs []string
+	for _, task := range result.Tasks {
+		if !task.Done {
+			continue
+		}
+		if _, ok := completed[task.TaskId]; !ok {
+			completed[task.TaskId] = task
+			allTaskIDs = append(allTaskIDs, task.TaskId)
+		}
+	}
+	if len(taskIDs) == 0 {
+		taskIDs = allTaskIDs
+	}
+
+	var (
+		tw       = tar.NewWriter(w)
+		exported []*ArchiveTask
+	)
+	for _, taskID := range taskIDs {
+		task, ok := completed[taskID]
+		if !ok {
+			return nil, errors.Errorf("completed task %s not found", taskID)
+		}
+		at := &ArchiveTask{
+			TaskID:        task.TaskId,
+			ContentLength: task.ContentLength,
+		}
+		if err = readArchivePieces(ctx, client, target, task, at); err != nil {
+			return nil, errors.Wrapf(err, "read pieces of task %s", taskID)
+		}
+		if err = writeArchiveTask(ctx, client, target, tw, task, at); err != nil {
+			return nil, errors.Wrapf(err, "export task %s", taskID)
+		}
+		logger.Infof("export task %s to archive, content length: %d", taskID, task.ContentLength)
+		exported = append(exported, at)
+	}
+	return exported, tw.Close()
+}
+
+// readArchivePieces reads the pieces and piece md5 sign of the completed task from daemon
+func readArchivePieces(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr,
+	task *dfdaemongrpc.TaskInfo, at *ArchiveTask) error {
+	for start := int32(0); start < task.TotalPiece; start += archivePieceLimit {
+		packet, err := client.GetPieceTasks(ctx, target, &base.PieceTaskRequest{
+			TaskId:   task.TaskId,
+			DstPid:   task.PeerId,
+			StartNum: start,
+			Limit:    archivePieceLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, piece := range packet.PieceInfos {
+			at.Pieces = append(at.Pieces, &ArchivePiece{
+				Num:   piece.PieceNum,
+				Start: int64(piece.RangeStart),
+				Size:  piece.RangeSize,
+				Md5:   piece.PieceMd5,
+			})
+		}
+		at.PieceMd5Sign = packet.PieceMd5Sign
+	}
+	return at.verifyPieces()
+}
+
+// verifyPieces checks the pieces are continuous and match the piece md5 sign
+func (at *ArchiveTask) verifyPieces() error {
+	var (
+		offset int64
+		md5s   = make([]string, 0, len(at.Pieces))
+	)
+	for i, piece := range at.Pieces {
+		if piece.Num != int32(i) || piece.Start != offset || piece.Md5 == "" {
+			return errors.Errorf("invalid piece %d, start: %d, size: %d, md5: %q", piece.Num, piece.Start, piece.Size, piece.Md5)
+		}
+		offset += int64(piece.Size)
+		md5s = append(md5s, piece.Md5)
+	}
+	if offset != at.ContentLength {
+		return errors.Errorf("pieces length not match, desired: %d, actual: %d", at.ContentLength, offset)
+	}
+	if len(md5s) > 0 && digestutils.Sha256(md5s...) != at.PieceMd5Sign {
+		return errors.Errorf("piece md5 sign not match, desired: %s, actual: %s", at.PieceMd5Sign, digestutils.Sha256(md5s...))
+	}
+	return nil
+}
+
+func writeArchiveTask(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr,
+	tw *tar.Writer, task *dfdaemongrpc.TaskInfo, at *ArchiveTask) error {
+	metadata, err := json.Marshal(at)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = tw.WriteHeader(&tar.Header{
+		Name:    path.Join(task.TaskId, archiveTaskMetadata),
+		Mode:    0644,
+		Size:    int64(len(metadata)),
+		ModTime: now,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = tw.Write(metadata); err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    path.Join(task.TaskId, archiveTaskData),
+		Mode:    0644,
+		Size:    task.ContentLength,
+		ModTime: now,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = ReadTask(ctx, client, target, &dfdaemongrpc.ExportTaskRequest{
+		Target: &dfdaemongrpc.TaskTarget{
+			TaskId: task.TaskId,
+			PeerId: task.PeerId,
+		},
+	}, tw)
+	return err
+}
+
+// ImportArchive imports all tasks in the archive into daemon storage with the original task ids,
+// the data of every task is verified with the piece digests in metadata and extracted to a temporary file in tmpDir
+// which must be accessible by daemon, tmpDir is the default directory for temporary files when it's empty,
+// so the user of dfget needs no permission of the data dir of daemon.
+func ImportArchive(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr, r io.Reader, tmpDir string) ([]*dfdaemongrpc.TaskInfo, error) {
+	if tmpDir == "" {
+		tmpDir = os.TempDir()
+	}
+	var (
+		tr       = tar.NewReader(r)
+		metadata = map[string]*ArchiveTask{}
+		imported []*dfdaemongrpc.TaskInfo
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return imported, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		taskID, name := path.Split(hdr.Name)
+		taskID = path.Clean(taskID)
+		switch name {
+		case archiveTaskMetadata:
+			var at ArchiveTask
+			if err = json.NewDecoder(tr).Decode(&at); err != nil {
+				return nil, errors.Wrapf(err, "decode metadata of task %s", taskID)
+			}
+			if err = at.verifyPieces(); err != nil {
+				return nil, errors.Wrapf(err, "verify metadata of task %s", taskID)
+			}
+			metadata[taskID] = &at
+		case archiveTaskData:
+			at, ok := metadata[taskID]
+			if !ok || at.TaskID != taskID {
+				return nil, errors.Errorf("metadata of task %s not found", taskID)
+			}
+			if hdr.Size != at.ContentLength {
+				return nil, errors.Errorf("content length of task %s not match, desired: %d, actual: %d",
+					taskID, at.ContentLength, hdr.Size)
+			}
+			task, err := importArchiveTask(ctx, client, target, tr, at, tmpDir)
+			if err != nil {
+				return nil, errors.Wrapf(err, "import task %s", taskID)
+			}
+			logger.Infof("import task %s from archive, content length: %d", taskID, task.ContentLength)
+			imported = append(imported, task)
+		default:
+			logger.Warnf("unknown entry %s in archive, skip it", hdr.Name)
+		}
+	}
+}
+
+func importArchiveTask(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr,
+	r io.Reader, at *ArchiveTask, tmpDir string) (*dfdaemongrpc.TaskInfo, error) {
+	tmp, err := ioutil.TempFile(tmpDir, "dfget-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	// daemon may run with another user
+	verifier := &pieceVerifier{pieces: at.Pieces, hash: md5.New()}
+	if err = tmp.Chmod(0644); err == nil {
+		_, err = io.Copy(io.MultiWriter(tmp, verifier), r)
+	}
+	if err == nil {
+		err = verifier.finish()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client.ImportTask(ctx, target, &dfdaemongrpc.ImportTaskRequest{
+		TaskId: at.TaskID,
+		Path:   tmp.Name(),
+	})
+}
+
+// pieceVerifier verifies the md5 of every piece when the data is written in order
+type pieceVerifier struct {
+	pieces  []*ArchivePiece
+	index   int
+	written int64
+	hash    hash.Hash
+}
+
+func (v *pieceVerifier) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		if v.index >= len(v.pieces) {
+			return total - len(p), errors.New("data exceeds the pieces")
+		}
+		piece := v.pieces[v.index]
+		n := int64(piece.Size) - v.written
+		if n > int64(len(p)) {
+			n = int64(len(p))
+		}
+		v.hash.Write(p[:n])
+		v.written += n
+		p = p[n:]
+		if v.written < int64(piece.Size) {
+			continue
+		}
+		if actual := digestutils.ToHashString(v.hash); actual != piece.Md5 {
+			return total - len(p), errors.Errorf("md5 of piece %d not match, desired: %s, actual: %s", piece.Num, piece.Md5, actual)
+		}
+		v.index++
+		v.written = 0
+		v.hash.Reset()
+	}
+	return total, nil
+}
+
+func (v *pieceVerifier) finish() error {
+	if v.index != len(v.pieces) {
+		return errors.Errorf("data is shorter than the pieces, %d of %d pieces verified", v.index, len(v.pieces))
+	}
+	return nil
+}
+
+// ReadTask reads the content of a completed task in daemon storage and writes to w
+func ReadTask(ctx context.Context, client dfclient.DaemonClient, target dfnet.NetAddr, req *dfdaemongrpc.ExportTaskRequest, w io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ReadTask(ctx, target, req)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	for {
+		data, err := stream.Recv()
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(data.Data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+}
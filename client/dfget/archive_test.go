@@ -0,0 +1,72 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dfget
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+func TestArchiveTask_VerifyPieces(t *testing.T) {
+	assert := testifyassert.New(t)
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	at := &ArchiveTask{TaskID: "task", ContentLength: int64(len(data))}
+	var md5s []string
+	for start := 0; start < len(data); start += 16 {
+		end := start + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		md5s = append(md5s, digestutils.Md5Bytes(data[start:end]))
+		at.Pieces = append(at.Pieces, &ArchivePiece{
+			Num:   int32(len(at.Pieces)),
+			Start: int64(start),
+			Size:  int32(end - start),
+			Md5:   md5s[len(md5s)-1],
+		})
+	}
+	at.PieceMd5Sign = digestutils.Sha256(md5s...)
+	assert.Nil(at.verifyPieces())
+
+	verifier := &pieceVerifier{pieces: at.Pieces, hash: md5.New()}
+	_, err := io.Copy(verifier, bytes.NewReader(data))
+	assert.Nil(err)
+	assert.Nil(verifier.finish())
+
+	// corrupted data
+	corrupted := append([]byte{}, data...)
+	corrupted[20] = '!'
+	verifier = &pieceVerifier{pieces: at.Pieces, hash: md5.New()}
+	_, err = io.Copy(verifier, bytes.NewReader(corrupted))
+	assert.NotNil(err)
+
+	// truncated data
+	verifier = &pieceVerifier{pieces: at.Pieces, hash: md5.New()}
+	_, err = io.Copy(verifier, bytes.NewReader(data[:20]))
+	assert.Nil(err)
+	assert.NotNil(verifier.finish())
+
+	// tampered metadata
+	at.Pieces[1].Md5 = md5s[0]
+	assert.NotNil(at.verifyPieces())
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,17 +28,20 @@ import (
 
 	"d7y.io/dragonfly/v2/client/clientutil/progressbar"
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/internal/dfcodes"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	dfdaemongrpc "d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
 	"d7y.io/dragonfly/v2/pkg/basic"
 	"d7y.io/dragonfly/v2/pkg/source"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 
 	// Init daemon rpc client
 	_ "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
 	dfclient "d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
 	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
 )
 
 var filter string
@@ -53,6 +57,10 @@ func Download(cfg *config.DfgetConfig, client dfclient.DaemonClient) error {
 		return downloadFromSource(cfg, hdr)
 	}
 
+	if cfg.Output == config.StdoutOutput {
+		return streamDownload(cfg, client, hdr)
+	}
+
 	output, err := filepath.Abs(cfg.Output)
 	if err != nil {
 		return err
@@ -120,13 +128,85 @@ func Download(cfg *config.DfgetConfig, client dfclient.DaemonClient) error {
 	return nil
 }
 
+// streamDownload writes the content to stdout in order while downloading with P2P,
+// it falls back to download from source only when nothing is written to stdout.
+func streamDownload(cfg *config.DfgetConfig, client dfclient.DaemonClient, hdr map[string]string) error {
+	var (
+		ctx    = context.Background()
+		cancel context.CancelFunc
+	)
+	if cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	request := &dfdaemongrpc.DownRequest{
+		Url: cfg.URL,
+		UrlMeta: &base.UrlMeta{
+			Digest: cfg.Digest,
+			Range:  hdr[headers.Range],
+			Header: hdr,
+			Filter: filter,
+		},
+		Callsystem: cfg.CallSystem,
+		Uid:        int64(basic.UserID),
+		Gid:        int64(basic.UserGroup),
+	}
+	start := time.Now()
+	written, err := receiveTaskData(ctx, client, request, os.Stdout)
+	if err == nil {
+		// keep stdout clean for the downloaded content
+		fmt.Fprintf(os.Stderr, "Download success, time cost: %dms, length: %d\n", time.Since(start).Milliseconds(), written)
+		return nil
+	}
+	logger.Errorf("stream download by dragonfly error: %s, written: %d", err, written)
+	if written > 0 {
+		// the partial content is already consumed by the reader of stdout, it can not be downloaded again
+		return err
+	}
+	return downloadFromSource(cfg, hdr)
+}
+
+func receiveTaskData(ctx context.Context, client dfclient.DaemonClient, request *dfdaemongrpc.DownRequest, w io.Writer) (int64, error) {
+	stream, err := client.StreamDownload(ctx, request)
+	if err != nil {
+		return 0, err
+	}
+	pb := progressbar.DefaultBytes(-1, "Downloading")
+	var written int64
+	for {
+		data, err := stream.Recv()
+		if err == io.EOF {
+			pb.Describe("Downloaded")
+			pb.Finish()
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(data.Data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		pb.Set64(written)
+	}
+}
+
 func downloadFromSource(cfg *config.DfgetConfig, hdr map[string]string) (err error) {
+	// keep stdout clean for the downloaded content when output is stdout
+	var msg io.Writer = os.Stdout
+	if cfg.Output == config.StdoutOutput {
+		msg = os.Stderr
+	}
 	if cfg.DisableBackSource {
 		err = fmt.Errorf("dfget download error, and back source disabled")
 		logger.Warnf("%s", err)
 		return err
 	}
-	fmt.Println("dfget download error, try to download from source")
+	fmt.Fprintln(msg, "dfget download error, try to download from source")
 
 	var (
 		start    = time.Now()
@@ -143,15 +223,49 @@ func downloadFromSource(cfg *config.DfgetConfig, hdr map[string]string) (err err
 	}
 	defer response.Close()
 
-	target, err = os.OpenFile(cfg.Output, os.O_RDWR|os.O_CREATE, 0644)
+	// verify the whole content when digest is specified
+	var reader io.Reader = response
+	if cfg.Digest != "" {
+		reader = digestutils.NewDigestReader(response, cfg.Digest)
+	}
+
+	if cfg.Output == config.StdoutOutput {
+		// stdout can not be rolled back, hold back the tail until the digest is verified
+		if cfg.Digest != "" {
+			reader = digestutils.NewVerifiedReader(response, cfg.Digest)
+		}
+		written, err = io.Copy(os.Stdout, reader)
+		if err != nil {
+			logger.Errorf("copied %d bytes to stdout, with error: %s", written, err)
+			return digestError(cfg, err)
+		}
+		fmt.Fprintf(msg, "Download from source success, time cost: %dms\n", time.Since(start).Milliseconds())
+		return nil
+	}
+
+	// write to a temporary file in the same directory, and rename it to output after verified,
+	// so the output is never left with partial or mismatched content
+	dir, name := filepath.Split(cfg.Output)
+	target, err = ioutil.TempFile(dir, "."+name+".dfget-")
 	if err != nil {
-		logger.Errorf("open %s error: %s", cfg.Output, err)
+		logger.Errorf("create temporary file in %s error: %s", dir, err)
 		return err
 	}
+	defer os.Remove(target.Name())
 
-	written, err = io.Copy(target, response)
+	written, err = io.Copy(target, reader)
+	if err == nil {
+		err = target.Chmod(0644)
+	}
+	if e := target.Close(); err == nil {
+		err = e
+	}
 	if err != nil {
-		logger.Errorf("copied %d bytes to %s, with error: %s", written, cfg.Output, err)
+		logger.Errorf("copied %d bytes to %s, with error: %s", written, target.Name(), err)
+		return digestError(cfg, err)
+	}
+	if err = os.Rename(target.Name(), cfg.Output); err != nil {
+		logger.Errorf("rename %s to %s error: %s", target.Name(), cfg.Output, err)
 		return err
 	}
 	logger.Infof("copied %d bytes to %s", written, cfg.Output)
@@ -167,6 +281,14 @@ func downloadFromSource(cfg *config.DfgetConfig, hdr map[string]string) (err err
 	return nil
 }
 
+// digestError converts the digest mismatch error to DfError with ClientDigestNotMatch code
+func digestError(cfg *config.DfgetConfig, err error) error {
+	if errors.Is(err, digestutils.ErrDigestNotMatch) {
+		return dferrors.New(dfcodes.ClientDigestNotMatch, fmt.Sprintf("content of %s does not match digest %s", cfg.URL, cfg.Digest))
+	}
+	return err
+}
+
 func parseHeader(s []string) map[string]string {
 	hdr := map[string]string{}
 	for _, h := range s {
@@ -129,6 +129,11 @@ func runDaemon() error {
 
 	logger.Infof("daemon is launched by pid:%d", viper.GetInt("launcher"))
 
+	// watch the daemon config file for proxy changes when proxy is configured inline
+	if cfg.Proxy != nil && cfg.Proxy.ConfigFile == "" {
+		cfg.Proxy.ConfigFile = viper.ConfigFileUsed()
+	}
+
 	// daemon config values
 	s, _ := yaml.Marshal(cfg)
 	logger.Infof("client daemon configuration:\n%s", string(s))
@@ -0,0 +1,156 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/client/dfget"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+)
+
+// stdioPath stands reading from stdin or writing to stdout instead of a file
+const stdioPath = "-"
+
+type exportOption struct {
+	output  string
+	archive bool
+	taskID  string
+	peerID  string
+	tag     string
+	digest  string
+	filter  string
+	rng     string
+	timeout time.Duration
+}
+
+var exportOpt = &exportOption{}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [url] -O path",
+	Short: "export the completed tasks from the storage of the local client daemon",
+	Long: `export writes the content of a completed task in the storage of the local client daemon to the output,
+the task is specified by url, --task-id or --peer-id, scheduler will not be contacted.
+with --archive, the completed tasks with the task ids in arguments are bundled into a tar archive,
+when there is no argument, all completed tasks are bundled, the archive can be imported by "dfget import --archive".
+use "-O -" to write to stdout.`,
+	Example: `  dfget export http://example.com/file -O /tmp/file
+  dfget export --task-id xxx -O - | tar x
+  dfget export --archive -O /tmp/tasks.tar task-id-1 task-id-2`,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportOpt.output == "" {
+			return errors.New("output is required")
+		}
+		if exportOpt.archive {
+			return runExportArchive(args)
+		}
+		if len(args) > 1 {
+			return errors.New("only one url is accepted")
+		}
+		return runExport(args)
+	},
+}
+
+func init() {
+	// Add the command to parent
+	rootCmd.AddCommand(exportCmd)
+
+	flags := exportCmd.Flags()
+	flags.StringVarP(&exportOpt.output, "output", "O", "", "destination path of the exported content, \"-\" stands stdout")
+	flags.BoolVar(&exportOpt.archive, "archive", false, "bundle the completed tasks with the task ids in arguments into a tar archive")
+	flags.StringVar(&exportOpt.taskID, "task-id", "", "id of the task")
+	flags.StringVar(&exportOpt.peerID, "peer-id", "", "id of the peer which downloads the task")
+	flags.StringVar(&exportOpt.tag, "tag", "", "tag of the url, used to generate task id with url")
+	flags.StringVar(&exportOpt.digest, "digest", "", "digest of the url, used to generate task id with url")
+	flags.StringVar(&exportOpt.filter, "filter", "", "filter of the url, used to generate task id with url")
+	flags.StringVar(&exportOpt.rng, "range", "", "range of the url, used to generate task id with url")
+	flags.DurationVar(&exportOpt.timeout, "timeout", 0, "timeout for exporting, 0 is infinite")
+}
+
+func runExport(args []string) error {
+	req := &dfdaemon.ExportTaskRequest{
+		Target: &dfdaemon.TaskTarget{
+			TaskId: exportOpt.taskID,
+			PeerId: exportOpt.peerID,
+		},
+	}
+	if len(args) > 0 {
+		req.Url = args[0]
+		req.UrlMeta = &base.UrlMeta{
+			Digest: exportOpt.digest,
+			Tag:    exportOpt.tag,
+			Range:  exportOpt.rng,
+			Filter: exportOpt.filter,
+		}
+	} else if exportOpt.taskID == "" && exportOpt.peerID == "" {
+		return errors.New("one of url, --task-id and --peer-id is required")
+	}
+
+	return runTaskCommand(exportOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+		if exportOpt.output == stdioPath {
+			_, err := dfget.ReadTask(ctx, dc, target, req, os.Stdout)
+			return err
+		}
+		output, err := filepath.Abs(exportOpt.output)
+		if err != nil {
+			return err
+		}
+		req.Output = output
+		task, err := dc.ExportTask(ctx, target, req)
+		if err != nil {
+			return err
+		}
+		printTasks([]*dfdaemon.TaskInfo{task})
+		return nil
+	})
+}
+
+func runExportArchive(taskIDs []string) error {
+	var w io.Writer = os.Stdout
+	if exportOpt.output != stdioPath {
+		f, err := os.OpenFile(exportOpt.output, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return runTaskCommand(exportOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+		tasks, err := dfget.ExportArchive(ctx, dc, target, taskIDs, w)
+		if err != nil {
+			return err
+		}
+		// keep stdout clean for archive content
+		fmt.Fprintf(os.Stderr, "exported %d task(s)\n", len(tasks))
+		return nil
+	})
+}
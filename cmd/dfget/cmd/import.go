@@ -0,0 +1,125 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/client/dfget"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+)
+
+type importOption struct {
+	url     string
+	archive bool
+	tag     string
+	digest  string
+	filter  string
+	rng     string
+	timeout time.Duration
+}
+
+var importOpt = &importOption{}
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import (file --url url | --archive file)",
+	Short: "import a local file into the P2P network as a seed",
+	Long: `import splits the local file into pieces and saves them in the storage of the local client daemon
+as a completed task, the task id is generated with the logical url like downloading.
+then the task is announced to scheduler, other peers can download it with the same url without any origin.
+the file and its directories must be readable by all users, because the daemon may run with another user.
+with --archive, all tasks in the archive exported by "dfget export --archive" are imported with the original task ids,
+those tasks are not announced to scheduler, use "-" to read the archive from stdin.`,
+	Args:              cobra.ExactArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importOpt.archive {
+			return runImportArchive(args[0])
+		}
+		if importOpt.url == "" {
+			return errors.New("--url is required")
+		}
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		return runTaskCommand(importOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			task, err := dc.ImportTask(ctx, target, &dfdaemon.ImportTaskRequest{
+				Url: importOpt.url,
+				UrlMeta: &base.UrlMeta{
+					Digest: importOpt.digest,
+					Tag:    importOpt.tag,
+					Filter: importOpt.filter,
+					Range:  importOpt.rng,
+				},
+				Path: path,
+			})
+			if err != nil {
+				return err
+			}
+			printTasks([]*dfdaemon.TaskInfo{task})
+			return nil
+		})
+	},
+}
+
+func init() {
+	// Add the command to parent
+	rootCmd.AddCommand(importCmd)
+
+	flags := importCmd.Flags()
+	flags.BoolVar(&importOpt.archive, "archive", false, "import all tasks in the archive file")
+	flags.StringVar(&importOpt.url, "url", "", "logical url of the file, other peers download the file with this url")
+	flags.StringVar(&importOpt.tag, "tag", "", "tag of the url, used to generate task id with url")
+	flags.StringVar(&importOpt.digest, "digest", "", "digest of the url, used to generate task id with url")
+	flags.StringVar(&importOpt.filter, "filter", "", "filter of the url, used to generate task id with url")
+	flags.StringVar(&importOpt.rng, "range", "", "range of the url, used to generate task id with url, the file is the content of the range")
+	flags.DurationVar(&importOpt.timeout, "timeout", 0, "timeout for importing, 0 is infinite")
+}
+
+func runImportArchive(archive string) error {
+	var r io.Reader = os.Stdin
+	if archive != stdioPath {
+		f, err := os.Open(archive)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return runTaskCommand(importOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+		tasks, err := dfget.ImportArchive(ctx, dc, target, r, "")
+		if err != nil {
+			return err
+		}
+		printTasks(tasks)
+		return nil
+	})
+}
@@ -0,0 +1,155 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+)
+
+// unlimitedRateLimit stands no limit in rate limit flags
+const unlimitedRateLimit = "unlimited"
+
+type rateLimitOption struct {
+	totalDownload string
+	perPeer       string
+	backSource    string
+	upload        string
+	clear         bool
+	timeout       time.Duration
+}
+
+var rateLimitOpt = &rateLimitOption{}
+
+// rateLimitCmd represents the ratelimit command
+var rateLimitCmd = &cobra.Command{
+	Use:   "ratelimit",
+	Short: "show or change the rate limits of the local client daemon",
+	Long: `show the effective rate limits of the local client daemon, or change them at runtime with "ratelimit set".
+the limits set at runtime override the limits in daemon config, client config from manager and schedules,
+until they are cleared with "ratelimit set --clear" or daemon restarts.`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTaskCommand(rateLimitOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			limits, err := dc.GetRateLimits(ctx, target)
+			if err != nil {
+				return err
+			}
+			printRateLimits(limits)
+			return nil
+		})
+	},
+}
+
+var rateLimitSetCmd = &cobra.Command{
+	Use:               "set",
+	Short:             "change the rate limits at runtime, like: ratelimit set --upload 10Mi, the limits not specified are unchanged",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limits := &dfdaemon.RateLimits{}
+		for _, l := range []struct {
+			value string
+			limit *float64
+		}{
+			{rateLimitOpt.totalDownload, &limits.TotalDownload},
+			{rateLimitOpt.perPeer, &limits.PerPeer},
+			{rateLimitOpt.backSource, &limits.BackSource},
+			{rateLimitOpt.upload, &limits.Upload},
+		} {
+			limit, err := parseRateLimit(l.value)
+			if err != nil {
+				return err
+			}
+			*l.limit = limit
+		}
+		return runTaskCommand(rateLimitOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			limits, err := dc.SetRateLimits(ctx, target, &dfdaemon.SetRateLimitsRequest{
+				Limits: limits,
+				Clear:  rateLimitOpt.clear,
+			})
+			if err != nil {
+				return err
+			}
+			printRateLimits(limits)
+			return nil
+		})
+	},
+}
+
+func init() {
+	// Add the command to parent
+	rootCmd.AddCommand(rateLimitCmd)
+	rateLimitCmd.AddCommand(rateLimitSetCmd)
+
+	rateLimitCmd.PersistentFlags().DurationVar(&rateLimitOpt.timeout, "timeout", 30*time.Second, "timeout for requesting daemon")
+
+	flags := rateLimitSetCmd.Flags()
+	flags.StringVar(&rateLimitOpt.totalDownload, "total-download", "", "total download limit from other peers per second, like 100Mi or unlimited")
+	flags.StringVar(&rateLimitOpt.perPeer, "per-peer", "", "download limit of every peer task per second, like 20Mi or unlimited")
+	flags.StringVar(&rateLimitOpt.backSource, "back-source", "", "total download limit from source per second, like 100Mi or unlimited")
+	flags.StringVar(&rateLimitOpt.upload, "upload", "", "total upload limit to other peers per second, like 100Mi or unlimited")
+	flags.BoolVar(&rateLimitOpt.clear, "clear", false, "clear the limits set at runtime before applying the specified limits")
+}
+
+// parseRateLimit parses the rate limit flag, empty value stands not set
+func parseRateLimit(value string) (float64, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case unlimitedRateLimit:
+		// negative limit stands no limit
+		return -1, nil
+	}
+	limit, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("invalid rate limit %q", value)
+	}
+	return float64(limit), nil
+}
+
+func printRateLimits(limits *dfdaemon.RateLimits) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Total Download", "Per Peer", "Back Source", "Upload"})
+	var row []string
+	for _, limit := range []float64{limits.TotalDownload, limits.PerPeer, limits.BackSource, limits.Upload} {
+		if rate.Limit(limit) == rate.Inf || limit <= 0 {
+			row = append(row, unlimitedRateLimit)
+			continue
+		}
+		row = append(row, units.BytesSize(limit)+"/s")
+	}
+	table.Append(row)
+	table.Render()
+}
@@ -86,7 +86,8 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		logger.Error(err)
-		fmt.Println(err)
+		// keep stdout clean for the downloaded content when output is stdout
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -104,7 +105,8 @@ func init() {
 		"download a file from the url, equivalent to the command's first position argument")
 
 	flagSet.StringP("output", "O", dfgetConfig.Output,
-		"destination path which is used to store the downloaded file. It must be a full path, for example, '/tmp/file.mp4'")
+		"destination path which is used to store the downloaded file. It must be a full path, for example, '/tmp/file.mp4', "+
+			"use '-' to write the downloaded content to stdout, for example, 'dfget -O - url | tar x'")
 
 	flagSet.DurationP("timeout", "e", dfgetConfig.Timeout,
 		"timeout for file downloading task. If dfget has not finished downloading all pieces of file "+
@@ -114,7 +116,8 @@ func init() {
 		"network bandwidth rate limit in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will be parsed as Byte, 0 is infinite")
 
 	flagSet.String("digest", dfgetConfig.Digest,
-		"digest is used to check the integrity of the downloaded file, in format of md5:xxx or sha256:yyy")
+		"digest is used to check the integrity of the downloaded file, in format of md5:xxx, sha256:yyy or sha512:zzz, "+
+			"the whole file is verified before it is moved to the output")
 
 	flagSet.StringP("identifier", "i", dfgetConfig.Identifier,
 		"different identifiers for the same url will be divided into different P2P tasks, it conflicts with --digest")
@@ -0,0 +1,286 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/internal/dfpath"
+	"d7y.io/dragonfly/v2/internal/idgen"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+type taskOption struct {
+	taskID  string
+	peerID  string
+	tag     string
+	digest  string
+	filter  string
+	rng     string
+	running bool
+	timeout time.Duration
+}
+
+var taskOpt = &taskOption{}
+
+// taskCmd represents the task command
+var taskCmd = &cobra.Command{
+	Use:               "task",
+	Short:             "manage the tasks of the local client daemon",
+	Long:              `list, stat, cancel, delete, pin and unpin the running and stored tasks of the local client daemon.`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+}
+
+var taskListCmd = &cobra.Command{
+	Use:               "list",
+	Short:             "list the running and stored tasks",
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTaskCommand(taskOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			result, err := dc.ListTasks(ctx, target, &dfdaemon.ListTasksRequest{RunningOnly: taskOpt.running})
+			if err != nil {
+				return err
+			}
+			printTasks(result.Tasks)
+			return nil
+		})
+	},
+}
+
+var taskStatCmd = &cobra.Command{
+	Use:               "stat [url]",
+	Short:             "show the status of a task, the task is specified by url, --task-id or --peer-id",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTaskCommand(taskOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			taskTarget, err := taskOpt.target(args)
+			if err != nil {
+				return err
+			}
+			task, err := dc.StatTask(ctx, target, taskTarget)
+			if err != nil {
+				return err
+			}
+			printTasks([]*dfdaemon.TaskInfo{task})
+			return nil
+		})
+	},
+}
+
+var taskCancelCmd = &cobra.Command{
+	Use:               "cancel [url]",
+	Short:             "cancel the running task, the task is specified by url, --task-id or --peer-id",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTaskCommand(taskOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			taskTarget, err := taskOpt.target(args)
+			if err != nil {
+				return err
+			}
+			return dc.CancelTask(ctx, target, taskTarget)
+		})
+	},
+}
+
+var taskDeleteCmd = &cobra.Command{
+	Use:               "delete [url]",
+	Short:             "delete the stored task data, the task is specified by url or --task-id",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTaskCommand(taskOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+			taskTarget, err := taskOpt.target(args)
+			if err != nil {
+				return err
+			}
+			if taskTarget.TaskId == "" {
+				return errors.New("task id is required for deleting task")
+			}
+			return dc.DeleteTask(ctx, target, taskTarget)
+		})
+	},
+}
+
+var taskPinCmd = &cobra.Command{
+	Use:               "pin [url]",
+	Short:             "pin the stored task, the pinned task is never reclaimed by gc, the task is specified by url or --task-id",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPinTask(args, true)
+	},
+}
+
+var taskUnpinCmd = &cobra.Command{
+	Use:               "unpin [url]",
+	Short:             "unpin the stored task, the task is specified by url or --task-id",
+	Args:              cobra.MaximumNArgs(1),
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPinTask(args, false)
+	},
+}
+
+func runPinTask(args []string, pinned bool) error {
+	return runTaskCommand(taskOpt.timeout, func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error {
+		taskTarget, err := taskOpt.target(args)
+		if err != nil {
+			return err
+		}
+		if taskTarget.TaskId == "" {
+			return errors.New("task id is required for pinning task")
+		}
+		return dc.PinTask(ctx, target, &dfdaemon.PinTaskRequest{
+			Target: taskTarget,
+			Pinned: pinned,
+		})
+	})
+}
+
+func init() {
+	// Add the command to parent
+	rootCmd.AddCommand(taskCmd)
+	taskCmd.AddCommand(taskListCmd, taskStatCmd, taskCancelCmd, taskDeleteCmd, taskPinCmd, taskUnpinCmd)
+
+	pflags := taskCmd.PersistentFlags()
+	pflags.DurationVar(&taskOpt.timeout, "timeout", 30*time.Second, "timeout for requesting daemon")
+
+	taskListCmd.Flags().BoolVar(&taskOpt.running, "running", false, "only list the running tasks")
+
+	for _, cmd := range []*cobra.Command{taskStatCmd, taskCancelCmd, taskDeleteCmd, taskPinCmd, taskUnpinCmd} {
+		flags := cmd.Flags()
+		flags.StringVar(&taskOpt.taskID, "task-id", "", "id of the task")
+		flags.StringVar(&taskOpt.peerID, "peer-id", "", "id of the peer which downloads the task")
+		flags.StringVar(&taskOpt.tag, "tag", "", "tag of the url, used to generate task id with url")
+		flags.StringVar(&taskOpt.digest, "digest", "", "digest of the url, used to generate task id with url")
+		flags.StringVar(&taskOpt.filter, "filter", "", "filter of the url, used to generate task id with url")
+		flags.StringVar(&taskOpt.rng, "range", "", "range of the url, used to generate task id with url")
+	}
+}
+
+// target generates the task target, task id is generated by the url when it's not specified
+func (o *taskOption) target(args []string) (*dfdaemon.TaskTarget, error) {
+	taskID := o.taskID
+	if taskID == "" && len(args) > 0 {
+		taskID = idgen.TaskID(args[0], o.filter, &base.UrlMeta{
+			Digest: o.digest,
+			Range:  o.rng,
+			Tag:    o.tag,
+			Filter: o.filter,
+		}, o.tag)
+	}
+	if taskID == "" && o.peerID == "" {
+		return nil, errors.New("one of url, --task-id and --peer-id is required")
+	}
+	return &dfdaemon.TaskTarget{
+		TaskId: taskID,
+		PeerId: o.peerID,
+	}, nil
+}
+
+// runTaskCommand connects to the local client daemon and calls fn, timeout 0 is infinite
+func runTaskCommand(timeout time.Duration, fn func(ctx context.Context, dc client.DaemonClient, target dfnet.NetAddr) error) error {
+	target := dfnet.NetAddr{Type: dfnet.UNIX, Addr: dfpath.DaemonSockPath}
+	daemonClient, err := client.GetClientByAddr([]dfnet.NetAddr{target})
+	if err != nil {
+		return err
+	}
+	defer daemonClient.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if timeout > 0 {
+		var timeoutCancel context.CancelFunc
+		ctx, timeoutCancel = context.WithTimeout(ctx, timeout)
+		defer timeoutCancel()
+	}
+	return fn(ctx, daemonClient, target)
+}
+
+func printTasks(tasks []*dfdaemon.TaskInfo) {
+	renderTasks(os.Stdout, tasks)
+}
+
+func renderTasks(w io.Writer, tasks []*dfdaemon.TaskInfo) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Task ID", "Peer ID", "State", "Biz", "Content Length", "Completed Length", "Total Piece", "Access Time"})
+	for _, task := range tasks {
+		contentLength := "unknown"
+		if task.ContentLength >= 0 {
+			contentLength = unit.Bytes(task.ContentLength).String()
+		}
+		table.Append([]string{
+			task.TaskId,
+			task.PeerId,
+			taskState(task),
+			task.Biz,
+			contentLength,
+			unit.Bytes(task.CompletedLength).String(),
+			strconv.Itoa(int(task.TotalPiece)),
+			taskAccessTime(task),
+		})
+	}
+	table.Render()
+	fmt.Fprintf(w, "total %d task(s)\n", len(tasks))
+}
+
+// taskAccessTime formats the access time of task, zero means unknown, e.g. the task is still running
+func taskAccessTime(task *dfdaemon.TaskInfo) string {
+	if task.AccessTime == 0 {
+		return "-"
+	}
+	return time.Unix(0, task.AccessTime).Format(time.RFC3339)
+}
+
+func taskState(task *dfdaemon.TaskInfo) string {
+	switch {
+	case task.Running:
+		return "Running"
+	case task.Done && task.Pinned:
+		return "Done (Pinned)"
+	case task.Done:
+		return "Done"
+	case task.Pinned:
+		return "Incomplete (Pinned)"
+	default:
+		return "Incomplete"
+	}
+}
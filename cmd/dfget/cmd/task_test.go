@@ -0,0 +1,72 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
+)
+
+func TestRenderTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	accessTime := time.Date(2021, 7, 1, 8, 0, 0, 0, time.Local)
+
+	testCases := []struct {
+		name       string
+		task       *dfdaemon.TaskInfo
+		accessTime string
+	}{
+		{
+			name: "done task",
+			task: &dfdaemon.TaskInfo{
+				TaskId:     "task-done",
+				PeerId:     "peer-done",
+				Done:       true,
+				AccessTime: accessTime.UnixNano(),
+			},
+			accessTime: accessTime.Format(time.RFC3339),
+		},
+		{
+			name: "running task with unknown access time",
+			task: &dfdaemon.TaskInfo{
+				TaskId:        "task-running",
+				PeerId:        "peer-running",
+				Running:       true,
+				ContentLength: -1,
+			},
+			accessTime: "-",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(tc.accessTime, taskAccessTime(tc.task))
+
+			buf := bytes.NewBuffer(nil)
+			renderTasks(buf, []*dfdaemon.TaskInfo{tc.task})
+			assert.Contains(buf.String(), tc.task.TaskId)
+			assert.Contains(buf.String(), tc.accessTime)
+			assert.NotContains(buf.String(), time.Unix(0, 0).Format(time.RFC3339))
+			assert.Contains(buf.String(), "total 1 task(s)")
+		})
+	}
+}
@@ -39,6 +39,7 @@ const (
 	ClientWaitPieceReady    base.Code = 4004 // when target peer downloads from source slowly, should wait
 	ClientPieceDownloadFail base.Code = 4005
 	ClientRequestLimitFail  base.Code = 4006
+	ClientDigestNotMatch    base.Code = 4007 // digest of the whole content does not match
 
 	// scheduler response error 5000-5999
 	SchedError          base.Code = 5000
@@ -49,6 +50,7 @@ const (
 	CdnError            base.Code = 6000
 	CdnTaskRegistryFail base.Code = 6001
 	CdnTaskDownloadFail base.Code = 6002
+	CdnSourceLimited    base.Code = 6003 // wait too long for the connection limit of source
 	CdnTaskNotFound     base.Code = 6404
 
 	// manager response error 7000-7999
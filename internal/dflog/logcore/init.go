@@ -122,6 +122,12 @@ func InitCdnSystem(console bool) error {
 	}
 	logger.SetDownloadLogger(downloaderLogger)
 
+	accessLogger, err := CreateLogger(path.Join(logDir, "access.log"), 300, 7, 0, false, true)
+	if err != nil {
+		return err
+	}
+	logger.SetAccessLogger(accessLogger)
+
 	keepAliveLogger, err := CreateLogger(path.Join(logDir, "keepAlive.log"), 300, 7, 0, false, false)
 	if err != nil {
 		return err
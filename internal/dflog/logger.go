@@ -31,6 +31,7 @@ var (
 	StatPeerLogger   *zap.Logger
 	StatSeedLogger   *zap.Logger
 	DownloaderLogger *zap.Logger
+	AccessLogger     *zap.Logger
 )
 
 func init() {
@@ -46,6 +47,7 @@ func init() {
 		SetStatPeerLogger(log)
 		SetStatSeedLogger(log)
 		SetDownloadLogger(log)
+		SetAccessLogger(log)
 	}
 }
 
@@ -73,6 +75,10 @@ func SetDownloadLogger(log *zap.Logger) {
 	DownloaderLogger = log
 }
 
+func SetAccessLogger(log *zap.Logger) {
+	AccessLogger = log
+}
+
 func SetGrpcLogger(log *zap.SugaredLogger) {
 	GrpcLogger = log
 	grpclog.SetLoggerV2(&zapGrpc{GrpcLogger})
@@ -23,11 +23,12 @@ import (
 )
 
 var (
-	DefaultDataDir = filepath.Join(WorkHome, "data")
-	DaemonSockPath = filepath.Join(WorkHome, "daemon.sock")
-	DaemonLockPath = filepath.Join(WorkHome, "daemon.lock")
-	DfgetLockPath  = filepath.Join(WorkHome, "dfget.lock")
-	PluginsDir     = filepath.Join(WorkHome, "plugins")
+	DefaultDataDir     = filepath.Join(WorkHome, "data")
+	DaemonSockPath     = filepath.Join(WorkHome, "daemon.sock")
+	DaemonHTTPSockPath = filepath.Join(WorkHome, "daemon-http.sock")
+	DaemonLockPath     = filepath.Join(WorkHome, "daemon.lock")
+	DfgetLockPath      = filepath.Join(WorkHome, "dfget.lock")
+	PluginsDir         = filepath.Join(WorkHome, "plugins")
 )
 
 func init() {
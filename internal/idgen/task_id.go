@@ -39,7 +39,7 @@ func TaskID(url string, filter string, meta *base.UrlMeta, bizID string) string
 
 	if meta != nil {
 		if meta.Digest != "" {
-			data = append(data, meta.Digest)
+			data = append(data, taskIDDigest(meta.Digest))
 		}
 
 		if meta.Range != "" {
@@ -54,6 +54,17 @@ func TaskID(url string, filter string, meta *base.UrlMeta, bizID string) string
 	return digestutils.Sha256(data...)
 }
 
+// taskIDDigest returns the digest to generate the task id, the digests of algorithms other than md5 are normalized
+// so that the same digest in different forms generates the same task id, md5 digests are kept as they are
+// to not change the task ids of the existing tasks.
+func taskIDDigest(digest string) string {
+	algorithm, encoded, err := digestutils.Parse(digest)
+	if err != nil || algorithm == digestutils.AlgorithmMD5 {
+		return digest
+	}
+	return digestutils.Format(algorithm, encoded)
+}
+
 // GenerateTwinsTaskId used A/B testing
 func TwinsTaskID(url string, filter string, meta *base.UrlMeta, bizID, peerID string) string {
 	taskID := TaskID(url, filter, meta, bizID)
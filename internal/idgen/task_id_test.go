@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"d7y.io/dragonfly/v2/internal/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -57,6 +58,34 @@ func TestTaskID(t *testing.T) {
 				assert.Equal("aeee0e0a2a0c75130582641353c539aaf9011a0088b31347f7588e70e449a3e0", d)
 			},
 		},
+		{
+			name:   "generate taskID with md5 digest",
+			url:    "https://example.com",
+			filter: "",
+			meta: &base.UrlMeta{
+				Digest: "MD5:5D41402ABC4B2A76B9719D911017C592",
+			},
+			bizID: "",
+			expect: func(t *testing.T, d interface{}) {
+				assert := assert.New(t)
+				// md5 digests are not normalized to keep the task ids of the existing tasks
+				assert.Equal(digestutils.Sha256("https://example.com", "MD5:5D41402ABC4B2A76B9719D911017C592"), d)
+			},
+		},
+		{
+			name:   "generate taskID with sha256 digest",
+			url:    "https://example.com",
+			filter: "",
+			meta: &base.UrlMeta{
+				Digest: "SHA256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824",
+			},
+			bizID: "",
+			expect: func(t *testing.T, d interface{}) {
+				assert := assert.New(t)
+				assert.Equal(TaskID("https://example.com", "",
+					&base.UrlMeta{Digest: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}, ""), d)
+			},
+		},
 		{
 			name:   "generate taskID with filter",
 			url:    "https://example.com?foo=foo&bar=bar",
@@ -0,0 +1,109 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pieceauth issues and verifies the tokens which authorize a child peer to download pieces from its parent,
+// the scheduler and the daemons share the secret.
+package pieceauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTokenTTL is the default duration for which a token is valid
+const DefaultTokenTTL = 30 * time.Minute
+
+var (
+	ErrTokenMissing = errors.New("piece token is missing")
+	ErrTokenInvalid = errors.New("piece token is invalid")
+	ErrTokenExpired = errors.New("piece token is expired")
+)
+
+// Signer signs and verifies the piece tokens with the shared secret
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewSigner returns a signer, DefaultTokenTTL is used when ttl is not positive
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Signer{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// TTL returns the duration for which a token is valid
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign returns a token which authorizes the child peer srcPid to download the pieces of task from the parent peer dstPid,
+// the token is formatted as base64(srcPid).expireUnixSeconds.hex(hmac)
+func (s *Signer) Sign(taskID, srcPid, dstPid string) string {
+	expire := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(srcPid)) + "." + expire + "." + s.mac(taskID, srcPid, dstPid, expire)
+}
+
+// Verify checks the token is signed for downloading the pieces of task from dstPid and not expired,
+// returns the child peer id in token
+func (s *Signer) Verify(token, taskID, dstPid string) (string, error) {
+	if token == "" {
+		return "", ErrTokenMissing
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrTokenInvalid
+	}
+	src, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrTokenInvalid
+	}
+	srcPid, expire := string(src), parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.mac(taskID, srcPid, dstPid, expire))) {
+		return "", ErrTokenInvalid
+	}
+	exp, err := strconv.ParseInt(expire, 10, 64)
+	if err != nil {
+		return "", ErrTokenInvalid
+	}
+	if s.now().Unix() > exp {
+		return "", ErrTokenExpired
+	}
+	return srcPid, nil
+}
+
+func (s *Signer) mac(taskID, srcPid, dstPid, expire string) string {
+	h := hmac.New(sha256.New, s.secret)
+	for _, v := range []string{taskID, srcPid, dstPid, expire} {
+		h.Write([]byte(v))
+		// separate the fields, the peer ids may share prefixes
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,54 @@
+/*
+ *     Copyright 2021 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pieceauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Now()
+	signer := NewSigner("secret", time.Minute)
+	signer.now = func() time.Time { return now }
+
+	token := signer.Sign("task", "child", "parent")
+	srcPid, err := signer.Verify(token, "task", "parent")
+	assert.Nil(t, err)
+	assert.Equal(t, "child", srcPid)
+
+	_, err = signer.Verify("", "task", "parent")
+	assert.Equal(t, ErrTokenMissing, err)
+
+	_, err = signer.Verify(token, "other-task", "parent")
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	_, err = signer.Verify(token, "task", "other-parent")
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	_, err = NewSigner("other-secret", time.Minute).Verify(token, "task", "parent")
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	_, err = signer.Verify("bad-token", "task", "parent")
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	signer.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, err = signer.Verify(token, "task", "parent")
+	assert.Equal(t, ErrTokenExpired, err)
+}
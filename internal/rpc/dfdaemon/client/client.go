@@ -71,10 +71,36 @@ func GetElasticClientByAdders(addrs []dfnet.NetAddr, opts ...grpc.DialOption) (D
 type DaemonClient interface {
 	Download(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (*DownResultStream, error)
 
+	// StreamDownload receives the downloaded content in order, it's not retried after the stream is created
+	StreamDownload(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_StreamDownloadClient, error)
+
 	GetPieceTasks(ctx context.Context, addr dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error)
 
+	// SyncPieceTasks subscribes the pieces of the task from the peer, it's not retried after the stream is created
+	SyncPieceTasks(ctx context.Context, addr dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_SyncPieceTasksClient, error)
+
 	CheckHealth(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) error
 
+	ListTasks(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ListTasksRequest, opts ...grpc.CallOption) (*dfdaemon.ListTasksResult, error)
+
+	StatTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error)
+
+	CancelTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) error
+
+	DeleteTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) error
+
+	PinTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.PinTaskRequest, opts ...grpc.CallOption) error
+
+	ImportTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ImportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error)
+
+	ExportTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ExportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error)
+
+	ReadTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ExportTaskRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_ReadTaskClient, error)
+
+	GetRateLimits(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) (*dfdaemon.RateLimits, error)
+
+	SetRateLimits(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.SetRateLimitsRequest, opts ...grpc.CallOption) (*dfdaemon.RateLimits, error)
+
 	Close() error
 }
 
@@ -105,6 +131,23 @@ func (dc *daemonClient) Download(ctx context.Context, req *dfdaemon.DownRequest,
 	return newDownResultStream(ctx, dc, taskID, req, opts)
 }
 
+func (dc *daemonClient) StreamDownload(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_StreamDownloadClient, error) {
+	req.Uuid = uuid.New().String()
+	// generate taskID
+	taskID := idgen.TaskID(req.Url, req.UrlMeta.Filter, req.UrlMeta, req.UrlMeta.Tag)
+	stream, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskID, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.StreamDownload(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return stream.(dfdaemon.Daemon_StreamDownloadClient), nil
+}
+
 func (dc *daemonClient) GetPieceTasks(ctx context.Context, target dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket,
 	error) {
 	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
@@ -133,3 +176,111 @@ func (dc *daemonClient) CheckHealth(ctx context.Context, target dfnet.NetAddr, o
 
 	return
 }
+
+func (dc *daemonClient) ListTasks(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ListTasksRequest, opts ...grpc.CallOption) (*dfdaemon.ListTasksResult, error) {
+	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+		}
+		return client.ListTasks(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+
+	if err == nil {
+		return res.(*dfdaemon.ListTasksResult), nil
+	}
+
+	return nil, err
+}
+
+func (dc *daemonClient) StatTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error) {
+	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+		}
+		return client.StatTask(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+
+	if err == nil {
+		return res.(*dfdaemon.TaskInfo), nil
+	}
+
+	return nil, err
+}
+
+// CancelTask is not retried, the running peer task may be canceled already
+func (dc *daemonClient) CancelTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) error {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	_, err = client.CancelTask(ctx, req, opts...)
+	return err
+}
+
+func (dc *daemonClient) DeleteTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.TaskTarget, opts ...grpc.CallOption) error {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	_, err = client.DeleteTask(ctx, req, opts...)
+	return err
+}
+
+func (dc *daemonClient) PinTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.PinTaskRequest, opts ...grpc.CallOption) error {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	_, err = client.PinTask(ctx, req, opts...)
+	return err
+}
+
+func (dc *daemonClient) ImportTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ImportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.ImportTask(ctx, req, opts...)
+}
+
+func (dc *daemonClient) ExportTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ExportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.ExportTask(ctx, req, opts...)
+}
+
+func (dc *daemonClient) SyncPieceTasks(ctx context.Context, target dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_SyncPieceTasksClient, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.SyncPieceTasks(ctx, ptr, opts...)
+}
+
+func (dc *daemonClient) ReadTask(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.ExportTaskRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_ReadTaskClient, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.ReadTask(ctx, req, opts...)
+}
+
+func (dc *daemonClient) GetRateLimits(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) (*dfdaemon.RateLimits, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.GetRateLimits(ctx, new(empty.Empty), opts...)
+}
+
+func (dc *daemonClient) SetRateLimits(ctx context.Context, target dfnet.NetAddr, req *dfdaemon.SetRateLimitsRequest, opts ...grpc.CallOption) (*dfdaemon.RateLimits, error) {
+	client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server %s: %v", target.GetEndpoint(), err)
+	}
+	return client.SetRateLimits(ctx, req, opts...)
+}
@@ -25,6 +25,7 @@ import (
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/base/common"
 	cdnclient "d7y.io/dragonfly/v2/internal/rpc/cdnsystem/client"
+	"d7y.io/dragonfly/v2/internal/rpc/dfdaemon"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
 	"google.golang.org/grpc"
@@ -49,6 +50,22 @@ func GetPieceTasks(ctx context.Context, destPeer *scheduler.PeerPacket_DestPeer,
 	return client.(DaemonClient).GetPieceTasks(ctx, netAddr, ptr, opts...)
 }
 
+// SyncPieceTasks subscribes the pieces of the task from the dest peer, cdn does not support it
+func SyncPieceTasks(ctx context.Context, destPeer *scheduler.PeerPacket_DestPeer, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_SyncPieceTasksClient, error) {
+	if strings.HasSuffix(destPeer.PeerId, common.CdnSuffix) {
+		return nil, fmt.Errorf("cdn peer %s does not support syncing piece tasks", destPeer.PeerId)
+	}
+	netAddr := dfnet.NetAddr{
+		Type: dfnet.TCP,
+		Addr: fmt.Sprintf("%s:%d", destPeer.Ip, destPeer.RpcPort),
+	}
+	client, err := GetElasticClientByAdders([]dfnet.NetAddr{netAddr})
+	if err != nil {
+		return nil, err
+	}
+	return client.SyncPieceTasks(ctx, netAddr, ptr, opts...)
+}
+
 func getClient(netAddr dfnet.NetAddr, toCdn bool) (rpc.Closer, error) {
 	if toCdn {
 		return cdnclient.GetElasticClientByAdders([]dfnet.NetAddr{netAddr})
@@ -23,8 +23,33 @@ type DaemonClient interface {
 	Download(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_DownloadClient, error)
 	// get piece tasks from other peers
 	GetPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error)
+	// subscribe pieces of the task from other peers, the available pieces since start_num are pushed
+	// until all pieces are sent with the piece md5 sign, limit is ignored
+	SyncPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (Daemon_SyncPieceTasksClient, error)
 	// check daemon health
 	CheckHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// list running and stored tasks
+	ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksResult, error)
+	// get status of a task, running peer is preferred when peer id is empty
+	StatTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*TaskInfo, error)
+	// cancel running peer tasks
+	CancelTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// delete task data from storage
+	DeleteTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// pin or unpin the stored task, the pinned task is never reclaimed by gc
+	PinTask(ctx context.Context, in *PinTaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// import a local file into storage as a completed task and announce it to scheduler
+	ImportTask(ctx context.Context, in *ImportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error)
+	// export a completed task from storage to the output path
+	ExportTask(ctx context.Context, in *ExportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error)
+	// read the content of a completed task from storage
+	ReadTask(ctx context.Context, in *ExportTaskRequest, opts ...grpc.CallOption) (Daemon_ReadTaskClient, error)
+	// trigger client to download file and send the content in order, output of request is ignored
+	StreamDownload(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_StreamDownloadClient, error)
+	// get the effective rate limits
+	GetRateLimits(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RateLimits, error)
+	// override the rate limits at runtime, returns the effective rate limits
+	SetRateLimits(ctx context.Context, in *SetRateLimitsRequest, opts ...grpc.CallOption) (*RateLimits, error)
 }
 
 type daemonClient struct {
@@ -76,6 +101,38 @@ func (c *daemonClient) GetPieceTasks(ctx context.Context, in *base.PieceTaskRequ
 	return out, nil
 }
 
+func (c *daemonClient) SyncPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (Daemon_SyncPieceTasksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[1], "/dfdaemon.Daemon/SyncPieceTasks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonSyncPieceTasksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_SyncPieceTasksClient interface {
+	Recv() (*base.PiecePacket, error)
+	grpc.ClientStream
+}
+
+type daemonSyncPieceTasksClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonSyncPieceTasksClient) Recv() (*base.PiecePacket, error) {
+	m := new(base.PiecePacket)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *daemonClient) CheckHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/CheckHealth", in, out, opts...)
@@ -85,6 +142,151 @@ func (c *daemonClient) CheckHealth(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *daemonClient) ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksResult, error) {
+	out := new(ListTasksResult)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/ListTasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) StatTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*TaskInfo, error) {
+	out := new(TaskInfo)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/StatTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) CancelTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/CancelTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) DeleteTask(ctx context.Context, in *TaskTarget, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/DeleteTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) PinTask(ctx context.Context, in *PinTaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/PinTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) ImportTask(ctx context.Context, in *ImportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error) {
+	out := new(TaskInfo)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/ImportTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) ExportTask(ctx context.Context, in *ExportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error) {
+	out := new(TaskInfo)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/ExportTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) ReadTask(ctx context.Context, in *ExportTaskRequest, opts ...grpc.CallOption) (Daemon_ReadTaskClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[2], "/dfdaemon.Daemon/ReadTask", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonReadTaskClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_ReadTaskClient interface {
+	Recv() (*TaskData, error)
+	grpc.ClientStream
+}
+
+type daemonReadTaskClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonReadTaskClient) Recv() (*TaskData, error) {
+	m := new(TaskData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) StreamDownload(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_StreamDownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[3], "/dfdaemon.Daemon/StreamDownload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonStreamDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_StreamDownloadClient interface {
+	Recv() (*TaskData, error)
+	grpc.ClientStream
+}
+
+type daemonStreamDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonStreamDownloadClient) Recv() (*TaskData, error) {
+	m := new(TaskData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) GetRateLimits(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RateLimits, error) {
+	out := new(RateLimits)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/GetRateLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) SetRateLimits(ctx context.Context, in *SetRateLimitsRequest, opts ...grpc.CallOption) (*RateLimits, error) {
+	out := new(RateLimits)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/SetRateLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -93,8 +295,33 @@ type DaemonServer interface {
 	Download(*DownRequest, Daemon_DownloadServer) error
 	// get piece tasks from other peers
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
+	// subscribe pieces of the task from other peers, the available pieces since start_num are pushed
+	// until all pieces are sent with the piece md5 sign, limit is ignored
+	SyncPieceTasks(*base.PieceTaskRequest, Daemon_SyncPieceTasksServer) error
 	// check daemon health
 	CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// list running and stored tasks
+	ListTasks(context.Context, *ListTasksRequest) (*ListTasksResult, error)
+	// get status of a task, running peer is preferred when peer id is empty
+	StatTask(context.Context, *TaskTarget) (*TaskInfo, error)
+	// cancel running peer tasks
+	CancelTask(context.Context, *TaskTarget) (*emptypb.Empty, error)
+	// delete task data from storage
+	DeleteTask(context.Context, *TaskTarget) (*emptypb.Empty, error)
+	// pin or unpin the stored task, the pinned task is never reclaimed by gc
+	PinTask(context.Context, *PinTaskRequest) (*emptypb.Empty, error)
+	// import a local file into storage as a completed task and announce it to scheduler
+	ImportTask(context.Context, *ImportTaskRequest) (*TaskInfo, error)
+	// export a completed task from storage to the output path
+	ExportTask(context.Context, *ExportTaskRequest) (*TaskInfo, error)
+	// read the content of a completed task from storage
+	ReadTask(*ExportTaskRequest, Daemon_ReadTaskServer) error
+	// trigger client to download file and send the content in order, output of request is ignored
+	StreamDownload(*DownRequest, Daemon_StreamDownloadServer) error
+	// get the effective rate limits
+	GetRateLimits(context.Context, *emptypb.Empty) (*RateLimits, error)
+	// override the rate limits at runtime, returns the effective rate limits
+	SetRateLimits(context.Context, *SetRateLimitsRequest) (*RateLimits, error)
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -108,9 +335,45 @@ func (UnimplementedDaemonServer) Download(*DownRequest, Daemon_DownloadServer) e
 func (UnimplementedDaemonServer) GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPieceTasks not implemented")
 }
+func (UnimplementedDaemonServer) SyncPieceTasks(*base.PieceTaskRequest, Daemon_SyncPieceTasksServer) error {
+	return status.Errorf(codes.Unimplemented, "method SyncPieceTasks not implemented")
+}
 func (UnimplementedDaemonServer) CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
 }
+func (UnimplementedDaemonServer) ListTasks(context.Context, *ListTasksRequest) (*ListTasksResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (UnimplementedDaemonServer) StatTask(context.Context, *TaskTarget) (*TaskInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatTask not implemented")
+}
+func (UnimplementedDaemonServer) CancelTask(context.Context, *TaskTarget) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTask not implemented")
+}
+func (UnimplementedDaemonServer) DeleteTask(context.Context, *TaskTarget) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTask not implemented")
+}
+func (UnimplementedDaemonServer) PinTask(context.Context, *PinTaskRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PinTask not implemented")
+}
+func (UnimplementedDaemonServer) ImportTask(context.Context, *ImportTaskRequest) (*TaskInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportTask not implemented")
+}
+func (UnimplementedDaemonServer) ExportTask(context.Context, *ExportTaskRequest) (*TaskInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportTask not implemented")
+}
+func (UnimplementedDaemonServer) ReadTask(*ExportTaskRequest, Daemon_ReadTaskServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadTask not implemented")
+}
+func (UnimplementedDaemonServer) StreamDownload(*DownRequest, Daemon_StreamDownloadServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDownload not implemented")
+}
+func (UnimplementedDaemonServer) GetRateLimits(context.Context, *emptypb.Empty) (*RateLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRateLimits not implemented")
+}
+func (UnimplementedDaemonServer) SetRateLimits(context.Context, *SetRateLimitsRequest) (*RateLimits, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRateLimits not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -163,6 +426,27 @@ func _Daemon_GetPieceTasks_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_SyncPieceTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(base.PieceTaskRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).SyncPieceTasks(m, &daemonSyncPieceTasksServer{stream})
+}
+
+type Daemon_SyncPieceTasksServer interface {
+	Send(*base.PiecePacket) error
+	grpc.ServerStream
+}
+
+type daemonSyncPieceTasksServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonSyncPieceTasksServer) Send(m *base.PiecePacket) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Daemon_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -181,6 +465,210 @@ func _Daemon_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_ListTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ListTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/ListTasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ListTasks(ctx, req.(*ListTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_StatTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).StatTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/StatTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).StatTask(ctx, req.(*TaskTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_CancelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).CancelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/CancelTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).CancelTask(ctx, req.(*TaskTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_DeleteTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).DeleteTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/DeleteTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).DeleteTask(ctx, req.(*TaskTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_PinTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).PinTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/PinTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).PinTask(ctx, req.(*PinTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_ImportTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ImportTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/ImportTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ImportTask(ctx, req.(*ImportTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_ExportTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ExportTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/ExportTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ExportTask(ctx, req.(*ExportTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_ReadTask_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTaskRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).ReadTask(m, &daemonReadTaskServer{stream})
+}
+
+type Daemon_ReadTaskServer interface {
+	Send(*TaskData) error
+	grpc.ServerStream
+}
+
+type daemonReadTaskServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonReadTaskServer) Send(m *TaskData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_StreamDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).StreamDownload(m, &daemonStreamDownloadServer{stream})
+}
+
+type Daemon_StreamDownloadServer interface {
+	Send(*TaskData) error
+	grpc.ServerStream
+}
+
+type daemonStreamDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonStreamDownloadServer) Send(m *TaskData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_GetRateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetRateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/GetRateLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetRateLimits(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_SetRateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRateLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).SetRateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/SetRateLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).SetRateLimits(ctx, req.(*SetRateLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Daemon_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "dfdaemon.Daemon",
 	HandlerType: (*DaemonServer)(nil),
@@ -193,6 +681,42 @@ var _Daemon_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CheckHealth",
 			Handler:    _Daemon_CheckHealth_Handler,
 		},
+		{
+			MethodName: "ListTasks",
+			Handler:    _Daemon_ListTasks_Handler,
+		},
+		{
+			MethodName: "StatTask",
+			Handler:    _Daemon_StatTask_Handler,
+		},
+		{
+			MethodName: "CancelTask",
+			Handler:    _Daemon_CancelTask_Handler,
+		},
+		{
+			MethodName: "DeleteTask",
+			Handler:    _Daemon_DeleteTask_Handler,
+		},
+		{
+			MethodName: "PinTask",
+			Handler:    _Daemon_PinTask_Handler,
+		},
+		{
+			MethodName: "ImportTask",
+			Handler:    _Daemon_ImportTask_Handler,
+		},
+		{
+			MethodName: "ExportTask",
+			Handler:    _Daemon_ExportTask_Handler,
+		},
+		{
+			MethodName: "GetRateLimits",
+			Handler:    _Daemon_GetRateLimits_Handler,
+		},
+		{
+			MethodName: "SetRateLimits",
+			Handler:    _Daemon_SetRateLimits_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -200,6 +724,21 @@ var _Daemon_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Daemon_Download_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SyncPieceTasks",
+			Handler:       _Daemon_SyncPieceTasks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReadTask",
+			Handler:       _Daemon_ReadTask_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDownload",
+			Handler:       _Daemon_StreamDownload_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "internal/rpc/dfdaemon/dfdaemon.proto",
 }
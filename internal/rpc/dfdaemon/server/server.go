@@ -47,7 +47,19 @@ type proxy struct {
 type DaemonServer interface {
 	Download(context.Context, *dfdaemon.DownRequest, chan<- *dfdaemon.DownResult) error
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
+	SyncPieceTasks(context.Context, *base.PieceTaskRequest, chan<- *base.PiecePacket) error
 	CheckHealth(context.Context) error
+	ListTasks(context.Context, *dfdaemon.ListTasksRequest) (*dfdaemon.ListTasksResult, error)
+	StatTask(context.Context, *dfdaemon.TaskTarget) (*dfdaemon.TaskInfo, error)
+	CancelTask(context.Context, *dfdaemon.TaskTarget) error
+	DeleteTask(context.Context, *dfdaemon.TaskTarget) error
+	PinTask(context.Context, *dfdaemon.PinTaskRequest) error
+	ImportTask(context.Context, *dfdaemon.ImportTaskRequest) (*dfdaemon.TaskInfo, error)
+	ExportTask(context.Context, *dfdaemon.ExportTaskRequest) (*dfdaemon.TaskInfo, error)
+	ReadTask(context.Context, *dfdaemon.ExportTaskRequest, chan<- *dfdaemon.TaskData) error
+	StreamDownload(context.Context, *dfdaemon.DownRequest, chan<- *dfdaemon.TaskData) error
+	GetRateLimits(context.Context) (*dfdaemon.RateLimits, error)
+	SetRateLimits(context.Context, *dfdaemon.SetRateLimitsRequest) (*dfdaemon.RateLimits, error)
 }
 
 func (p *proxy) Download(req *dfdaemon.DownRequest, stream dfdaemon.Daemon_DownloadServer) (err error) {
@@ -86,11 +98,121 @@ func (p *proxy) GetPieceTasks(ctx context.Context, ptr *base.PieceTaskRequest) (
 	return p.server.GetPieceTasks(ctx, ptr)
 }
 
+func (p *proxy) SyncPieceTasks(ptr *base.PieceTaskRequest, stream dfdaemon.Daemon_SyncPieceTasksServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ppc := make(chan *base.PiecePacket, 4)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(ppc)
+		var err error
+		if perr := safe.Call(func() {
+			err = p.server.SyncPieceTasks(ctx, ptr, ppc)
+		}); perr != nil {
+			err = perr
+		}
+		errChan <- err
+	}()
+
+	for pp := range ppc {
+		if err := stream.Send(pp); err != nil {
+			// stop syncing and drain the pushed piece packets
+			cancel()
+			for range ppc {
+			}
+			return err
+		}
+	}
+	return <-errChan
+}
+
 func (p *proxy) CheckHealth(ctx context.Context, req *empty.Empty) (*empty.Empty, error) {
 	_ = req
 	return new(empty.Empty), p.server.CheckHealth(ctx)
 }
 
+func (p *proxy) ListTasks(ctx context.Context, req *dfdaemon.ListTasksRequest) (*dfdaemon.ListTasksResult, error) {
+	return p.server.ListTasks(ctx, req)
+}
+
+func (p *proxy) StatTask(ctx context.Context, req *dfdaemon.TaskTarget) (*dfdaemon.TaskInfo, error) {
+	return p.server.StatTask(ctx, req)
+}
+
+func (p *proxy) CancelTask(ctx context.Context, req *dfdaemon.TaskTarget) (*empty.Empty, error) {
+	return new(empty.Empty), p.server.CancelTask(ctx, req)
+}
+
+func (p *proxy) DeleteTask(ctx context.Context, req *dfdaemon.TaskTarget) (*empty.Empty, error) {
+	return new(empty.Empty), p.server.DeleteTask(ctx, req)
+}
+
+func (p *proxy) PinTask(ctx context.Context, req *dfdaemon.PinTaskRequest) (*empty.Empty, error) {
+	return new(empty.Empty), p.server.PinTask(ctx, req)
+}
+
+func (p *proxy) ImportTask(ctx context.Context, req *dfdaemon.ImportTaskRequest) (*dfdaemon.TaskInfo, error) {
+	return p.server.ImportTask(ctx, req)
+}
+
+func (p *proxy) ExportTask(ctx context.Context, req *dfdaemon.ExportTaskRequest) (*dfdaemon.TaskInfo, error) {
+	return p.server.ExportTask(ctx, req)
+}
+
+func (p *proxy) ReadTask(req *dfdaemon.ExportTaskRequest, stream dfdaemon.Daemon_ReadTaskServer) error {
+	return streamTaskData(stream.Context(), stream, func(ctx context.Context, tdc chan<- *dfdaemon.TaskData) error {
+		return p.server.ReadTask(ctx, req, tdc)
+	})
+}
+
+func (p *proxy) StreamDownload(req *dfdaemon.DownRequest, stream dfdaemon.Daemon_StreamDownloadServer) error {
+	peerAddr := "unknown"
+	if pe, ok := peer.FromContext(stream.Context()); ok {
+		peerAddr = pe.Addr.String()
+	}
+	logger.Infof("trigger stream download for url:%s,from:%s,uuid:%s", req.Url, peerAddr, req.Uuid)
+
+	return streamTaskData(stream.Context(), stream, func(ctx context.Context, tdc chan<- *dfdaemon.TaskData) error {
+		return p.server.StreamDownload(ctx, req, tdc)
+	})
+}
+
+func (p *proxy) GetRateLimits(ctx context.Context, _ *empty.Empty) (*dfdaemon.RateLimits, error) {
+	return p.server.GetRateLimits(ctx)
+}
+
+func (p *proxy) SetRateLimits(ctx context.Context, req *dfdaemon.SetRateLimitsRequest) (*dfdaemon.RateLimits, error) {
+	return p.server.SetRateLimits(ctx, req)
+}
+
+// streamTaskData calls fn to produce task data and sends them to stream until fn returns
+func streamTaskData(ctx context.Context, stream grpc.ServerStream, fn func(context.Context, chan<- *dfdaemon.TaskData) error) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errChan := make(chan error, 10)
+	tdc := make(chan *dfdaemon.TaskData, 4)
+
+	once := new(sync.Once)
+	closeTdc := func() {
+		once.Do(func() {
+			close(tdc)
+		})
+	}
+	defer closeTdc()
+
+	go callTaskData(ctx, tdc, closeTdc, fn, errChan)
+
+	go sendTaskData(tdc, stream, errChan)
+
+	if err = <-errChan; dferrors.IsEndOfStream(err) {
+		err = nil
+	}
+
+	return
+}
+
 func send(drc chan *dfdaemon.DownResult, closeDrc func(), stream dfdaemon.Daemon_DownloadServer, errChan chan error) {
 	err := safe.Call(func() {
 		defer closeDrc()
@@ -125,3 +247,36 @@ func call(ctx context.Context, drc chan *dfdaemon.DownResult, p *proxy, req *dfd
 		errChan <- err
 	}
 }
+
+func sendTaskData(tdc chan *dfdaemon.TaskData, stream grpc.ServerStream, errChan chan error) {
+	err := safe.Call(func() {
+		for v := range tdc {
+			if err := stream.SendMsg(v); err != nil {
+				errChan <- err
+				return
+			}
+		}
+
+		errChan <- dferrors.ErrEndOfStream
+	})
+
+	if err != nil {
+		errChan <- err
+	}
+}
+
+// callTaskData closes tdc after all data sent to it, then sendTaskData will finish the stream
+func callTaskData(ctx context.Context, tdc chan *dfdaemon.TaskData, closeTdc func(),
+	fn func(context.Context, chan<- *dfdaemon.TaskData) error, errChan chan error) {
+	err := safe.Call(func() {
+		if err := fn(ctx, tdc); err != nil {
+			errChan <- err
+			return
+		}
+		closeTdc()
+	})
+
+	if err != nil {
+		errChan <- err
+	}
+}
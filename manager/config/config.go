@@ -12,6 +12,7 @@ type Config struct {
 	Server       *ServerConfig   `yaml:"server" mapstructure:"server"`
 	Database     *DatabaseConfig `yaml:"database" mapstructure:"database"`
 	Cache        *CacheConfig    `yaml:"cache" mapstructure:"cache"`
+	CDNAdmin     *CDNAdminConfig `yaml:"cdnAdmin" mapstructure:"cdnAdmin"`
 }
 
 type ServerConfig struct {
@@ -53,6 +54,15 @@ type LocalCacheConfig struct {
 	TTL  time.Duration `yaml:"ttl" mapstructure:"ttl"`
 }
 
+type CDNAdminConfig struct {
+	// Port is the port of admin api listened by cdn,
+	// admin.addr of cdn must listen on a reachable address with it instead of the default loopback address
+	Port int `yaml:"port" mapstructure:"port"`
+	// Token is the bearer token of admin api configured in cdn,
+	// it is required because cdn refuses to listen admin api on a non-loopback address without token
+	Token string `yaml:"token" mapstructure:"token"`
+}
+
 type RestConfig struct {
 	Addr string `yaml:"addr" mapstructure:"addr"`
 }
@@ -92,6 +102,9 @@ func New() *Config {
 				TTL:  30 * time.Second,
 			},
 		},
+		CDNAdmin: &CDNAdminConfig{
+			Port: 8005,
+		},
 	}
 }
 
@@ -142,5 +155,15 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.CDNAdmin == nil {
+		return errors.New("empty cdn admin config is not specified")
+	}
+
+	if cfg.CDNAdmin != nil {
+		if cfg.CDNAdmin.Port == 0 {
+			return errors.New("empty cdn admin port is not specified")
+		}
+	}
+
 	return nil
 }
@@ -65,6 +65,10 @@ func TestManagerConfig_Load(t *testing.T) {
 				TTL:  1000,
 			},
 		},
+		CDNAdmin: &CDNAdminConfig{
+			Port:  8005,
+			Token: "baz",
+		},
 	}
 
 	managerConfigYAML := &Config{}
@@ -157,3 +157,37 @@ func (h *Handlers) GetCDNs(ctx *gin.Context) {
 	h.setPaginationLinkHeader(ctx, query.Page, query.PerPage, int(totalCount))
 	ctx.JSON(http.StatusOK, cdns)
 }
+
+// @Summary Get CDN Tasks
+// @Description Get the tasks cached by CDN
+// @Tags CDN
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param url query string false "url regular expression"
+// @Success 200 {object} []types.CDNTask
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Router /cdns/{id}/tasks [get]
+func (h *Handlers) GetCDNTasks(ctx *gin.Context) {
+	var params types.CDNParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var query types.GetCDNTasksQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	tasks, err := h.service.GetCDNTasks(params.ID, query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tasks)
+}
@@ -235,3 +235,37 @@ func (h *Handlers) AddSchedulerClusterToCDNCluster(ctx *gin.Context) {
 
 	ctx.Status(http.StatusOK)
 }
+
+// @Summary Purge CDNCluster
+// @Description Purge the cached task by task id or url pattern on all active cdns of CDNCluster
+// @Tags CDNCluster
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param Purge body types.PurgeCDNClusterRequest true "Purge"
+// @Success 200 {object} []types.PurgeCDNResult
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Router /cdn-clusters/{id}/purge [post]
+func (h *Handlers) PurgeCDNCluster(ctx *gin.Context) {
+	var params types.CDNClusterParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var json types.PurgeCDNClusterRequest
+	if err := ctx.ShouldBindJSON(&json); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	results, err := h.service.PurgeCDNCluster(params.ID, json)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
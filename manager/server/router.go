@@ -55,6 +55,7 @@ func initRouter(verbose bool, service service.REST) (*gin.Engine, error) {
 	cc.GET("", h.GetCDNClusters)
 	cc.PUT(":id/cdns/:cdn_id", h.AddCDNToCDNCluster)
 	cc.PUT(":id/scheduler-clusters/:scheduler_cluster_id", h.AddSchedulerClusterToCDNCluster)
+	cc.POST(":id/purge", h.PurgeCDNCluster)
 
 	// CDN
 	ci := apiv1.Group("/cdns")
@@ -63,6 +64,7 @@ func initRouter(verbose bool, service service.REST) (*gin.Engine, error) {
 	ci.PATCH(":id", h.UpdateCDN)
 	ci.GET(":id", h.GetCDN)
 	ci.GET("", h.GetCDNs)
+	ci.GET(":id/tasks", h.GetCDNTasks)
 
 	// Security Group
 	sg := apiv1.Group("/security-groups")
@@ -56,6 +56,7 @@ func New(cfg *config.Config) (*Server, error) {
 	restService := service.NewREST(
 		service.WithDatabase(db),
 		service.WithCache(cache),
+		service.WithCDNAdmin(cfg.CDNAdmin),
 	)
 
 	// Initialize GRPC service
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/manager/model"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+const (
+	// cdnAdminPathPrefix is the path prefix of the admin api served by cdn
+	cdnAdminPathPrefix = "/admin/v1"
+
+	cdnAdminTimeout = 30 * time.Second
+)
+
+var cdnAdminClient = &http.Client{Timeout: cdnAdminTimeout}
+
+// cdnTask is the task responded by the admin api of cdn
+type cdnTask struct {
+	TaskID     string    `json:"taskId"`
+	URL        string    `json:"url"`
+	Size       int64     `json:"size"`
+	Status     string    `json:"status"`
+	AccessTime time.Time `json:"accessTime"`
+	Tier       string    `json:"tier"`
+}
+
+func (s *rest) GetCDNTasks(id uint, query types.GetCDNTasksQuery) ([]types.CDNTask, error) {
+	cdn := model.CDN{}
+	if err := s.db.First(&cdn, id).Error; err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	if query.URL != "" {
+		params.Set("url", query.URL)
+	}
+
+	var cdnTasks []cdnTask
+	if err := s.doCDNAdminRequest(&cdn, http.MethodGet, "/tasks", params, &cdnTasks); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]types.CDNTask, 0, len(cdnTasks))
+	for _, task := range cdnTasks {
+		tasks = append(tasks, types.CDNTask(task))
+	}
+
+	return tasks, nil
+}
+
+func (s *rest) PurgeCDNCluster(id uint, json types.PurgeCDNClusterRequest) ([]types.PurgeCDNResult, error) {
+	cdnCluster := model.CDNCluster{}
+	if err := s.db.First(&cdnCluster, id).Error; err != nil {
+		return nil, err
+	}
+
+	cdns := []model.CDN{}
+	if err := s.db.Where(&model.CDN{CDNClusterID: &id, Status: model.CDNStatusActive}).Find(&cdns).Error; err != nil {
+		return nil, err
+	}
+
+	method, path, params := http.MethodDelete, "/tasks", url.Values{}
+	if json.TaskID != "" {
+		path = "/tasks/" + url.PathEscape(json.TaskID)
+	} else {
+		params.Set("url", json.URL)
+	}
+
+	results := make([]types.PurgeCDNResult, len(cdns))
+	var wg sync.WaitGroup
+	for i := range cdns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cdn := &cdns[i]
+			results[i] = types.PurgeCDNResult{
+				CDNID:    cdn.ID,
+				HostName: cdn.HostName,
+			}
+			if err := s.doCDNAdminRequest(cdn, method, path, params, &results[i]); err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// doCDNAdminRequest calls the admin api of cdn, which listens on loopback address by default
+// and requires token on other addresses, so admin.addr and admin.token of cdn must be set
+func (s *rest) doCDNAdminRequest(cdn *model.CDN, method, path string, params url.Values, v interface{}) error {
+	if s.cdnAdmin.Token == "" {
+		return errors.New("cdnAdmin.token of manager is not set, admin.addr of cdn must listen on a reachable address and admin.token of cdn must be set to the same token")
+	}
+
+	u := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(cdn.IP, strconv.Itoa(s.cdnAdmin.Port)),
+		Path:     cdnAdminPathPrefix + path,
+		RawQuery: params.Encode(),
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.cdnAdmin.Token)
+
+	resp, err := cdnAdminClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cdn %s admin api is unreachable at %s, admin.addr of cdn must listen on a reachable address with port %d: %w", cdn.HostName, u.Host, s.cdnAdmin.Port, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("cdn %s responds %s, admin.token of cdn must be the same as cdnAdmin.token of manager", cdn.HostName, resp.Status)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("cdn %s responds %s: %s", cdn.HostName, resp.Status, msg)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"d7y.io/dragonfly/v2/manager/cache"
+	"d7y.io/dragonfly/v2/manager/config"
 	"d7y.io/dragonfly/v2/manager/database"
 	"d7y.io/dragonfly/v2/manager/model"
 	"d7y.io/dragonfly/v2/manager/types"
@@ -20,6 +21,7 @@ type REST interface {
 	CDNClusterTotalCount(types.GetCDNClustersQuery) (int64, error)
 	AddCDNToCDNCluster(uint, uint) error
 	AddSchedulerClusterToCDNCluster(uint, uint) error
+	PurgeCDNCluster(uint, types.PurgeCDNClusterRequest) ([]types.PurgeCDNResult, error)
 
 	CreateCDN(types.CreateCDNRequest) (*model.CDN, error)
 	DestroyCDN(uint) error
@@ -27,6 +29,7 @@ type REST interface {
 	GetCDN(uint) (*model.CDN, error)
 	GetCDNs(types.GetCDNsQuery) (*[]model.CDN, error)
 	CDNTotalCount(types.GetCDNsQuery) (int64, error)
+	GetCDNTasks(uint, types.GetCDNTasksQuery) ([]types.CDNTask, error)
 
 	CreateSchedulerCluster(types.CreateSchedulerClusterRequest) (*model.SchedulerCluster, error)
 	CreateSchedulerClusterWithSecurityGroupDomain(types.CreateSchedulerClusterRequest) (*model.SchedulerCluster, error)
@@ -56,9 +59,10 @@ type REST interface {
 }
 
 type rest struct {
-	db    *gorm.DB
-	rdb   *redis.Client
-	cache *cache.Cache
+	db       *gorm.DB
+	rdb      *redis.Client
+	cache    *cache.Cache
+	cdnAdmin *config.CDNAdminConfig
 }
 
 // Option is a functional option for rest
@@ -79,6 +83,13 @@ func WithCache(cache *cache.Cache) Option {
 	}
 }
 
+// WithCDNAdmin set the config to call the admin api of cdn
+func WithCDNAdmin(cdnAdmin *config.CDNAdminConfig) Option {
+	return func(s *rest) {
+		s.cdnAdmin = cdnAdmin
+	}
+}
+
 // NewREST returns a new REST instence
 func NewREST(options ...Option) REST {
 	s := &rest{}
@@ -434,7 +434,7 @@ func (s *GRPC) ListSchedulers(ctx context.Context, req *manager.ListSchedulersRe
 	// Cache Miss
 	logger.Infof("%s cache miss", cacheKey)
 	schedulers := []model.Scheduler{}
-	if err := s.db.Find(&schedulers, &model.Scheduler{
+	if err := s.db.Preload("SchedulerCluster").Find(&schedulers, &model.Scheduler{
 		Status: model.SchedulerStatusActive,
 	}).Error; err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
@@ -446,6 +446,11 @@ func (s *GRPC) ListSchedulers(ctx context.Context, req *manager.ListSchedulersRe
 			return nil, status.Error(codes.DataLoss, err.Error())
 		}
 
+		schedulerClusterClientConfig, err := scheduler.SchedulerCluster.ClientConfig.MarshalJSON()
+		if err != nil {
+			return nil, status.Error(codes.DataLoss, err.Error())
+		}
+
 		pbListSchedulersResponse.Schedulers = append(pbListSchedulersResponse.Schedulers, &manager.Scheduler{
 			Id:        uint64(scheduler.ID),
 			HostName:  scheduler.HostName,
@@ -456,6 +461,11 @@ func (s *GRPC) ListSchedulers(ctx context.Context, req *manager.ListSchedulersRe
 			Ip:        scheduler.IP,
 			Port:      scheduler.Port,
 			Status:    scheduler.Status,
+			SchedulerCluster: &manager.SchedulerCluster{
+				Id:           uint64(scheduler.SchedulerCluster.ID),
+				Name:         scheduler.SchedulerCluster.Name,
+				ClientConfig: schedulerClusterClientConfig,
+			},
 		})
 	}
 
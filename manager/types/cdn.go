@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type CDNParams struct {
 	ID uint `uri:"id" binding:"required"`
 }
@@ -33,3 +35,16 @@ type GetCDNsQuery struct {
 	PerPage      int    `form:"per_page" binding:"omitempty,gte=1,lte=50"`
 	Status       string `form:"status" binding:"omitempty,oneof=active inactive"`
 }
+
+type GetCDNTasksQuery struct {
+	URL string `form:"url" binding:"omitempty"`
+}
+
+type CDNTask struct {
+	TaskID     string    `json:"task_id"`
+	URL        string    `json:"url"`
+	Size       int64     `json:"size"`
+	Status     string    `json:"status"`
+	AccessTime time.Time `json:"access_time"`
+	Tier       string    `json:"tier"`
+}
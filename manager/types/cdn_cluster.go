@@ -33,3 +33,16 @@ type GetCDNClustersQuery struct {
 	Page    int    `form:"page" binding:"omitempty,gte=1"`
 	PerPage int    `form:"per_page" binding:"omitempty,gte=1,lte=50"`
 }
+
+type PurgeCDNClusterRequest struct {
+	TaskID string `json:"task_id" binding:"required_without=URL"`
+	URL    string `json:"url" binding:"required_without=TaskID"`
+}
+
+type PurgeCDNResult struct {
+	CDNID    uint              `json:"cdn_id"`
+	HostName string            `json:"host_name"`
+	Purged   []string          `json:"purged"`
+	Failed   map[string]string `json:"failed,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
@@ -32,4 +32,7 @@ func (h ResponseHeader) Get(key string) string {
 const (
 	LastModified = "Last-Modified"
 	ETag         = "ETag"
+	CacheControl = "Cache-Control"
+	Expires      = "Expires"
+	ContentRange = "Content-Range"
 )
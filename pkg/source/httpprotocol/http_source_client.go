@@ -154,6 +154,9 @@ func (client *httpSourceClient) DownloadWithResponseHeader(ctx context.Context,
 		responseHeader := source.ResponseHeader{
 			source.LastModified: resp.Header.Get(headers.LastModified),
 			source.ETag:         resp.Header.Get(headers.ETag),
+			source.CacheControl: resp.Header.Get(headers.CacheControl),
+			source.Expires:      resp.Header.Get(headers.Expires),
+			source.ContentRange: resp.Header.Get(headers.ContentRange),
 		}
 		return resp.Body, responseHeader, nil
 	}
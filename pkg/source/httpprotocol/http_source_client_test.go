@@ -162,6 +162,9 @@ func (suite *HTTPSourceClientTestSuite) TestHttpSourceClientDownloadWithResponse
 			expireInfo: source.ResponseHeader{
 				source.LastModified: lastModified,
 				source.ETag:         etag,
+				source.CacheControl: "",
+				source.Expires:      "",
+				source.ContentRange: "",
 			},
 			wantErr: nil,
 		}, {
@@ -175,6 +178,9 @@ func (suite *HTTPSourceClientTestSuite) TestHttpSourceClientDownloadWithResponse
 			expireInfo: source.ResponseHeader{
 				headers.LastModified: lastModified,
 				headers.ETag:         etag,
+				headers.CacheControl: "",
+				headers.Expires:      "",
+				headers.ContentRange: "",
 			},
 			wantErr: nil,
 		}, {
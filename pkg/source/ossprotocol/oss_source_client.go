@@ -135,6 +135,9 @@ func (osc *ossSourceClient) DownloadWithResponseHeader(ctx context.Context, url
 		responseHeader := source.ResponseHeader{
 			source.LastModified: resp.Headers.Get(headers.LastModified),
 			source.ETag:         resp.Headers.Get(headers.ETag),
+			source.CacheControl: resp.Headers.Get(headers.CacheControl),
+			source.Expires:      resp.Headers.Get(headers.Expires),
+			source.ContentRange: resp.Headers.Get(headers.ContentRange),
 		}
 		return resp.Body, responseHeader, nil
 	}
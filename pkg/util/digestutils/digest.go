@@ -20,15 +20,27 @@ import (
 	"bufio"
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"hash"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/unit"
 	"d7y.io/dragonfly/v2/pkg/util/fileutils"
 )
 
+// digest algorithms, digest is in format of algorithm:encoded, like sha256:xxx
+const (
+	AlgorithmMD5    = "md5"
+	AlgorithmSHA256 = "sha256"
+	AlgorithmSHA512 = "sha512"
+)
+
 func Sha256(values ...string) string {
 	if len(values) == 0 {
 		return ""
@@ -86,3 +98,80 @@ func Md5File(name string) string {
 func ToHashString(h hash.Hash) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// Parse splits digest into algorithm and encoded value,
+// digest without algorithm is treated as md5 for compatibility.
+func Parse(digest string) (algorithm string, encoded string, err error) {
+	algorithm, encoded = AlgorithmMD5, digest
+	if idx := strings.IndexByte(digest, ':'); idx >= 0 {
+		algorithm, encoded = strings.ToLower(digest[:idx]), digest[idx+1:]
+	}
+	if _, err = NewHash(algorithm); err != nil {
+		return "", "", err
+	}
+	if _, err = hex.DecodeString(encoded); err != nil || encoded == "" {
+		return "", "", errors.Errorf("invalid encoded digest %q", encoded)
+	}
+	return algorithm, strings.ToLower(encoded), nil
+}
+
+// Format returns the digest of algorithm and encoded value,
+// md5 digest is formatted without algorithm to be compatible with the legacy md5 values.
+func Format(algorithm string, encoded string) string {
+	if algorithm == AlgorithmMD5 {
+		return encoded
+	}
+	return algorithm + ":" + encoded
+}
+
+// Normalize formats digest in the canonical form, so that digests like md5:XXX and xxx are the same,
+// digest which can not be parsed is returned as it is.
+func Normalize(digest string) string {
+	algorithm, encoded, err := Parse(digest)
+	if err != nil {
+		return digest
+	}
+	return Format(algorithm, encoded)
+}
+
+// NewHash returns a new hash of the algorithm
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmMD5:
+		return md5.New(), nil
+	case AlgorithmSHA256:
+		return sha256.New(), nil
+	case AlgorithmSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, errors.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+}
+
+// VerifyFile checks the whole content of file with digest, returns ErrDigestNotMatch when mismatch
+func VerifyFile(name string, digest string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Verify(bufio.NewReaderSize(f, int(4*unit.MB)), digest)
+}
+
+// Verify checks all content of reader with digest, returns ErrDigestNotMatch when mismatch
+func Verify(reader io.Reader, digest string) error {
+	algorithm, encoded, err := Parse(digest)
+	if err != nil {
+		return err
+	}
+	h, _ := NewHash(algorithm)
+	if _, err = io.Copy(h, reader); err != nil {
+		return err
+	}
+	if actual := ToHashString(h); actual != encoded {
+		logger.Warnf("%s digest not match, desired: %s, actual: %s", algorithm, encoded, actual)
+		return ErrDigestNotMatch
+	}
+	return nil
+}
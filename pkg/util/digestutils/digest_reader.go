@@ -18,7 +18,6 @@ package digestutils
 
 import (
 	"crypto/md5"
-	"encoding/hex"
 	"hash"
 	"io"
 
@@ -45,17 +44,25 @@ type DigestReader interface {
 
 // TODO add AF_ALG digest https://github.com/golang/sys/commit/e24f485414aeafb646f6fca458b0bf869c0880a1
 
+// NewDigestReader calculates the md5 digest of contents read, when digest is given,
+// the contents will be verified with the algorithm in digest, see Parse for the format.
 func NewDigestReader(reader io.Reader, digest ...string) io.Reader {
-	var d string
-	if len(digest) > 0 {
-		d = digest[0]
-	}
-	return &digestReader{
-		digest: d,
-		// TODO support more digest method like sha1, sha256
+	dr := &digestReader{
 		hash: md5.New(),
 		r:    reader,
 	}
+	if len(digest) > 0 && digest[0] != "" {
+		algorithm, encoded, err := Parse(digest[0])
+		if err != nil {
+			// keep the origin digest, it will be reported as not match
+			logger.Warnf("parse digest %q error: %s", digest[0], err)
+			dr.digest = digest[0]
+			return dr
+		}
+		dr.hash, _ = NewHash(algorithm)
+		dr.digest = encoded
+	}
+	return dr
 }
 
 func (dr *digestReader) Read(p []byte) (int, error) {
@@ -79,5 +86,49 @@ func (dr *digestReader) Read(p []byte) (int, error) {
 
 // GetDigest returns the digest of contents read.
 func (dr *digestReader) Digest() string {
-	return hex.EncodeToString(dr.hash.Sum(nil)[:16])
+	return ToHashString(dr.hash)
+}
+
+// holdBackSize is the size of data held back by verifiedReader until the contents are verified
+const holdBackSize = 32 * 1024
+
+// verifiedReader holds back the last data until the whole contents are verified
+type verifiedReader struct {
+	r   io.Reader
+	buf []byte
+	err error
+}
+
+// NewVerifiedReader verifies the contents with digest like NewDigestReader, and holds back the last data
+// until the whole contents are verified, so the complete contents are never returned when digest mismatches.
+func NewVerifiedReader(reader io.Reader, digest string) io.Reader {
+	return &verifiedReader{
+		r: NewDigestReader(reader, digest),
+	}
+}
+
+func (vr *verifiedReader) Read(p []byte) (int, error) {
+	var chunk []byte
+	for len(vr.buf) <= holdBackSize && vr.err == nil {
+		if chunk == nil {
+			chunk = make([]byte, holdBackSize)
+		}
+		n, err := vr.r.Read(chunk)
+		vr.buf = append(vr.buf, chunk[:n]...)
+		vr.err = err
+	}
+	// the held data is dropped when any error occurs
+	if vr.err != nil && vr.err != io.EOF {
+		return 0, vr.err
+	}
+	available := len(vr.buf)
+	if vr.err == nil {
+		available -= holdBackSize
+	}
+	if available == 0 {
+		return 0, vr.err
+	}
+	n := copy(p, vr.buf[:available])
+	vr.buf = vr.buf[n:]
+	return n, nil
 }
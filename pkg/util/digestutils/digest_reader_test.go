@@ -46,3 +46,23 @@ func TestNewDigestReader(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(testBytes, data)
 }
+
+func TestNewVerifiedReader(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	testBytes := bytes.Repeat([]byte("hello world"), 10000)
+	digest := Md5Bytes(testBytes)
+
+	data, err := ioutil.ReadAll(NewVerifiedReader(bytes.NewReader(testBytes), digest))
+	assert.Nil(err)
+	assert.Equal(testBytes, data)
+
+	// the last data is held back when digest mismatches
+	data, err = ioutil.ReadAll(NewVerifiedReader(bytes.NewReader(testBytes), Md5Bytes([]byte("hello"))))
+	assert.Equal(ErrDigestNotMatch, err)
+	assert.Equal(len(testBytes)-holdBackSize, len(data))
+
+	data, err = ioutil.ReadAll(NewVerifiedReader(bytes.NewReader(testBytes[:10]), Md5Bytes([]byte("hello"))))
+	assert.Equal(ErrDigestNotMatch, err)
+	assert.Empty(data)
+}
@@ -62,3 +62,37 @@ func TestMd5File(t *testing.T) {
 
 	assert.Equal(t, expected, Md5File(path))
 }
+
+func TestParse(t *testing.T) {
+	algorithm, encoded, err := Parse("5d41402abc4b2a76b9719d911017c592")
+	assert.Nil(t, err)
+	assert.Equal(t, AlgorithmMD5, algorithm)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", encoded)
+
+	algorithm, encoded, err = Parse("SHA256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824")
+	assert.Nil(t, err)
+	assert.Equal(t, AlgorithmSHA256, algorithm)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", encoded)
+
+	_, _, err = Parse("sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d")
+	assert.NotNil(t, err)
+
+	_, _, err = Parse("sha256:not-hex")
+	assert.NotNil(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", Normalize("MD5:5D41402ABC4B2A76B9719D911017C592"))
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", Normalize("5d41402abc4b2a76b9719d911017c592"))
+	assert.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		Normalize("SHA256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824"))
+	assert.Equal(t, "sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Normalize("sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+}
+
+func TestVerify(t *testing.T) {
+	assert.Nil(t, Verify(strings.NewReader("hello"), "md5:5d41402abc4b2a76b9719d911017c592"))
+	assert.Nil(t, Verify(strings.NewReader("hello"), "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+	assert.Nil(t, Verify(strings.NewReader("hello"), "sha512:9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca7"+
+		"2323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"))
+	assert.Equal(t, ErrDigestNotMatch, Verify(strings.NewReader("world"), "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+}
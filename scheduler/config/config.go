@@ -32,6 +32,7 @@ type Config struct {
 	GC           GCConfig              `yaml:"gc" mapstructure:"gc"`
 	Dynconfig    *DynconfigOptions     `yaml:"dynconfig" mapstructure:"dynconfig"`
 	Manager      ManagerConfig         `yaml:"manager" mapstructure:"manager"`
+	Security     SecurityConfig        `yaml:"security" mapstructure:"security"`
 }
 
 func New() *Config {
@@ -123,6 +124,15 @@ type SchedulerWorkerConfig struct {
 	SenderJobPoolSize int `yaml:"senderJobPoolSize" mapstructure:"senderJobPoolSize"`
 }
 
+type SecurityConfig struct {
+	// PieceTokenSecret is the secret shared with the daemons to sign the piece tokens,
+	// parents reject the piece requests without a valid token when the secret is set
+	PieceTokenSecret string `yaml:"pieceTokenSecret" mapstructure:"pieceTokenSecret"`
+
+	// PieceTokenTTL is the duration for which a piece token is valid
+	PieceTokenTTL time.Duration `yaml:"pieceTokenTTL" mapstructure:"pieceTokenTTL"`
+}
+
 type GCConfig struct {
 	PeerTaskDelay int64 `yaml:"peerTaskDelay" mapstructure:"peerTaskDelay"`
 	TaskDelay     int64 `yaml:"taskDelay" mapstructure:"taskDelay"`
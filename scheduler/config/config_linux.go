@@ -30,6 +30,9 @@ var config = Config{
 	Scheduler: SchedulerConfig{
 		ABTest: false,
 	},
+	Security: SecurityConfig{
+		PieceTokenTTL: 30 * time.Minute,
+	},
 	GC: GCConfig{
 		TaskDelay:     3600 * 1000,
 		PeerTaskDelay: 3600 * 1000,
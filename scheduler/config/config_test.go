@@ -68,6 +68,10 @@ func TestSchedulerConfig_Load(t *testing.T) {
 			TaskDelay:     3600 * 1000,
 			PeerTaskDelay: 3600 * 1000,
 		},
+		Security: SecurityConfig{
+			PieceTokenSecret: "secret",
+			PieceTokenTTL:    30 * time.Minute,
+		},
 	}
 
 	schedulerConfigYAML := &Config{}
@@ -27,6 +27,7 @@ import (
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	"d7y.io/dragonfly/v2/internal/dferrors"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/pieceauth"
 	"d7y.io/dragonfly/v2/pkg/structure/sortedlist"
 	"d7y.io/dragonfly/v2/pkg/structure/workqueue"
 	"d7y.io/dragonfly/v2/scheduler/config"
@@ -48,6 +49,7 @@ type PeerTask struct {
 	downloadMonitorCallBack func(*types.PeerTask)
 	taskManager             *TaskManager
 	hostManager             *HostManager
+	signer                  *pieceauth.Signer
 	verbose                 bool
 }
 
@@ -67,6 +69,9 @@ func newPeerTask(cfg *config.Config, taskManager *TaskManager, hostManager *Host
 		hostManager:          hostManager,
 		verbose:              cfg.Verbose,
 	}
+	if cfg.Security.PieceTokenSecret != "" {
+		ptm.signer = pieceauth.NewSigner(cfg.Security.PieceTokenSecret, cfg.Security.PieceTokenTTL)
+	}
 
 	go ptm.downloadMonitorWorkingLoop()
 
@@ -83,7 +88,7 @@ func (m *PeerTask) Add(pid string, task *types.Task, host *types.Host) *types.Pe
 		return v.(*types.PeerTask)
 	}
 
-	pt := types.NewPeerTask(pid, task, host, m.addToGCQueue)
+	pt := types.NewPeerTask(pid, task, host, m.addToGCQueue, m.signer)
 	m.data.Store(pid, pt)
 
 	m.taskManager.Touch(task.TaskID)
@@ -105,7 +110,7 @@ func (m *PeerTask) AddFake(pid string, task *types.Task) *types.PeerTask {
 		return v.(*types.PeerTask)
 	}
 
-	pt := types.NewPeerTask(pid, task, nil, m.addToGCQueue)
+	pt := types.NewPeerTask(pid, task, nil, m.addToGCQueue, m.signer)
 	m.data.Store(pid, pt)
 	pt.SetDown()
 	return pt
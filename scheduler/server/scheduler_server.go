@@ -99,7 +99,15 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	var isCdn = false
 	pkg.TaskId = s.service.GenerateTaskID(request.Url, request.Filter, request.UrlMeta, request.BizId, request.PeerId)
 	task, ok := s.service.GetTask(pkg.TaskId)
-	if !ok {
+	if request.IsSeed {
+		task = s.service.AddSeedTask(&types.Task{
+			TaskID:  pkg.TaskId,
+			URL:     request.Url,
+			Filter:  request.Filter,
+			BizID:   request.BizId,
+			URLMata: request.UrlMeta,
+		})
+	} else if !ok {
 		task, err = s.service.AddTask(&types.Task{
 			TaskID:  pkg.TaskId,
 			URL:     request.Url,
@@ -126,7 +134,7 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	pkg.SizeScope = task.SizeScope
 
 	// case base.SizeScope_TINY
-	if pkg.SizeScope == base.SizeScope_TINY {
+	if pkg.SizeScope == base.SizeScope_TINY && !request.IsSeed {
 		pkg.DirectPiece = task.DirectPiece
 		return
 	}
@@ -172,6 +180,13 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 		peerTask.Host = host
 	}
 
+	if request.IsSeed {
+		// the seed peer has the whole content, other peers select it as parent like the peer of cdn
+		peerTask.Success = true
+		s.service.TaskManager.PeerTask.Update(peerTask)
+		return
+	}
+
 	if isCdn {
 		peerTask.SetDown()
 		err = dferrors.New(dfcodes.SchedNeedBackSource, "there is no cdn")
@@ -204,6 +219,8 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 			DstAddr: fmt.Sprintf("%s:%d", parent.Host.Ip, parent.Host.DownPort),
 			// one piece task
 			PieceInfo: &task.PieceList[0].PieceInfo,
+			// token to download the piece from parent
+			PieceToken: peerTask.PieceToken(parent),
 		},
 	}
 
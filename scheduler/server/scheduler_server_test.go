@@ -0,0 +1,114 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/internal/dfcodes"
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	"d7y.io/dragonfly/v2/internal/rpc/base"
+	rpcmanager "d7y.io/dragonfly/v2/internal/rpc/manager"
+	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/manager"
+	schedulerpkg "d7y.io/dragonfly/v2/scheduler/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/service"
+)
+
+type testDynconfig struct{}
+
+func (d *testDynconfig) Get() (*rpcmanager.Scheduler, error) {
+	return &rpcmanager.Scheduler{
+		Cdns: []*rpcmanager.CDN{{HostName: "cdn", Ip: "127.0.0.1", Port: 8003}},
+	}, nil
+}
+func (d *testDynconfig) Register(config.Observer)   {}
+func (d *testDynconfig) Deregister(config.Observer) {}
+func (d *testDynconfig) Notify() error              { return nil }
+func (d *testDynconfig) Serve() error               { return nil }
+func (d *testDynconfig) Stop()                      {}
+
+// newTestSchedulerServer creates a scheduler server without cdn
+func newTestSchedulerServer(t *testing.T) (*SchedulerServer, *service.SchedulerService) {
+	cfg := config.New()
+	mgr, err := manager.New(cfg, &testDynconfig{})
+	testifyassert.Nil(t, err)
+	svc := &service.SchedulerService{
+		// cdn manager without client behaves like there is no cdn
+		CDNManager:  &manager.CDNManager{},
+		TaskManager: mgr.TaskManager,
+		HostManager: mgr.HostManager,
+		Scheduler:   schedulerpkg.New(cfg.Scheduler, mgr.TaskManager),
+	}
+	return NewSchedulerServer(cfg, WithSchedulerService(svc)), svc
+}
+
+func newTestPeerTaskRequest(peerID, hostID string, isSeed bool) *scheduler.PeerTaskRequest {
+	return &scheduler.PeerTaskRequest{
+		Url:     "http://example.com/imported",
+		UrlMeta: &base.UrlMeta{},
+		PeerId:  peerID,
+		PeerHost: &scheduler.PeerHost{
+			Uuid: hostID,
+			Ip:   "127.0.0.1",
+		},
+		IsSeed: isSeed,
+	}
+}
+
+func TestSchedulerServer_RegisterPeerTaskWithoutCDN(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, _ := newTestSchedulerServer(t)
+
+	result, err := s.RegisterPeerTask(context.Background(), newTestPeerTaskRequest("peer-1", "host-1", false))
+	assert.NotNil(result)
+	de, ok := err.(*dferrors.DfError)
+	assert.True(ok)
+	assert.Equal(dfcodes.SchedNeedBackSource, de.Code)
+}
+
+func TestSchedulerServer_RegisterSeedPeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, svc := newTestSchedulerServer(t)
+
+	seedResult, err := s.RegisterPeerTask(context.Background(), newTestPeerTaskRequest("seed-peer", "seed-host", true))
+	assert.Nil(err, "seed peer does not trigger cdn")
+
+	task, ok := svc.GetTask(seedResult.TaskId)
+	assert.True(ok)
+	assert.Nil(task.CDNError)
+	seed, err := svc.GetPeerTask("seed-peer")
+	assert.Nil(err)
+	assert.True(seed.Success)
+
+	result, err := s.RegisterPeerTask(context.Background(), newTestPeerTaskRequest("peer-1", "host-1", false))
+	assert.Nil(err, "other peers download from seed peer")
+	assert.Equal(seedResult.TaskId, result.TaskId)
+	assert.Equal(base.SizeScope_NORMAL, result.SizeScope)
+
+	peerTask, err := svc.GetPeerTask("peer-1")
+	assert.Nil(err)
+	parent, _, err := svc.ScheduleParent(peerTask)
+	assert.Nil(err)
+	if assert.NotNil(parent) {
+		assert.Equal("seed-peer", parent.Pid)
+	}
+}
@@ -71,13 +71,25 @@ func (s *SchedulerService) AddTask(task *types.Task) (*types.Task, error) {
 
 	// Task does not exist
 	ret := s.TaskManager.Set(task.TaskID, task)
+	s.TaskManager.PeerTask.AddTask(ret)
+	// the task is returned with the error, peers download it from source when there is no cdn
 	if err := s.CDNManager.TriggerTask(ret, s.TaskManager.PeerTask.CDNCallback); err != nil {
-		return nil, err
+		return ret, err
 	}
-	s.TaskManager.PeerTask.AddTask(ret)
 	return ret, nil
 }
 
+// AddSeedTask adds the task whose content is provided by the registering seed peer, e.g. imported from local file,
+// cdn is not triggered because there may be no origin of the task.
+func (s *SchedulerService) AddSeedTask(task *types.Task) *types.Task {
+	ret, ok := s.TaskManager.Get(task.TaskID)
+	if !ok {
+		ret = s.TaskManager.Set(task.TaskID, task)
+	}
+	s.TaskManager.PeerTask.AddTask(ret)
+	return ret
+}
+
 func (s *SchedulerService) ScheduleParent(task *types.PeerTask) (primary *types.PeerTask,
 	secondary []*types.PeerTask, err error) {
 	return s.Scheduler.ScheduleParent(task)
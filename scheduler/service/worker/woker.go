@@ -150,6 +150,10 @@ func (w *Worker) UpdatePieceResult(pr *scheduler2.PieceResult) (peerTask *types.
 		peerTask.SetNodeStatus(types.PeerTaskStatusNeedAdjustNode)
 		needSchedule = true
 	}
+	if !needSchedule && peerTask.NeedRefreshPieceToken() {
+		// keep the parent, send the schedule result again with a new piece token
+		w.sendScheduleResult(peerTask)
+	}
 
 	pt.RefreshDownloadMonitor(peerTask)
 
@@ -24,6 +24,7 @@ import (
 
 	"d7y.io/dragonfly/v2/internal/dfcodes"
 	"d7y.io/dragonfly/v2/internal/dferrors"
+	"d7y.io/dragonfly/v2/internal/pieceauth"
 	"d7y.io/dragonfly/v2/internal/rpc/base"
 	"d7y.io/dragonfly/v2/internal/rpc/scheduler"
 )
@@ -54,6 +55,8 @@ type PeerTask struct {
 	startTime      int64
 	lastActiveTime int64
 	touch          func(*PeerTask)
+	signer         *pieceauth.Signer // signs the piece tokens for parents, nil when piece token is disabled
+	pieceTokenTime int64             // the time when the piece token for parent was issued
 
 	parent          *PeerEdge // primary download provider
 	children        *sync.Map // all primary download consumers
@@ -88,7 +91,7 @@ func (pe *PeerEdge) AddCost(cost int64) {
 	}
 }
 
-func NewPeerTask(pid string, task *Task, host *Host, touch func(*PeerTask)) *PeerTask {
+func NewPeerTask(pid string, task *Task, host *Host, touch func(*PeerTask), signer *pieceauth.Signer) *PeerTask {
 	pt := &PeerTask{
 		Pid:             pid,
 		Task:            task,
@@ -97,6 +100,7 @@ func NewPeerTask(pid string, task *Task, host *Host, touch func(*PeerTask)) *Pee
 		startTime:       time.Now().UnixNano(),
 		lastActiveTime:  time.Now().UnixNano(),
 		touch:           touch,
+		signer:          signer,
 		children:        new(sync.Map),
 		subTreeNodesNum: 1,
 	}
@@ -312,12 +316,45 @@ func (pt *PeerTask) GetSendPkg() (pkg *scheduler.PeerPacket) {
 			RpcPort: pt.parent.DstPeerTask.Host.PeerHost.RpcPort,
 			PeerId:  pt.parent.DstPeerTask.Pid,
 		}
+		pkg.MainPeer.PieceToken = pt.PieceToken(pt.parent.DstPeerTask)
+		if pkg.MainPeer.PieceToken != "" {
+			pt.pieceTokenTime = time.Now().UnixNano()
+		}
 	}
 	// TODO select StealPeers
 
 	return
 }
 
+// PieceToken returns the token which authorizes the peer to download pieces from parent,
+// empty when piece token is disabled
+func (pt *PeerTask) PieceToken(parent *PeerTask) string {
+	if pt.signer == nil || parent == nil {
+		return ""
+	}
+	return pt.signer.Sign(pt.Task.TaskID, pt.Pid, parent.Pid)
+}
+
+// NeedRefreshPieceToken returns true when the piece token for parent passes the half of its ttl,
+// the schedule result needs to be sent again with a new token before the old one expires.
+// It restarts the timing when returning true, so that only one refresh is triggered.
+func (pt *PeerTask) NeedRefreshPieceToken() bool {
+	if pt == nil || pt.signer == nil {
+		return false
+	}
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.parent == nil || pt.pieceTokenTime == 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	if now-pt.pieceTokenTime < int64(pt.signer.TTL()/2) {
+		return false
+	}
+	pt.pieceTokenTime = now
+	return true
+}
+
 func (pt *PeerTask) Send() error {
 	if pt == nil {
 		return nil